@@ -0,0 +1,260 @@
+package cmd_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/ebanfa/skeleton/cmd"
+	"github.com/ebanfa/skeleton/pkg/common"
+	"github.com/ebanfa/skeleton/pkg/component"
+	"github.com/ebanfa/skeleton/pkg/mocks"
+	"github.com/ebanfa/skeleton/pkg/types"
+)
+
+var (
+	callsMutex sync.Mutex
+	calls      []string
+)
+
+// record records a lifecycle call of the test services.
+func record(call string) {
+	callsMutex.Lock()
+	defer callsMutex.Unlock()
+	calls = append(calls, call)
+}
+
+var _ = BeforeSuite(func() {
+	cmd.RegisterFactory("testServiceFactory", component.FactoryFunc(func(config *types.ComponentConfig) (types.ComponentInterface, error) {
+		service := &mocks.SystemServiceInterface{}
+		service.On("ID").Return(config.ID)
+		service.On("Name").Return("TestService")
+		service.On("Type").Return(types.ServiceType)
+		service.On("Initialize", mock.Anything, mock.Anything).Return(nil)
+		service.On("Start", mock.Anything).Run(func(mock.Arguments) { record("start " + config.ID) }).Return(nil)
+		service.On("Stop", mock.Anything).Run(func(mock.Arguments) { record("stop " + config.ID) }).Return(nil)
+		return service, nil
+	}))
+})
+
+var _ = Describe("skeleton", func() {
+	var (
+		dir    string
+		stdout *bytes.Buffer
+		stderr *bytes.Buffer
+	)
+
+	writeConfig := func(content string) string {
+		path := filepath.Join(dir, "app.yaml")
+		Expect(os.WriteFile(path, []byte(content), 0o600)).To(Succeed())
+		return path
+	}
+
+	execute := func(ctx context.Context, args ...string) error {
+		rootCmd := cmd.NewRootCommand()
+		rootCmd.SetArgs(args)
+		rootCmd.SetOut(stdout)
+		rootCmd.SetErr(stderr)
+		return rootCmd.ExecuteContext(ctx)
+	}
+
+	BeforeEach(func() {
+		dir = GinkgoT().TempDir()
+		stdout = &bytes.Buffer{}
+		stderr = &bytes.Buffer{}
+		calls = nil
+	})
+
+	Describe("version", func() {
+		It("prints the build information", func() {
+			Expect(execute(context.Background(), "version")).To(Succeed())
+			Expect(stdout.String()).To(HavePrefix("skeleton " + cmd.Version + " (commit " + cmd.Commit))
+		})
+	})
+
+	Describe("config validate", func() {
+		It("accepts a valid configuration", func() {
+			path := writeConfig("services:\n  - id: svc\n    factoryId: testServiceFactory\n")
+
+			Expect(execute(context.Background(), "config", "validate", path)).To(Succeed())
+			Expect(stdout.String()).To(Equal(path + " is valid\n"))
+		})
+
+		It("reports the problems of an invalid configuration", func() {
+			path := writeConfig("services:\n  - id: svc\n    factoryId: missing\n  - id: svc\n    factoryId: testServiceFactory\n")
+
+			err := execute(context.Background(), "config", "validate", "--config", path)
+			Expect(err).To(MatchError(cmd.ErrInvalidConfigurationFile))
+			Expect(err.Error()).To(HaveSuffix("2 problem(s)"))
+			Expect(stderr.String()).To(ContainSubstring(`app.yaml:3: /services/0/factoryId: unknown factory "missing"`))
+			Expect(stderr.String()).To(ContainSubstring(`duplicate component ID "svc"`))
+		})
+
+		It("requires a configuration file", func() {
+			Expect(execute(context.Background(), "config", "validate")).To(MatchError("a configuration file is required"))
+		})
+	})
+
+	Describe("components list", func() {
+		It("lists the registered factories", func() {
+			Expect(execute(context.Background(), "components", "list")).To(Succeed())
+			Expect(stdout.String()).To(Equal("FACTORY\nskeleton.Bridge\nskeleton.Pipeline\ntestServiceFactory\n\nID  TYPE  NAME\n"))
+		})
+
+		It("lists the components of the configured system", func() {
+			path := writeConfig("services:\n  - id: svc\n    factoryId: testServiceFactory\n")
+
+			Expect(execute(context.Background(), "components", "list", "-c", path)).To(Succeed())
+			Expect(stdout.String()).To(ContainSubstring("svc  service  TestService\n"))
+		})
+	})
+
+	Describe("deadletters", func() {
+		var (
+			server   *httptest.Server
+			requests chan string
+		)
+
+		BeforeEach(func() {
+			requests = make(chan string, 10)
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests <- r.Method + " " + r.URL.Path + " " + r.Header.Get("Authorization")
+				switch {
+				case r.Method == http.MethodGet:
+					fmt.Fprint(w, `[{"id":"3","event":{"type":"order.created","source":"api"},"topic":"order.>","attempts":2,"error":"database unavailable","failedAt":"2024-05-01T10:00:00Z"}]`)
+				case r.URL.Path == "/deadletters/3/redrive":
+					fmt.Fprint(w, `{"type":"order.created"}`)
+				case r.URL.Path == "/deadletters/3":
+					w.WriteHeader(http.StatusNoContent)
+				default:
+					w.WriteHeader(http.StatusNotFound)
+					fmt.Fprint(w, `{"error":"dead letter not found: 4"}`)
+				}
+			}))
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("lists the dead letters", func() {
+			Expect(execute(context.Background(), "deadletters", "list", "--admin-addr", server.URL, "--admin-token", "secret")).To(Succeed())
+			Expect(requests).To(Receive(Equal("GET /deadletters Bearer secret")))
+			Expect(stdout.String()).To(Equal(
+				"ID  EVENT          SOURCE  SUBSCRIPTION  ATTEMPTS  FAILED AT             ERROR\n" +
+					"3   order.created  api     order.>       2         2024-05-01T10:00:00Z  database unavailable\n"))
+		})
+
+		It("redrives and removes the dead letters", func() {
+			Expect(execute(context.Background(), "deadletters", "redrive", "3", "--admin-addr", server.URL)).To(Succeed())
+			Expect(requests).To(Receive(Equal("POST /deadletters/3/redrive ")))
+
+			Expect(execute(context.Background(), "deadletters", "remove", "3", "--admin-addr", server.URL)).To(Succeed())
+			Expect(requests).To(Receive(Equal("DELETE /deadletters/3 ")))
+
+			Expect(stdout.String()).To(Equal("Redrove dead letter 3 to order.created\nRemoved dead letter 3\n"))
+		})
+
+		It("reports the errors of the API", func() {
+			Expect(execute(context.Background(), "deadletters", "remove", "4", "--admin-addr", server.URL)).To(MatchError("admin API: dead letter not found: 4"))
+		})
+	})
+
+	Describe("run", func() {
+		It("runs the system until it is interrupted", func() {
+			path := writeConfig("services:\n  - id: svc\n    factoryId: testServiceFactory\n")
+			ctx, cancel := context.WithCancel(context.Background())
+
+			done := make(chan error, 1)
+			go func() {
+				defer GinkgoRecover()
+				done <- execute(ctx, "run", "--config", path)
+			}()
+
+			Eventually(func() []string {
+				callsMutex.Lock()
+				defer callsMutex.Unlock()
+				return append([]string(nil), calls...)
+			}).Should(Equal([]string{"start svc"}))
+
+			cancel()
+			Eventually(done).Should(Receive(BeNil()))
+			Expect(calls).To(Equal([]string{"start svc", "stop svc"}))
+		})
+
+		It("runs the event bridges declared in the configuration", func() {
+			address := filepath.Join(dir, "bridge.sock")
+			path := writeConfig(fmt.Sprintf(`services:
+  - id: bridge
+    factoryId: skeleton.Bridge
+    customConfig:
+      transport: unix
+      address: %s
+      listen: true
+      outbound: ["order.>"]
+`, address))
+			ctx, cancel := context.WithCancel(context.Background())
+
+			done := make(chan error, 1)
+			go func() {
+				defer GinkgoRecover()
+				done <- execute(ctx, "run", "--config", path)
+			}()
+
+			// The bridge accepts the peers once the system is started
+			Eventually(func() error {
+				conn, err := net.Dial("unix", address)
+				if err == nil {
+					conn.Close()
+				}
+				return err
+			}).Should(Succeed())
+
+			cancel()
+			Eventually(done).Should(Receive(BeNil()))
+		})
+
+		It("returns an error when the configuration is invalid", func() {
+			path := writeConfig("services:\n  - id: svc\n    factoryId: missing\n")
+
+			Expect(execute(context.Background(), "run", "--config", path)).To(MatchError(types.ErrInvalidConfiguration))
+		})
+
+		It("closes the store when the system cannot be initialized", func() {
+			path := writeConfig("services:\n  - id: svc\n    factoryId: missing\n")
+			dataDir := filepath.Join(dir, "data")
+
+			Expect(execute(context.Background(), "run", "--config", path, "--data-dir", dataDir)).To(MatchError(types.ErrInvalidConfiguration))
+			// The store of the first run would otherwise still be locked
+			Expect(execute(context.Background(), "run", "--config", path, "--data-dir", dataDir)).To(MatchError(types.ErrInvalidConfiguration))
+		})
+
+		It("requires an admin token on a non-loopback address", func() {
+			path := writeConfig("services:\n  - id: svc\n    factoryId: testServiceFactory\n")
+
+			Expect(execute(context.Background(), "run", "--config", path, "--admin-addr", "0.0.0.0:0")).To(MatchError(types.ErrAdminTokenRequired))
+		})
+
+		It("requires a data directory for the event journal", func() {
+			path := writeConfig("services:\n  - id: svc\n    factoryId: testServiceFactory\n")
+
+			Expect(execute(context.Background(), "run", "--config", path, "--journal")).To(MatchError(ContainSubstring("requires a data directory")))
+		})
+
+		It("returns an error when the overflow policy of the event bus is unknown", func() {
+			path := writeConfig("eventBus:\n  bufferSize: 16\n  overflow: drop\n")
+
+			Expect(execute(context.Background(), "run", "--config", path)).To(MatchError(common.ErrUnknownOverflowPolicy))
+		})
+	})
+})
@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ebanfa/skeleton/pkg/common"
+)
+
+// newComponentsCommand creates the command grouping the component commands.
+func newComponentsCommand() *cobra.Command {
+	componentsCmd := &cobra.Command{
+		Use:   "components",
+		Short: "Inspect the components of a system",
+	}
+
+	componentsCmd.AddCommand(&cobra.Command{
+		Use:   "list",
+		Short: "List the registered factories and the components of a system",
+		Long: `List prints the factories registered with the host. When a configuration is given,
+the system is initialized and the components it creates are printed along with their type.`,
+		Args: cobra.NoArgs,
+		RunE: listComponents,
+	})
+
+	return componentsCmd
+}
+
+// listComponents prints the registered factories and the components of the configured system.
+func listComponents(cmd *cobra.Command, args []string) error {
+	ctx := common.Background()
+
+	configuration, err := loadConfiguration(cmd)
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	h, err := newHost(ctx, configuration, "")
+	if err != nil {
+		return err
+	}
+
+	// The components are only created when the system is initialized
+	if filePath, _ := cmd.Flags().GetString("config"); filePath != "" {
+		if err := h.system.Initialize(ctx); err != nil {
+			return fmt.Errorf("failed to initialize system: %w", err)
+		}
+	}
+
+	out := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(out, "FACTORY")
+	for _, id := range h.registrar.GetFactoryIDs() {
+		fmt.Fprintln(out, id)
+	}
+
+	components := h.registrar.GetAllComponents()
+	sort.Slice(components, func(i, j int) bool {
+		return components[i].ID() < components[j].ID()
+	})
+
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "ID\tTYPE\tNAME")
+	for _, c := range components {
+		fmt.Fprintf(out, "%s\t%s\t%s\n", c.ID(), c.Type(), c.Name())
+	}
+
+	return out.Flush()
+}
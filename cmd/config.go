@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ebanfa/skeleton/pkg/common"
+	"github.com/ebanfa/skeleton/pkg/system"
+)
+
+// ErrInvalidConfigurationFile is returned when a configuration file fails validation.
+var ErrInvalidConfigurationFile = errors.New("invalid configuration file")
+
+// newConfigCommand creates the command grouping the configuration commands.
+func newConfigCommand() *cobra.Command {
+	configCmd := &cobra.Command{
+		Use:   "config",
+		Short: "Inspect system configurations",
+	}
+
+	configCmd.AddCommand(&cobra.Command{
+		Use:   "validate [file]",
+		Short: "Validate a configuration file",
+		Long: `Validate checks a configuration file against the schemas of the configuration and of the
+registered factories, then checks the configuration once the environment and flag overrides
+are applied. Every problem is reported along with its location.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: validateConfiguration,
+	})
+
+	return configCmd
+}
+
+// validateConfiguration validates the configuration file given as argument or with the config flag.
+func validateConfiguration(cmd *cobra.Command, args []string) error {
+	ctx := common.Background()
+
+	if len(args) > 0 {
+		if err := cmd.Flags().Set("config", args[0]); err != nil {
+			return err
+		}
+	}
+	filePath, _ := cmd.Flags().GetString("config")
+	if filePath == "" {
+		return errors.New("a configuration file is required")
+	}
+
+	h, err := newHost(ctx, nil, "")
+	if err != nil {
+		return err
+	}
+	validator := system.NewConfigValidator(h.registrar)
+
+	if err := validator.ValidateFile(filePath); err != nil {
+		return reportProblems(cmd, filePath, err)
+	}
+
+	// The overrides are validated against the merged configuration
+	configuration, err := loadConfiguration(cmd)
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	if err := validator.Validate(configuration); err != nil {
+		return reportProblems(cmd, filePath, err)
+	}
+
+	fmt.Fprintf(cmd.OutOrStdout(), "%s is valid\n", filePath)
+	return nil
+}
+
+// reportProblems prints the validation problems, one per line.
+func reportProblems(cmd *cobra.Command, filePath string, err error) error {
+	problems := strings.Split(err.Error(), "\n")
+	for _, problem := range problems {
+		fmt.Fprintln(cmd.ErrOrStderr(), problem)
+	}
+	return fmt.Errorf("%w %s: %d problem(s)", ErrInvalidConfigurationFile, filePath, len(problems))
+}
@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// adminRequestTimeout is the timeout of the requests to the admin API.
+const adminRequestTimeout = 10 * time.Second
+
+// deadLetter represents a dead letter returned by the admin API.
+type deadLetter struct {
+	ID    string `json:"id"`
+	Event struct {
+		Type   string `json:"type"`
+		Source string `json:"source"`
+	} `json:"event"`
+	Topic    string    `json:"topic"`
+	Attempts int       `json:"attempts"`
+	Error    string    `json:"error"`
+	FailedAt time.Time `json:"failedAt"`
+}
+
+// newDeadLettersCommand creates the command grouping the dead-letter commands.
+func newDeadLettersCommand() *cobra.Command {
+	deadLettersCmd := &cobra.Command{
+		Use:   "deadletters",
+		Short: "Inspect and redrive the events whose handlers failed",
+		Long: `The dead-letter commands call the HTTP admin API of a running system, started with
+skeleton run --admin-addr and --dead-letters, to inspect the events whose handlers failed
+every attempt, and to publish them again once the cause of the failure is fixed.`,
+	}
+
+	deadLettersCmd.PersistentFlags().String("admin-addr", "localhost:8080", "address of the HTTP admin API of the running system")
+	deadLettersCmd.PersistentFlags().String("admin-token", "", "bearer token of the HTTP admin API")
+
+	deadLettersCmd.AddCommand(
+		&cobra.Command{
+			Use:   "list",
+			Short: "List the dead letters, the oldest first",
+			Args:  cobra.NoArgs,
+			RunE:  listDeadLetters,
+		},
+		&cobra.Command{
+			Use:   "redrive ID",
+			Short: "Publish a dead letter again and remove it from the queue",
+			Args:  cobra.ExactArgs(1),
+			RunE:  redriveDeadLetter,
+		},
+		&cobra.Command{
+			Use:   "remove ID",
+			Short: "Remove a dead letter from the queue without publishing it",
+			Args:  cobra.ExactArgs(1),
+			RunE:  removeDeadLetter,
+		},
+	)
+
+	return deadLettersCmd
+}
+
+// listDeadLetters prints the dead letters of the running system.
+func listDeadLetters(cmd *cobra.Command, args []string) error {
+	var letters []deadLetter
+	if err := adminRequest(cmd, http.MethodGet, "/deadletters", &letters); err != nil {
+		return err
+	}
+
+	out := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(out, "ID\tEVENT\tSOURCE\tSUBSCRIPTION\tATTEMPTS\tFAILED AT\tERROR")
+	for _, letter := range letters {
+		fmt.Fprintf(out, "%s\t%s\t%s\t%s\t%d\t%s\t%s\n", letter.ID, letter.Event.Type, letter.Event.Source,
+			letter.Topic, letter.Attempts, letter.FailedAt.Format(time.RFC3339), letter.Error)
+	}
+	return out.Flush()
+}
+
+// redriveDeadLetter publishes a dead letter of the running system again.
+func redriveDeadLetter(cmd *cobra.Command, args []string) error {
+	var event struct {
+		Type string `json:"type"`
+	}
+	if err := adminRequest(cmd, http.MethodPost, "/deadletters/"+url.PathEscape(args[0])+"/redrive", &event); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(cmd.OutOrStdout(), "Redrove dead letter %s to %s\n", args[0], event.Type)
+	return nil
+}
+
+// removeDeadLetter removes a dead letter of the running system.
+func removeDeadLetter(cmd *cobra.Command, args []string) error {
+	if err := adminRequest(cmd, http.MethodDelete, "/deadletters/"+url.PathEscape(args[0]), nil); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(cmd.OutOrStdout(), "Removed dead letter %s\n", args[0])
+	return nil
+}
+
+// adminRequest sends a request to the admin API given by the flags of the command, and decodes
+// the JSON response into result, if not nil. Returns the error reported by the API, if any.
+func adminRequest(cmd *cobra.Command, method, path string, result interface{}) error {
+	address, _ := cmd.Flags().GetString("admin-addr")
+	token, _ := cmd.Flags().GetString("admin-token")
+	if !strings.Contains(address, "://") {
+		address = "http://" + address
+	}
+
+	req, err := http.NewRequestWithContext(cmd.Context(), method, strings.TrimSuffix(address, "/")+path, nil)
+	if err != nil {
+		return fmt.Errorf("invalid admin address %s: %w", address, err)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	client := &http.Client{Timeout: adminRequestTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call admin API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		var failure struct {
+			Error string `json:"error"`
+		}
+		if json.NewDecoder(resp.Body).Decode(&failure) != nil || failure.Error == "" {
+			failure.Error = resp.Status
+		}
+		return fmt.Errorf("admin API: %s", failure.Error)
+	}
+	if result != nil {
+		if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+			return fmt.Errorf("failed to decode admin API response: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,209 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/ebanfa/skeleton/pkg/bridge"
+	"github.com/ebanfa/skeleton/pkg/common"
+	"github.com/ebanfa/skeleton/pkg/component"
+	"github.com/ebanfa/skeleton/pkg/db"
+	"github.com/ebanfa/skeleton/pkg/plugin"
+	"github.com/ebanfa/skeleton/pkg/store"
+	"github.com/ebanfa/skeleton/pkg/system"
+	"github.com/ebanfa/skeleton/pkg/types"
+)
+
+const (
+	// systemStoreName is the name of the root store of the system multistore.
+	systemStoreName = "system"
+
+	// pipelineFactoryID is the ID of the factory of the pipelines declared in the configuration.
+	pipelineFactoryID = "skeleton.Pipeline"
+
+	// bridgeFactoryID is the ID of the factory of the event bridges declared in the configuration.
+	bridgeFactoryID = "skeleton.Bridge"
+)
+
+var (
+	registryMutex sync.Mutex
+	factories     = make(map[string]types.ComponentFactoryInterface)
+	plugins       []types.PluginInterface
+)
+
+// RegisterFactory makes a component factory available to the systems run by the host under the given ID.
+// It is meant to be called before Execute, typically from main. It panics if the factory is nil
+// or if a factory with the same ID was already registered.
+func RegisterFactory(id string, factory types.ComponentFactoryInterface) {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+
+	if factory == nil {
+		panic("skeleton: RegisterFactory factory is nil")
+	}
+	if _, exists := factories[id]; exists {
+		panic("skeleton: RegisterFactory called twice for factory " + id)
+	}
+	factories[id] = factory
+}
+
+// RegisterPlugin adds a plugin to the systems run by the host.
+// It is meant to be called before Execute, typically from main. It panics if the plugin is nil.
+func RegisterPlugin(p types.PluginInterface) {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+
+	if p == nil {
+		panic("skeleton: RegisterPlugin plugin is nil")
+	}
+	plugins = append(plugins, p)
+}
+
+// host holds a system built from a configuration along with its dependencies.
+type host struct {
+	system        *system.SystemImpl
+	registrar     *component.ComponentRegistrar
+	pluginManager types.PluginManagerInterface
+	store         types.MultiStore
+	eventBus      *common.ChannelEventBus
+	logger        common.LoggerInterface
+}
+
+// newHost builds a system from the given configuration, with the registered factories and plugins.
+// The system multistore is only created when a data directory is given, it is closed when the
+// host cannot be built.
+func newHost(ctx *common.Context, configuration *types.Configuration, dataDir string) (_ *host, err error) {
+	if configuration == nil {
+		configuration = &types.Configuration{}
+	}
+
+	level := common.LevelInfo
+	if configuration.Debug {
+		level = common.LevelDebug
+	}
+
+	h := &host{
+		registrar:     component.NewComponentRegistrar(),
+		pluginManager: plugin.NewPluginManager(),
+		logger:        common.NewLogrusLogger(level),
+	}
+	defer func() {
+		if err != nil {
+			h.closeStore()
+		}
+	}()
+
+	if dataDir != "" {
+		if err := os.MkdirAll(dataDir, 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create data directory: %w", err)
+		}
+		storeFactory := store.NewStoreFactory(dataDir, db.NewIAVLDatabaseFactory())
+		multiStore, err := store.CreateMultiStore(systemStoreName, dataDir, storeFactory)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create system store: %w", err)
+		}
+		h.store = multiStore
+	}
+
+	overflow, err := common.ParseOverflowPolicy(configuration.EventBus.Overflow)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create event bus: %w", err)
+	}
+	h.eventBus = common.NewChannelEventBus(common.ChannelEventBusOptions{
+		BufferSize: configuration.EventBus.BufferSize,
+		Overflow:   overflow,
+		Logger:     h.logger,
+	})
+
+	h.system = system.NewSystem(h.logger, h.eventBus, configuration, h.pluginManager, h.registrar, h.store)
+	h.system.AddOperationInterceptors(
+		system.RecoveryInterceptor(h.logger),
+		system.LoggingInterceptor(h.logger, common.LevelDebug),
+	)
+
+	// Pipelines and bridges are built in, so that they can be declared in the configuration
+	if err := h.registrar.RegisterFactory(ctx, pipelineFactoryID, system.NewPipelineFactory()); err != nil {
+		return nil, fmt.Errorf("failed to register factory %s: %w", pipelineFactoryID, err)
+	}
+	if err := h.registrar.RegisterFactory(ctx, bridgeFactoryID, bridge.NewBridgeFactory()); err != nil {
+		return nil, fmt.Errorf("failed to register factory %s: %w", bridgeFactoryID, err)
+	}
+
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+
+	ids := make([]string, 0, len(factories))
+	for id := range factories {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		if err := h.registrar.RegisterFactory(ctx, id, factories[id]); err != nil {
+			return nil, fmt.Errorf("failed to register factory %s: %w", id, err)
+		}
+	}
+
+	// Plugins are initialized with the system, so that they can register their resources
+	if err := h.pluginManager.Initialize(ctx, h.system); err != nil {
+		return nil, fmt.Errorf("failed to initialize plugin manager: %w", err)
+	}
+	for _, p := range plugins {
+		if err := h.pluginManager.AddPlugin(ctx, p); err != nil {
+			return nil, err
+		}
+	}
+
+	return h, nil
+}
+
+// closeStore closes the system multistore, if any. It is meant for the hosts whose system did not start.
+func (h *host) closeStore() {
+	if h.store == nil {
+		return
+	}
+	if err := h.store.Close(); err != nil {
+		h.logger.Logf(common.LevelError, "Failed to close system store: %v", err)
+	}
+}
+
+// configFlagNames are the names of the command flags overriding the configuration key of the same name.
+var configFlagNames = []string{"debug", "verbose"}
+
+// configLoaderOptions returns the sources of the configuration of a command:
+// the configuration file, the SKELETON_ environment variables and the configuration flags.
+// The environment variables that cannot be applied are logged as warnings.
+func configLoaderOptions(cmd *cobra.Command) system.ConfigLoaderOptions {
+	filePath, _ := cmd.Flags().GetString("config")
+	return system.ConfigLoaderOptions{
+		FilePath:  filePath,
+		EnvPrefix: system.DefaultEnvPrefix,
+		Logger:    common.NewLogrusLogger(common.LevelWarn),
+		Flags:     configFlags(cmd),
+	}
+}
+
+// configFlags returns the flags of a command overriding configuration keys, leaving out
+// the flags of the command itself such as --data-dir or --watch.
+func configFlags(cmd *cobra.Command) *pflag.FlagSet {
+	flags := pflag.NewFlagSet(cmd.Name(), pflag.ContinueOnError)
+	for _, name := range configFlagNames {
+		if flag := cmd.Flags().Lookup(name); flag != nil {
+			flags.AddFlag(flag)
+		}
+	}
+	return flags
+}
+
+// loadConfiguration loads the configuration of a command from its sources.
+func loadConfiguration(cmd *cobra.Command) (*types.Configuration, error) {
+	configuration := &types.Configuration{}
+	if err := system.LoadConfiguration(configLoaderOptions(cmd), configuration); err != nil {
+		return nil, err
+	}
+	return configuration, nil
+}
@@ -10,42 +10,42 @@ import (
 	"github.com/spf13/cobra"
 )
 
+// NewRootCommand creates the skeleton command along with its subcommands.
+func NewRootCommand() *cobra.Command {
+	rootCmd := &cobra.Command{
+		Use:   "skeleton",
+		Short: "Host and run systems of components",
+		Long: `skeleton hosts a system of services and operations described by a configuration file.
+
+The components are created by the factories and plugins registered with the host,
+see RegisterFactory and RegisterPlugin. Configuration values can be overridden
+with SKELETON_ prefixed environment variables, such as SKELETON_SERVICES_0_RETRYINTERVAL.`,
+		Version:       versionString(),
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
 
+	rootCmd.PersistentFlags().StringP("config", "c", "", "configuration file (JSON, YAML or TOML)")
+	rootCmd.PersistentFlags().Bool("debug", false, "enable debug mode")
+	rootCmd.PersistentFlags().Bool("verbose", false, "enable verbose output")
 
-// rootCmd represents the base command when called without any subcommands
-var rootCmd = &cobra.Command{
-	Use:   "skeleton",
-	Short: "A brief description of your application",
-	Long: `A longer description that spans multiple lines and likely contains
-examples and usage of using your application. For example:
+	rootCmd.AddCommand(
+		newRunCommand(),
+		newConfigCommand(),
+		newComponentsCommand(),
+		newDeadLettersCommand(),
+		newVersionCommand(),
+	)
 
-Cobra is a CLI library for Go that empowers applications.
-This application is a tool to generate the needed files
-to quickly create a Cobra application.`,
-	// Uncomment the following line if your bare application
-	// has an action associated with it:
-	// Run: func(cmd *cobra.Command, args []string) { },
+	return rootCmd
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
-	err := rootCmd.Execute()
-	if err != nil {
+	rootCmd := NewRootCommand()
+	if err := rootCmd.Execute(); err != nil {
+		rootCmd.PrintErrln("Error:", err)
 		os.Exit(1)
 	}
 }
-
-func init() {
-	// Here you will define your flags and configuration settings.
-	// Cobra supports persistent flags, which, if defined here,
-	// will be global for your application.
-
-	// rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.skeleton.yaml)")
-
-	// Cobra also supports local flags, which will only run
-	// when this action is called directly.
-	rootCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
-}
-
-
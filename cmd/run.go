@@ -0,0 +1,185 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ebanfa/skeleton/pkg/common"
+	"github.com/ebanfa/skeleton/pkg/component"
+	"github.com/ebanfa/skeleton/pkg/system"
+	"github.com/ebanfa/skeleton/pkg/types"
+)
+
+const (
+	// configWatcherID is the ID of the service reloading the configuration of a running system.
+	configWatcherID = "skeleton.ConfigWatcher"
+
+	// schedulerID is the ID of the service running the scheduled operations.
+	schedulerID = "skeleton.Scheduler"
+
+	// adminServerID is the ID of the service exposing the system over HTTP.
+	adminServerID = "skeleton.AdminServer"
+
+	// eventJournalID is the ID of the service persisting the events for replay.
+	eventJournalID = "skeleton.EventJournal"
+
+	// deadLetterQueueID is the ID of the service collecting the events whose handlers failed.
+	deadLetterQueueID = "skeleton.DeadLetterQueue"
+)
+
+// newRunCommand creates the command running a system until it is interrupted.
+func newRunCommand() *cobra.Command {
+	runCmd := &cobra.Command{
+		Use:   "run",
+		Short: "Run a system until it receives SIGINT or SIGTERM",
+		Long: `Run builds a system from the configuration, starts it and runs it until the
+process receives SIGINT or SIGTERM. The system is then stopped within the shutdown timeout.`,
+		Args: cobra.NoArgs,
+		RunE: runSystem,
+	}
+
+	runCmd.Flags().String("data-dir", "", "directory of the system store, no store is created when empty")
+	runCmd.Flags().Bool("watch", false, "reload the configuration when the file changes or on SIGHUP")
+	runCmd.Flags().String("admin-addr", "", "address of the HTTP admin API, the API is disabled when empty; a non-loopback address requires --admin-token")
+	runCmd.Flags().String("admin-token", "", "bearer token required by the HTTP admin API; without it, anyone reaching the address controls the system")
+	runCmd.Flags().Bool("journal", false, "persist the events in the system store for replay, requires a data directory")
+	runCmd.Flags().Duration("journal-max-age", 0, "age after which the journaled events are removed, zero keeping them forever")
+	runCmd.Flags().Bool("dead-letters", false, "collect the events whose handlers failed, persisted in the system store when there is a data directory")
+	runCmd.Flags().Duration("shutdown-timeout", system.DefaultShutdownTimeout, "maximum duration of the system shutdown, a second signal abandons the services still stopping")
+
+	return runCmd
+}
+
+// runSystem runs the system described by the configuration of the command.
+// The system store is closed when the system fails to start.
+func runSystem(cmd *cobra.Command, args []string) error {
+	ctx := common.Background()
+
+	configuration, err := loadConfiguration(cmd)
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	dataDir, _ := cmd.Flags().GetString("data-dir")
+	journal, _ := cmd.Flags().GetBool("journal")
+	if journal && dataDir == "" {
+		return fmt.Errorf("the event journal requires a data directory")
+	}
+
+	h, err := newHost(ctx, configuration, dataDir)
+	if err != nil {
+		return err
+	}
+	started := false
+	defer func() {
+		if !started {
+			h.closeStore()
+		}
+	}()
+
+	if watch, _ := cmd.Flags().GetBool("watch"); watch {
+		if err := h.watchConfiguration(ctx, configLoaderOptions(cmd)); err != nil {
+			return err
+		}
+	}
+
+	if hasSchedules(configuration) {
+		if err := h.scheduleOperations(ctx); err != nil {
+			return err
+		}
+	}
+
+	if journal {
+		maxAge, _ := cmd.Flags().GetDuration("journal-max-age")
+		if err := h.journalEvents(ctx, system.EventJournalOptions{MaxAge: maxAge}); err != nil {
+			return err
+		}
+	}
+
+	if deadLetters, _ := cmd.Flags().GetBool("dead-letters"); deadLetters {
+		if err := h.collectDeadLetters(ctx, system.DeadLetterQueueOptions{Persistent: dataDir != ""}); err != nil {
+			return err
+		}
+	}
+
+	if address, _ := cmd.Flags().GetString("admin-addr"); address != "" {
+		token, _ := cmd.Flags().GetString("admin-token")
+		if err := h.serveAdmin(ctx, system.AdminServerOptions{Address: address, Token: token}); err != nil {
+			return err
+		}
+	}
+
+	if err := h.system.Initialize(ctx); err != nil {
+		return fmt.Errorf("failed to initialize system: %w", err)
+	}
+	if err := h.system.Start(ctx); err != nil {
+		return fmt.Errorf("failed to start system: %w", err)
+	}
+	started = true
+	h.logger.Log(common.LevelInfo, "System started")
+
+	timeout, _ := cmd.Flags().GetDuration("shutdown-timeout")
+	coordinator := system.NewShutdownCoordinator(h.system, system.ShutdownOptions{Timeout: timeout})
+	if err := coordinator.Wait(common.WithContext(cmd.Context())); err != nil {
+		return fmt.Errorf("failed to stop system: %w", err)
+	}
+	h.eventBus.Close()
+	h.logger.Log(common.LevelInfo, "System stopped")
+
+	return nil
+}
+
+// watchConfiguration adds a configuration watcher to the system, so that the configuration
+// is reloaded when the configuration file changes or the process receives SIGHUP.
+func (h *host) watchConfiguration(ctx *common.Context, options system.ConfigLoaderOptions) error {
+	return h.addService(ctx, configWatcherID, system.NewConfigWatcher(configWatcherID, system.ConfigWatcherOptions{Loader: options}))
+}
+
+// scheduleOperations adds a scheduler to the system, so that the operations
+// with a schedule in the configuration are run periodically.
+func (h *host) scheduleOperations(ctx *common.Context) error {
+	return h.addService(ctx, schedulerID, system.NewScheduler(schedulerID, system.SchedulerOptions{}))
+}
+
+// serveAdmin adds an admin server to the system, so that the system is exposed over HTTP.
+func (h *host) serveAdmin(ctx *common.Context, options system.AdminServerOptions) error {
+	return h.addService(ctx, adminServerID, system.NewAdminServer(adminServerID, options))
+}
+
+// journalEvents adds an event journal to the system, so that the events are persisted for replay.
+func (h *host) journalEvents(ctx *common.Context, options system.EventJournalOptions) error {
+	return h.addService(ctx, eventJournalID, system.NewEventJournal(eventJournalID, options))
+}
+
+// collectDeadLetters adds a dead-letter queue to the system, so that the events whose handlers
+// failed can be inspected and redriven.
+func (h *host) collectDeadLetters(ctx *common.Context, options system.DeadLetterQueueOptions) error {
+	return h.addService(ctx, deadLetterQueueID, system.NewDeadLetterQueue(deadLetterQueueID, options))
+}
+
+// addService adds a service built by the host to the system: a factory returning the service is
+// registered under the given ID, the service is created with the same ID and initialized.
+func (h *host) addService(ctx *common.Context, factoryID string, service types.SystemServiceInterface) error {
+	factory := component.FactoryFunc(func(config *types.ComponentConfig) (types.ComponentInterface, error) {
+		return service, nil
+	})
+	if err := h.registrar.RegisterFactory(ctx, factoryID, factory); err != nil {
+		return fmt.Errorf("failed to register factory %s: %w", factoryID, err)
+	}
+	if _, err := h.registrar.CreateComponent(ctx, &types.ComponentConfig{ID: factoryID, FactoryID: factoryID}); err != nil {
+		return fmt.Errorf("failed to create component %s: %w", factoryID, err)
+	}
+
+	return service.Initialize(ctx, h.system)
+}
+
+// hasSchedules checks if an operation of the configuration has a schedule.
+func hasSchedules(configuration *types.Configuration) bool {
+	for _, operation := range configuration.Operations {
+		if operation.Schedule != nil {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,248 @@
+// Package bridge forwards events between the event buses of several processes.
+//
+// A Bridge subscribes to selected topics of the local event bus and sends their events through
+// a Transport, while the events received from the transport are published on the local event bus.
+// The built-in transports are SocketTransport, over TCP or Unix sockets, and LoopbackTransport,
+// in memory. The events carry the IDs of the bridges they went through, so that they never loop
+// back, and every bridge drops the events it already received, by event ID.
+package bridge
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/ebanfa/skeleton/pkg/common"
+	"github.com/ebanfa/skeleton/pkg/system"
+	"github.com/ebanfa/skeleton/pkg/types"
+)
+
+// PathHeader is the header listing the node IDs of the bridges an event went through, separated by commas.
+const PathHeader = "bridgePath"
+
+const defaultDedupWindow = 10000
+
+// BridgeOptions represents the options of a bridge.
+type BridgeOptions struct {
+	// NodeID identifies the bridge among the connected bridges, a random ID when empty.
+	NodeID string
+
+	// Outbound are the topics or topic patterns of the local events sent through the transport.
+	// No event is sent when empty, the bridge only receiving events.
+	Outbound []string
+
+	// Inbound are the topics or topic patterns of the received events published on the local event bus,
+	// every topic when nil.
+	Inbound []string
+
+	// DedupWindow is the number of recent event IDs remembered to drop the duplicates, 10000 when zero.
+	DedupWindow int
+}
+
+// Bridge is a service forwarding the events of the local event bus to the bridges of other processes
+// through a transport, and publishing the events they forward on the local event bus.
+//
+// The received events whose topic is also outbound are forwarded to the other peers, so that a bridge
+// can relay the events of several processes. The events without ID are given one when sent. The data
+// of the received events is a json.RawMessage, which typed subscribers decode with common.DecodeEvent.
+type Bridge struct {
+	system.BaseSystemService
+	mutex         sync.Mutex
+	options       BridgeOptions
+	transport     Transport
+	running       atomic.Bool // Received events are published while running
+	subscriptions []common.Subscription
+	seen          *recentIDs
+}
+
+// NewBridge creates a new instance of Bridge sending and receiving the events through the given transport.
+func NewBridge(id string, transport Transport, options BridgeOptions) *Bridge {
+	if options.NodeID == "" {
+		options.NodeID = common.NewEventID()
+	}
+	if options.Inbound == nil {
+		options.Inbound = []string{common.TopicTailWildcard}
+	}
+	if options.DedupWindow <= 0 {
+		options.DedupWindow = defaultDedupWindow
+	}
+
+	return &Bridge{
+		BaseSystemService: *system.NewBaseSystemService(id, "EventBridge", "Forwards events between processes"),
+		options:           options,
+		transport:         transport,
+		seen:              newRecentIDs(options.DedupWindow),
+	}
+}
+
+// NodeID returns the ID of the bridge among the connected bridges.
+func (b *Bridge) NodeID() string {
+	return b.options.NodeID
+}
+
+// Start opens the transport and subscribes to the outbound topics.
+// Returns an error if the transport cannot be opened or a topic pattern is invalid.
+func (b *Bridge) Start(ctx *common.Context) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.System == nil {
+		return types.ErrSystemNotInitialized
+	}
+	if b.running.Load() {
+		return nil
+	}
+	eventBus := b.System.EventBus()
+	if eventBus == nil {
+		return nil
+	}
+
+	b.running.Store(true)
+	if err := b.transport.Open(b.receive); err != nil {
+		b.running.Store(false)
+		return fmt.Errorf("failed to open transport: %w", err)
+	}
+
+	for _, topic := range b.options.Outbound {
+		subscription, err := eventBus.Subscribe(common.BusSubscriptionParams{
+			Topic:        topic,
+			EventHandler: b.forward,
+		})
+		if err != nil {
+			b.stop()
+			return fmt.Errorf("failed to subscribe to %s: %w", topic, err)
+		}
+		b.subscriptions = append(b.subscriptions, subscription)
+	}
+
+	return nil
+}
+
+// Stop unsubscribes from the outbound topics and closes the transport.
+func (b *Bridge) Stop(ctx *common.Context) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if !b.running.Load() {
+		return nil
+	}
+	return b.stop()
+}
+
+// stop unsubscribes from the outbound topics and closes the transport. The caller must hold the mutex,
+// which the receiving goroutines of the transport never take, so that it can wait for them.
+func (b *Bridge) stop() error {
+	for _, subscription := range b.subscriptions {
+		subscription.Unsubscribe()
+	}
+	b.subscriptions = nil
+	b.running.Store(false)
+
+	if err := b.transport.Close(); err != nil {
+		return fmt.Errorf("failed to close transport: %w", err)
+	}
+	return nil
+}
+
+// forward sends a local event through the transport, unless it already went through the bridge.
+func (b *Bridge) forward(event common.Event) {
+	path := event.Headers[PathHeader]
+	if onPath(path, b.options.NodeID) {
+		return
+	}
+
+	message := newMessage(event)
+	if message.ID == "" {
+		message.ID = common.NewEventID()
+	}
+	if message.Headers == nil {
+		message.Headers = make(map[string]string, 1)
+	}
+	if path == "" {
+		message.Headers[PathHeader] = b.options.NodeID
+	} else {
+		message.Headers[PathHeader] = path + "," + b.options.NodeID
+	}
+
+	// The event comes back when a peer relays it, or when it reaches us through another path
+	b.seen.add(message.ID)
+
+	if err := b.transport.Send(message); err != nil {
+		b.logError("Error forwarding event "+event.Type+":", err)
+	}
+}
+
+// receive publishes a message received from the transport on the local event bus, unless it went
+// through the bridge already, is not inbound or was already received.
+func (b *Bridge) receive(message Message) {
+	if !b.running.Load() || onPath(message.Headers[PathHeader], b.options.NodeID) || !b.inbound(message.Type) {
+		return
+	}
+	if message.ID != "" && !b.seen.add(message.ID) {
+		return
+	}
+
+	if eventBus := b.System.EventBus(); eventBus != nil {
+		eventBus.Publish(message.event())
+	}
+}
+
+// inbound checks if the events of the given topic are published on the local event bus.
+func (b *Bridge) inbound(topic string) bool {
+	for _, pattern := range b.options.Inbound {
+		if common.MatchTopic(pattern, topic) {
+			return true
+		}
+	}
+	return false
+}
+
+// logError logs an error if the system has a logger.
+func (b *Bridge) logError(message string, err error) {
+	if logger := b.System.Logger(); logger != nil {
+		logger.Log(common.LevelError, message, err)
+	}
+}
+
+// onPath checks if the node with the given ID is on the path of an event.
+func onPath(path, nodeID string) bool {
+	for path != "" {
+		var node string
+		node, path, _ = strings.Cut(path, ",")
+		if node == nodeID {
+			return true
+		}
+	}
+	return false
+}
+
+// recentIDs remembers a bounded number of IDs, forgetting the oldest first.
+type recentIDs struct {
+	mutex sync.Mutex
+	ids   map[string]struct{}
+	order []string // IDs in a ring, next being the position of the oldest
+	next  int
+}
+
+// newRecentIDs creates a set remembering the given number of IDs.
+func newRecentIDs(size int) *recentIDs {
+	return &recentIDs{ids: make(map[string]struct{}, size), order: make([]string, size)}
+}
+
+// add adds an ID, returning false if it was already remembered.
+func (r *recentIDs) add(id string) bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, ok := r.ids[id]; ok {
+		return false
+	}
+	if oldest := r.order[r.next]; oldest != "" {
+		delete(r.ids, oldest)
+	}
+	r.ids[id] = struct{}{}
+	r.order[r.next] = id
+	r.next = (r.next + 1) % len(r.order)
+	return true
+}
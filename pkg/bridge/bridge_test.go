@@ -0,0 +1,224 @@
+package bridge_test
+
+import (
+	"encoding/json"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/ebanfa/skeleton/pkg/bridge"
+	"github.com/ebanfa/skeleton/pkg/common"
+	"github.com/ebanfa/skeleton/pkg/mocks"
+	"github.com/ebanfa/skeleton/pkg/system"
+	"github.com/ebanfa/skeleton/pkg/types"
+)
+
+// process represents a process with its own event bus, recording the events of its bus.
+type process struct {
+	eventBus common.EventBusInterface
+	system   *mocks.SystemInterface
+	events   chan common.Event
+}
+
+// newProcess creates a process recording the events of the given topics.
+func newProcess(topics ...string) *process {
+	p := &process{eventBus: common.NewSystemEventBus(), events: make(chan common.Event, 100)}
+
+	p.system = &mocks.SystemInterface{}
+	p.system.On("EventBus").Return(p.eventBus)
+	p.system.On("Logger").Return(nil)
+
+	for _, topic := range topics {
+		Expect(p.eventBus.Subscribe(common.BusSubscriptionParams{
+			Topic:        topic,
+			EventHandler: func(event common.Event) { p.events <- event },
+		})).Error().NotTo(HaveOccurred())
+	}
+	return p
+}
+
+// bridge starts a bridge of the process on the given transport.
+func (p *process) bridge(transport bridge.Transport, options bridge.BridgeOptions) *bridge.Bridge {
+	b := bridge.NewBridge("bridge", transport, options)
+	Expect(b.Initialize(common.Background(), p.system)).To(Succeed())
+	Expect(b.Start(common.Background())).To(Succeed())
+	DeferCleanup(b.Stop, common.Background())
+	return b
+}
+
+var _ = Describe("Bridge", func() {
+	var network *bridge.LoopbackNetwork
+
+	BeforeEach(func() {
+		network = bridge.NewLoopbackNetwork()
+	})
+
+	It("requires a system", func() {
+		Expect(bridge.NewBridge("bridge", network.Transport(), bridge.BridgeOptions{}).Start(common.Background())).To(MatchError(types.ErrSystemNotInitialized))
+	})
+
+	It("forwards the outbound events with their envelope", func() {
+		type order struct {
+			ID string `json:"id"`
+		}
+		schema := common.EventSchema[order]{Topic: "order.created", Version: 2}
+
+		a, b := newProcess(), newProcess(">")
+		a.bridge(network.Transport(), bridge.BridgeOptions{NodeID: "a", Outbound: []string{"order.>"}})
+		b.bridge(network.Transport(), bridge.BridgeOptions{NodeID: "b"})
+
+		published := common.Publish(common.Background().WithTraceID("trace"), a.eventBus, "api", schema, order{ID: "1"})
+
+		var event common.Event
+		Expect(b.events).To(Receive(&event))
+		Expect(event.Type).To(Equal("order.created"))
+		Expect(event.Data).To(Equal(json.RawMessage(`{"id":"1"}`)))
+		Expect(event.ID).To(Equal(published.ID))
+		Expect(event.Source).To(Equal("api"))
+		Expect(event.Time).To(BeTemporally("==", published.Time))
+		Expect(event.TraceID).To(Equal("trace"))
+		Expect(event.Headers).To(HaveKeyWithValue(bridge.PathHeader, "a"))
+		Expect(common.DecodeEvent(schema, event)).To(Equal(order{ID: "1"}))
+	})
+
+	It("only forwards the outbound topics and publishes the inbound topics", func() {
+		a, b := newProcess(), newProcess(">")
+		a.bridge(network.Transport(), bridge.BridgeOptions{Outbound: []string{"order.>", "service.>"}})
+		b.bridge(network.Transport(), bridge.BridgeOptions{Inbound: []string{"order.*"}})
+
+		a.eventBus.Publish(common.Event{Type: "service.started"})
+		a.eventBus.Publish(common.Event{Type: "invoice.created"})
+		a.eventBus.Publish(common.Event{Type: "order.created"})
+
+		Expect(b.events).To(Receive(HaveField("Type", "order.created")))
+		Expect(b.events).NotTo(Receive())
+	})
+
+	It("gives an ID to the events without one", func() {
+		a, b := newProcess(), newProcess(">")
+		a.bridge(network.Transport(), bridge.BridgeOptions{Outbound: []string{">"}})
+		b.bridge(network.Transport(), bridge.BridgeOptions{})
+
+		a.eventBus.Publish(common.Event{Type: "topic"})
+
+		Expect(b.events).To(Receive(HaveField("ID", HaveLen(32))))
+	})
+
+	It("does not send the events back to their origin", func() {
+		a, b := newProcess(">"), newProcess(">")
+		a.bridge(network.Transport(), bridge.BridgeOptions{NodeID: "a", Outbound: []string{">"}})
+		b.bridge(network.Transport(), bridge.BridgeOptions{NodeID: "b", Outbound: []string{">"}})
+
+		a.eventBus.Publish(common.Event{Type: "topic", ID: "1"})
+
+		Expect(a.events).To(Receive(HaveField("ID", "1")))
+		Expect(a.events).NotTo(Receive())
+		Expect(b.events).To(Receive(HaveField("ID", "1")))
+		Expect(b.events).NotTo(Receive())
+	})
+
+	It("relays the events between networks", func() {
+		other := bridge.NewLoopbackNetwork()
+		a, hub, c := newProcess(">"), newProcess(), newProcess(">")
+		a.bridge(network.Transport(), bridge.BridgeOptions{NodeID: "a", Outbound: []string{">"}})
+		hub.bridge(network.Transport(), bridge.BridgeOptions{NodeID: "hub-a", Outbound: []string{">"}})
+		hub.bridge(other.Transport(), bridge.BridgeOptions{NodeID: "hub-c", Outbound: []string{">"}})
+		c.bridge(other.Transport(), bridge.BridgeOptions{NodeID: "c", Outbound: []string{">"}})
+
+		a.eventBus.Publish(common.Event{Type: "topic", ID: "1"})
+
+		var event common.Event
+		Expect(c.events).To(Receive(&event))
+		Expect(event.Headers).To(HaveKeyWithValue(bridge.PathHeader, "a,hub-c"))
+		Expect(c.events).NotTo(Receive())
+
+		Expect(a.events).To(Receive(HaveField("ID", "1")))
+		Expect(a.events).NotTo(Receive())
+	})
+
+	It("drops the duplicates", func() {
+		b := newProcess(">")
+		b.bridge(network.Transport(), bridge.BridgeOptions{DedupWindow: 2})
+
+		sender := network.Transport()
+		Expect(sender.Open(func(bridge.Message) {})).To(Succeed())
+		for _, id := range []string{"1", "1", "2", "3", "1"} {
+			Expect(sender.Send(bridge.Message{Type: "topic", ID: id})).To(Succeed())
+		}
+
+		// The first ID is forgotten once two newer IDs were received
+		for _, id := range []string{"1", "2", "3", "1"} {
+			Expect(b.events).To(Receive(HaveField("ID", id)))
+		}
+		Expect(b.events).NotTo(Receive())
+	})
+
+	It("stops forwarding and publishing when stopped", func() {
+		a, b := newProcess(), newProcess(">")
+		bridgeA := a.bridge(network.Transport(), bridge.BridgeOptions{Outbound: []string{">"}})
+		bridgeB := b.bridge(network.Transport(), bridge.BridgeOptions{Outbound: []string{">"}})
+
+		Expect(bridgeA.Stop(common.Background())).To(Succeed())
+		a.eventBus.Publish(common.Event{Type: "topic"})
+		Expect(b.events).NotTo(Receive())
+
+		Expect(bridgeA.Start(common.Background())).To(Succeed())
+		Expect(bridgeB.Stop(common.Background())).To(Succeed())
+		a.eventBus.Publish(common.Event{Type: "topic"})
+		Expect(b.events).NotTo(Receive())
+	})
+
+	It("rejects the invalid outbound topic patterns", func() {
+		p := newProcess()
+		b := bridge.NewBridge("bridge", network.Transport(), bridge.BridgeOptions{Outbound: []string{"order.>.created"}})
+		Expect(b.Initialize(common.Background(), p.system)).To(Succeed())
+		Expect(b.Start(common.Background())).To(MatchError(common.ErrInvalidTopicPattern))
+	})
+
+	Describe("BridgeFactory", func() {
+		// create creates a bridge of the process from the given custom configuration and starts it.
+		create := func(p *process, customConfig map[string]interface{}) *bridge.Bridge {
+			factory := bridge.NewBridgeFactory()
+			decoded, err := system.DecodeCustomConfig(factory, customConfig)
+			Expect(err).NotTo(HaveOccurred())
+
+			component, err := factory.CreateComponent(&types.ComponentConfig{ID: "bridge", CustomConfig: decoded})
+			Expect(err).NotTo(HaveOccurred())
+
+			b := component.(*bridge.Bridge)
+			Expect(b.Initialize(common.Background(), p.system)).To(Succeed())
+			Expect(b.Start(common.Background())).To(Succeed())
+			DeferCleanup(b.Stop, common.Background())
+			return b
+		}
+
+		It("creates bridges over socket transports from their decoded custom configuration", func() {
+			address := filepath.Join(GinkgoT().TempDir(), "bridge.sock")
+			a, b := newProcess(), newProcess("order.>")
+			create(a, map[string]interface{}{
+				"transport": "unix", "address": address, "listen": true, "nodeId": "a", "outbound": []interface{}{"order.>"},
+			})
+			Expect(create(b, map[string]interface{}{"transport": "unix", "address": address}).NodeID()).NotTo(BeEmpty())
+
+			// The dialing bridge connects in the background
+			Eventually(func() bool {
+				a.eventBus.Publish(common.NewEvent(nil, "api", "order.created", "1"))
+				select {
+				case <-b.events:
+					return true
+				default:
+					return false
+				}
+			}).Should(BeTrue())
+		})
+
+		It("rejects the invalid bridge configurations", func() {
+			factory := bridge.NewBridgeFactory()
+			Expect(factory.CreateComponent(&types.ComponentConfig{ID: "bridge"})).Error().To(MatchError(types.ErrInvalidConfiguration))
+			Expect(factory.CreateComponent(&types.ComponentConfig{ID: "bridge", CustomConfig: &bridge.BridgeConfiguration{
+				Transport: "udp", Address: "localhost:7070",
+			}})).Error().To(MatchError(types.ErrInvalidConfiguration))
+		})
+	})
+})
@@ -0,0 +1,63 @@
+package bridge
+
+import (
+	"fmt"
+
+	"github.com/ebanfa/skeleton/pkg/types"
+)
+
+// BridgeConfiguration represents the custom configuration of the bridges created by BridgeFactory,
+// which forward the events through a socket transport.
+type BridgeConfiguration struct {
+	Transport   string   `json:"transport"`                 // Network of the socket transport, tcp or unix, tcp when empty
+	Address     string   `json:"address" schema:"required"` // Address listened on or dialed
+	Listen      bool     `json:"listen"`                    // Accept the peers dialing the address, instead of dialing it
+	NodeID      string   `json:"nodeId"`                    // ID of the bridge among the connected bridges, a random ID when empty
+	Inbound     []string `json:"inbound"`                   // Topics of the received events published locally, every topic when absent
+	Outbound    []string `json:"outbound"`                  // Topics of the local events sent to the peers
+	DedupWindow int      `json:"dedupWindow"`               // Number of recent event IDs remembered to drop the duplicates
+}
+
+// BridgeFactory creates bridges over socket transports from the bridge configuration held in their
+// custom configuration.
+type BridgeFactory struct{}
+
+// NewBridgeFactory creates a new instance of BridgeFactory.
+func NewBridgeFactory() *BridgeFactory {
+	return &BridgeFactory{}
+}
+
+// NewCustomConfig returns a new bridge configuration, the type of the custom configuration of bridges.
+func (f *BridgeFactory) NewCustomConfig() interface{} {
+	return &BridgeConfiguration{}
+}
+
+// CreateComponent creates a bridge from the given configuration.
+// Returns an error if the custom configuration is not a valid bridge configuration.
+func (f *BridgeFactory) CreateComponent(config *types.ComponentConfig) (types.ComponentInterface, error) {
+	bridgeConfig, ok := config.CustomConfig.(*BridgeConfiguration)
+	if !ok {
+		return nil, fmt.Errorf("%w: custom configuration of type %T, expected *bridge.BridgeConfiguration",
+			types.ErrInvalidConfiguration, config.CustomConfig)
+	}
+	switch bridgeConfig.Transport {
+	case "", "tcp", "unix":
+	default:
+		return nil, fmt.Errorf("%w: unknown transport %q of bridge %s", types.ErrInvalidConfiguration, bridgeConfig.Transport, config.ID)
+	}
+	if bridgeConfig.Address == "" {
+		return nil, fmt.Errorf("%w: no address given for bridge %s", types.ErrInvalidConfiguration, config.ID)
+	}
+
+	transport := NewSocketTransport(SocketTransportOptions{
+		Network: bridgeConfig.Transport,
+		Address: bridgeConfig.Address,
+		Listen:  bridgeConfig.Listen,
+	})
+	return NewBridge(config.ID, transport, BridgeOptions{
+		NodeID:      bridgeConfig.NodeID,
+		Outbound:    bridgeConfig.Outbound,
+		Inbound:     bridgeConfig.Inbound,
+		DedupWindow: bridgeConfig.DedupWindow,
+	}), nil
+}
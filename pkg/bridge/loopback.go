@@ -0,0 +1,71 @@
+package bridge
+
+import "sync"
+
+// LoopbackNetwork connects in-memory transports, a message sent on one of them being received
+// by all the others. Messages are delivered synchronously, on the goroutine of the sender,
+// which makes it suitable for tests.
+type LoopbackNetwork struct {
+	mutex      sync.RWMutex
+	transports map[*LoopbackTransport]func(message Message)
+}
+
+// NewLoopbackNetwork creates a new instance of LoopbackNetwork.
+func NewLoopbackNetwork() *LoopbackNetwork {
+	return &LoopbackNetwork{transports: make(map[*LoopbackTransport]func(message Message))}
+}
+
+// Transport creates a transport connected to the network.
+func (n *LoopbackNetwork) Transport() *LoopbackTransport {
+	return &LoopbackTransport{network: n}
+}
+
+// LoopbackTransport is an in-memory implementation of Transport connected to a LoopbackNetwork.
+type LoopbackTransport struct {
+	network *LoopbackNetwork
+}
+
+// Ensure LoopbackTransport implements Transport.
+var _ Transport = (*LoopbackTransport)(nil)
+
+// Open connects the transport to the network.
+func (t *LoopbackTransport) Open(receive func(message Message)) error {
+	t.network.mutex.Lock()
+	defer t.network.mutex.Unlock()
+
+	t.network.transports[t] = receive
+	return nil
+}
+
+// Send delivers a message to the other open transports of the network.
+func (t *LoopbackTransport) Send(message Message) error {
+	t.network.mutex.RLock()
+	if _, ok := t.network.transports[t]; !ok {
+		t.network.mutex.RUnlock()
+		return ErrTransportClosed
+	}
+	receivers := make([]func(message Message), 0, len(t.network.transports))
+	for transport, receive := range t.network.transports {
+		if transport != t {
+			receivers = append(receivers, receive)
+		}
+	}
+	t.network.mutex.RUnlock()
+
+	// The receivers are called without the lock, so that they can send messages themselves
+	for _, receive := range receivers {
+		copied := message
+		copied.Headers = copyHeaders(message.Headers)
+		receive(copied)
+	}
+	return nil
+}
+
+// Close disconnects the transport from the network.
+func (t *LoopbackTransport) Close() error {
+	t.network.mutex.Lock()
+	defer t.network.mutex.Unlock()
+
+	delete(t.network.transports, t)
+	return nil
+}
@@ -0,0 +1,324 @@
+package bridge
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+const (
+	defaultSocketNetwork        = "tcp"
+	defaultSocketBufferSize     = 256
+	defaultReconnectInterval    = 100 * time.Millisecond
+	defaultMaxReconnectInterval = 10 * time.Second
+)
+
+// SocketTransportOptions represents the options of a socket transport.
+type SocketTransportOptions struct {
+	// Network is the network of the address, either tcp or unix, tcp when empty.
+	Network string
+
+	// Address is the address listened on or dialed, such as localhost:7070 or the path of a Unix socket.
+	Address string
+
+	// Listen accepts the peers dialing the address, instead of dialing it.
+	Listen bool
+
+	// BufferSize is the number of messages queued per peer, 256 when zero.
+	BufferSize int
+
+	// ReconnectInterval is the delay before dialing again after a failure, 100ms when zero.
+	// It is doubled after each failure, up to MaxReconnectInterval.
+	ReconnectInterval time.Duration
+
+	// MaxReconnectInterval caps the delay between two dialing attempts, 10s when zero.
+	MaxReconnectInterval time.Duration
+}
+
+// SocketTransport is an implementation of Transport over TCP or Unix sockets, the messages being written
+// as JSON lines. A listening transport accepts any number of peers and sends the messages to all of them.
+// A dialing transport connects to a listening one in the background, and dials again with an exponential
+// backoff whenever the connection fails.
+//
+// The messages sent while a dialing transport is disconnected are queued until it reconnects, and the
+// message being written when the connection failed is written again, so a peer may receive it twice:
+// the bridges drop the duplicates by event ID. The messages queued for a peer of a listening transport
+// are lost when the peer disconnects.
+type SocketTransport struct {
+	mutex    sync.Mutex
+	options  SocketTransportOptions
+	listener net.Listener
+	peers    map[*socketPeer]struct{} // Connected peers
+	outbox   chan Message             // Messages queued by a dialing transport, kept across reconnections
+	done     chan struct{}            // Closed when the transport closes
+	routines sync.WaitGroup
+}
+
+// socketPeer represents the connection to a peer.
+type socketPeer struct {
+	conn   net.Conn
+	outbox chan Message
+}
+
+// Ensure SocketTransport implements Transport.
+var _ Transport = (*SocketTransport)(nil)
+
+// NewSocketTransport creates a new instance of SocketTransport.
+func NewSocketTransport(options SocketTransportOptions) *SocketTransport {
+	if options.Network == "" {
+		options.Network = defaultSocketNetwork
+	}
+	if options.BufferSize <= 0 {
+		options.BufferSize = defaultSocketBufferSize
+	}
+	if options.ReconnectInterval <= 0 {
+		options.ReconnectInterval = defaultReconnectInterval
+	}
+	if options.MaxReconnectInterval <= 0 {
+		options.MaxReconnectInterval = defaultMaxReconnectInterval
+	}
+
+	return &SocketTransport{
+		options: options,
+		peers:   make(map[*socketPeer]struct{}),
+	}
+}
+
+// Addr returns the address a listening transport listens on, or an empty string if it is not open.
+func (t *SocketTransport) Addr() string {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.listener == nil {
+		return ""
+	}
+	return t.listener.Addr().String()
+}
+
+// Peers returns the number of connected peers, at most one for a dialing transport.
+func (t *SocketTransport) Peers() int {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	return len(t.peers)
+}
+
+// Open listens on the address, or starts dialing it in the background.
+// Returns an error if the address cannot be listened on.
+func (t *SocketTransport) Open(receive func(message Message)) error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.done != nil {
+		return nil
+	}
+
+	done := make(chan struct{})
+	if t.options.Listen {
+		listener, err := net.Listen(t.options.Network, t.options.Address)
+		if err != nil {
+			return fmt.Errorf("failed to listen on %s: %w", t.options.Address, err)
+		}
+		t.listener = listener
+		t.routines.Add(1)
+		go t.accept(listener, receive, done)
+	} else {
+		t.outbox = make(chan Message, t.options.BufferSize)
+		t.routines.Add(1)
+		go t.dial(t.outbox, receive, done)
+	}
+	t.done = done
+
+	return nil
+}
+
+// Send queues a message for every connected peer, or until a dialing transport is connected.
+func (t *SocketTransport) Send(message Message) error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.done == nil {
+		return ErrTransportClosed
+	}
+	if !t.options.Listen {
+		return enqueue(t.outbox, message)
+	}
+
+	var err error
+	for peer := range t.peers {
+		if enqueueErr := enqueue(peer.outbox, message); enqueueErr != nil {
+			err = enqueueErr
+		}
+	}
+	return err
+}
+
+// Close stops listening or dialing and closes the connections, waiting for their goroutines to end.
+func (t *SocketTransport) Close() error {
+	t.mutex.Lock()
+	if t.done == nil {
+		t.mutex.Unlock()
+		return nil
+	}
+
+	close(t.done)
+	var err error
+	if t.listener != nil {
+		err = t.listener.Close()
+	}
+	for peer := range t.peers {
+		peer.conn.Close()
+	}
+	t.done, t.listener, t.outbox = nil, nil, nil
+	t.mutex.Unlock()
+
+	t.routines.Wait()
+	return err
+}
+
+// accept accepts the peers until the listener is closed.
+func (t *SocketTransport) accept(listener net.Listener, receive func(message Message), done chan struct{}) {
+	defer t.routines.Done()
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			select {
+			case <-done:
+				return
+			case <-time.After(t.options.ReconnectInterval):
+				continue
+			}
+		}
+
+		peer := &socketPeer{conn: conn, outbox: make(chan Message, t.options.BufferSize)}
+		if !t.register(peer, done) {
+			conn.Close()
+			return
+		}
+		t.routines.Add(1)
+		go func() {
+			defer t.routines.Done()
+			t.exchange(peer, receive, nil, done)
+		}()
+	}
+}
+
+// dial connects to the address until the transport closes, dialing again with an exponential backoff
+// whenever the connection fails.
+func (t *SocketTransport) dial(outbox chan Message, receive func(message Message), done chan struct{}) {
+	defer t.routines.Done()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-done:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	var (
+		dialer  net.Dialer
+		pending *Message
+		delay   = t.options.ReconnectInterval
+	)
+	for {
+		conn, err := dialer.DialContext(ctx, t.options.Network, t.options.Address)
+		if err == nil {
+			peer := &socketPeer{conn: conn, outbox: outbox}
+			if !t.register(peer, done) {
+				conn.Close()
+				return
+			}
+			delay = t.options.ReconnectInterval
+			pending = t.exchange(peer, receive, pending, done)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+		delay = min(delay*2, t.options.MaxReconnectInterval)
+	}
+}
+
+// exchange reads the messages of a peer and writes the queued messages to it, starting with the pending
+// message if not nil, until the connection fails or the transport closes. Returns the message whose
+// writing failed, if any.
+func (t *SocketTransport) exchange(peer *socketPeer, receive func(message Message), pending *Message, done chan struct{}) *Message {
+	defer t.unregister(peer)
+
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		decoder := json.NewDecoder(peer.conn)
+		for {
+			var message Message
+			if err := decoder.Decode(&message); err != nil {
+				return
+			}
+			receive(message)
+		}
+	}()
+
+	// Closing the connection ends the reading goroutine
+	defer func() {
+		peer.conn.Close()
+		<-closed
+	}()
+
+	encoder := json.NewEncoder(peer.conn)
+	if pending != nil {
+		if err := encoder.Encode(pending); err != nil {
+			return pending
+		}
+	}
+	for {
+		select {
+		case <-done:
+			return nil
+		case <-closed:
+			return nil
+		case message := <-peer.outbox:
+			if err := encoder.Encode(&message); err != nil {
+				return &message
+			}
+		}
+	}
+}
+
+// register adds a connected peer, unless the transport was closed meanwhile.
+func (t *SocketTransport) register(peer *socketPeer, done chan struct{}) bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.done != done {
+		return false
+	}
+	t.peers[peer] = struct{}{}
+	return true
+}
+
+// unregister removes a disconnected peer.
+func (t *SocketTransport) unregister(peer *socketPeer) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	delete(t.peers, peer)
+}
+
+// enqueue queues a message without blocking, returning ErrTransportFull if the queue is full.
+func enqueue(outbox chan Message, message Message) error {
+	select {
+	case outbox <- message:
+		return nil
+	default:
+		return ErrTransportFull
+	}
+}
@@ -0,0 +1,132 @@
+package bridge_test
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/ebanfa/skeleton/pkg/bridge"
+	"github.com/ebanfa/skeleton/pkg/common"
+)
+
+var _ = Describe("SocketTransport", func() {
+	var (
+		listenerOptions bridge.SocketTransportOptions
+		dialerOptions   bridge.SocketTransportOptions
+		listener        *bridge.SocketTransport
+		dialer          *bridge.SocketTransport
+		listenerInbox   chan bridge.Message
+		dialerInbox     chan bridge.Message
+	)
+
+	receiver := func(inbox chan bridge.Message) func(bridge.Message) {
+		return func(message bridge.Message) { inbox <- message }
+	}
+
+	BeforeEach(func() {
+		listenerOptions = bridge.SocketTransportOptions{Address: "127.0.0.1:0", Listen: true}
+		dialerOptions = bridge.SocketTransportOptions{ReconnectInterval: 10 * time.Millisecond, MaxReconnectInterval: 20 * time.Millisecond}
+		listenerInbox = make(chan bridge.Message, 100)
+		dialerInbox = make(chan bridge.Message, 100)
+	})
+
+	JustBeforeEach(func() {
+		listener = bridge.NewSocketTransport(listenerOptions)
+		Expect(listener.Open(receiver(listenerInbox))).To(Succeed())
+		DeferCleanup(listener.Close)
+
+		if dialerOptions.Address == "" {
+			dialerOptions.Network, dialerOptions.Address = listenerOptions.Network, listener.Addr()
+		}
+		dialer = bridge.NewSocketTransport(dialerOptions)
+		Expect(dialer.Open(receiver(dialerInbox))).To(Succeed())
+		DeferCleanup(dialer.Close)
+	})
+
+	// exchange checks that the messages go both ways.
+	exchange := func() {
+		Expect(dialer.Send(bridge.Message{Type: "ping", ID: "1", Data: []byte(`{"n":1}`)})).To(Succeed())
+		var message bridge.Message
+		Eventually(listenerInbox).Should(Receive(&message))
+		Expect(message.Type).To(Equal("ping"))
+		Expect(string(message.Data)).To(Equal(`{"n":1}`))
+
+		// The dialer is connected once the listener received its message
+		Expect(listener.Send(bridge.Message{Type: "pong", ID: "2"})).To(Succeed())
+		Eventually(dialerInbox).Should(Receive(HaveField("Type", "pong")))
+	}
+
+	It("exchanges messages over TCP", func() {
+		exchange()
+	})
+
+	Context("over a Unix socket", func() {
+		BeforeEach(func() {
+			dir, err := os.MkdirTemp("", "bridge")
+			Expect(err).NotTo(HaveOccurred())
+			DeferCleanup(os.RemoveAll, dir)
+
+			listenerOptions.Network, listenerOptions.Address = "unix", filepath.Join(dir, "events.sock")
+		})
+
+		It("exchanges messages", func() {
+			exchange()
+		})
+	})
+
+	It("reconnects and sends the messages queued while disconnected", func() {
+		exchange()
+
+		Expect(dialer.Peers()).To(Equal(1))
+		Expect(listener.Close()).To(Succeed())
+		Eventually(dialer.Peers).Should(BeZero())
+		Expect(dialer.Send(bridge.Message{Type: "queued", ID: "3"})).To(Succeed())
+
+		listenerOptions.Address = dialerOptions.Address
+		listener = bridge.NewSocketTransport(listenerOptions)
+		Expect(listener.Open(receiver(listenerInbox))).To(Succeed())
+		DeferCleanup(listener.Close)
+
+		Eventually(listenerInbox).Should(Receive(HaveField("Type", "queued")))
+	})
+
+	It("reports the closed transports", func() {
+		Expect(dialer.Close()).To(Succeed())
+		Expect(dialer.Send(bridge.Message{Type: "topic"})).To(MatchError(bridge.ErrTransportClosed))
+		Expect(dialer.Close()).To(Succeed())
+	})
+
+	Context("when the peer is unreachable", func() {
+		BeforeEach(func() {
+			dialerOptions.Address = "127.0.0.1:1"
+			dialerOptions.BufferSize = 1
+		})
+
+		It("reports the full buffers", func() {
+			Expect(dialer.Send(bridge.Message{Type: "topic"})).To(Succeed())
+			Expect(dialer.Send(bridge.Message{Type: "topic"})).To(MatchError(bridge.ErrTransportFull))
+		})
+	})
+
+	It("bridges the event buses of two processes", func() {
+		a, b := newProcess(">"), newProcess(">")
+		transport := bridge.NewSocketTransport(bridge.SocketTransportOptions{Address: "127.0.0.1:0", Listen: true})
+		a.bridge(transport, bridge.BridgeOptions{NodeID: "a", Outbound: []string{">"}})
+		b.bridge(bridge.NewSocketTransport(bridge.SocketTransportOptions{Address: transport.Addr()}),
+			bridge.BridgeOptions{NodeID: "b", Outbound: []string{">"}})
+
+		b.eventBus.Publish(common.Event{Type: "order.created", ID: "1"})
+		Expect(b.events).To(Receive(HaveField("ID", "1")))
+		Eventually(a.events).Should(Receive(HaveField("ID", "1")))
+
+		a.eventBus.Publish(common.Event{Type: "order.shipped", ID: "2"})
+		Expect(a.events).To(Receive(HaveField("ID", "2")))
+		Eventually(b.events).Should(Receive(HaveField("ID", "2")))
+
+		Consistently(a.events, 100*time.Millisecond).ShouldNot(Receive())
+		Consistently(b.events).ShouldNot(Receive())
+	})
+})
@@ -0,0 +1,92 @@
+package bridge
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ebanfa/skeleton/pkg/common"
+)
+
+var (
+	// ErrTransportClosed is returned when sending a message on a transport that is not open.
+	ErrTransportClosed = errors.New("transport closed")
+
+	// ErrTransportFull is returned when a message is dropped because the send buffer of a peer is full.
+	ErrTransportFull = errors.New("transport buffer full")
+)
+
+// Message is an event crossing a transport, with its envelope and its data encoded as JSON.
+type Message struct {
+	Type    string            `json:"type"`
+	Data    json.RawMessage   `json:"data,omitempty"`
+	ID      string            `json:"id"`
+	Source  string            `json:"source,omitempty"`
+	Time    time.Time         `json:"time"`
+	TraceID string            `json:"traceId,omitempty"`
+	Version int               `json:"version,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+// Transport carries the messages between the bridges of several processes.
+type Transport interface {
+	// Open starts the transport, passing the messages received from the peers to receive until
+	// the transport is closed. receive may be called concurrently for messages of different peers.
+	Open(receive func(message Message)) error
+
+	// Send sends a message to the peers. Returns ErrTransportClosed if the transport is not open,
+	// or ErrTransportFull if the message could not be queued for a peer.
+	Send(message Message) error
+
+	// Close closes the transport. It can be opened again afterwards.
+	Close() error
+}
+
+// newMessage returns the message of an event. Data that cannot be encoded as JSON is sent as text.
+func newMessage(event common.Event) Message {
+	data, err := json.Marshal(event.Data)
+	if err != nil {
+		data, _ = json.Marshal(fmt.Sprint(event.Data))
+	}
+
+	return Message{
+		Type:    event.Type,
+		Data:    data,
+		ID:      event.ID,
+		Source:  event.Source,
+		Time:    event.Time,
+		TraceID: event.TraceID,
+		Version: event.Version,
+		Headers: copyHeaders(event.Headers),
+	}
+}
+
+// event returns the event of the message, its data being a json.RawMessage.
+func (m Message) event() common.Event {
+	event := common.Event{
+		Type:    m.Type,
+		ID:      m.ID,
+		Source:  m.Source,
+		Time:    m.Time,
+		TraceID: m.TraceID,
+		Version: m.Version,
+		Headers: copyHeaders(m.Headers),
+	}
+	if len(m.Data) > 0 && string(m.Data) != "null" {
+		event.Data = m.Data
+	}
+	return event
+}
+
+// copyHeaders returns a copy of the headers, so that the receivers of a message do not share them.
+func copyHeaders(headers map[string]string) map[string]string {
+	if headers == nil {
+		return nil
+	}
+	copied := make(map[string]string, len(headers))
+	for key, value := range headers {
+		copied[key] = value
+	}
+	return copied
+}
@@ -0,0 +1,362 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+const defaultChannelBufferSize = 64
+
+// ErrUnknownOverflowPolicy is returned when parsing the name of an unknown overflow policy.
+var ErrUnknownOverflowPolicy = errors.New("unknown overflow policy")
+
+// OverflowPolicy determines what a ChannelEventBus does when the buffer of a subscriber is full.
+type OverflowPolicy int
+
+const (
+	// OverflowBlock blocks the publisher until the subscriber has room for the event.
+	OverflowBlock OverflowPolicy = iota
+
+	// OverflowDropOldest discards the oldest buffered event of the subscriber to make room for the new one.
+	OverflowDropOldest
+
+	// OverflowDropNewest discards the new event, keeping the buffered ones.
+	OverflowDropNewest
+)
+
+// String returns the string representation of the overflow policy.
+func (p OverflowPolicy) String() string {
+	switch p {
+	case OverflowBlock:
+		return "block"
+	case OverflowDropOldest:
+		return "drop-oldest"
+	case OverflowDropNewest:
+		return "drop-newest"
+	default:
+		return fmt.Sprintf("OverflowPolicy(%d)", int(p))
+	}
+}
+
+// ParseOverflowPolicy returns the overflow policy with the given name, as returned by String.
+// An empty name is OverflowBlock.
+func ParseOverflowPolicy(name string) (OverflowPolicy, error) {
+	for _, policy := range []OverflowPolicy{OverflowBlock, OverflowDropOldest, OverflowDropNewest} {
+		if name == policy.String() {
+			return policy, nil
+		}
+	}
+	if name == "" {
+		return OverflowBlock, nil
+	}
+	return OverflowBlock, fmt.Errorf("%w %q, expected %s, %s or %s",
+		ErrUnknownOverflowPolicy, name, OverflowBlock, OverflowDropOldest, OverflowDropNewest)
+}
+
+// ChannelEventBusOptions represents the options of a ChannelEventBus.
+type ChannelEventBusOptions struct {
+	// BufferSize is the number of events buffered per subscriber, 64 when zero.
+	BufferSize int
+
+	// Overflow is the policy applied when the buffer of a subscriber is full.
+	Overflow OverflowPolicy
+
+	// Logger logs the events whose handler failed every attempt, when given.
+	Logger LoggerInterface
+}
+
+// ChannelEventBus is an implementation of the EventBusInterface delivering the events through a bounded
+// buffered channel per subscriber. Each subscriber has its own goroutine, which handles the events one
+// at a time in the order they were published, so that a slow handler only holds back its own events.
+// A full buffer blocks the publisher or drops events depending on the overflow policy.
+//
+// As every handler runs on the goroutine of its subscriber, Subscribe and SubscribeAsync behave the same,
+// and all subscriptions are transactional. Failures and panics of the handlers are retried and then
+// dead-lettered, so that they do not stop the delivery of the next events; removing a subscription
+// or closing the bus ends the retries. Under OverflowBlock, a handler
+// publishing to its own topic blocks forever once its buffer is full.
+type ChannelEventBus struct {
+	mutex         sync.RWMutex                      // Mutex guarding the subscriptions
+	options       ChannelEventBusOptions            // Options of the bus
+	subscriptions *topicTrie[*channelSubscription]  // Subscriptions by topic or topic pattern
+	all           map[*channelSubscription]struct{} // Every subscription, for Close
+	sequence      uint64                            // Sequence number of the last subscription
+	pending       sync.WaitGroup                    // Events buffered or being handled
+	dropped       atomic.Uint64                     // Events dropped by the overflow policy
+}
+
+// Ensure ChannelEventBus implements EventBusInterface.
+var _ EventBusInterface = (*ChannelEventBus)(nil)
+
+// channelSubscription represents the subscription of a handler to a ChannelEventBus.
+type channelSubscription struct {
+	bus      *ChannelEventBus
+	topic    string
+	handler  EventHandler
+	sequence uint64        // Order of the subscription, in which the events are buffered
+	once     bool          // Handle a single event
+	fired    atomic.Bool   // Whether the single event of a once subscription was buffered
+	events   chan Event    // Buffered events
+	done     chan struct{} // Closed when the subscription is removed
+	stop     sync.Once
+	lock     sync.RWMutex // Held for reading by the publishers, for writing when closing the buffer
+	closed   bool         // Whether the buffer accepts no more events
+}
+
+// NewChannelEventBus creates a new instance of the ChannelEventBus.
+func NewChannelEventBus(options ChannelEventBusOptions) *ChannelEventBus {
+	if options.BufferSize <= 0 {
+		options.BufferSize = defaultChannelBufferSize
+	}
+
+	return &ChannelEventBus{
+		options:       options,
+		subscriptions: newTopicTrie[*channelSubscription](),
+		all:           make(map[*channelSubscription]struct{}),
+	}
+}
+
+// Subscribe subscribes to an event topic with the given parameters.
+func (eb *ChannelEventBus) Subscribe(params BusSubscriptionParams) (Subscription, error) {
+	return eb.subscribe(params, false)
+}
+
+// SubscribeAsync subscribes to an event topic with the given parameters. Handlers always run on the
+// goroutine of their subscriber, so the subscription is transactional whatever the given value.
+func (eb *ChannelEventBus) SubscribeAsync(params BusSubscriptionParams, transactional bool) (Subscription, error) {
+	return eb.subscribe(params, false)
+}
+
+// SubscribeOnce subscribes to an event topic for a single event occurrence with the given parameters.
+func (eb *ChannelEventBus) SubscribeOnce(params BusSubscriptionParams) (Subscription, error) {
+	return eb.subscribe(params, true)
+}
+
+// SubscribeOnceAsync subscribes to an event topic for a single event occurrence with the given parameters.
+func (eb *ChannelEventBus) SubscribeOnceAsync(params BusSubscriptionParams) (Subscription, error) {
+	return eb.subscribe(params, true)
+}
+
+// Unsubscribe removes every subscription to the topic of the given parameters. The events already
+// buffered are still handled. Returns an error if no handler is subscribed to the topic.
+func (eb *ChannelEventBus) Unsubscribe(params BusSubscriptionParams) error {
+	eb.mutex.Lock()
+	subscriptions := eb.subscriptions.removeAll(params.Topic)
+	for _, sub := range subscriptions {
+		delete(eb.all, sub)
+	}
+	eb.mutex.Unlock()
+
+	if len(subscriptions) == 0 {
+		return fmt.Errorf("%w: no handler found for topic %s", ErrSubscriptionNotFound, params.Topic)
+	}
+	for _, sub := range subscriptions {
+		sub.close()
+	}
+	return nil
+}
+
+// Publish buffers an event for the subscribers of its topic and of the topic patterns matching it,
+// in the order they subscribed, applying the overflow policy to the subscribers with a full buffer.
+func (eb *ChannelEventBus) Publish(event Event) {
+	eb.mutex.RLock()
+	subscriptions := eb.subscriptions.match(event.Type)
+	eb.mutex.RUnlock()
+
+	sort.Slice(subscriptions, func(i, j int) bool { return subscriptions[i].sequence < subscriptions[j].sequence })
+
+	for _, sub := range subscriptions {
+		if !sub.once {
+			sub.send(event)
+			continue
+		}
+		// Only the first publisher to fire the subscription buffers the event,
+		// which is handled before the subscriber stops
+		if sub.fired.CompareAndSwap(false, true) {
+			sub.send(event)
+			sub.Unsubscribe()
+		}
+	}
+}
+
+// HasCallback checks if a handler is registered for the given topic, either for the topic itself
+// or for a topic pattern matching it.
+func (eb *ChannelEventBus) HasCallback(topic string) bool {
+	eb.mutex.RLock()
+	defer eb.mutex.RUnlock()
+
+	return eb.subscriptions.has(topic) || len(eb.subscriptions.match(topic)) > 0
+}
+
+// WaitAsync blocks until all the buffered events are handled.
+func (eb *ChannelEventBus) WaitAsync() {
+	eb.pending.Wait()
+}
+
+// Dropped returns the number of events dropped by the overflow policy.
+func (eb *ChannelEventBus) Dropped() uint64 {
+	return eb.dropped.Load()
+}
+
+// deadLetter logs an event whose handler failed every attempt and publishes it on the dead-letter topic.
+func (eb *ChannelEventBus) deadLetter(letter DeadLetter) {
+	if eb.options.Logger != nil {
+		eb.options.Logger.Logf(LevelError, "topic=%s event=%s attempts=%d error=%s", letter.Topic, letter.Event.Type, letter.Attempts, letter.Error)
+	}
+	eb.Publish(NewEvent(nil, EventBusSource, DeadLetterTopic, letter))
+}
+
+// Close removes every subscription, stopping the goroutines of the subscribers once they have
+// handled their buffered events.
+func (eb *ChannelEventBus) Close() {
+	eb.mutex.Lock()
+	subscriptions := make([]*channelSubscription, 0, len(eb.all))
+	for sub := range eb.all {
+		eb.subscriptions.remove(sub.topic, sub)
+		subscriptions = append(subscriptions, sub)
+	}
+	eb.all = make(map[*channelSubscription]struct{})
+	eb.mutex.Unlock()
+
+	for _, sub := range subscriptions {
+		sub.close()
+	}
+}
+
+// subscribe adds a subscription to a topic or topic pattern and starts its goroutine.
+func (eb *ChannelEventBus) subscribe(params BusSubscriptionParams, once bool) (Subscription, error) {
+	if err := params.validate(); err != nil {
+		return nil, err
+	}
+
+	eb.mutex.Lock()
+	defer eb.mutex.Unlock()
+
+	eb.sequence++
+	sub := &channelSubscription{
+		bus:      eb,
+		topic:    params.Topic,
+		sequence: eb.sequence,
+		once:     once,
+		events:   make(chan Event, eb.options.BufferSize),
+		done:     make(chan struct{}),
+	}
+
+	// The single event of a once subscription is retried although the subscription is removed
+	var done <-chan struct{}
+	if !once {
+		done = sub.done
+	}
+	sub.handler = params.handler(eb.deadLetter, done, nil)
+	eb.subscriptions.insert(params.Topic, sub)
+	eb.all[sub] = struct{}{}
+	go sub.run()
+
+	return sub, nil
+}
+
+// Topic returns the topic or topic pattern of the subscription.
+func (s *channelSubscription) Topic() string {
+	return s.topic
+}
+
+// Unsubscribe removes the subscription from the bus. The events already buffered are still handled.
+// Returns an error if the subscription was already removed.
+func (s *channelSubscription) Unsubscribe() error {
+	s.bus.mutex.Lock()
+	removed := s.bus.subscriptions.remove(s.topic, s)
+	delete(s.bus.all, s)
+	s.bus.mutex.Unlock()
+
+	if !removed {
+		return fmt.Errorf("%w: topic %s", ErrSubscriptionNotFound, s.topic)
+	}
+	s.close()
+	return nil
+}
+
+// close signals the goroutine of the subscription to stop.
+func (s *channelSubscription) close() {
+	s.stop.Do(func() { close(s.done) })
+}
+
+// send buffers an event, applying the overflow policy when the buffer is full.
+func (s *channelSubscription) send(event Event) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	if s.closed {
+		return
+	}
+
+	s.bus.pending.Add(1)
+	switch s.bus.options.Overflow {
+	case OverflowDropNewest:
+		select {
+		case s.events <- event:
+		default:
+			s.drop()
+		}
+	case OverflowDropOldest:
+		for {
+			select {
+			case s.events <- event:
+				return
+			default:
+			}
+			select {
+			case <-s.events:
+				s.drop()
+			default:
+			}
+		}
+	default:
+		select {
+		case s.events <- event:
+		case <-s.done:
+			// The subscriber stops, without room for the event
+			s.bus.pending.Done()
+		}
+	}
+}
+
+// drop accounts for an event dropped by the overflow policy.
+func (s *channelSubscription) drop() {
+	s.bus.dropped.Add(1)
+	s.bus.pending.Done()
+}
+
+// run handles the buffered events until the subscription is removed, then handles
+// the events left in the buffer.
+func (s *channelSubscription) run() {
+	for {
+		select {
+		case event := <-s.events:
+			s.handle(event)
+		case <-s.done:
+			// Wait for the publishers in progress, after which no event can be buffered
+			s.lock.Lock()
+			s.closed = true
+			s.lock.Unlock()
+
+			for {
+				select {
+				case event := <-s.events:
+					s.handle(event)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// handle calls the handler with an event.
+func (s *channelSubscription) handle(event Event) {
+	defer s.bus.pending.Done()
+
+	s.handler(event)
+}
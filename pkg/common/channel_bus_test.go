@@ -0,0 +1,342 @@
+package common_test
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/asaskevich/EventBus"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/ebanfa/skeleton/pkg/common"
+	"github.com/ebanfa/skeleton/pkg/mocks"
+)
+
+var _ = Describe("ChannelEventBus", func() {
+	var (
+		options  common.ChannelEventBusOptions
+		eventBus *common.ChannelEventBus
+		received chan common.Event
+	)
+
+	BeforeEach(func() {
+		options = common.ChannelEventBusOptions{}
+		received = make(chan common.Event, 100)
+	})
+
+	JustBeforeEach(func() {
+		eventBus = common.NewChannelEventBus(options)
+	})
+
+	AfterEach(func() {
+		eventBus.Close()
+	})
+
+	handler := func(event common.Event) {
+		received <- event
+	}
+
+	events := func(count int) []common.Event {
+		events := make([]common.Event, count)
+		for i := range events {
+			events[i] = common.Event{Type: "topic", Data: i}
+		}
+		return events
+	}
+
+	It("should deliver the events in order to every subscriber", func() {
+		first := make(chan common.Event, 100)
+		Expect(eventBus.Subscribe(common.BusSubscriptionParams{Topic: "topic", EventHandler: func(event common.Event) { first <- event }})).Error().NotTo(HaveOccurred())
+		Expect(eventBus.SubscribeAsync(common.BusSubscriptionParams{Topic: "*", EventHandler: handler}, false)).Error().NotTo(HaveOccurred())
+
+		published := events(50)
+		for _, event := range published {
+			eventBus.Publish(event)
+		}
+		eventBus.WaitAsync()
+
+		for _, event := range published {
+			Expect(first).To(Receive(Equal(event)))
+			Expect(received).To(Receive(Equal(event)))
+		}
+	})
+
+	It("should deliver a single event to the once subscribers", func() {
+		Expect(eventBus.SubscribeOnce(common.BusSubscriptionParams{Topic: "topic", EventHandler: handler})).Error().NotTo(HaveOccurred())
+
+		for _, event := range events(3) {
+			eventBus.Publish(event)
+		}
+		eventBus.WaitAsync()
+
+		Expect(received).To(Receive(HaveField("Data", 0)))
+		Expect(received).NotTo(Receive())
+		Expect(eventBus.HasCallback("topic")).To(BeFalse())
+	})
+
+	It("should stop delivering the events once unsubscribed", func() {
+		subscription, err := eventBus.Subscribe(common.BusSubscriptionParams{Topic: "topic", EventHandler: handler})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(subscription.Unsubscribe()).To(Succeed())
+		eventBus.Publish(common.Event{Type: "topic"})
+		eventBus.WaitAsync()
+
+		Expect(received).NotTo(Receive())
+		Expect(subscription.Unsubscribe()).To(MatchError(common.ErrSubscriptionNotFound))
+		Expect(eventBus.Unsubscribe(common.BusSubscriptionParams{Topic: "topic"})).To(MatchError(common.ErrSubscriptionNotFound))
+	})
+
+	Context("when a handler panics", func() {
+		var logger *mocks.LoggerInterface
+
+		BeforeEach(func() {
+			logger = &mocks.LoggerInterface{}
+			logger.On("Logf", common.LevelError, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return()
+			options.Logger = logger
+		})
+
+		It("should log the panic and keep delivering the events", func() {
+			Expect(eventBus.Subscribe(common.BusSubscriptionParams{
+				Topic: "topic",
+				EventHandler: func(event common.Event) {
+					if event.Data == 0 {
+						panic("handler failed")
+					}
+					received <- event
+				},
+			})).Error().NotTo(HaveOccurred())
+
+			for _, event := range events(2) {
+				eventBus.Publish(event)
+			}
+			eventBus.WaitAsync()
+
+			Expect(received).To(Receive(HaveField("Data", 1)))
+			logger.AssertNumberOfCalls(GinkgoT(), "Logf", 1)
+		})
+	})
+
+	Context("when the buffer of a subscriber is full", func() {
+		var (
+			started chan struct{}
+			release chan struct{}
+		)
+
+		BeforeEach(func() {
+			options.BufferSize = 2
+			started = make(chan struct{}, 10)
+			release = make(chan struct{})
+		})
+
+		// fill subscribes a handler blocked on its first event, then publishes the other events
+		fill := func(published []common.Event) {
+			Expect(eventBus.Subscribe(common.BusSubscriptionParams{
+				Topic: "topic",
+				EventHandler: func(event common.Event) {
+					started <- struct{}{}
+					<-release
+					received <- event
+				},
+			})).Error().NotTo(HaveOccurred())
+
+			eventBus.Publish(published[0])
+			Eventually(started).Should(Receive())
+			for _, event := range published[1:] {
+				eventBus.Publish(event)
+			}
+		}
+
+		receivedData := func() []interface{} {
+			var data []interface{}
+			for len(received) > 0 {
+				data = append(data, (<-received).Data)
+			}
+			return data
+		}
+
+		Context("with the block policy", func() {
+			It("should block the publisher until the subscriber has room", func() {
+				published := make(chan struct{})
+				go func() {
+					defer GinkgoRecover()
+					defer close(published)
+					fill(events(4))
+				}()
+
+				Consistently(published).ShouldNot(BeClosed())
+				close(release)
+				Eventually(published).Should(BeClosed())
+				eventBus.WaitAsync()
+
+				Expect(receivedData()).To(Equal([]interface{}{0, 1, 2, 3}))
+				Expect(eventBus.Dropped()).To(BeZero())
+			})
+		})
+
+		Context("with the drop-oldest policy", func() {
+			BeforeEach(func() {
+				options.Overflow = common.OverflowDropOldest
+			})
+
+			It("should drop the oldest buffered events", func() {
+				fill(events(5))
+				close(release)
+				eventBus.WaitAsync()
+
+				Expect(receivedData()).To(Equal([]interface{}{0, 3, 4}))
+				Expect(eventBus.Dropped()).To(Equal(uint64(2)))
+			})
+		})
+
+		Context("with the drop-newest policy", func() {
+			BeforeEach(func() {
+				options.Overflow = common.OverflowDropNewest
+			})
+
+			It("should drop the new events", func() {
+				fill(events(5))
+				close(release)
+				eventBus.WaitAsync()
+
+				Expect(receivedData()).To(Equal([]interface{}{0, 1, 2}))
+				Expect(eventBus.Dropped()).To(Equal(uint64(2)))
+			})
+		})
+	})
+
+	It("should name the overflow policies", func() {
+		Expect(common.OverflowBlock.String()).To(Equal("block"))
+		Expect(common.OverflowDropOldest.String()).To(Equal("drop-oldest"))
+		Expect(common.OverflowDropNewest.String()).To(Equal("drop-newest"))
+	})
+
+	It("should parse the names of the overflow policies", func() {
+		for _, policy := range []common.OverflowPolicy{common.OverflowBlock, common.OverflowDropOldest, common.OverflowDropNewest} {
+			Expect(common.ParseOverflowPolicy(policy.String())).To(Equal(policy))
+		}
+		Expect(common.ParseOverflowPolicy("")).To(Equal(common.OverflowBlock))
+		Expect(common.ParseOverflowPolicy("drop")).Error().To(MatchError(common.ErrUnknownOverflowPolicy))
+	})
+})
+
+// benchmarkBus is an event bus under benchmark.
+type benchmarkBus struct {
+	subscribe func(handler common.EventHandler) error
+	publish   func(event common.Event)
+	wait      func()
+	close     func()
+}
+
+// newLibraryBus returns the asaskevich/EventBus library wrapped the way the SystemEventBus wrapped it
+// before it was replaced, the handlers receiving the event data as their first argument.
+func newLibraryBus(topic string, subscribe func(bus EventBus.Bus, fn interface{}) error) *benchmarkBus {
+	bus := EventBus.New()
+	return &benchmarkBus{
+		subscribe: func(handler common.EventHandler) error {
+			return subscribe(bus, func(args ...interface{}) {
+				handler(common.Event{Type: topic, Data: args[0]})
+			})
+		},
+		publish: func(event common.Event) { bus.Publish(event.Type, event.Data) },
+		wait:    bus.WaitAsync,
+		close:   func() {},
+	}
+}
+
+// newNativeBus returns an implementation of the EventBusInterface.
+func newNativeBus(eventBus common.EventBusInterface, subscribe func(bus common.EventBusInterface, params common.BusSubscriptionParams) (common.Subscription, error)) *benchmarkBus {
+	return &benchmarkBus{
+		subscribe: func(handler common.EventHandler) error {
+			_, err := subscribe(eventBus, common.BusSubscriptionParams{Topic: "bench.topic", EventHandler: handler})
+			return err
+		},
+		publish: eventBus.Publish,
+		wait:    eventBus.WaitAsync,
+		close: func() {
+			if closer, ok := eventBus.(interface{ Close() }); ok {
+				closer.Close()
+			}
+		},
+	}
+}
+
+// BenchmarkEventBus compares the publishing throughput of the event buses with the asaskevich/EventBus
+// library they replaced, each event being handled by every subscriber before the benchmark ends.
+func BenchmarkEventBus(b *testing.B) {
+	subscribe := func(bus common.EventBusInterface, params common.BusSubscriptionParams) (common.Subscription, error) {
+		return bus.Subscribe(params)
+	}
+	subscribeAsync := func(transactional bool) func(common.EventBusInterface, common.BusSubscriptionParams) (common.Subscription, error) {
+		return func(bus common.EventBusInterface, params common.BusSubscriptionParams) (common.Subscription, error) {
+			return bus.SubscribeAsync(params, transactional)
+		}
+	}
+
+	buses := []struct {
+		name   string
+		create func() *benchmarkBus
+	}{
+		{"EventBus/sync", func() *benchmarkBus {
+			return newLibraryBus("bench.topic", func(bus EventBus.Bus, fn interface{}) error {
+				return bus.Subscribe("bench.topic", fn)
+			})
+		}},
+		{"EventBus/async", func() *benchmarkBus {
+			return newLibraryBus("bench.topic", func(bus EventBus.Bus, fn interface{}) error {
+				return bus.SubscribeAsync("bench.topic", fn, false)
+			})
+		}},
+		{"EventBus/transactional", func() *benchmarkBus {
+			return newLibraryBus("bench.topic", func(bus EventBus.Bus, fn interface{}) error {
+				return bus.SubscribeAsync("bench.topic", fn, true)
+			})
+		}},
+		{"SystemEventBus/sync", func() *benchmarkBus {
+			return newNativeBus(common.NewSystemEventBus(), subscribe)
+		}},
+		{"SystemEventBus/async", func() *benchmarkBus {
+			return newNativeBus(common.NewSystemEventBus(), subscribeAsync(false))
+		}},
+		{"SystemEventBus/transactional", func() *benchmarkBus {
+			return newNativeBus(common.NewSystemEventBus(), subscribeAsync(true))
+		}},
+		{"ChannelEventBus", func() *benchmarkBus {
+			return newNativeBus(common.NewChannelEventBus(common.ChannelEventBusOptions{BufferSize: 1024}), subscribe)
+		}},
+	}
+
+	for _, bus := range buses {
+		for _, subscribers := range []int{1, 10} {
+			b.Run(fmt.Sprintf("%s/subscribers=%d", bus.name, subscribers), func(b *testing.B) {
+				eventBus := bus.create()
+				var mutex sync.Mutex
+				count := 0
+				for i := 0; i < subscribers; i++ {
+					err := eventBus.subscribe(func(event common.Event) {
+						mutex.Lock()
+						count++
+						mutex.Unlock()
+					})
+					if err != nil {
+						b.Fatal(err)
+					}
+				}
+
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					eventBus.publish(common.Event{Type: "bench.topic", Data: i})
+				}
+				eventBus.wait()
+				b.StopTimer()
+
+				if count != b.N*subscribers {
+					b.Fatalf("handled %d events, expected %d", count, b.N*subscribers)
+				}
+				eventBus.close()
+			})
+		}
+	}
+}
@@ -85,7 +85,7 @@ func (c *Context) WithTraceID(traceID string) *Context {
 		values:  make(map[interface{}]interface{}),
 	}
 	maps.Copy(newCtx.values, c.values)
-	newCtx.values["traceID"] = traceID
+	newCtx.values[TraceIDKey] = traceID
 	return newCtx
 }
 
@@ -118,6 +118,29 @@ func WithContext(ctx context.Context) *Context {
 	}
 }
 
+// WithCancel returns a new Context that is canceled when the returned cancel function is called
+// or when the parent is done. It is similar to the standard context.WithCancel() function but
+// returns a custom Context type.
+func WithCancel(parent *Context) (*Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(parent.Context)
+	newCtx := &Context{
+		Context: ctx,
+		values:  parent.values,
+	}
+
+	return newCtx, cancel
+}
+
+// WithoutCancel returns a new Context holding the values of the parent that is not canceled
+// when the parent is. It is similar to the standard context.WithoutCancel() function but
+// returns a custom Context type.
+func WithoutCancel(parent *Context) *Context {
+	return &Context{
+		Context: context.WithoutCancel(parent.Context),
+		values:  parent.values,
+	}
+}
+
 // WithTimeout returns a new Context with the given timeout duration.
 // It is similar to the standard context.WithTimeout() function but returns
 // a custom Context type and logs the timeout event.
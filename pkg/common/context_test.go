@@ -106,6 +106,30 @@ var _ = Describe("Context", func() {
 		})
 	})
 
+	Describe("WithCancel", func() {
+		It("should cancel the context and keep the parent values", func() {
+			parent := ctx.WithValue("key", "value")
+			newCtx, cancel := common.WithCancel(parent)
+
+			Expect(newCtx.Err()).NotTo(HaveOccurred())
+			cancel()
+			Expect(newCtx.Err()).To(Equal(context.Canceled))
+			Expect(newCtx.Value("key")).To(Equal("value"))
+		})
+	})
+
+	Describe("WithoutCancel", func() {
+		It("should not be canceled with its parent and keep the parent values", func() {
+			parent, cancel := common.WithCancel(ctx.WithValue("key", "value"))
+			newCtx := common.WithoutCancel(parent)
+
+			cancel()
+			Expect(parent.Err()).To(Equal(context.Canceled))
+			Expect(newCtx.Err()).NotTo(HaveOccurred())
+			Expect(newCtx.Value("key")).To(Equal("value"))
+		})
+	})
+
 	Describe("WithTimeout", func() {
 		It("should create a context with a timeout", func() {
 			timeout := 100 * time.Millisecond
@@ -0,0 +1,132 @@
+package common
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// TraceIDKey is the key of the trace ID in the values of a Context.
+const TraceIDKey = "traceID"
+
+var (
+	// ErrEventVersionMismatch is returned when an event does not have the schema version expected by its subscriber.
+	ErrEventVersionMismatch = errors.New("event version mismatch")
+
+	// ErrEventPayloadMismatch is returned when the data of an event cannot be decoded into the type expected by its subscriber.
+	ErrEventPayloadMismatch = errors.New("event payload mismatch")
+)
+
+// NewEvent creates an event published by the given source, with a new ID, the current time
+// and the trace ID of the context, if any.
+func NewEvent(ctx *Context, source, eventType string, data interface{}) Event {
+	event := Event{
+		Type:   eventType,
+		Data:   data,
+		ID:     NewEventID(),
+		Source: source,
+		Time:   time.Now(),
+	}
+	if ctx != nil {
+		if traceID, ok := ctx.Value(TraceIDKey).(string); ok {
+			event.TraceID = traceID
+		}
+	}
+	return event
+}
+
+// EventSchema describes the events of a topic whose data has the type T, in a given schema version.
+type EventSchema[T any] struct {
+	// Topic is the topic of the events.
+	Topic string
+
+	// Version is the schema version of the data. Subscribers reject the events of other versions.
+	Version int
+}
+
+// TypedEventHandler defines the signature for a handler of events whose data has the type T.
+type TypedEventHandler[T any] func(event Event, payload T)
+
+// NewTypedEvent creates an event of a schema published by the given source, as NewEvent does.
+// Set its headers before publishing it when needed.
+func NewTypedEvent[T any](ctx *Context, source string, schema EventSchema[T], payload T) Event {
+	event := NewEvent(ctx, source, schema.Topic, payload)
+	event.Version = schema.Version
+	return event
+}
+
+// Publish publishes an event of a schema, returning the published event.
+func Publish[T any](ctx *Context, publisher BusPublisher, source string, schema EventSchema[T], payload T) Event {
+	event := NewTypedEvent(ctx, source, schema, payload)
+	publisher.Publish(event)
+	return event
+}
+
+// Subscribe subscribes a typed handler to the events of a schema. The events of another version, or
+// whose data cannot be decoded into T, are not handled: they are passed to reject, when not nil,
+// along with an error wrapping ErrEventVersionMismatch or ErrEventPayloadMismatch.
+func Subscribe[T any](subscriber BusSubscriber, schema EventSchema[T], handler TypedEventHandler[T], reject func(event Event, err error)) (Subscription, error) {
+	if handler == nil {
+		return nil, fmt.Errorf("no handler given for topic %s", schema.Topic)
+	}
+
+	return subscriber.Subscribe(BusSubscriptionParams{
+		Topic: schema.Topic,
+		EventHandler: func(event Event) {
+			payload, err := DecodeEvent(schema, event)
+			if err != nil {
+				if reject != nil {
+					reject(event, err)
+				}
+				return
+			}
+			handler(event, payload)
+		},
+	})
+}
+
+// DecodeEvent returns the data of an event of a schema as T. Data given as JSON, either []byte
+// or json.RawMessage, such as the data of the events received from a journal or a remote system,
+// is decoded into T. Returns an error if the event has another version or its data cannot be decoded.
+func DecodeEvent[T any](schema EventSchema[T], event Event) (T, error) {
+	var payload T
+
+	if event.Version != schema.Version {
+		return payload, fmt.Errorf("%w: event %s has version %d, expected %d", ErrEventVersionMismatch, event.Type, event.Version, schema.Version)
+	}
+	if event.Data == nil {
+		return payload, nil
+	}
+	if typed, ok := event.Data.(T); ok {
+		return typed, nil
+	}
+
+	var raw []byte
+	switch data := event.Data.(type) {
+	case json.RawMessage:
+		raw = data
+	case []byte:
+		raw = data
+	default:
+		return payload, fmt.Errorf("%w: event %s has data of type %T, expected %s", ErrEventPayloadMismatch, event.Type, event.Data, reflect.TypeFor[T]())
+	}
+
+	if err := json.Unmarshal(raw, &payload); err != nil {
+		return payload, fmt.Errorf("%w: event %s: failed to decode data as %s: %w", ErrEventPayloadMismatch, event.Type, reflect.TypeFor[T](), err)
+	}
+	return payload, nil
+}
+
+// NewEventID returns a random event ID, as given to the events created by NewEvent.
+func NewEventID() string {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		// The clock is unique enough when the random source fails
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(id)
+}
@@ -0,0 +1,108 @@
+package common_test
+
+import (
+	"encoding/json"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/ebanfa/skeleton/pkg/common"
+)
+
+var _ = Describe("Event envelopes", func() {
+	type serviceStarted struct {
+		ServiceID string `json:"serviceId"`
+	}
+
+	var (
+		ctx      *common.Context
+		eventBus common.EventBusInterface
+		schema   common.EventSchema[serviceStarted]
+	)
+
+	BeforeEach(func() {
+		ctx = common.Background().WithTraceID("trace-123")
+		eventBus = common.NewSystemEventBus()
+		schema = common.EventSchema[serviceStarted]{Topic: "service.started", Version: 2}
+	})
+
+	Describe("NewEvent", func() {
+		It("fills the envelope of the event", func() {
+			event := common.NewEvent(ctx, "api", "service.started", "data")
+
+			Expect(event.Type).To(Equal("service.started"))
+			Expect(event.Data).To(Equal("data"))
+			Expect(event.ID).To(HaveLen(32))
+			Expect(event.Source).To(Equal("api"))
+			Expect(event.Time).To(BeTemporally("~", time.Now(), time.Second))
+			Expect(event.TraceID).To(Equal("trace-123"))
+			Expect(event.Version).To(BeZero())
+		})
+
+		It("gives every event its own ID", func() {
+			Expect(common.NewEvent(nil, "api", "topic", nil).ID).NotTo(Equal(common.NewEvent(nil, "api", "topic", nil).ID))
+		})
+
+		It("leaves the trace ID empty without context", func() {
+			Expect(common.NewEvent(nil, "api", "topic", nil).TraceID).To(BeEmpty())
+		})
+	})
+
+	Describe("Publish and Subscribe", func() {
+		var (
+			received chan serviceStarted
+			rejected chan error
+		)
+
+		BeforeEach(func() {
+			received = make(chan serviceStarted, 1)
+			rejected = make(chan error, 1)
+
+			Expect(common.Subscribe(eventBus, schema,
+				func(event common.Event, payload serviceStarted) {
+					Expect(event.Source).To(Equal("api"))
+					received <- payload
+				},
+				func(event common.Event, err error) { rejected <- err },
+			)).Error().NotTo(HaveOccurred())
+		})
+
+		It("delivers the typed payloads", func() {
+			event := common.Publish(ctx, eventBus, "api", schema, serviceStarted{ServiceID: "db"})
+			Expect(event.Version).To(Equal(2))
+			Expect(event.TraceID).To(Equal("trace-123"))
+
+			Expect(received).To(Receive(Equal(serviceStarted{ServiceID: "db"})))
+		})
+
+		It("decodes the JSON payloads", func() {
+			event := common.NewTypedEvent(ctx, "api", schema, serviceStarted{})
+			event.Data = json.RawMessage(`{"serviceId":"db"}`)
+			eventBus.Publish(event)
+
+			Expect(received).To(Receive(Equal(serviceStarted{ServiceID: "db"})))
+		})
+
+		It("rejects the events of another version", func() {
+			common.Publish(ctx, eventBus, "api", common.EventSchema[serviceStarted]{Topic: "service.started", Version: 1}, serviceStarted{})
+
+			Expect(rejected).To(Receive(MatchError(common.ErrEventVersionMismatch)))
+			Expect(received).NotTo(Receive())
+		})
+
+		It("rejects the payloads of another type", func() {
+			eventBus.Publish(common.Event{Type: "service.started", Version: 2, Data: 42})
+			Expect(rejected).To(Receive(MatchError(common.ErrEventPayloadMismatch)))
+
+			eventBus.Publish(common.Event{Type: "service.started", Version: 2, Data: []byte("not json")})
+			Expect(rejected).To(Receive(MatchError(common.ErrEventPayloadMismatch)))
+
+			Expect(received).NotTo(Receive())
+		})
+	})
+
+	It("requires a handler to subscribe", func() {
+		Expect(common.Subscribe(eventBus, schema, nil, nil)).Error().To(HaveOccurred())
+	})
+})
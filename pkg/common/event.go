@@ -1,9 +1,12 @@
 package common
 
 import (
+	"errors"
 	"fmt"
-
-	"github.com/asaskevich/EventBus"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
 const (
@@ -17,42 +20,91 @@ const (
 	EventTypeDataLoaded string = "data_loaded"
 )
 
-// Event represents an event within the system.
+// ErrSubscriptionNotFound is returned when unsubscribing a subscription that was already removed.
+var ErrSubscriptionNotFound = errors.New("subscription not found")
+
+// Event represents an event within the system. Besides its type and data, an event is an envelope
+// telling who published it, when, and in which schema version; NewEvent fills the envelope.
 type Event struct {
 	// Type is the type or identifier of the event.
 	Type string
 
 	// Data is the payload or data associated with the event.
 	Data interface{}
+
+	// ID is the unique identifier of the event.
+	ID string
+
+	// Source is the ID of the component that published the event.
+	Source string
+
+	// Time is the time the event was published.
+	Time time.Time
+
+	// TraceID is the trace ID of the context the event was published in, if any.
+	TraceID string
+
+	// Version is the schema version of the data, zero for unversioned data.
+	Version int
+
+	// Headers are the metadata of the event, such as correlation identifiers.
+	Headers map[string]string
 }
 
 // EventHandler defines the signature for an event handler function.
 type EventHandler func(event Event)
 
+// ErrorEventHandler defines the signature for an event handler function reporting its failures.
+type ErrorEventHandler func(event Event) error
+
 // BusSubscriptionParams represents the parameters for subscribing to an event topic.
 type BusSubscriptionParams struct {
-	// Topic is the event topic to subscribe to.
+	// Topic is the event topic to subscribe to. Topics are hierarchical, their tokens being separated
+	// by dots, and a topic with wildcard tokens subscribes to every matching topic: * matches one token,
+	// as in service.*.started, and > matches one or more trailing tokens, as in store.>.
 	Topic string
 
 	// EventHandler is the function that will handle the events for the subscribed topic.
 	EventHandler EventHandler
+
+	// ErrorHandler handles the events for the subscribed topic, reporting its failures.
+	// It is used instead of EventHandler when set.
+	ErrorHandler ErrorEventHandler
+
+	// Retry is the retry policy of the handler, which is called once per event when nil.
+	// Removing the subscription ends the wait before the next retry. The retries of the handlers
+	// called on the goroutine of the publisher run on their own goroutine, so that the publisher
+	// is not held back.
+	Retry *RetryPolicy
+}
+
+// Subscription represents the subscription of a handler to an event topic or topic pattern.
+type Subscription interface {
+	// Topic returns the topic or topic pattern of the subscription.
+	Topic() string
+
+	// Unsubscribe removes the subscription, so that its handler receives no more events.
+	// Returns an error if the subscription was already removed.
+	Unsubscribe() error
 }
 
 // BusSubscriber defines subscription-related bus behavior.
 type BusSubscriber interface {
 	// Subscribe subscribes to an event topic with the given parameters.
-	Subscribe(params BusSubscriptionParams) error
+	Subscribe(params BusSubscriptionParams) (Subscription, error)
 
 	// SubscribeAsync subscribes to an event topic asynchronously with the given parameters.
-	SubscribeAsync(params BusSubscriptionParams, transactional bool) error
+	// Transactional handlers handle the events one at a time.
+	SubscribeAsync(params BusSubscriptionParams, transactional bool) (Subscription, error)
 
 	// SubscribeOnce subscribes to an event topic for a single event occurrence with the given parameters.
-	SubscribeOnce(params BusSubscriptionParams) error
+	SubscribeOnce(params BusSubscriptionParams) (Subscription, error)
 
 	// SubscribeOnceAsync subscribes to an event topic asynchronously for a single event occurrence with the given parameters.
-	SubscribeOnceAsync(params BusSubscriptionParams) error
+	SubscribeOnceAsync(params BusSubscriptionParams) (Subscription, error)
 
-	// Unsubscribe unsubscribes from an event topic with the given parameters.
+	// Unsubscribe removes every subscription to the topic of the given parameters.
+	// Use the Subscription returned on subscription to remove a single handler.
 	Unsubscribe(params BusSubscriptionParams) error
 }
 
@@ -78,120 +130,198 @@ type EventBusInterface interface {
 	BusPublisher
 }
 
-// SystemEventBus is a concrete implementation of the EventBusInterface.
+// SystemEventBus is a concrete implementation of the EventBusInterface. Any number of handlers can
+// subscribe to a topic or topic pattern, the subscriptions being matched against the published topics
+// with a trie. It is safe for concurrent use, and handlers can subscribe, unsubscribe and publish
+// while handling an event.
 type SystemEventBus struct {
-	bus      EventBus.Bus           // Underlying third-party EventBus instance
-	handlers map[string]interface{} // Map to store function references used for subscription
+	mutex         sync.RWMutex              // Mutex guarding the subscriptions
+	subscriptions *topicTrie[*subscription] // Subscriptions by topic or topic pattern
+	sequence      uint64                    // Sequence number of the last subscription
+	async         sync.WaitGroup            // Asynchronous handlers in progress
+}
+
+// subscription represents the subscription of a handler to a SystemEventBus.
+type subscription struct {
+	bus           *SystemEventBus
+	topic         string
+	handler       EventHandler
+	sequence      uint64     // Order of the subscription, in which handlers receive the events
+	async         bool       // Handle the events in their own goroutine
+	transactional bool       // Handle the events one at a time when asynchronous
+	once          bool       // Handle a single event
+	lock          sync.Mutex // Serializes the transactional handler
+	fired         atomic.Bool
+	done          chan struct{} // Closed when the subscription is removed, ending the waits before the retries
+	stop          sync.Once
 }
 
 // NewSystemEventBus creates a new instance of the SystemEventBus.
 func NewSystemEventBus() EventBusInterface {
 	return &SystemEventBus{
-		bus:      EventBus.New(),
-		handlers: make(map[string]interface{}),
+		subscriptions: newTopicTrie[*subscription](),
 	}
 }
 
 // Subscribe subscribes to an event topic with the given parameters.
-func (eb *SystemEventBus) Subscribe(params BusSubscriptionParams) error {
-	// Create a wrapper function that constructs the Event and calls the provided EventHandler
-	handler := func(args ...interface{}) {
-		event := Event{
-			Type: params.Topic,
-			Data: args[0],
-		}
-		params.EventHandler(event)
-	}
-
-	// Store the function reference for later use in Unsubscribe
-	eb.handlers[params.Topic] = handler
-
-	// Subscribe to the underlying EventBus using the wrapper function
-	return eb.bus.Subscribe(params.Topic, handler)
+func (eb *SystemEventBus) Subscribe(params BusSubscriptionParams) (Subscription, error) {
+	return eb.subscribe(params, false, false, false)
 }
 
 // SubscribeAsync subscribes to an event topic asynchronously with the given parameters.
-func (eb *SystemEventBus) SubscribeAsync(params BusSubscriptionParams, transactional bool) error {
-	// Create a wrapper function that constructs the Event and calls the provided EventHandler
-	handler := func(args ...interface{}) {
-		event := Event{
-			Type: params.Topic,
-			Data: args[0],
-		}
-		params.EventHandler(event)
-	}
+// Transactional handlers handle the events one at a time, in the order they are published.
+func (eb *SystemEventBus) SubscribeAsync(params BusSubscriptionParams, transactional bool) (Subscription, error) {
+	return eb.subscribe(params, true, transactional, false)
+}
 
-	// Store the function reference for later use in Unsubscribe
-	eb.handlers[params.Topic] = handler
+// SubscribeOnce subscribes to an event topic for a single event occurrence with the given parameters.
+func (eb *SystemEventBus) SubscribeOnce(params BusSubscriptionParams) (Subscription, error) {
+	return eb.subscribe(params, false, false, true)
+}
 
-	// Subscribe asynchronously to the underlying EventBus using the wrapper function
-	return eb.bus.SubscribeAsync(params.Topic, handler, transactional)
+// SubscribeOnceAsync subscribes to an event topic asynchronously for a single event occurrence with the given parameters.
+func (eb *SystemEventBus) SubscribeOnceAsync(params BusSubscriptionParams) (Subscription, error) {
+	return eb.subscribe(params, true, false, true)
 }
 
-// SubscribeOnce subscribes to an event topic for a single event occurrence with the given parameters.
-func (eb *SystemEventBus) SubscribeOnce(params BusSubscriptionParams) error {
-	// Create a wrapper function that constructs the Event and calls the provided EventHandler
-	handler := func(args ...interface{}) {
-		event := Event{
-			Type: params.Topic,
-			Data: args[0],
-		}
-		params.EventHandler(event)
+// Unsubscribe removes every subscription to the topic of the given parameters.
+// Returns an error if no handler is subscribed to the topic.
+func (eb *SystemEventBus) Unsubscribe(params BusSubscriptionParams) error {
+	eb.mutex.Lock()
+	subscriptions := eb.subscriptions.removeAll(params.Topic)
+	eb.mutex.Unlock()
+
+	if len(subscriptions) == 0 {
+		return fmt.Errorf("%w: no handler found for topic %s", ErrSubscriptionNotFound, params.Topic)
+	}
+	for _, sub := range subscriptions {
+		sub.close()
 	}
+	return nil
+}
 
-	// Store the function reference for later use in Unsubscribe
-	eb.handlers[params.Topic] = handler
+// Publish publishes an event to the event bus, to the subscribers of its topic
+// and of the topic patterns matching it, in the order they subscribed.
+func (eb *SystemEventBus) Publish(event Event) {
+	eb.mutex.RLock()
+	subscriptions := eb.subscriptions.match(event.Type)
+	eb.mutex.RUnlock()
+
+	sort.Slice(subscriptions, func(i, j int) bool { return subscriptions[i].sequence < subscriptions[j].sequence })
+
+	for _, sub := range subscriptions {
+		if sub.once {
+			// Only the first publisher to fire the subscription handles the event
+			if !sub.fired.CompareAndSwap(false, true) {
+				continue
+			}
+			sub.Unsubscribe()
+		}
 
-	// Subscribe once to the underlying EventBus using the wrapper function
-	return eb.bus.SubscribeOnce(params.Topic, handler)
-}
+		if !sub.async {
+			sub.handler(event)
+			continue
+		}
 
-// SubscribeOnceAsync subscribes to an event topic asynchronously for a single event occurrence with the given parameters.
-func (eb *SystemEventBus) SubscribeOnceAsync(params BusSubscriptionParams) error {
-	// Create a wrapper function that constructs the Event and calls the provided EventHandler
-	handler := func(args ...interface{}) {
-		event := Event{
-			Type: params.Topic,
-			Data: args[0],
+		// Transactional handlers are locked before starting, so that they handle the events in order
+		eb.async.Add(1)
+		if sub.transactional {
+			sub.lock.Lock()
 		}
-		params.EventHandler(event)
+		go func(sub *subscription) {
+			defer eb.async.Done()
+			if sub.transactional {
+				defer sub.lock.Unlock()
+			}
+			sub.handler(event)
+		}(sub)
 	}
+}
 
-	// Store the function reference for later use in Unsubscribe
-	eb.handlers[params.Topic] = handler
+// HasCallback checks if a handler is registered for the given topic, either for the topic itself
+// or for a topic pattern matching it.
+func (eb *SystemEventBus) HasCallback(topic string) bool {
+	eb.mutex.RLock()
+	defer eb.mutex.RUnlock()
 
-	// Subscribe once asynchronously to the underlying EventBus using the wrapper function
-	return eb.bus.SubscribeOnceAsync(params.Topic, handler)
+	return eb.subscriptions.has(topic) || len(eb.subscriptions.match(topic)) > 0
 }
 
-// Unsubscribe unsubscribes from an event topic with the given parameters.
-func (eb *SystemEventBus) Unsubscribe(params BusSubscriptionParams) error {
-	// Retrieve the function reference used for subscription
-	handler, ok := eb.handlers[params.Topic]
-	if !ok {
-		return fmt.Errorf("no handler found for topic %s", params.Topic)
+// WaitAsync blocks until all asynchronous operations are completed.
+func (eb *SystemEventBus) WaitAsync() {
+	eb.async.Wait()
+}
+
+// subscribe adds a subscription to a topic or topic pattern.
+func (eb *SystemEventBus) subscribe(params BusSubscriptionParams, async, transactional, once bool) (Subscription, error) {
+	if err := params.validate(); err != nil {
+		return nil, err
 	}
 
-	// Unsubscribe from the underlying EventBus using the stored function reference
-	err := eb.bus.Unsubscribe(params.Topic, handler)
-	if err == nil {
-		// Remove the function reference from the handlers map if unsubscription succeeded
-		delete(eb.handlers, params.Topic)
+	eb.mutex.Lock()
+	defer eb.mutex.Unlock()
+
+	eb.sequence++
+	sub := &subscription{
+		bus:           eb,
+		topic:         params.Topic,
+		sequence:      eb.sequence,
+		async:         async,
+		transactional: transactional,
+		once:          once,
+		done:          make(chan struct{}),
 	}
-	return err
+
+	// The single event of a once subscription is retried although the subscription is removed
+	var done <-chan struct{}
+	if !once {
+		done = sub.done
+	}
+	// The retries of the synchronous handlers do not hold back the publishers
+	var detach func(func())
+	if !async {
+		detach = eb.detach
+	}
+	sub.handler = params.handler(eb.deadLetter, done, detach)
+	eb.subscriptions.insert(params.Topic, sub)
+
+	return sub, nil
 }
 
-// Publish publishes an event to the event bus.
-func (eb *SystemEventBus) Publish(event Event) {
-	eb.bus.Publish(event.Type, event.Data)
+// detach runs the retries of a synchronous handler on their own goroutine, awaited by WaitAsync.
+func (eb *SystemEventBus) detach(retry func()) {
+	eb.async.Add(1)
+	go func() {
+		defer eb.async.Done()
+		retry()
+	}()
 }
 
-// HasCallback checks if a handler is registered for the given topic.
-func (eb *SystemEventBus) HasCallback(topic string) bool {
-	return eb.bus.HasCallback(topic)
+// deadLetter publishes an event whose handler failed every attempt on the dead-letter topic.
+func (eb *SystemEventBus) deadLetter(letter DeadLetter) {
+	eb.Publish(NewEvent(nil, EventBusSource, DeadLetterTopic, letter))
 }
 
-// WaitAsync blocks until all asynchronous operations are completed.
-func (eb *SystemEventBus) WaitAsync() {
-	eb.bus.WaitAsync()
+// Topic returns the topic or topic pattern of the subscription.
+func (s *subscription) Topic() string {
+	return s.topic
+}
+
+// Unsubscribe removes the subscription from the bus.
+// Returns an error if the subscription was already removed.
+func (s *subscription) Unsubscribe() error {
+	s.bus.mutex.Lock()
+	removed := s.bus.subscriptions.remove(s.topic, s)
+	s.bus.mutex.Unlock()
+
+	if !removed {
+		return fmt.Errorf("%w: topic %s", ErrSubscriptionNotFound, s.topic)
+	}
+	s.close()
+	return nil
+}
+
+// close ends the waits of the handler before its retries.
+func (s *subscription) close() {
+	s.stop.Do(func() { close(s.done) })
 }
@@ -2,6 +2,7 @@ package common_test
 
 import (
 	"sync"
+	"sync/atomic"
 	"time"
 
 	. "github.com/onsi/ginkgo/v2"
@@ -26,7 +27,7 @@ var _ = Describe("SystemEventBus", func() {
 				receivedEvent <- event
 			}
 
-			err := eventBus.Subscribe(common.BusSubscriptionParams{
+			_, err := eventBus.Subscribe(common.BusSubscriptionParams{
 				Topic:        "test_topic",
 				EventHandler: handler,
 			})
@@ -55,7 +56,7 @@ var _ = Describe("SystemEventBus", func() {
 				receivedEvent <- event
 			}
 
-			err := eventBus.SubscribeAsync(common.BusSubscriptionParams{
+			_, err := eventBus.SubscribeAsync(common.BusSubscriptionParams{
 				Topic:        "async_topic",
 				EventHandler: handler,
 			}, false)
@@ -81,7 +82,7 @@ var _ = Describe("SystemEventBus", func() {
 				receivedEvents <- event
 			}
 
-			err := eventBus.SubscribeOnce(common.BusSubscriptionParams{
+			_, err := eventBus.SubscribeOnce(common.BusSubscriptionParams{
 				Topic:        "once_topic",
 				EventHandler: handler,
 			})
@@ -109,7 +110,7 @@ var _ = Describe("SystemEventBus", func() {
 				receivedEvents <- event
 			}
 
-			err := eventBus.SubscribeOnceAsync(common.BusSubscriptionParams{
+			_, err := eventBus.SubscribeOnceAsync(common.BusSubscriptionParams{
 				Topic:        "once_async_topic",
 				EventHandler: handler,
 			})
@@ -139,7 +140,7 @@ var _ = Describe("SystemEventBus", func() {
 				EventHandler: handler,
 			}
 
-			err := eventBus.Subscribe(params)
+			_, err := eventBus.Subscribe(params)
 			Expect(err).NotTo(HaveOccurred())
 
 			event := common.Event{Type: "unsub_topic", Data: "unsub_data"}
@@ -168,7 +169,7 @@ var _ = Describe("SystemEventBus", func() {
 
 	Describe("HasCallback", func() {
 		It("should return true for a subscribed topic", func() {
-			err := eventBus.Subscribe(common.BusSubscriptionParams{
+			_, err := eventBus.Subscribe(common.BusSubscriptionParams{
 				Topic:        "callback_topic",
 				EventHandler: func(event common.Event) {},
 			})
@@ -182,12 +183,184 @@ var _ = Describe("SystemEventBus", func() {
 		})
 	})
 
+	Describe("topic patterns", func() {
+		var received chan common.Event
+
+		BeforeEach(func() {
+			received = make(chan common.Event, 10)
+		})
+
+		handler := func(event common.Event) {
+			received <- event
+		}
+
+		It("should deliver the events of every matching topic with their own type", func() {
+			Expect(eventBus.Subscribe(common.BusSubscriptionParams{Topic: "service.*.started", EventHandler: handler})).Error().NotTo(HaveOccurred())
+
+			eventBus.Publish(common.Event{Type: "service.api.started", Data: "api"})
+			eventBus.Publish(common.Event{Type: "service.api.stopped", Data: "api"})
+			eventBus.Publish(common.Event{Type: "service.db.started", Data: "db"})
+
+			Expect(received).To(Receive(Equal(common.Event{Type: "service.api.started", Data: "api"})))
+			Expect(received).To(Receive(Equal(common.Event{Type: "service.db.started", Data: "db"})))
+			Expect(received).NotTo(Receive())
+		})
+
+		It("should deliver the events to the exact and pattern subscribers alike", func() {
+			Expect(eventBus.Subscribe(common.BusSubscriptionParams{Topic: "store.opened", EventHandler: handler})).Error().NotTo(HaveOccurred())
+			Expect(eventBus.Subscribe(common.BusSubscriptionParams{Topic: "store.>", EventHandler: handler})).Error().NotTo(HaveOccurred())
+
+			eventBus.Publish(common.Event{Type: "store.opened"})
+
+			Expect(received).To(HaveLen(2))
+		})
+
+		It("should deliver the events asynchronously", func() {
+			Expect(eventBus.SubscribeAsync(common.BusSubscriptionParams{Topic: "store.>", EventHandler: handler}, true)).Error().NotTo(HaveOccurred())
+
+			eventBus.Publish(common.Event{Type: "store.jobs.saved"})
+			eventBus.WaitAsync()
+
+			Expect(received).To(Receive(HaveField("Type", "store.jobs.saved")))
+		})
+
+		It("should deliver a single event to the once subscribers", func() {
+			Expect(eventBus.SubscribeOnce(common.BusSubscriptionParams{Topic: "store.>", EventHandler: handler})).Error().NotTo(HaveOccurred())
+			Expect(eventBus.SubscribeOnceAsync(common.BusSubscriptionParams{Topic: "service.>", EventHandler: handler})).Error().NotTo(HaveOccurred())
+
+			eventBus.Publish(common.Event{Type: "store.opened"})
+			eventBus.Publish(common.Event{Type: "store.closed"})
+			eventBus.Publish(common.Event{Type: "service.started"})
+			eventBus.Publish(common.Event{Type: "service.stopped"})
+			eventBus.WaitAsync()
+
+			Expect(received).To(Receive(HaveField("Type", "store.opened")))
+			Expect(received).To(Receive(HaveField("Type", "service.started")))
+			Expect(received).NotTo(Receive())
+			Expect(eventBus.HasCallback("store.opened")).To(BeFalse())
+		})
+
+		It("should stop delivering the events once unsubscribed", func() {
+			params := common.BusSubscriptionParams{Topic: "store.>", EventHandler: handler}
+			Expect(eventBus.Subscribe(params)).Error().NotTo(HaveOccurred())
+			Expect(eventBus.HasCallback("store.opened")).To(BeTrue())
+			Expect(eventBus.HasCallback("store.>")).To(BeTrue())
+
+			Expect(eventBus.Unsubscribe(params)).To(Succeed())
+			eventBus.Publish(common.Event{Type: "store.opened"})
+
+			Expect(received).NotTo(Receive())
+			Expect(eventBus.HasCallback("store.opened")).To(BeFalse())
+		})
+
+		It("should reject the invalid patterns", func() {
+			_, err := eventBus.Subscribe(common.BusSubscriptionParams{Topic: "store.>.saved", EventHandler: handler})
+			Expect(err).To(MatchError(common.ErrInvalidTopicPattern))
+		})
+	})
+
+	Describe("subscriptions", func() {
+		var received []string
+
+		BeforeEach(func() {
+			received = nil
+		})
+
+		handler := func(name string) common.EventHandler {
+			return func(event common.Event) { received = append(received, name) }
+		}
+
+		It("should deliver the events to every handler of a topic in subscription order", func() {
+			for _, name := range []string{"first", "second", "third"} {
+				Expect(eventBus.Subscribe(common.BusSubscriptionParams{Topic: "topic", EventHandler: handler(name)})).Error().NotTo(HaveOccurred())
+			}
+
+			eventBus.Publish(common.Event{Type: "topic"})
+
+			Expect(received).To(Equal([]string{"first", "second", "third"}))
+		})
+
+		It("should remove only the handler of the unsubscribed subscription", func() {
+			first, err := eventBus.Subscribe(common.BusSubscriptionParams{Topic: "topic", EventHandler: handler("first")})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(first.Topic()).To(Equal("topic"))
+			Expect(eventBus.Subscribe(common.BusSubscriptionParams{Topic: "topic", EventHandler: handler("second")})).Error().NotTo(HaveOccurred())
+
+			Expect(first.Unsubscribe()).To(Succeed())
+			eventBus.Publish(common.Event{Type: "topic"})
+
+			Expect(received).To(Equal([]string{"second"}))
+			Expect(first.Unsubscribe()).To(MatchError(common.ErrSubscriptionNotFound))
+		})
+
+		It("should remove every handler of the topic when unsubscribing with parameters", func() {
+			params := common.BusSubscriptionParams{Topic: "topic", EventHandler: handler("first")}
+			Expect(eventBus.Subscribe(params)).Error().NotTo(HaveOccurred())
+			Expect(eventBus.Subscribe(common.BusSubscriptionParams{Topic: "topic", EventHandler: handler("second")})).Error().NotTo(HaveOccurred())
+
+			Expect(eventBus.Unsubscribe(params)).To(Succeed())
+			eventBus.Publish(common.Event{Type: "topic"})
+
+			Expect(received).To(BeEmpty())
+			Expect(eventBus.HasCallback("topic")).To(BeFalse())
+			Expect(eventBus.Unsubscribe(params)).To(MatchError(common.ErrSubscriptionNotFound))
+		})
+
+		It("should let the handlers subscribe and unsubscribe while handling an event", func() {
+			var subscription common.Subscription
+			subscription, err := eventBus.Subscribe(common.BusSubscriptionParams{
+				Topic: "topic",
+				EventHandler: func(event common.Event) {
+					received = append(received, "outer")
+					Expect(subscription.Unsubscribe()).To(Succeed())
+					Expect(eventBus.Subscribe(common.BusSubscriptionParams{Topic: "topic", EventHandler: handler("inner")})).Error().NotTo(HaveOccurred())
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			eventBus.Publish(common.Event{Type: "topic"})
+			eventBus.Publish(common.Event{Type: "topic"})
+
+			Expect(received).To(Equal([]string{"outer", "inner"}))
+		})
+
+		It("should reject subscriptions without handler", func() {
+			Expect(eventBus.Subscribe(common.BusSubscriptionParams{Topic: "topic"})).Error().To(HaveOccurred())
+		})
+
+		It("should be safe for concurrent use", func() {
+			var (
+				wg    sync.WaitGroup
+				count atomic.Int64
+			)
+			for i := 0; i < 10; i++ {
+				wg.Add(1)
+				go func() {
+					defer GinkgoRecover()
+					defer wg.Done()
+
+					subscription, err := eventBus.Subscribe(common.BusSubscriptionParams{
+						Topic:        "concurrent.topic",
+						EventHandler: func(event common.Event) { count.Add(1) },
+					})
+					Expect(err).NotTo(HaveOccurred())
+					eventBus.Publish(common.Event{Type: "concurrent.topic"})
+					Expect(subscription.Unsubscribe()).To(Succeed())
+				}()
+			}
+			wg.Wait()
+
+			Expect(count.Load()).To(BeNumerically(">=", 10))
+			Expect(eventBus.HasCallback("concurrent.topic")).To(BeFalse())
+		})
+	})
+
 	Describe("WaitAsync", func() {
 		It("should wait for all asynchronous operations to complete", func() {
 			var wg sync.WaitGroup
 			wg.Add(1)
 
-			err := eventBus.SubscribeAsync(common.BusSubscriptionParams{
+			_, err := eventBus.SubscribeAsync(common.BusSubscriptionParams{
 				Topic: "wait_async_topic",
 				EventHandler: func(event common.Event) {
 					time.Sleep(100 * time.Millisecond)
@@ -0,0 +1,261 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+const (
+	// ReplyToHeader is the header of a request giving the topic its replies are published on.
+	ReplyToHeader = "replyTo"
+
+	// CorrelationIDHeader is the header matching the replies with their request.
+	CorrelationIDHeader = "correlationId"
+
+	// ReplyErrorHeader is the header of a reply carrying the error of the responder instead of data.
+	ReplyErrorHeader = "replyError"
+
+	// InboxTopicPrefix is the prefix of the topics the replies are published on, followed by the request ID.
+	InboxTopicPrefix = "_inbox."
+)
+
+var (
+	// ErrNoResponders is returned when a request is published on a topic without responders.
+	ErrNoResponders = errors.New("no responders")
+
+	// ErrNoDeadline is returned when a request waiting for its replies has a context that is never done.
+	ErrNoDeadline = errors.New("request context has no deadline")
+
+	// ErrRequestTimeout is returned when the context of a request is done before its replies arrive.
+	ErrRequestTimeout = errors.New("request timed out")
+
+	// ErrRequestFailed is returned when the responder to a request replies with an error.
+	ErrRequestFailed = errors.New("request failed")
+
+	// ErrNotRequest is returned when replying to an event that is not a request.
+	ErrNotRequest = errors.New("event is not a request")
+)
+
+// RequestHandler defines the signature for a handler of requests, returning the data of the reply.
+type RequestHandler func(request Event) (interface{}, error)
+
+// Request publishes a request on a topic and waits for the first reply, until the context is done.
+// The request is an event created by NewEvent whose headers give the topic of the replies and the
+// correlation ID matching them with the request. Returns an error wrapping ErrNoDeadline if the
+// context is never done, ErrNoResponders if no responder subscribed to the topic with Respond,
+// ErrRequestTimeout if the context is done first, or ErrRequestFailed along with the reply if the
+// responder replied with an error.
+func Request(ctx *Context, bus EventBusInterface, source, topic string, payload interface{}) (Event, error) {
+	if ctx.Done() == nil {
+		return Event{}, fmt.Errorf("%w: requesting %s requires a context with a deadline", ErrNoDeadline, topic)
+	}
+
+	replies, err := request(ctx, bus, source, topic, payload, 1)
+	if len(replies) == 0 {
+		return Event{}, err
+	}
+	return replies[0], ReplyError(replies[0])
+}
+
+// Gather publishes a request on a topic and gathers the replies of its responders, until count replies
+// arrive or the context is done. With a count of zero, the replies are gathered until the context is
+// done, which requires a deadline. Returns the replies gathered, along with an error wrapping
+// ErrRequestTimeout if fewer than count replies arrived. The replies carrying an error are gathered
+// like the others, ReplyError returning their error.
+func Gather(ctx *Context, bus EventBusInterface, source, topic string, payload interface{}, count int) ([]Event, error) {
+	if count <= 0 && ctx.Done() == nil {
+		return nil, fmt.Errorf("%w: gathering every reply to %s requires a context with a deadline", ErrNoDeadline, topic)
+	}
+	return request(ctx, bus, source, topic, payload, count)
+}
+
+// Reply publishes the reply to a request, with the given data or, when err is not nil, with the
+// error of the responder. Returns an error wrapping ErrNotRequest if the event is not a request.
+func Reply(publisher BusPublisher, source string, request Event, payload interface{}, err error) error {
+	replyTo := request.Headers[ReplyToHeader]
+	if replyTo == "" {
+		return fmt.Errorf("%w: event %s has no %s header", ErrNotRequest, request.Type, ReplyToHeader)
+	}
+	correlationID := request.Headers[CorrelationIDHeader]
+	if correlationID == "" {
+		correlationID = request.ID
+	}
+
+	reply := NewEvent(nil, source, replyTo, payload)
+	reply.TraceID = request.TraceID
+	reply.Headers = map[string]string{CorrelationIDHeader: correlationID}
+	if err != nil {
+		reply.Data = nil
+		reply.Headers[ReplyErrorHeader] = err.Error()
+	}
+
+	publisher.Publish(reply)
+	return nil
+}
+
+// ReplyError returns the error carried by a reply, wrapping ErrRequestFailed, or nil if the reply has data.
+func ReplyError(reply Event) error {
+	if message, ok := reply.Headers[ReplyErrorHeader]; ok {
+		return fmt.Errorf("%w: %s", ErrRequestFailed, message)
+	}
+	return nil
+}
+
+// Respond subscribes a handler to the requests of a topic or topic pattern, replying with the data
+// or the error it returns. The events without a reply topic are ignored. The requests only detect
+// the responders subscribed with Respond, until their subscription is removed with Unsubscribe.
+func Respond(bus EventBusInterface, source, topic string, handler RequestHandler) (Subscription, error) {
+	if handler == nil {
+		return nil, fmt.Errorf("no handler given for topic %s", topic)
+	}
+
+	subscription, err := bus.Subscribe(BusSubscriptionParams{
+		Topic: topic,
+		EventHandler: func(request Event) {
+			if request.Headers[ReplyToHeader] == "" {
+				return
+			}
+			payload, err := handler(request)
+			Reply(bus, source, request, payload, err)
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	responder := &responderSubscription{Subscription: subscription, bus: bus}
+	responders.add(responder)
+	return responder, nil
+}
+
+// responderSubscription is the subscription of a responder, which stops being detected once removed.
+type responderSubscription struct {
+	Subscription
+	bus EventBusInterface
+}
+
+// Unsubscribe removes the subscription of the responder.
+func (s *responderSubscription) Unsubscribe() error {
+	responders.remove(s)
+	return s.Subscription.Unsubscribe()
+}
+
+// responderRegistry tracks the responders subscribed with Respond by event bus, so that the requests
+// detect their responders without counting the other subscribers, such as the subscribers to >.
+type responderRegistry struct {
+	mutex  sync.Mutex
+	topics map[EventBusInterface]*topicTrie[*responderSubscription]
+	counts map[EventBusInterface]int // Number of responders by event bus, to forget the buses without responders
+}
+
+// responders is the registry of the responders of every event bus.
+var responders = &responderRegistry{
+	topics: make(map[EventBusInterface]*topicTrie[*responderSubscription]),
+	counts: make(map[EventBusInterface]int),
+}
+
+// add registers a responder.
+func (r *responderRegistry) add(responder *responderSubscription) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	topics, ok := r.topics[responder.bus]
+	if !ok {
+		topics = newTopicTrie[*responderSubscription]()
+		r.topics[responder.bus] = topics
+	}
+	topics.insert(responder.Topic(), responder)
+	r.counts[responder.bus]++
+}
+
+// remove unregisters a responder.
+func (r *responderRegistry) remove(responder *responderSubscription) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	topics, ok := r.topics[responder.bus]
+	if !ok || !topics.remove(responder.Topic(), responder) {
+		return
+	}
+	if r.counts[responder.bus]--; r.counts[responder.bus] == 0 {
+		delete(r.topics, responder.bus)
+		delete(r.counts, responder.bus)
+	}
+}
+
+// has checks if a responder of the given event bus is subscribed to the topic or to a topic pattern matching it.
+func (r *responderRegistry) has(bus EventBusInterface, topic string) bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	topics, ok := r.topics[bus]
+	return ok && len(topics.match(topic)) > 0
+}
+
+// request publishes a request and gathers its replies until count replies arrive, count being
+// zero for no limit, or the context is done.
+func request(ctx *Context, bus EventBusInterface, source, topic string, payload interface{}, count int) ([]Event, error) {
+	if !responders.has(bus, topic) {
+		return nil, fmt.Errorf("%w: %s", ErrNoResponders, topic)
+	}
+
+	request := NewEvent(ctx, source, topic, payload)
+	inbox := InboxTopicPrefix + request.ID
+	request.Headers = map[string]string{ReplyToHeader: inbox, CorrelationIDHeader: request.ID}
+
+	var (
+		mutex   sync.Mutex
+		replies []Event
+		arrived = make(chan struct{}, 1)
+	)
+	subscription, err := bus.Subscribe(BusSubscriptionParams{
+		Topic: inbox,
+		EventHandler: func(reply Event) {
+			if reply.Headers[CorrelationIDHeader] != request.ID {
+				return
+			}
+			mutex.Lock()
+			replies = append(replies, reply)
+			mutex.Unlock()
+
+			select {
+			case arrived <- struct{}{}:
+			default:
+			}
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to subscribe to the replies to %s: %w", topic, err)
+	}
+	defer subscription.Unsubscribe()
+
+	bus.Publish(request)
+
+	// gathered returns a copy of the replies gathered so far, at most count
+	gathered := func() []Event {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		if count > 0 && len(replies) > count {
+			return append([]Event(nil), replies[:count]...)
+		}
+		return append([]Event(nil), replies...)
+	}
+
+	for {
+		if result := gathered(); count > 0 && len(result) == count {
+			return result, nil
+		}
+
+		select {
+		case <-arrived:
+		case <-ctx.Done():
+			result := gathered()
+			if count > 0 && len(result) < count {
+				return result, fmt.Errorf("%w: received %d of %d replies to %s: %w", ErrRequestTimeout, len(result), count, topic, ctx.Err())
+			}
+			return result, nil
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package common_test
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/ebanfa/skeleton/pkg/common"
+)
+
+var _ = Describe("Request and reply", func() {
+	// withTimeout returns a context done after the given timeout.
+	withTimeout := func(timeout time.Duration) *common.Context {
+		timeoutCtx, cancel := context.WithTimeout(context.Background(), timeout)
+		DeferCleanup(cancel)
+		return common.WithContext(timeoutCtx).WithTraceID("trace")
+	}
+
+	// Requests work the same way on both buses.
+	for _, bus := range []struct {
+		name string
+		new  func() common.EventBusInterface
+	}{
+		{"SystemEventBus", func() common.EventBusInterface { return common.NewSystemEventBus() }},
+		{"ChannelEventBus", func() common.EventBusInterface {
+			return common.NewChannelEventBus(common.ChannelEventBusOptions{})
+		}},
+	} {
+		bus := bus
+
+		Describe(bus.name, func() {
+			var (
+				ctx      *common.Context
+				eventBus common.EventBusInterface
+			)
+
+			// respond subscribes a responder replying with the given data.
+			respond := func(topic string, data interface{}) {
+				Expect(common.Respond(eventBus, "responder", topic, func(common.Event) (interface{}, error) {
+					return data, nil
+				})).Error().NotTo(HaveOccurred())
+			}
+
+			BeforeEach(func() {
+				ctx = withTimeout(time.Second)
+				eventBus = bus.new()
+			})
+
+			Describe("Request", func() {
+				It("returns the reply of the responder", func() {
+					Expect(common.Respond(eventBus, "responder", "price.get", func(request common.Event) (interface{}, error) {
+						Expect(request.Data).To(Equal("apple"))
+						Expect(request.Source).To(Equal("requester"))
+						Expect(request.TraceID).To(Equal("trace"))
+						return 42, nil
+					})).Error().NotTo(HaveOccurred())
+
+					reply, err := common.Request(ctx, eventBus, "requester", "price.get", "apple")
+					Expect(err).NotTo(HaveOccurred())
+					Expect(reply.Data).To(Equal(42))
+					Expect(reply.Source).To(Equal("responder"))
+					Expect(reply.TraceID).To(Equal("trace"))
+					Expect(reply.Type).To(HavePrefix(common.InboxTopicPrefix))
+					Expect(reply.Headers).To(HaveKey(common.CorrelationIDHeader))
+
+					// The reply topic is unsubscribed once the reply arrived
+					Expect(eventBus.HasCallback(reply.Type)).To(BeFalse())
+				})
+
+				It("waits for the reply of the slow responders", func() {
+					Expect(common.Respond(eventBus, "responder", "price.*", func(common.Event) (interface{}, error) {
+						time.Sleep(10 * time.Millisecond)
+						return "slow", nil
+					})).Error().NotTo(HaveOccurred())
+
+					Expect(common.Request(ctx, eventBus, "requester", "price.get", nil)).To(HaveField("Data", "slow"))
+				})
+
+				It("returns the error of the responder", func() {
+					Expect(common.Respond(eventBus, "responder", "price.get", func(request common.Event) (interface{}, error) {
+						return nil, errors.New("unknown product")
+					})).Error().NotTo(HaveOccurred())
+
+					reply, err := common.Request(ctx, eventBus, "requester", "price.get", "pear")
+					Expect(err).To(MatchError(common.ErrRequestFailed))
+					Expect(err).To(MatchError(ContainSubstring("unknown product")))
+					Expect(reply.Data).To(BeNil())
+				})
+
+				It("fails without responders", func() {
+					Expect(common.Request(ctx, eventBus, "requester", "price.get", nil)).Error().To(MatchError(common.ErrNoResponders))
+				})
+
+				It("fails when only the subscribers to every topic are present", func() {
+					Expect(eventBus.Subscribe(common.BusSubscriptionParams{
+						Topic:        common.TopicTailWildcard,
+						EventHandler: func(common.Event) {},
+					})).Error().NotTo(HaveOccurred())
+
+					Expect(common.Request(ctx, eventBus, "requester", "price.get", nil)).Error().To(MatchError(common.ErrNoResponders))
+				})
+
+				It("fails once the responders unsubscribed", func() {
+					subscription, err := common.Respond(eventBus, "responder", "price.get", func(common.Event) (interface{}, error) {
+						return 42, nil
+					})
+					Expect(err).NotTo(HaveOccurred())
+					Expect(subscription.Unsubscribe()).To(Succeed())
+
+					Expect(common.Request(ctx, eventBus, "requester", "price.get", nil)).Error().To(MatchError(common.ErrNoResponders))
+				})
+
+				It("requires a deadline", func() {
+					respond("price.get", 42)
+
+					Expect(common.Request(common.Background(), eventBus, "requester", "price.get", nil)).Error().To(MatchError(common.ErrNoDeadline))
+				})
+
+				It("times out when the deadline passes before the reply", func() {
+					// The responder fails without replying
+					Expect(common.Respond(eventBus, "responder", "price.get", func(common.Event) (interface{}, error) {
+						panic("lost")
+					})).Error().NotTo(HaveOccurred())
+
+					_, err := common.Request(withTimeout(20*time.Millisecond), eventBus, "requester", "price.get", nil)
+					Expect(err).To(MatchError(common.ErrRequestTimeout))
+					Expect(err).To(MatchError(context.DeadlineExceeded))
+				})
+
+				It("ignores the replies of other requests", func() {
+					Expect(common.Respond(eventBus, "responder", "price.get", func(request common.Event) (interface{}, error) {
+						other := request
+						other.Headers = map[string]string{
+							common.ReplyToHeader:       request.Headers[common.ReplyToHeader],
+							common.CorrelationIDHeader: "other",
+						}
+						Expect(common.Reply(eventBus, "responder", other, "wrong", nil)).To(Succeed())
+						return "right", nil
+					})).Error().NotTo(HaveOccurred())
+
+					Expect(common.Request(ctx, eventBus, "requester", "price.get", nil)).To(HaveField("Data", "right"))
+				})
+			})
+
+			Describe("Gather", func() {
+				BeforeEach(func() {
+					respond("price.get", 1)
+					respond("price.*", 2)
+					respond("price.>", 3)
+				})
+
+				It("gathers the given number of replies", func() {
+					replies, err := common.Gather(ctx, eventBus, "requester", "price.get", nil, 2)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(replies).To(HaveLen(2))
+				})
+
+				It("gathers every reply until the deadline", func() {
+					replies, err := common.Gather(withTimeout(20*time.Millisecond), eventBus, "requester", "price.get", nil, 0)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(replies).To(HaveLen(3))
+				})
+
+				It("returns the replies gathered when the deadline passes first", func() {
+					replies, err := common.Gather(withTimeout(20*time.Millisecond), eventBus, "requester", "price.get", nil, 4)
+					Expect(err).To(MatchError(common.ErrRequestTimeout))
+					Expect(replies).To(HaveLen(3))
+				})
+
+				It("requires a deadline to gather every reply", func() {
+					Expect(common.Gather(common.Background(), eventBus, "requester", "price.get", nil, 0)).Error().To(MatchError(common.ErrNoDeadline))
+				})
+			})
+		})
+	}
+
+	It("only replies to requests", func() {
+		Expect(common.Reply(common.NewSystemEventBus(), "responder", common.Event{Type: "price.get"}, nil, nil)).To(MatchError(common.ErrNotRequest))
+		Expect(common.ReplyError(common.Event{})).To(Succeed())
+	})
+})
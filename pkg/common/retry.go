@@ -0,0 +1,149 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	// DeadLetterTopic is the topic of the events whose handler failed every attempt, their data being a DeadLetter.
+	// The failures of the handlers of dead letters are not dead-lettered.
+	DeadLetterTopic = "dead_letter"
+
+	// EventBusSource is the source of the events published by the event buses themselves.
+	EventBusSource = "event_bus"
+)
+
+// ErrHandlerPanicked is returned when an event handler panics.
+var ErrHandlerPanicked = errors.New("event handler panicked")
+
+// RetryPolicy determines how often a failing event handler is called again.
+type RetryPolicy struct {
+	// MaxAttempts is the number of calls of the handler per event, including the first one.
+	MaxAttempts int
+
+	// Backoff is the delay before the first retry, doubled after each retry.
+	Backoff time.Duration
+
+	// MaxBackoff caps the delay between two retries. Zero means no cap.
+	MaxBackoff time.Duration
+}
+
+// delay returns the delay before the given retry, the first retry being 1.
+func (p *RetryPolicy) delay(retry int) time.Duration {
+	delay := p.Backoff
+	for i := 1; i < retry && (p.MaxBackoff <= 0 || delay < p.MaxBackoff); i++ {
+		delay *= 2
+	}
+	if p.MaxBackoff > 0 && delay > p.MaxBackoff {
+		delay = p.MaxBackoff
+	}
+	return delay
+}
+
+// DeadLetter represents the data of the events published on DeadLetterTopic.
+type DeadLetter struct {
+	// Event is the event the handler failed to handle.
+	Event Event
+
+	// Topic is the topic or topic pattern of the subscription of the handler.
+	Topic string
+
+	// Attempts is the number of calls of the handler.
+	Attempts int
+
+	// Error is the error of the last attempt.
+	Error string
+
+	// FailedAt is the time of the last attempt.
+	FailedAt time.Time
+}
+
+// validate checks that the parameters have a handler and a valid topic.
+func (params BusSubscriptionParams) validate() error {
+	if params.EventHandler == nil && params.ErrorHandler == nil {
+		return fmt.Errorf("no handler given for topic %s", params.Topic)
+	}
+	if IsTopicPattern(params.Topic) {
+		return ValidateTopicPattern(params.Topic)
+	}
+	return nil
+}
+
+// handler returns the function handling the events of a subscription. Panics of the handler are
+// recovered as errors wrapping ErrHandlerPanicked, the failures are retried according to the retry
+// policy, and the events still failing are passed to deadLetter. The wait before a retry ends when
+// done is closed, the event being dead-lettered without further attempts. When detach is given,
+// the retries run on the goroutine it starts rather than on the goroutine calling the handler.
+func (params BusSubscriptionParams) handler(deadLetter func(DeadLetter), done <-chan struct{}, detach func(func())) EventHandler {
+	handle := params.ErrorHandler
+	if handle == nil {
+		eventHandler := params.EventHandler
+		handle = func(event Event) error {
+			eventHandler(event)
+			return nil
+		}
+	}
+
+	attempts := 1
+	if params.Retry != nil && params.Retry.MaxAttempts > 1 {
+		attempts = params.Retry.MaxAttempts
+	}
+
+	// retry calls the handler again after a failed first attempt
+	retry := func(event Event, err error) {
+		attempt := 1
+		for attempt < attempts && wait(params.Retry.delay(attempt), done) {
+			attempt++
+			if err = recoverHandler(handle, event); err == nil {
+				return
+			}
+		}
+
+		if event.Type != DeadLetterTopic {
+			deadLetter(DeadLetter{
+				Event:    event,
+				Topic:    params.Topic,
+				Attempts: attempt,
+				Error:    err.Error(),
+				FailedAt: time.Now(),
+			})
+		}
+	}
+
+	return func(event Event) {
+		err := recoverHandler(handle, event)
+		switch {
+		case err == nil:
+		case attempts > 1 && detach != nil:
+			detach(func() { retry(event, err) })
+		default:
+			retry(event, err)
+		}
+	}
+}
+
+// wait waits for the given delay. Returns false if done is closed first.
+func wait(delay time.Duration, done <-chan struct{}) bool {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-done:
+		return false
+	}
+}
+
+// recoverHandler calls a handler with an event, turning its panics into errors.
+func recoverHandler(handle ErrorEventHandler, event Event) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%w: %v", ErrHandlerPanicked, r)
+		}
+	}()
+
+	return handle(event)
+}
@@ -0,0 +1,174 @@
+package common_test
+
+import (
+	"errors"
+	"sync/atomic"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/ebanfa/skeleton/pkg/common"
+)
+
+var _ = Describe("Retries and dead letters", func() {
+	var errFailed = errors.New("handler failed")
+
+	// Both buses retry and dead-letter the failing handlers the same way.
+	for _, bus := range []struct {
+		name string
+		new  func() common.EventBusInterface
+	}{
+		{"SystemEventBus", func() common.EventBusInterface { return common.NewSystemEventBus() }},
+		{"ChannelEventBus", func() common.EventBusInterface {
+			return common.NewChannelEventBus(common.ChannelEventBusOptions{})
+		}},
+	} {
+		bus := bus
+
+		Describe(bus.name, func() {
+			var (
+				eventBus    common.EventBusInterface
+				attempts    atomic.Int64
+				deadLetters chan common.Event
+			)
+
+			BeforeEach(func() {
+				eventBus = bus.new()
+				attempts.Store(0)
+				deadLetters = make(chan common.Event, 10)
+
+				Expect(eventBus.Subscribe(common.BusSubscriptionParams{
+					Topic:        common.DeadLetterTopic,
+					EventHandler: func(event common.Event) { deadLetters <- event },
+				})).Error().NotTo(HaveOccurred())
+			})
+
+			AfterEach(func() {
+				if closer, ok := eventBus.(interface{ Close() }); ok {
+					closer.Close()
+				}
+			})
+
+			It("retries a failing handler until it succeeds", func() {
+				Expect(eventBus.Subscribe(common.BusSubscriptionParams{
+					Topic: "topic",
+					ErrorHandler: func(event common.Event) error {
+						if attempts.Add(1) < 3 {
+							return errFailed
+						}
+						return nil
+					},
+					Retry: &common.RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond},
+				})).Error().NotTo(HaveOccurred())
+
+				eventBus.Publish(common.Event{Type: "topic"})
+
+				Eventually(attempts.Load).Should(Equal(int64(3)))
+				Consistently(deadLetters, 50*time.Millisecond).ShouldNot(Receive())
+			})
+
+			It("dead-letters the events still failing after the last attempt", func() {
+				Expect(eventBus.Subscribe(common.BusSubscriptionParams{
+					Topic: "service.*",
+					ErrorHandler: func(event common.Event) error {
+						attempts.Add(1)
+						return errFailed
+					},
+					Retry: &common.RetryPolicy{MaxAttempts: 2, Backoff: time.Millisecond},
+				})).Error().NotTo(HaveOccurred())
+
+				published := common.NewEvent(nil, "api", "service.started", "data")
+				eventBus.Publish(published)
+
+				var event common.Event
+				Eventually(deadLetters).Should(Receive(&event))
+				Expect(event.Source).To(Equal(common.EventBusSource))
+
+				deadLetter, ok := event.Data.(common.DeadLetter)
+				Expect(ok).To(BeTrue())
+				Expect(deadLetter.Event.ID).To(Equal(published.ID))
+				Expect(deadLetter.Topic).To(Equal("service.*"))
+				Expect(deadLetter.Attempts).To(Equal(2))
+				Expect(deadLetter.Error).To(Equal(errFailed.Error()))
+				Expect(deadLetter.FailedAt).To(BeTemporally("~", time.Now(), time.Second))
+				Expect(attempts.Load()).To(Equal(int64(2)))
+			})
+
+			It("ends the retries when the subscription is removed", func() {
+				subscription, err := eventBus.Subscribe(common.BusSubscriptionParams{
+					Topic: "topic",
+					ErrorHandler: func(event common.Event) error {
+						attempts.Add(1)
+						return errFailed
+					},
+					Retry: &common.RetryPolicy{MaxAttempts: 3, Backoff: time.Hour},
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				// The publisher is not held back by the backoff
+				eventBus.Publish(common.Event{Type: "topic"})
+				Eventually(attempts.Load).Should(Equal(int64(1)))
+				Expect(subscription.Unsubscribe()).To(Succeed())
+
+				var event common.Event
+				Eventually(deadLetters).Should(Receive(&event))
+				Expect(event.Data.(common.DeadLetter).Attempts).To(Equal(1))
+				Expect(attempts.Load()).To(Equal(int64(1)))
+			})
+
+			It("recovers the panics of the handlers", func() {
+				Expect(eventBus.Subscribe(common.BusSubscriptionParams{
+					Topic:        "topic",
+					EventHandler: func(event common.Event) { panic("boom") },
+				})).Error().NotTo(HaveOccurred())
+
+				Expect(func() { eventBus.Publish(common.Event{Type: "topic"}) }).NotTo(Panic())
+
+				var event common.Event
+				Eventually(deadLetters).Should(Receive(&event))
+				Expect(event.Data.(common.DeadLetter).Attempts).To(Equal(1))
+				Expect(event.Data.(common.DeadLetter).Error).To(ContainSubstring("boom"))
+			})
+
+			It("does not dead-letter the failures of the dead-letter handlers", func() {
+				Expect(eventBus.Subscribe(common.BusSubscriptionParams{
+					Topic:        common.DeadLetterTopic,
+					ErrorHandler: func(event common.Event) error { return errFailed },
+				})).Error().NotTo(HaveOccurred())
+
+				eventBus.Publish(common.Event{Type: common.DeadLetterTopic})
+
+				Eventually(deadLetters).Should(Receive())
+				Consistently(deadLetters, 50*time.Millisecond).ShouldNot(Receive())
+			})
+		})
+	}
+
+	It("caps the backoff between the retries", func() {
+		eventBus := common.NewSystemEventBus()
+		var times []time.Time
+
+		Expect(eventBus.Subscribe(common.BusSubscriptionParams{
+			Topic: "topic",
+			ErrorHandler: func(event common.Event) error {
+				times = append(times, time.Now())
+				return errFailed
+			},
+			Retry: &common.RetryPolicy{MaxAttempts: 4, Backoff: 10 * time.Millisecond, MaxBackoff: 20 * time.Millisecond},
+		})).Error().NotTo(HaveOccurred())
+
+		eventBus.Publish(common.Event{Type: "topic"})
+		eventBus.WaitAsync()
+
+		Expect(times).To(HaveLen(4))
+		Expect(times[1].Sub(times[0])).To(BeNumerically(">=", 10*time.Millisecond))
+		Expect(times[2].Sub(times[1])).To(BeNumerically(">=", 20*time.Millisecond))
+		Expect(times[3].Sub(times[2])).To(BeNumerically(">=", 20*time.Millisecond))
+		Expect(times[3].Sub(times[2])).To(BeNumerically("<", 40*time.Millisecond))
+	})
+
+	It("requires a handler", func() {
+		Expect(common.NewSystemEventBus().Subscribe(common.BusSubscriptionParams{Topic: "topic"})).Error().To(HaveOccurred())
+	})
+})
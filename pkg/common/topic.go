@@ -0,0 +1,186 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	// TopicSeparator separates the tokens of hierarchical topics, such as service.api.started.
+	TopicSeparator = "."
+
+	// TopicWildcard matches exactly one token of a topic, such as in service.*.started.
+	TopicWildcard = "*"
+
+	// TopicTailWildcard matches one or more trailing tokens of a topic, such as in store.>.
+	// It must be the last token of a pattern.
+	TopicTailWildcard = ">"
+)
+
+// ErrInvalidTopicPattern is returned when a topic pattern has empty tokens or a misplaced tail wildcard.
+var ErrInvalidTopicPattern = errors.New("invalid topic pattern")
+
+// IsTopicPattern checks if a topic contains wildcard tokens, and so matches a family of topics.
+func IsTopicPattern(topic string) bool {
+	for _, token := range strings.Split(topic, TopicSeparator) {
+		if token == TopicWildcard || token == TopicTailWildcard {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateTopicPattern checks that a topic pattern has no empty token and that its tail wildcard,
+// if any, is its last token.
+func ValidateTopicPattern(pattern string) error {
+	tokens := strings.Split(pattern, TopicSeparator)
+	for i, token := range tokens {
+		switch {
+		case token == "":
+			return fmt.Errorf("%w: %q has an empty token", ErrInvalidTopicPattern, pattern)
+		case token == TopicTailWildcard && i != len(tokens)-1:
+			return fmt.Errorf("%w: %q has %s before its last token", ErrInvalidTopicPattern, pattern, TopicTailWildcard)
+		}
+	}
+	return nil
+}
+
+// MatchTopic checks if a topic matches a pattern. A topic without wildcards only matches itself.
+func MatchTopic(pattern, topic string) bool {
+	patternTokens := strings.Split(pattern, TopicSeparator)
+	topicTokens := strings.Split(topic, TopicSeparator)
+
+	for i, token := range patternTokens {
+		if token == TopicTailWildcard {
+			return i < len(topicTokens)
+		}
+		if i >= len(topicTokens) || (token != TopicWildcard && token != topicTokens[i]) {
+			return false
+		}
+	}
+	return len(patternTokens) == len(topicTokens)
+}
+
+// topicTrie stores values by topic pattern, a node per token, so that the values of the patterns
+// matching a topic are found in a single walk of the topic tokens.
+type topicTrie[T comparable] struct {
+	root *topicNode[T]
+}
+
+// topicNode represents a token of the patterns stored in a trie.
+type topicNode[T comparable] struct {
+	children map[string]*topicNode[T]
+	values   []T // Values of the patterns ending at this node
+}
+
+// newTopicTrie creates a new empty trie.
+func newTopicTrie[T comparable]() *topicTrie[T] {
+	return &topicTrie[T]{root: &topicNode[T]{}}
+}
+
+// insert adds a value to a pattern.
+func (t *topicTrie[T]) insert(pattern string, value T) {
+	node := t.root
+	for _, token := range strings.Split(pattern, TopicSeparator) {
+		if node.children == nil {
+			node.children = make(map[string]*topicNode[T])
+		}
+		child, ok := node.children[token]
+		if !ok {
+			child = &topicNode[T]{}
+			node.children[token] = child
+		}
+		node = child
+	}
+	node.values = append(node.values, value)
+}
+
+// remove removes a value from a pattern, pruning the nodes left empty.
+// Returns false if the value is not stored for the pattern.
+func (t *topicTrie[T]) remove(pattern string, value T) bool {
+	return t.root.remove(strings.Split(pattern, TopicSeparator), value)
+}
+
+// remove removes a value from the pattern with the given tokens below the node.
+func (n *topicNode[T]) remove(tokens []string, value T) bool {
+	if len(tokens) == 0 {
+		for i, v := range n.values {
+			if v == value {
+				n.values = append(n.values[:i], n.values[i+1:]...)
+				return true
+			}
+		}
+		return false
+	}
+
+	child, ok := n.children[tokens[0]]
+	if !ok || !child.remove(tokens[1:], value) {
+		return false
+	}
+	if len(child.values) == 0 && len(child.children) == 0 {
+		delete(n.children, tokens[0])
+	}
+	return true
+}
+
+// match returns the values of the patterns matching a topic.
+func (t *topicTrie[T]) match(topic string) []T {
+	var values []T
+	t.root.match(strings.Split(topic, TopicSeparator), &values)
+	return values
+}
+
+// match appends the values of the patterns below the node matching the given tokens.
+func (n *topicNode[T]) match(tokens []string, values *[]T) {
+	if len(tokens) == 0 {
+		*values = append(*values, n.values...)
+		return
+	}
+
+	if tail, ok := n.children[TopicTailWildcard]; ok {
+		*values = append(*values, tail.values...)
+	}
+	if wildcard, ok := n.children[TopicWildcard]; ok {
+		wildcard.match(tokens[1:], values)
+	}
+	if tokens[0] == TopicWildcard || tokens[0] == TopicTailWildcard {
+		return // Already matched by the wildcard children
+	}
+	if child, ok := n.children[tokens[0]]; ok {
+		child.match(tokens[1:], values)
+	}
+}
+
+// removeAll removes every value of a pattern, pruning the nodes left empty.
+// Returns the removed values.
+func (t *topicTrie[T]) removeAll(pattern string) []T {
+	node := t.root.find(strings.Split(pattern, TopicSeparator))
+	if node == nil {
+		return nil
+	}
+
+	values := append([]T(nil), node.values...)
+	for _, value := range values {
+		t.remove(pattern, value)
+	}
+	return values
+}
+
+// has checks if values are stored for a pattern.
+func (t *topicTrie[T]) has(pattern string) bool {
+	node := t.root.find(strings.Split(pattern, TopicSeparator))
+	return node != nil && len(node.values) > 0
+}
+
+// find returns the node of the pattern with the given tokens below the node, or nil if there is none.
+func (n *topicNode[T]) find(tokens []string) *topicNode[T] {
+	for _, token := range tokens {
+		child, ok := n.children[token]
+		if !ok {
+			return nil
+		}
+		n = child
+	}
+	return n
+}
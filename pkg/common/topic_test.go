@@ -0,0 +1,72 @@
+package common_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/ebanfa/skeleton/pkg/common"
+)
+
+var _ = Describe("Topics", func() {
+	DescribeTable("MatchTopic",
+		func(pattern, topic string, matches bool) {
+			Expect(common.MatchTopic(pattern, topic)).To(Equal(matches))
+		},
+		Entry("an exact topic", "service.api.started", "service.api.started", true),
+		Entry("another exact topic", "service.api.started", "service.api.stopped", false),
+		Entry("a single token wildcard", "service.*.started", "service.api.started", true),
+		Entry("a single token wildcard with more tokens", "service.*.started", "service.api.v2.started", false),
+		Entry("a single token wildcard with fewer tokens", "service.*", "service", false),
+		Entry("a trailing wildcard with one token", "store.>", "store.opened", true),
+		Entry("a trailing wildcard with more tokens", "store.>", "store.jobs.saved", true),
+		Entry("a trailing wildcard without tokens", "store.>", "store", false),
+		Entry("a trailing wildcard of another prefix", "store.>", "service.started", false),
+		Entry("both wildcards", "*.jobs.>", "store.jobs.saved", true),
+		Entry("a lone trailing wildcard", ">", "anything.at.all", true),
+	)
+
+	It("recognizes the topic patterns", func() {
+		Expect(common.IsTopicPattern("service.*.started")).To(BeTrue())
+		Expect(common.IsTopicPattern("store.>")).To(BeTrue())
+		Expect(common.IsTopicPattern("service_state_changed")).To(BeFalse())
+		Expect(common.IsTopicPattern("service.api*")).To(BeFalse())
+	})
+
+	DescribeTable("ValidateTopicPattern rejects invalid patterns",
+		func(pattern string) {
+			Expect(common.ValidateTopicPattern(pattern)).To(MatchError(common.ErrInvalidTopicPattern))
+		},
+		Entry("an empty token", "service..*"),
+		Entry("a trailing separator", "service.*."),
+		Entry("a misplaced trailing wildcard", "store.>.saved"),
+	)
+
+	It("delivers the events to the patterns matching their topic", func() {
+		patterns := []string{"service.*.started", "service.>", "*.api.*", "store.>", ">"}
+		topics := []string{"service.api.started", "service.db.stopped", "store.api.saved", "store", "service"}
+
+		eventBus := common.NewSystemEventBus()
+		received := make(map[string][]string)
+		for _, pattern := range patterns {
+			pattern := pattern
+			Expect(eventBus.Subscribe(common.BusSubscriptionParams{
+				Topic:        pattern,
+				EventHandler: func(event common.Event) { received[pattern] = append(received[pattern], event.Type) },
+			})).Error().NotTo(HaveOccurred())
+		}
+
+		for _, topic := range topics {
+			eventBus.Publish(common.Event{Type: topic})
+		}
+
+		for _, pattern := range patterns {
+			var expected []string
+			for _, topic := range topics {
+				if common.MatchTopic(pattern, topic) {
+					expected = append(expected, topic)
+				}
+			}
+			Expect(received[pattern]).To(Equal(expected), "pattern %s", pattern)
+		}
+	})
+})
@@ -0,0 +1,13 @@
+package component
+
+import (
+	"github.com/ebanfa/skeleton/pkg/types"
+)
+
+// FactoryFunc is an adapter allowing the use of an ordinary function as a component factory.
+type FactoryFunc func(config *types.ComponentConfig) (types.ComponentInterface, error)
+
+// CreateComponent creates a new instance of the component by calling the function.
+func (f FactoryFunc) CreateComponent(config *types.ComponentConfig) (types.ComponentInterface, error) {
+	return f(config)
+}
@@ -0,0 +1,22 @@
+package component_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/ebanfa/skeleton/pkg/component"
+	"github.com/ebanfa/skeleton/pkg/types"
+)
+
+var _ = Describe("FactoryFunc", func() {
+	It("creates components by calling the function", func() {
+		factory := component.FactoryFunc(func(config *types.ComponentConfig) (types.ComponentInterface, error) {
+			return component.NewComponentImpl(config.ID, config.Name, config.Description), nil
+		})
+
+		created, err := factory.CreateComponent(&types.ComponentConfig{ID: "id", Name: "name"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(created.ID()).To(Equal("id"))
+		Expect(created.Name()).To(Equal("name"))
+	})
+})
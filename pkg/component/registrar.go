@@ -2,6 +2,7 @@ package component
 
 import (
 	"fmt"
+	"sort"
 	"sync"
 
 	"github.com/ebanfa/skeleton/pkg/common"
@@ -93,6 +94,20 @@ func (cr *ComponentRegistrar) GetFactory(id string) (types.ComponentFactoryInter
 	return factory, nil
 }
 
+// GetFactoryIDs returns the IDs of all registered component factories, sorted alphabetically.
+func (cr *ComponentRegistrar) GetFactoryIDs() []string {
+	cr.factoriesMutex.RLock()
+	defer cr.factoriesMutex.RUnlock()
+
+	ids := make([]string, 0, len(cr.factories))
+	for id := range cr.factories {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	return ids
+}
+
 // GetAllFactories returns a list of all registered component factories.
 func (cr *ComponentRegistrar) GetAllFactories() []types.ComponentFactoryInterface {
 	// Lock the factories mutex for reading to prevent concurrent access while reading
@@ -167,6 +182,11 @@ func (cr *ComponentRegistrar) UnregisterComponent(ctx *common.Context, id string
 	return nil
 }
 
+// RemoveComponent removes the component with the specified ID from the registry.
+func (cr *ComponentRegistrar) RemoveComponent(ctx *common.Context, id string) error {
+	return cr.UnregisterComponent(ctx, id)
+}
+
 // UnregisterFactory unregisters the factory with the specified ID.
 func (cr *ComponentRegistrar) UnregisterFactory(ctx *common.Context, id string) error {
 	cr.factoriesMutex.Lock()
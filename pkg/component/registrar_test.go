@@ -171,6 +171,10 @@ var _ = Describe("ComponentRegistrar", func() {
 			factories := registrar.GetAllFactories()
 			Expect(factories).To(HaveLen(2))
 		})
+
+		It("should return the IDs of all registered factories", func() {
+			Expect(registrar.GetFactoryIDs()).To(Equal([]string{"mock-factory1", "mock-factory2"}))
+		})
 	})
 
 	Describe("Unregister Component and Factory", func() {
@@ -204,6 +208,14 @@ var _ = Describe("ComponentRegistrar", func() {
 			Expect(err).To(MatchError("component with ID test-component not found"))
 		})
 
+		It("should remove a component successfully", func() {
+			err := registrar.RemoveComponent(ctx, "test-component")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = registrar.GetComponent("test-component")
+			Expect(err).To(MatchError("component with ID test-component not found"))
+		})
+
 		It("should return an error when unregistering a non-existent component", func() {
 			err := registrar.UnregisterComponent(ctx, "non-existent-component")
 			Expect(err).To(MatchError("component with ID non-existent-component not found"))
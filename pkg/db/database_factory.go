@@ -35,6 +35,8 @@ func (f *IAVLDatabaseFactory) CreateDatabase(name, path string) (types.Database,
 	// Initialize the IAVLDB instance
 	iavlTree := iavl.NewMutableTree(db.NewPrefixDB(ldb, []byte("s/k:main/")), 100, false, log.NewNopLogger())
 	iavlDB := NewIAVLDatabase(iavlTree)
+	// The tree does not close its database, so that the LevelDB lock is released on Close
+	iavlDB.closer = ldb
 
 	return iavlDB, nil
 }
@@ -37,6 +37,17 @@ var _ = Describe("IAVLDatabaseFactory", func() {
 				Expect(database).NotTo(BeNil())
 				// Additional expectations specific to IAVL database can be added here if necessary
 			})
+
+			It("should release the database on close, so that it can be reopened", func() {
+				dbPath := GinkgoT().TempDir()
+				database, err := factory.CreateDatabase("test", dbPath)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(database.Close()).To(Succeed())
+
+				database, err = factory.CreateDatabase("test", dbPath)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(database.Close()).To(Succeed())
+			})
 		})
 
 		Context("when creation fails", func() {
@@ -1,6 +1,8 @@
 package db
 
 import (
+	"errors"
+	"io"
 	"sync"
 
 	"github.com/cosmos/iavl"
@@ -12,8 +14,9 @@ type MutableTree interface {
 
 // IAVLDatabase wraps an IAVL+ tree to implement the Database interface.
 type IAVLDatabase struct {
-	tree *iavl.MutableTree
-	mtx  sync.RWMutex // Mutex for concurrent access
+	tree   *iavl.MutableTree
+	closer io.Closer    // Underlying database closed with the tree, if any
+	mtx    sync.RWMutex // Mutex for concurrent access
 }
 
 // NewIAVLDatabase creates a new IAVLDatabase instance.
@@ -127,6 +130,14 @@ func (db *IAVLDatabase) SaveVersion() ([]byte, int64, error) {
 	return hash, version, err
 }
 
+// DeleteVersionsTo deletes the saved versions of the tree up to the given version.
+func (db *IAVLDatabase) DeleteVersionsTo(toVersion int64) error {
+	db.mtx.Lock()
+	defer db.mtx.Unlock()
+	// Delete the versions and the nodes they no longer share with the later versions
+	return db.tree.DeleteVersionsTo(toVersion)
+}
+
 // Rollback resets the working tree to the latest saved version, discarding
 // any unsaved modifications.
 func (db *IAVLDatabase) Rollback() {
@@ -136,12 +147,16 @@ func (db *IAVLDatabase) Rollback() {
 	db.tree.Rollback()
 }
 
-// Close closes the tree.
+// Close closes the tree, then the underlying database if any.
 func (db *IAVLDatabase) Close() error {
 	db.mtx.Lock()
 	defer db.mtx.Unlock()
-	// Close the tree
-	return db.tree.Close()
+	// Close the tree, which leaves its database open
+	err := db.tree.Close()
+	if db.closer != nil {
+		err = errors.Join(err, db.closer.Close())
+	}
+	return err
 }
 
 // String returns a string representation of the tree.
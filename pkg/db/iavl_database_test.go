@@ -179,6 +179,26 @@ var _ = Describe("IAVLDatabase", func() {
 		})
 	})
 
+	Describe("DeleteVersionsTo", func() {
+		It("should delete the versions before the latest one", func() {
+			for i := 0; i < 3; i++ {
+				Expect(mockDB.Set([]byte("key"), []byte{byte(i)})).To(Succeed())
+				_, _, err := mockDB.SaveVersion()
+				Expect(err).NotTo(HaveOccurred())
+			}
+
+			Expect(mockDB.DeleteVersionsTo(2)).To(Succeed())
+			Expect(mockDB.AvailableVersions()).To(Equal([]int{3}))
+			Expect(mockDB.Get([]byte("key"))).To(Equal([]byte{2}))
+		})
+
+		It("should not delete the latest version", func() {
+			_, _, err := mockDB.SaveVersion()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(mockDB.DeleteVersionsTo(1)).NotTo(Succeed())
+		})
+	})
+
 	Describe("IsEmpty", func() {
 		It("should return true for an empty database", func() {
 			empty := mockDB.IsEmpty()
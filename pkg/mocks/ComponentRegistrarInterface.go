@@ -144,6 +144,26 @@ func (_m *ComponentRegistrarInterface) GetFactory(id string) (types.ComponentFac
 	return r0, r1
 }
 
+// GetFactoryIDs provides a mock function with given fields:
+func (_m *ComponentRegistrarInterface) GetFactoryIDs() []string {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetFactoryIDs")
+	}
+
+	var r0 []string
+	if rf, ok := ret.Get(0).(func() []string); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	return r0
+}
+
 // RegisterFactory provides a mock function with given fields: ctx, id, factory
 func (_m *ComponentRegistrarInterface) RegisterFactory(ctx *common.Context, id string, factory types.ComponentFactoryInterface) error {
 	ret := _m.Called(ctx, id, factory)
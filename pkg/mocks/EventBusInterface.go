@@ -36,75 +36,123 @@ func (_m *EventBusInterface) Publish(event common.Event) {
 }
 
 // Subscribe provides a mock function with given fields: params
-func (_m *EventBusInterface) Subscribe(params common.BusSubscriptionParams) error {
+func (_m *EventBusInterface) Subscribe(params common.BusSubscriptionParams) (common.Subscription, error) {
 	ret := _m.Called(params)
 
 	if len(ret) == 0 {
 		panic("no return value specified for Subscribe")
 	}
 
-	var r0 error
-	if rf, ok := ret.Get(0).(func(common.BusSubscriptionParams) error); ok {
+	var r0 common.Subscription
+	var r1 error
+	if rf, ok := ret.Get(0).(func(common.BusSubscriptionParams) (common.Subscription, error)); ok {
+		return rf(params)
+	}
+	if rf, ok := ret.Get(0).(func(common.BusSubscriptionParams) common.Subscription); ok {
 		r0 = rf(params)
 	} else {
-		r0 = ret.Error(0)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(common.Subscription)
+		}
 	}
 
-	return r0
+	if rf, ok := ret.Get(1).(func(common.BusSubscriptionParams) error); ok {
+		r1 = rf(params)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
 }
 
 // SubscribeAsync provides a mock function with given fields: params, transactional
-func (_m *EventBusInterface) SubscribeAsync(params common.BusSubscriptionParams, transactional bool) error {
+func (_m *EventBusInterface) SubscribeAsync(params common.BusSubscriptionParams, transactional bool) (common.Subscription, error) {
 	ret := _m.Called(params, transactional)
 
 	if len(ret) == 0 {
 		panic("no return value specified for SubscribeAsync")
 	}
 
-	var r0 error
-	if rf, ok := ret.Get(0).(func(common.BusSubscriptionParams, bool) error); ok {
+	var r0 common.Subscription
+	var r1 error
+	if rf, ok := ret.Get(0).(func(common.BusSubscriptionParams, bool) (common.Subscription, error)); ok {
+		return rf(params, transactional)
+	}
+	if rf, ok := ret.Get(0).(func(common.BusSubscriptionParams, bool) common.Subscription); ok {
 		r0 = rf(params, transactional)
 	} else {
-		r0 = ret.Error(0)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(common.Subscription)
+		}
 	}
 
-	return r0
+	if rf, ok := ret.Get(1).(func(common.BusSubscriptionParams, bool) error); ok {
+		r1 = rf(params, transactional)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
 }
 
 // SubscribeOnce provides a mock function with given fields: params
-func (_m *EventBusInterface) SubscribeOnce(params common.BusSubscriptionParams) error {
+func (_m *EventBusInterface) SubscribeOnce(params common.BusSubscriptionParams) (common.Subscription, error) {
 	ret := _m.Called(params)
 
 	if len(ret) == 0 {
 		panic("no return value specified for SubscribeOnce")
 	}
 
-	var r0 error
-	if rf, ok := ret.Get(0).(func(common.BusSubscriptionParams) error); ok {
+	var r0 common.Subscription
+	var r1 error
+	if rf, ok := ret.Get(0).(func(common.BusSubscriptionParams) (common.Subscription, error)); ok {
+		return rf(params)
+	}
+	if rf, ok := ret.Get(0).(func(common.BusSubscriptionParams) common.Subscription); ok {
 		r0 = rf(params)
 	} else {
-		r0 = ret.Error(0)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(common.Subscription)
+		}
 	}
 
-	return r0
+	if rf, ok := ret.Get(1).(func(common.BusSubscriptionParams) error); ok {
+		r1 = rf(params)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
 }
 
 // SubscribeOnceAsync provides a mock function with given fields: params
-func (_m *EventBusInterface) SubscribeOnceAsync(params common.BusSubscriptionParams) error {
+func (_m *EventBusInterface) SubscribeOnceAsync(params common.BusSubscriptionParams) (common.Subscription, error) {
 	ret := _m.Called(params)
 
 	if len(ret) == 0 {
 		panic("no return value specified for SubscribeOnceAsync")
 	}
 
-	var r0 error
-	if rf, ok := ret.Get(0).(func(common.BusSubscriptionParams) error); ok {
+	var r0 common.Subscription
+	var r1 error
+	if rf, ok := ret.Get(0).(func(common.BusSubscriptionParams) (common.Subscription, error)); ok {
+		return rf(params)
+	}
+	if rf, ok := ret.Get(0).(func(common.BusSubscriptionParams) common.Subscription); ok {
 		r0 = rf(params)
 	} else {
-		r0 = ret.Error(0)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(common.Subscription)
+		}
 	}
 
-	return r0
+	if rf, ok := ret.Get(1).(func(common.BusSubscriptionParams) error); ok {
+		r1 = rf(params)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
 }
 
 // Unsubscribe provides a mock function with given fields: params
@@ -105,6 +105,24 @@ func (_m *MultiStore) Delete(key []byte) error {
 	return r0
 }
 
+// DeleteVersionsTo provides a mock function with given fields: toVersion
+func (_m *MultiStore) DeleteVersionsTo(toVersion int64) error {
+	ret := _m.Called(toVersion)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteVersionsTo")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(int64) error); ok {
+		r0 = rf(toVersion)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Get provides a mock function with given fields: key
 func (_m *MultiStore) Get(key []byte) ([]byte, error) {
 	ret := _m.Called(key)
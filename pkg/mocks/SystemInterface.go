@@ -14,6 +14,35 @@ type SystemInterface struct {
 	mock.Mock
 }
 
+// AddOperationInterceptors provides a mock function with given fields: interceptors
+func (_m *SystemInterface) AddOperationInterceptors(interceptors ...types.OperationInterceptor) {
+	_va := make([]interface{}, len(interceptors))
+	for _i := range interceptors {
+		_va[_i] = interceptors[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, _va...)
+	_m.Called(_ca...)
+}
+
+// CancelJob provides a mock function with given fields: jobID
+func (_m *SystemInterface) CancelJob(jobID string) error {
+	ret := _m.Called(jobID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CancelJob")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(jobID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // ComponentRegistry provides a mock function with given fields:
 func (_m *SystemInterface) ComponentRegistry() types.ComponentRegistrarInterface {
 	ret := _m.Called()
@@ -122,6 +151,74 @@ func (_m *SystemInterface) Initialize(ctx *common.Context) error {
 	return r0
 }
 
+// JobQueueStats provides a mock function with given fields:
+func (_m *SystemInterface) JobQueueStats() types.JobQueueStats {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for JobQueueStats")
+	}
+
+	var r0 types.JobQueueStats
+	if rf, ok := ret.Get(0).(func() types.JobQueueStats); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(types.JobQueueStats)
+	}
+
+	return r0
+}
+
+// JobStatus provides a mock function with given fields: jobID
+func (_m *SystemInterface) JobStatus(jobID string) (*types.JobInfo, error) {
+	ret := _m.Called(jobID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for JobStatus")
+	}
+
+	var r0 *types.JobInfo
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (*types.JobInfo, error)); ok {
+		return rf(jobID)
+	}
+	if rf, ok := ret.Get(0).(func(string) *types.JobInfo); ok {
+		r0 = rf(jobID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*types.JobInfo)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(jobID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListServiceStatuses provides a mock function with given fields:
+func (_m *SystemInterface) ListServiceStatuses() map[string]types.ServiceStatusType {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListServiceStatuses")
+	}
+
+	var r0 map[string]types.ServiceStatusType
+	if rf, ok := ret.Get(0).(func() map[string]types.ServiceStatusType); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[string]types.ServiceStatusType)
+		}
+	}
+
+	return r0
+}
+
 // Logger provides a mock function with given fields:
 func (_m *SystemInterface) Logger() common.LoggerInterface {
 	ret := _m.Called()
@@ -182,6 +279,29 @@ func (_m *SystemInterface) PluginManager() types.PluginManagerInterface {
 	return r0
 }
 
+// RegisterInputCodec provides a mock function with given fields: operationID, codec
+func (_m *SystemInterface) RegisterInputCodec(operationID string, codec types.InputCodec) {
+	_m.Called(operationID, codec)
+}
+
+// Reload provides a mock function with given fields: ctx, configuration
+func (_m *SystemInterface) Reload(ctx *common.Context, configuration *types.Configuration) error {
+	ret := _m.Called(ctx, configuration)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Reload")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*common.Context, *types.Configuration) error); ok {
+		r0 = rf(ctx, configuration)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // RestartService provides a mock function with given fields: ctx, serviceID
 func (_m *SystemInterface) RestartService(ctx *common.Context, serviceID string) error {
 	ret := _m.Called(ctx, serviceID)
@@ -200,6 +320,34 @@ func (_m *SystemInterface) RestartService(ctx *common.Context, serviceID string)
 	return r0
 }
 
+// ServiceStatus provides a mock function with given fields: serviceID
+func (_m *SystemInterface) ServiceStatus(serviceID string) (types.ServiceStatusType, error) {
+	ret := _m.Called(serviceID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ServiceStatus")
+	}
+
+	var r0 types.ServiceStatusType
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (types.ServiceStatusType, error)); ok {
+		return rf(serviceID)
+	}
+	if rf, ok := ret.Get(0).(func(string) types.ServiceStatusType); ok {
+		r0 = rf(serviceID)
+	} else {
+		r0 = ret.Get(0).(types.ServiceStatusType)
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(serviceID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Start provides a mock function with given fields: ctx
 func (_m *SystemInterface) Start(ctx *common.Context) error {
 	ret := _m.Called(ctx)
@@ -236,6 +384,24 @@ func (_m *SystemInterface) StartService(ctx *common.Context, serviceID string) e
 	return r0
 }
 
+// Status provides a mock function with given fields:
+func (_m *SystemInterface) Status() types.SystemStatusType {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for Status")
+	}
+
+	var r0 types.SystemStatusType
+	if rf, ok := ret.Get(0).(func() types.SystemStatusType); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(types.SystemStatusType)
+	}
+
+	return r0
+}
+
 // Stop provides a mock function with given fields: ctx
 func (_m *SystemInterface) Stop(ctx *common.Context) error {
 	ret := _m.Called(ctx)
@@ -272,6 +438,34 @@ func (_m *SystemInterface) StopService(ctx *common.Context, serviceID string) er
 	return r0
 }
 
+// SubmitOperation provides a mock function with given fields: ctx, operationID, data
+func (_m *SystemInterface) SubmitOperation(ctx *common.Context, operationID string, data *types.SystemOperationInput) (string, error) {
+	ret := _m.Called(ctx, operationID, data)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SubmitOperation")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(*common.Context, string, *types.SystemOperationInput) (string, error)); ok {
+		return rf(ctx, operationID, data)
+	}
+	if rf, ok := ret.Get(0).(func(*common.Context, string, *types.SystemOperationInput) string); ok {
+		r0 = rf(ctx, operationID, data)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(*common.Context, string, *types.SystemOperationInput) error); ok {
+		r1 = rf(ctx, operationID, data)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // NewSystemInterface creates a new instance of SystemInterface. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewSystemInterface(t interface {
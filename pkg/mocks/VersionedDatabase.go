@@ -9,6 +9,24 @@ type VersionedDatabase struct {
 	mock.Mock
 }
 
+// DeleteVersionsTo provides a mock function with given fields: toVersion
+func (_m *VersionedDatabase) DeleteVersionsTo(toVersion int64) error {
+	ret := _m.Called(toVersion)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteVersionsTo")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(int64) error); ok {
+		r0 = rf(toVersion)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Load provides a mock function with given fields:
 func (_m *VersionedDatabase) Load() (int64, error) {
 	ret := _m.Called()
@@ -0,0 +1,218 @@
+package remote
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"github.com/ebanfa/skeleton/pkg/common"
+	"github.com/ebanfa/skeleton/pkg/remote/remotepb"
+	"github.com/ebanfa/skeleton/pkg/types"
+)
+
+// DefaultStatusTimeout is the default timeout of the ServiceStatus calls, which take no context.
+const DefaultStatusTimeout = 10 * time.Second
+
+// ClientOptions represents the options of a gRPC client.
+type ClientOptions struct {
+	// Codecs are the codecs of the operations keyed by operation ID, DefaultCodec being used for the others.
+	// They must match the codecs of the server.
+	Codecs map[string]OperationCodecs
+
+	// DialOptions are the options of the gRPC connection, the connection being insecure
+	// unless they provide credentials.
+	DialOptions []grpc.DialOption
+
+	// StatusTimeout is the timeout of the ServiceStatus calls, DefaultStatusTimeout when zero.
+	StatusTimeout time.Duration
+}
+
+// Client drives a remote system over gRPC. It implements types.SystemControllerInterface, so that
+// remote systems are driven like local ones. Errors recognized by the server are wrapped in *Error,
+// which matches the errors of local systems with errors.Is.
+type Client struct {
+	conn    *grpc.ClientConn
+	options ClientOptions
+}
+
+// Ensure Client implements SystemControllerInterface.
+var _ types.SystemControllerInterface = (*Client)(nil)
+
+// NewClient creates a new instance of Client calling the server at the given target.
+// The connection is established lazily, on the first call.
+func NewClient(target string, options ClientOptions) (*Client, error) {
+	if options.StatusTimeout <= 0 {
+		options.StatusTimeout = DefaultStatusTimeout
+	}
+	dialOptions := append([]grpc.DialOption{
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	}, options.DialOptions...)
+
+	conn, err := grpc.NewClient(target, dialOptions...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create client of %s: %w", target, err)
+	}
+	return &Client{conn: conn, options: options}, nil
+}
+
+// Close closes the connection to the server.
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// ExecuteOperation executes the operation with the given ID on the remote system, encoding its input
+// and decoding its output with the codecs of the operation.
+func (c *Client) ExecuteOperation(ctx *common.Context, operationID string, data *types.SystemOperationInput) (*types.SystemOperationOutput, error) {
+	codecs := codecs(c.options.Codecs, operationID)
+
+	request := &remotepb.ExecuteRequest{OperationId: operationID}
+	if data != nil && data.Data != nil {
+		input, err := codecs.Input.Encode(data.Data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode input of operation %s: %w", operationID, err)
+		}
+		request.Input = input
+	}
+
+	response := &remotepb.ExecuteResponse{}
+	if err := c.conn.Invoke(ctx, methodName("ExecuteOperation"), request, response); err != nil {
+		return nil, fromStatus(err)
+	}
+
+	output := &types.SystemOperationOutput{}
+	if len(response.Output) > 0 {
+		data, err := codecs.Output.Decode(response.Output)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode output of operation %s: %w", operationID, err)
+		}
+		output.Data = data
+	}
+	return output, nil
+}
+
+// StartService starts the service with the given ID on the remote system.
+func (c *Client) StartService(ctx *common.Context, serviceID string) error {
+	_, err := c.controlService(ctx, "StartService", serviceID)
+	return err
+}
+
+// StopService stops the service with the given ID on the remote system.
+func (c *Client) StopService(ctx *common.Context, serviceID string) error {
+	_, err := c.controlService(ctx, "StopService", serviceID)
+	return err
+}
+
+// RestartService restarts the service with the given ID on the remote system.
+func (c *Client) RestartService(ctx *common.Context, serviceID string) error {
+	_, err := c.controlService(ctx, "RestartService", serviceID)
+	return err
+}
+
+// ServiceStatus returns the lifecycle status of the service with the given ID on the remote system.
+// The call fails with context.DeadlineExceeded when the server does not answer within the status timeout.
+func (c *Client) ServiceStatus(serviceID string) (types.ServiceStatusType, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.options.StatusTimeout)
+	defer cancel()
+
+	return c.controlService(ctx, "ServiceStatus", serviceID)
+}
+
+// ListComponents returns the components registered on the remote system.
+func (c *Client) ListComponents(ctx *common.Context) ([]ComponentInfo, error) {
+	return c.listComponents(ctx, &remotepb.ListComponentsRequest{})
+}
+
+// ListComponentsByType returns the components of the given type registered on the remote system.
+func (c *Client) ListComponentsByType(ctx *common.Context, componentType types.ComponentType) ([]ComponentInfo, error) {
+	return c.listComponents(ctx, &remotepb.ListComponentsRequest{Type: componentType.String()})
+}
+
+// SubscribeEvents subscribes to the events of the given topics on the remote system, or to all
+// the events streamed by the server when no topic is given. It returns once the subscription is active.
+// The subscription ends when the context is done or the server stops.
+func (c *Client) SubscribeEvents(ctx *common.Context, topics ...string) (*EventStream, error) {
+	desc := &serviceDesc.Streams[0]
+	stream, err := c.conn.NewStream(ctx, desc, methodName(desc.StreamName))
+	if err != nil {
+		return nil, fromStatus(err)
+	}
+	if err := stream.SendMsg(&remotepb.SubscribeRequest{Topics: topics}); err != nil {
+		return nil, fromStatus(err)
+	}
+	if err := stream.CloseSend(); err != nil {
+		return nil, fromStatus(err)
+	}
+
+	// The server sends the headers once the subscription is registered
+	if _, err := stream.Header(); err != nil {
+		return nil, fromStatus(err)
+	}
+	return &EventStream{stream: stream}, nil
+}
+
+// controlService calls the given service method, then returns the status of the service.
+func (c *Client) controlService(ctx context.Context, method, serviceID string) (types.ServiceStatusType, error) {
+	response := &remotepb.ServiceResponse{}
+	if err := c.conn.Invoke(ctx, methodName(method), &remotepb.ServiceRequest{ServiceId: serviceID}, response); err != nil {
+		return 0, fromStatus(err)
+	}
+
+	for status := types.ServiceCreatedType; status <= types.ServiceFailedType; status++ {
+		if status.String() == response.Status {
+			return status, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown status %q of service %s", response.Status, serviceID)
+}
+
+// listComponents calls ListComponents.
+func (c *Client) listComponents(ctx *common.Context, request *remotepb.ListComponentsRequest) ([]ComponentInfo, error) {
+	response := &remotepb.ListComponentsResponse{}
+	if err := c.conn.Invoke(ctx, methodName("ListComponents"), request, response); err != nil {
+		return nil, fromStatus(err)
+	}
+
+	components := make([]ComponentInfo, 0, len(response.Components))
+	for _, component := range response.Components {
+		components = append(components, ComponentInfo{
+			ID:          component.Id,
+			Name:        component.Name,
+			Description: component.Description,
+			Type:        component.Type,
+		})
+	}
+	return components, nil
+}
+
+// EventStream represents a subscription to the events of a remote system.
+type EventStream struct {
+	stream grpc.ClientStream
+}
+
+// Recv returns the next event of the stream with its envelope, blocking until it is received. The data
+// of the event is the JSON encoding of the data published on the remote system, as a json.RawMessage.
+// Returns io.EOF when the server ends the stream.
+func (s *EventStream) Recv() (common.Event, error) {
+	message := &remotepb.Event{}
+	if err := s.stream.RecvMsg(message); err != nil {
+		return common.Event{}, fromStatus(err)
+	}
+
+	event := common.Event{
+		Type:    message.Type,
+		ID:      message.Id,
+		Source:  message.Source,
+		Time:    message.Time.AsTime(),
+		TraceID: message.TraceId,
+		Version: int(message.Version),
+		Headers: message.Headers,
+	}
+	if len(message.Data) > 0 {
+		event.Data = json.RawMessage(message.Data)
+	}
+	return event, nil
+}
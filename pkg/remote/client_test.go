@@ -0,0 +1,259 @@
+package remote_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"github.com/ebanfa/skeleton/pkg/common"
+	"github.com/ebanfa/skeleton/pkg/component"
+	"github.com/ebanfa/skeleton/pkg/mocks"
+	"github.com/ebanfa/skeleton/pkg/remote"
+	"github.com/ebanfa/skeleton/pkg/remote/remotepb"
+	"github.com/ebanfa/skeleton/pkg/system"
+	"github.com/ebanfa/skeleton/pkg/types"
+)
+
+var _ = Describe("Client", func() {
+	type addition struct {
+		A int `json:"a"`
+		B int `json:"b"`
+	}
+
+	var (
+		ctx           *common.Context
+		sys           *system.SystemImpl
+		server        *remote.Server
+		clientOptions remote.ClientOptions
+		client        *remote.Client
+	)
+
+	BeforeEach(func() {
+		ctx = common.Background()
+		clientOptions = remote.ClientOptions{}
+
+		worker := &mocks.SystemServiceInterface{}
+		worker.On("ID").Return("worker")
+		worker.On("Name").Return("Worker")
+		worker.On("Description").Return("Does the work")
+		worker.On("Type").Return(types.ServiceType)
+		worker.On("Initialize", mock.Anything, mock.Anything).Return(nil)
+		worker.On("Start", mock.Anything).Return(nil)
+		worker.On("Stop", mock.Anything).Return(nil)
+
+		server = remote.NewServer("remote", remote.ServerOptions{Address: "127.0.0.1:0"})
+		components := map[string]types.ComponentInterface{
+			"remote": server,
+			"worker": worker,
+			"add": system.NewTypedOperation("add", "Add", "Adds two numbers",
+				func(ctx *common.Context, input addition) (int, error) {
+					return input.A + input.B, nil
+				}),
+			"fail": system.NewTypedOperation("fail", "Fail", "Always fails",
+				func(ctx *common.Context, input string) (string, error) {
+					return "", types.ErrServiceFailed
+				}),
+			"wait": system.NewTypedOperation("wait", "Wait", "Waits until canceled",
+				func(ctx *common.Context, input string) (string, error) {
+					<-ctx.Done()
+					return "", ctx.Err()
+				}),
+		}
+
+		registrar := component.NewComponentRegistrar()
+		factory := component.FactoryFunc(func(config *types.ComponentConfig) (types.ComponentInterface, error) {
+			return components[config.ID], nil
+		})
+		Expect(registrar.RegisterFactory(ctx, "factory", factory)).To(Succeed())
+
+		pluginManager := &mocks.PluginManagerInterface{}
+		pluginManager.On("Initialize", ctx, mock.Anything).Return(nil)
+		pluginManager.On("StartPlugins", ctx).Return(nil)
+		pluginManager.On("StopPlugins", mock.Anything).Return(nil)
+
+		configuration := &types.Configuration{
+			Services: []*types.ServiceConfiguration{
+				{ComponentConfig: types.ComponentConfig{ID: "remote", FactoryID: "factory"}},
+				{ComponentConfig: types.ComponentConfig{ID: "worker", FactoryID: "factory"}},
+			},
+		}
+		for _, id := range []string{"add", "fail", "wait"} {
+			configuration.Operations = append(configuration.Operations, &types.OperationConfiguration{
+				ComponentConfig: types.ComponentConfig{ID: id, FactoryID: "factory"},
+			})
+		}
+
+		sys = system.NewSystem(nil, common.NewSystemEventBus(), configuration, pluginManager, registrar, nil)
+		Expect(sys.Initialize(ctx)).To(Succeed())
+		Expect(sys.Start(ctx)).To(Succeed())
+	})
+
+	JustBeforeEach(func() {
+		var err error
+		client, err = remote.NewClient(server.Addr(), clientOptions)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		Expect(client.Close()).To(Succeed())
+		Expect(sys.Stop(ctx)).To(Succeed())
+	})
+
+	Describe("ExecuteOperation", func() {
+		It("executes operations with JSON data by default", func() {
+			output, err := client.ExecuteOperation(ctx, "add", &types.SystemOperationInput{Data: addition{A: 1, B: 2}})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(output.Data).To(Equal(json.RawMessage("3")))
+		})
+
+		It("lets typed executions drive remote systems", func() {
+			sum, err := system.ExecuteTyped[addition, int](client, ctx, "add", addition{A: 2, B: 3})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(sum).To(Equal(5))
+		})
+
+		Context("with codecs", func() {
+			BeforeEach(func() {
+				clientOptions.Codecs = map[string]remote.OperationCodecs{
+					"add": {Output: remote.JSONCodec[int]{}},
+				}
+			})
+
+			It("decodes the output with the codec of the operation", func() {
+				output, err := client.ExecuteOperation(ctx, "add", &types.SystemOperationInput{Data: addition{A: 1, B: 2}})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(output.Data).To(Equal(3))
+			})
+		})
+
+		It("returns errors matching the errors of local systems", func() {
+			_, err := client.ExecuteOperation(ctx, "unknown", nil)
+			Expect(err).To(MatchError(types.ErrComponentNotFound))
+
+			_, err = client.ExecuteOperation(ctx, "worker", nil)
+			Expect(err).To(MatchError(types.ErrComponentNotFound))
+
+			_, err = client.ExecuteOperation(ctx, "add", &types.SystemOperationInput{Data: "1 + 2"})
+			Expect(err).To(MatchError(types.ErrOperationTypeMismatch))
+
+			_, err = client.ExecuteOperation(ctx, "fail", nil)
+			Expect(err).To(MatchError(types.ErrServiceFailed))
+
+			var remoteErr *remote.Error
+			Expect(errors.As(err, &remoteErr)).To(BeTrue())
+			Expect(remoteErr.Message).To(Equal(types.ErrServiceFailed.Error()))
+		})
+
+		It("cancels the remote execution with the context", func() {
+			cancelCtx, cancel := common.WithCancel(ctx)
+			go cancel()
+
+			_, err := client.ExecuteOperation(cancelCtx, "wait", &types.SystemOperationInput{Data: "forever"})
+			Expect(err).To(MatchError(context.Canceled))
+		})
+	})
+
+	Describe("services", func() {
+		It("stops, starts and restarts services", func() {
+			Expect(client.StopService(ctx, "worker")).To(Succeed())
+			Expect(client.ServiceStatus("worker")).To(Equal(types.ServiceStoppedType))
+			Expect(sys.ServiceStatus("worker")).To(Equal(types.ServiceStoppedType))
+
+			Expect(client.StopService(ctx, "worker")).To(MatchError(types.ErrInvalidStateTransition))
+
+			Expect(client.StartService(ctx, "worker")).To(Succeed())
+			Expect(client.RestartService(ctx, "worker")).To(Succeed())
+			Expect(client.ServiceStatus("worker")).To(Equal(types.ServiceRunningType))
+		})
+
+		It("refuses to stop the server through its own API", func() {
+			Expect(client.StopService(ctx, "remote")).NotTo(Succeed())
+			Expect(client.ServiceStatus("remote")).To(Equal(types.ServiceRunningType))
+		})
+
+		It("reports the unknown services", func() {
+			_, err := client.ServiceStatus("add")
+			Expect(err).To(MatchError(types.ErrComponentNotFound))
+		})
+
+		It("bounds the status calls with the status timeout", func() {
+			// The listener never answers the calls
+			listener, err := net.Listen("tcp", "127.0.0.1:0")
+			Expect(err).NotTo(HaveOccurred())
+			defer listener.Close()
+
+			unresponsive, err := remote.NewClient(listener.Addr().String(), remote.ClientOptions{StatusTimeout: 50 * time.Millisecond})
+			Expect(err).NotTo(HaveOccurred())
+			defer unresponsive.Close()
+
+			_, err = unresponsive.ServiceStatus("worker")
+			Expect(err).To(MatchError(context.DeadlineExceeded))
+		})
+	})
+
+	Describe("protocol", func() {
+		It("serves the protobuf messages of the published schema", func() {
+			conn, err := grpc.NewClient(server.Addr(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+			Expect(err).NotTo(HaveOccurred())
+			defer conn.Close()
+
+			response := &remotepb.ServiceResponse{}
+			Expect(conn.Invoke(ctx, "/skeleton.remote.v1.System/ServiceStatus", &remotepb.ServiceRequest{ServiceId: "worker"}, response)).To(Succeed())
+			Expect(response.ServiceId).To(Equal("worker"))
+			Expect(response.Status).To(Equal(types.ServiceRunningType.String()))
+		})
+	})
+
+	Describe("ListComponents", func() {
+		It("lists the components", func() {
+			components, err := client.ListComponents(ctx)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(components).To(HaveLen(5))
+		})
+
+		It("lists the components of a type", func() {
+			components, err := client.ListComponentsByType(ctx, types.ServiceType)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(components).To(Equal([]remote.ComponentInfo{
+				{ID: "remote", Name: "RemoteServer", Description: "Exposes the system over gRPC", Type: "service"},
+				{ID: "worker", Name: "Worker", Description: "Does the work", Type: "service"},
+			}))
+		})
+	})
+
+	Describe("SubscribeEvents", func() {
+		It("streams the events of the requested topics", func() {
+			stream, err := client.SubscribeEvents(ctx, system.EventTypeServiceStateChanged)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(client.StopService(ctx, "worker")).To(Succeed())
+
+			event, err := stream.Recv()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(event.Type).To(Equal(system.EventTypeServiceStateChanged))
+			Expect(event.Source).To(Equal(system.SystemEventSource))
+			Expect(event.ID).NotTo(BeEmpty())
+			Expect(event.Time).NotTo(BeZero())
+			Expect(event.Data).To(BeAssignableToTypeOf(json.RawMessage{}))
+			Expect(string(event.Data.(json.RawMessage))).To(ContainSubstring(`"worker"`))
+		})
+
+		It("ends the stream when the server stops", func() {
+			stream, err := client.SubscribeEvents(ctx)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(server.Stop(ctx)).To(Succeed())
+			_, err = stream.Recv()
+			Expect(err).To(Equal(io.EOF))
+		})
+	})
+})
@@ -0,0 +1,54 @@
+package remote
+
+import (
+	"encoding/json"
+)
+
+// Codec encodes the untyped data of operation inputs and outputs exchanged with remote systems.
+type Codec interface {
+	// Encode encodes the given data.
+	Encode(data interface{}) ([]byte, error)
+
+	// Decode decodes data encoded with Encode.
+	Decode(data []byte) (interface{}, error)
+}
+
+// OperationCodecs represents the codecs of the input and output of an operation.
+// A nil codec is replaced by DefaultCodec.
+type OperationCodecs struct {
+	Input  Codec
+	Output Codec
+}
+
+// DefaultCodec is the codec of the operations without codecs. It encodes data as JSON and decodes
+// it as json.RawMessage, which typed operations and ExecuteTyped decode into their own types.
+var DefaultCodec Codec = JSONCodec[json.RawMessage]{}
+
+// JSONCodec encodes data as JSON and decodes it into a value of type T.
+type JSONCodec[T any] struct{}
+
+// Encode encodes the given data as JSON.
+func (JSONCodec[T]) Encode(data interface{}) ([]byte, error) {
+	return json.Marshal(data)
+}
+
+// Decode decodes JSON data into a value of type T.
+func (JSONCodec[T]) Decode(data []byte) (interface{}, error) {
+	var value T
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// codecs returns the codecs of the operation with the given ID.
+func codecs(registered map[string]OperationCodecs, operationID string) OperationCodecs {
+	codecs := registered[operationID]
+	if codecs.Input == nil {
+		codecs.Input = DefaultCodec
+	}
+	if codecs.Output == nil {
+		codecs.Output = DefaultCodec
+	}
+	return codecs
+}
@@ -0,0 +1,40 @@
+package remote_test
+
+import (
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/ebanfa/skeleton/pkg/remote"
+)
+
+var _ = Describe("JSONCodec", func() {
+	type order struct {
+		ID    string `json:"id"`
+		Total int    `json:"total"`
+	}
+
+	It("decodes the encoded data into a value of its type", func() {
+		codec := remote.JSONCodec[order]{}
+
+		data, err := codec.Encode(order{ID: "o-1", Total: 42})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(data).To(MatchJSON(`{"id": "o-1", "total": 42}`))
+
+		value, err := codec.Decode(data)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(value).To(Equal(order{ID: "o-1", Total: 42}))
+	})
+
+	It("reports the data it cannot decode", func() {
+		_, err := remote.JSONCodec[order]{}.Decode([]byte(`"o-1"`))
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("decodes the data as raw JSON by default", func() {
+		value, err := remote.DefaultCodec.Decode([]byte(`{"id": "o-1"}`))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(value).To(Equal(json.RawMessage(`{"id": "o-1"}`)))
+	})
+})
@@ -0,0 +1,111 @@
+package remote
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/ebanfa/skeleton/pkg/types"
+)
+
+// errorDomain is the domain of the error details sent by the server.
+const errorDomain = "skeleton"
+
+// remoteErrors lists the errors recognized across the wire, along with their status code and
+// the reason identifying them in the error details.
+var remoteErrors = []struct {
+	err    error
+	code   codes.Code
+	reason string
+}{
+	{types.ErrComponentNotFound, codes.NotFound, "COMPONENT_NOT_FOUND"},
+	{types.ErrOperationTypeMismatch, codes.InvalidArgument, "OPERATION_TYPE_MISMATCH"},
+	{types.ErrInvalidStateTransition, codes.FailedPrecondition, "INVALID_STATE_TRANSITION"},
+	{types.ErrSystemNotInitialized, codes.FailedPrecondition, "SYSTEM_NOT_INITIALIZED"},
+	{types.ErrSystemNotStarted, codes.FailedPrecondition, "SYSTEM_NOT_STARTED"},
+	{types.ErrSystemStopping, codes.Unavailable, "SYSTEM_STOPPING"},
+	{types.ErrDependencyFailed, codes.FailedPrecondition, "DEPENDENCY_FAILED"},
+	{types.ErrServiceFailed, codes.Internal, "SERVICE_FAILED"},
+	{types.ErrOperationPanicked, codes.Internal, "OPERATION_PANICKED"},
+	{types.ErrOperationTimeout, codes.DeadlineExceeded, "OPERATION_TIMEOUT"},
+	{context.DeadlineExceeded, codes.DeadlineExceeded, "DEADLINE_EXCEEDED"},
+	{context.Canceled, codes.Canceled, "CANCELED"},
+}
+
+// Error represents an error returned by a remote system.
+type Error struct {
+	// Code is the gRPC status code of the error.
+	Code codes.Code
+
+	// Message is the message of the error on the remote system.
+	Message string
+
+	// Err is the error recognized from the error details, nil if the error is not recognized.
+	Err error
+}
+
+// Error returns the error message.
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Unwrap returns the recognized error, so that remote errors match the errors of local systems.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// toStatus converts an error of the system to a gRPC status error, identifying the recognized errors
+// in its details.
+func toStatus(err error) error {
+	for _, remote := range remoteErrors {
+		if !errors.Is(err, remote.err) {
+			continue
+		}
+
+		st := status.New(remote.code, err.Error())
+		if detailed, detailsErr := st.WithDetails(&errdetails.ErrorInfo{
+			Reason: remote.reason,
+			Domain: errorDomain,
+		}); detailsErr == nil {
+			st = detailed
+		}
+		return st.Err()
+	}
+
+	return status.Error(codes.Unknown, err.Error())
+}
+
+// fromStatus converts a gRPC status error to an *Error, which wraps the error identified in its details.
+func fromStatus(err error) error {
+	st, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+
+	remoteErr := &Error{Code: st.Code(), Message: st.Message()}
+	for _, detail := range st.Details() {
+		info, ok := detail.(*errdetails.ErrorInfo)
+		if !ok || info.Domain != errorDomain {
+			continue
+		}
+		for _, remote := range remoteErrors {
+			if remote.reason == info.Reason {
+				remoteErr.Err = remote.err
+			}
+		}
+	}
+
+	// Errors of the client side, such as the cancellation of the call, are not detailed
+	if remoteErr.Err == nil {
+		switch st.Code() {
+		case codes.Canceled:
+			remoteErr.Err = context.Canceled
+		case codes.DeadlineExceeded:
+			remoteErr.Err = context.DeadlineExceeded
+		}
+	}
+	return remoteErr
+}
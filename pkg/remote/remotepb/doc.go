@@ -0,0 +1,5 @@
+// Package remotepb holds the messages of the gRPC service skeleton.remote.v1.System,
+// generated from system.proto.
+package remotepb
+
+//go:generate protoc -I ../../.. --go_out=../../.. --go_opt=paths=source_relative pkg/remote/remotepb/system.proto
@@ -0,0 +1,397 @@
+// Package remote exposes systems over gRPC and drives remote systems like local ones.
+//
+// The gRPC service skeleton.remote.v1.System is described by remotepb/system.proto, from which
+// clients in other languages generate their stubs. The untyped input and output data of the
+// operations are encoded by per-operation codecs.
+package remote
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/ebanfa/skeleton/pkg/common"
+	"github.com/ebanfa/skeleton/pkg/remote/remotepb"
+	"github.com/ebanfa/skeleton/pkg/system"
+	"github.com/ebanfa/skeleton/pkg/types"
+)
+
+const (
+	defaultServerAddress = "localhost:9090"
+	subscriberBuffer     = 64
+)
+
+// ServerOptions represents the options of a gRPC server.
+type ServerOptions struct {
+	// Address is the TCP address the server listens on, localhost:9090 when empty.
+	Address string
+
+	// Codecs are the codecs of the operations keyed by operation ID, DefaultCodec being used for the others.
+	Codecs map[string]OperationCodecs
+
+	// EventTopics are the topics or topic patterns of the events streamed to the clients, the system events when nil.
+	EventTopics []string
+
+	// GRPCOptions are the options of the gRPC server, such as its credentials and interceptors.
+	GRPCOptions []grpc.ServerOption
+}
+
+// Server is a service exposing the system over gRPC. It executes operations, starts, stops
+// and restarts services, lists the components and streams the system events.
+type Server struct {
+	system.BaseSystemService
+	mutex         sync.Mutex
+	options       ServerOptions
+	server        *grpc.Server
+	listener      net.Listener
+	done          chan struct{}
+	subscriptions []common.Subscription
+	subscribers   map[*subscriber]struct{}
+}
+
+// subscriber represents a client of the event stream.
+type subscriber struct {
+	topics []string // Topics or topic patterns of the events sent to the client, all topics when empty
+	events chan common.Event
+}
+
+// wants checks if the client wants the events of the given topic.
+func (c *subscriber) wants(topic string) bool {
+	if len(c.topics) == 0 {
+		return true
+	}
+	for _, pattern := range c.topics {
+		if common.MatchTopic(pattern, topic) {
+			return true
+		}
+	}
+	return false
+}
+
+// NewServer creates a new instance of Server.
+func NewServer(id string, options ServerOptions) *Server {
+	if options.Address == "" {
+		options.Address = defaultServerAddress
+	}
+	if options.EventTopics == nil {
+		options.EventTopics = system.SystemEventTopics()
+	}
+
+	return &Server{
+		BaseSystemService: *system.NewBaseSystemService(id, "RemoteServer", "Exposes the system over gRPC"),
+		options:           options,
+		subscribers:       make(map[*subscriber]struct{}),
+	}
+}
+
+// Register registers the gRPC service of the server with another gRPC server.
+func (s *Server) Register(registrar grpc.ServiceRegistrar) {
+	registrar.RegisterService(&serviceDesc, s)
+}
+
+// Addr returns the address the server listens on, or an empty string if it is not started.
+func (s *Server) Addr() string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.listener == nil {
+		return ""
+	}
+	return s.listener.Addr().String()
+}
+
+// Start starts listening on the configured address and serving the gRPC service.
+// Returns an error if the address cannot be listened on.
+func (s *Server) Start(ctx *common.Context) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.System == nil {
+		return types.ErrSystemNotInitialized
+	}
+	if s.server != nil {
+		return nil
+	}
+
+	listener, err := net.Listen("tcp", s.options.Address)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", s.options.Address, err)
+	}
+	if err := s.subscribe(); err != nil {
+		listener.Close()
+		return err
+	}
+
+	s.listener = listener
+	s.done = make(chan struct{})
+	s.server = grpc.NewServer(s.options.GRPCOptions...)
+	s.Register(s.server)
+	go s.serve(s.server, listener)
+
+	return nil
+}
+
+// Stop closes the event streams and stops the server gracefully, waiting for the calls in progress
+// until the context is done.
+func (s *Server) Stop(ctx *common.Context) error {
+	s.mutex.Lock()
+	server, done := s.server, s.done
+	s.server, s.listener, s.done = nil, nil, nil
+	s.unsubscribe()
+	s.mutex.Unlock()
+
+	if server == nil {
+		return nil
+	}
+
+	close(done)
+	stopped := make(chan struct{})
+	go func() {
+		server.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		server.Stop()
+		return ctx.Err()
+	}
+}
+
+// serve serves the gRPC service until the server is stopped.
+func (s *Server) serve(server *grpc.Server, listener net.Listener) {
+	if err := server.Serve(listener); err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+		if logger := s.System.Logger(); logger != nil {
+			logger.Log(common.LevelError, "Error serving gRPC service:", err)
+		}
+	}
+}
+
+// subscribe subscribes to the event topics, broadcasting the events to the stream clients.
+// The caller must hold the mutex.
+func (s *Server) subscribe() error {
+	eventBus := s.System.EventBus()
+	if eventBus == nil {
+		return nil
+	}
+
+	for _, topic := range s.options.EventTopics {
+		subscription, err := eventBus.Subscribe(common.BusSubscriptionParams{
+			Topic:        topic,
+			EventHandler: s.broadcast,
+		})
+		if err != nil {
+			s.unsubscribe()
+			return fmt.Errorf("failed to subscribe to %s: %w", topic, err)
+		}
+		s.subscriptions = append(s.subscriptions, subscription)
+	}
+	return nil
+}
+
+// unsubscribe removes the subscriptions to the event topics.
+func (s *Server) unsubscribe() {
+	for _, subscription := range s.subscriptions {
+		subscription.Unsubscribe()
+	}
+	s.subscriptions = nil
+}
+
+// broadcast sends an event to the stream clients interested in its topic.
+// Events are dropped for the clients that do not keep up.
+func (s *Server) broadcast(event common.Event) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for client := range s.subscribers {
+		if !client.wants(event.Type) {
+			continue
+		}
+		select {
+		case client.events <- event:
+		default:
+		}
+	}
+}
+
+// executeOperation executes an operation, decoding its input and encoding its output with its codecs.
+func (s *Server) executeOperation(ctx context.Context, request *remotepb.ExecuteRequest) (*remotepb.ExecuteResponse, error) {
+	if err := s.lookup(request.OperationId, types.OperationType); err != nil {
+		return nil, toStatus(err)
+	}
+	codecs := codecs(s.options.Codecs, request.OperationId)
+
+	input := &types.SystemOperationInput{}
+	if len(request.Input) > 0 {
+		data, err := codecs.Input.Decode(request.Input)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "failed to decode input of operation %s: %v", request.OperationId, err)
+		}
+		input.Data = data
+	}
+
+	output, err := s.System.ExecuteOperation(common.WithContext(ctx), request.OperationId, input)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	response := &remotepb.ExecuteResponse{}
+	if output != nil && output.Data != nil {
+		if response.Output, err = codecs.Output.Encode(output.Data); err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to encode output of operation %s: %v", request.OperationId, err)
+		}
+	}
+	return response, nil
+}
+
+// startService starts a service.
+func (s *Server) startService(ctx context.Context, request *remotepb.ServiceRequest) (*remotepb.ServiceResponse, error) {
+	return s.controlService(ctx, request, s.System.StartService)
+}
+
+// stopService stops a service.
+func (s *Server) stopService(ctx context.Context, request *remotepb.ServiceRequest) (*remotepb.ServiceResponse, error) {
+	return s.controlService(ctx, request, s.System.StopService)
+}
+
+// restartService restarts a service.
+func (s *Server) restartService(ctx context.Context, request *remotepb.ServiceRequest) (*remotepb.ServiceResponse, error) {
+	return s.controlService(ctx, request, s.System.RestartService)
+}
+
+// serviceStatus returns the status of a service.
+func (s *Server) serviceStatus(ctx context.Context, request *remotepb.ServiceRequest) (*remotepb.ServiceResponse, error) {
+	return s.controlService(ctx, request, nil)
+}
+
+// controlService runs the given action on a service, then returns its status.
+func (s *Server) controlService(ctx context.Context, request *remotepb.ServiceRequest, action func(*common.Context, string) error) (*remotepb.ServiceResponse, error) {
+	if err := s.lookup(request.ServiceId, types.ServiceType); err != nil {
+		return nil, toStatus(err)
+	}
+	if request.ServiceId == s.ID() && action != nil {
+		// Stopping the server waits for the calls in progress, including this one
+		return nil, status.Errorf(codes.FailedPrecondition, "service %s cannot be controlled through its own API", s.ID())
+	}
+
+	if action != nil {
+		if err := action(common.WithContext(ctx), request.ServiceId); err != nil {
+			return nil, toStatus(err)
+		}
+	}
+
+	serviceStatus, err := s.System.ServiceStatus(request.ServiceId)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &remotepb.ServiceResponse{ServiceId: request.ServiceId, Status: serviceStatus.String()}, nil
+}
+
+// listComponents returns the registered components, optionally filtered by type.
+func (s *Server) listComponents(ctx context.Context, request *remotepb.ListComponentsRequest) (*remotepb.ListComponentsResponse, error) {
+	registry := s.System.ComponentRegistry()
+
+	var components []types.ComponentInterface
+	if request.Type != "" {
+		componentType, ok := types.ParseComponentType(request.Type)
+		if !ok {
+			return nil, status.Errorf(codes.InvalidArgument, "unknown component type %q", request.Type)
+		}
+		components = registry.GetComponentsByType(componentType)
+	} else {
+		components = registry.GetAllComponents()
+	}
+
+	response := &remotepb.ListComponentsResponse{Components: make([]*remotepb.Component, 0, len(components))}
+	for _, component := range components {
+		response.Components = append(response.Components, &remotepb.Component{
+			Id:          component.ID(),
+			Name:        component.Name(),
+			Description: component.Description(),
+			Type:        component.Type().String(),
+		})
+	}
+	sort.Slice(response.Components, func(i, j int) bool { return response.Components[i].Id < response.Components[j].Id })
+
+	return response, nil
+}
+
+// subscribeEvents streams the events of the requested topics, until the client cancels the call
+// or the server stops. The data of the events is encoded as JSON.
+func (s *Server) subscribeEvents(request *remotepb.SubscribeRequest, stream grpc.ServerStream) error {
+	client := &subscriber{topics: request.Topics, events: make(chan common.Event, subscriberBuffer)}
+
+	s.mutex.Lock()
+	done := s.done
+	s.subscribers[client] = struct{}{}
+	s.mutex.Unlock()
+
+	defer func() {
+		s.mutex.Lock()
+		delete(s.subscribers, client)
+		s.mutex.Unlock()
+	}()
+
+	// Send the headers, so that the client knows the subscription is active
+	if err := stream.SendHeader(nil); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case <-done:
+			return nil
+		case event := <-client.events:
+			data, err := json.Marshal(event.Data)
+			if err != nil {
+				data, _ = json.Marshal(fmt.Sprint(event.Data))
+			}
+			message := &remotepb.Event{
+				Type:    event.Type,
+				Data:    data,
+				Id:      event.ID,
+				Source:  event.Source,
+				Time:    timestamppb.New(event.Time),
+				TraceId: event.TraceID,
+				Version: int32(event.Version),
+				Headers: event.Headers,
+			}
+			if err := stream.SendMsg(message); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// lookup checks that the component with the given ID exists and has the given type.
+func (s *Server) lookup(id string, componentType types.ComponentType) error {
+	component, err := s.System.ComponentRegistry().GetComponent(id)
+	if err != nil {
+		return fmt.Errorf("%w: %w", types.ErrComponentNotFound, err)
+	}
+
+	switch componentType {
+	case types.ServiceType:
+		if _, ok := component.(types.SystemServiceInterface); !ok {
+			return fmt.Errorf("%w: component %s is not a service", types.ErrComponentNotFound, id)
+		}
+	case types.OperationType:
+		if _, ok := component.(types.SystemOperationInterface); !ok {
+			return fmt.Errorf("%w: component %s is not an operation", types.ErrComponentNotFound, id)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package remote
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+
+	"github.com/ebanfa/skeleton/pkg/remote/remotepb"
+)
+
+// serviceName is the full name of the gRPC service.
+const serviceName = "skeleton.remote.v1.System"
+
+// ComponentInfo represents a component of a remote system.
+type ComponentInfo struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Type        string `json:"type"`
+}
+
+// serviceDesc describes the gRPC service System of remotepb/system.proto. It is written by hand,
+// only the messages of the service being generated.
+var serviceDesc = grpc.ServiceDesc{
+	ServiceName: serviceName,
+	HandlerType: (*interface{})(nil),
+	Methods: []grpc.MethodDesc{
+		unaryMethod("ExecuteOperation", (*Server).executeOperation),
+		unaryMethod("StartService", (*Server).startService),
+		unaryMethod("StopService", (*Server).stopService),
+		unaryMethod("RestartService", (*Server).restartService),
+		unaryMethod("ServiceStatus", (*Server).serviceStatus),
+		unaryMethod("ListComponents", (*Server).listComponents),
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "SubscribeEvents",
+			ServerStreams: true,
+			Handler: func(srv interface{}, stream grpc.ServerStream) error {
+				request := &remotepb.SubscribeRequest{}
+				if err := stream.RecvMsg(request); err != nil {
+					return err
+				}
+				return srv.(*Server).subscribeEvents(request, stream)
+			},
+		},
+	},
+	Metadata: "pkg/remote/remotepb/system.proto",
+}
+
+// unaryMethod describes the unary method with the given name, handled by the given method of the service.
+func unaryMethod[Req, Resp any](name string, handle func(s *Server, ctx context.Context, request *Req) (*Resp, error)) grpc.MethodDesc {
+	return grpc.MethodDesc{
+		MethodName: name,
+		Handler: func(srv interface{}, ctx context.Context, decode func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+			request := new(Req)
+			if err := decode(request); err != nil {
+				return nil, err
+			}
+
+			handler := func(ctx context.Context, request interface{}) (interface{}, error) {
+				return handle(srv.(*Server), ctx, request.(*Req))
+			}
+			if interceptor == nil {
+				return handler(ctx, request)
+			}
+
+			info := &grpc.UnaryServerInfo{Server: srv, FullMethod: methodName(name)}
+			return interceptor(ctx, request, info, handler)
+		},
+	}
+}
+
+// methodName returns the full name of the method with the given name.
+func methodName(name string) string {
+	return "/" + serviceName + "/" + name
+}
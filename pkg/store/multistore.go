@@ -2,6 +2,7 @@ package store
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"sync"
 
@@ -163,3 +164,22 @@ func (ms *MultiStoreImpl) SaveVersion() ([]byte, int64, error) {
 
 	return data, version, nil
 }
+
+// Close closes the stores of the multistore, then the multistore database.
+// Returns the errors of the stores that could not be closed joined together.
+func (ms *MultiStoreImpl) Close() error {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+
+	var errs []error
+	for id, store := range ms.stores {
+		if err := store.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to close store %s: %w", id, err))
+		}
+	}
+	if err := ms.Store.Close(); err != nil {
+		errs = append(errs, err)
+	}
+
+	return errors.Join(errs...)
+}
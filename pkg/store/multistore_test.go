@@ -1,6 +1,8 @@
 package store_test
 
 import (
+	"errors"
+
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"github.com/stretchr/testify/mock"
@@ -191,4 +193,36 @@ var _ = Describe("MultiStore", func() {
 			Expect(version).To(Equal(int64(1)))
 		})
 	})
+
+	Describe("Close", func() {
+		var namespaceStore *mocks.Store
+
+		BeforeEach(func() {
+			namespaceStore = &mocks.Store{}
+			mockStoreFactory.On("CreateStore", mock.Anything).Return(namespaceStore, nil)
+
+			var err error
+			ms, err = store.NewMultiStore(mockStore, mockStoreFactory)
+			Expect(err).NotTo(HaveOccurred())
+			_, _, err = ms.CreateStore("namespace")
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("closes the stores and the multistore database", func() {
+			namespaceStore.On("Close").Return(nil)
+			mockStore.On("Close").Return(nil)
+
+			Expect(ms.Close()).To(Succeed())
+			namespaceStore.AssertCalled(GinkgoT(), "Close")
+			mockStore.AssertCalled(GinkgoT(), "Close")
+		})
+
+		It("closes the multistore database when a store cannot be closed", func() {
+			namespaceStore.On("Close").Return(errors.New("close error"))
+			mockStore.On("Close").Return(nil)
+
+			Expect(ms.Close()).To(MatchError(ContainSubstring("close error")))
+			mockStore.AssertCalled(GinkgoT(), "Close")
+		})
+	})
 })
@@ -0,0 +1,772 @@
+package system
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ebanfa/skeleton/pkg/common"
+	"github.com/ebanfa/skeleton/pkg/types"
+)
+
+const (
+	defaultAdminAddress      = "localhost:8080"
+	defaultHeartbeatInterval = 15 * time.Second
+	adminClientBuffer        = 64
+)
+
+// AdminServerOptions represents the options of an admin server.
+type AdminServerOptions struct {
+	// Address is the TCP address the server listens on, localhost:8080 when empty.
+	Address string
+
+	// Token is the bearer token the requests must carry in their Authorization header.
+	// Requests are not authenticated when empty, which is only allowed on a loopback address.
+	Token string
+
+	// EventTopics are the topics or topic patterns of the events streamed to the clients, the system events when nil.
+	EventTopics []string
+
+	// HeartbeatInterval is the interval between two keep-alive comments sent on the event streams.
+	HeartbeatInterval time.Duration
+}
+
+// AdminServer is a service exposing the system over HTTP with a JSON API. It lists the components,
+// starts, stops and restarts services, executes and submits operations, reports the status
+// of the system and of the jobs, and streams the system events with Server-Sent Events.
+//
+// The API consists of:
+//
+//	GET    /system                        status of the system, services and job queue
+//	GET    /components?type=service       components, optionally of the given type
+//	GET    /services                      status of every service
+//	GET    /services/{id}                 status of a service
+//	POST   /services/{id}/{action}        start, stop or restart a service
+//	POST   /operations/{id}[?async=true]  execute an operation, or submit it as a job, with the JSON body as input
+//	GET    /jobs/{id}                     status of a job
+//	DELETE /jobs/{id}                     cancel a job
+//	GET    /events[?topic=...]            stream of the events, optionally of the given topics or topic patterns
+//	GET    /deadletters                   events whose handlers failed, when the system has a DeadLetterQueue
+//	POST   /deadletters/{id}/redrive      publish a dead letter again and remove it from the queue
+//	DELETE /deadletters/{id}              remove a dead letter from the queue
+type AdminServer struct {
+	BaseSystemService
+	mutex         sync.Mutex
+	options       AdminServerOptions
+	handler       http.Handler
+	server        *http.Server
+	listener      net.Listener
+	done          chan struct{}
+	subscriptions []common.Subscription
+	clients       map[*adminClient]struct{}
+}
+
+// adminClient represents a client of the event stream.
+type adminClient struct {
+	topics []string // Topics or topic patterns of the events sent to the client, all topics when empty
+	events chan common.Event
+}
+
+// wants checks if the client wants the events of the given topic.
+func (c *adminClient) wants(topic string) bool {
+	if len(c.topics) == 0 {
+		return true
+	}
+	for _, pattern := range c.topics {
+		if common.MatchTopic(pattern, topic) {
+			return true
+		}
+	}
+	return false
+}
+
+// NewAdminServer creates a new instance of AdminServer.
+func NewAdminServer(id string, options AdminServerOptions) *AdminServer {
+	if options.Address == "" {
+		options.Address = defaultAdminAddress
+	}
+	if options.EventTopics == nil {
+		options.EventTopics = SystemEventTopics()
+	}
+	if options.HeartbeatInterval <= 0 {
+		options.HeartbeatInterval = defaultHeartbeatInterval
+	}
+
+	s := &AdminServer{
+		BaseSystemService: *NewBaseSystemService(id, "AdminServer", "Exposes the system over HTTP"),
+		options:           options,
+		clients:           make(map[*adminClient]struct{}),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /system", s.handleSystem)
+	mux.HandleFunc("GET /components", s.handleComponents)
+	mux.HandleFunc("GET /services", s.handleServices)
+	mux.HandleFunc("GET /services/{id}", s.handleService)
+	mux.HandleFunc("POST /services/{id}/{action}", s.handleServiceAction)
+	mux.HandleFunc("POST /operations/{id}", s.handleOperation)
+	mux.HandleFunc("GET /jobs/{id}", s.handleJob)
+	mux.HandleFunc("DELETE /jobs/{id}", s.handleCancelJob)
+	mux.HandleFunc("GET /events", s.handleEvents)
+	mux.HandleFunc("GET /deadletters", s.handleDeadLetters)
+	mux.HandleFunc("POST /deadletters/{id}/redrive", s.handleRedriveDeadLetter)
+	mux.HandleFunc("DELETE /deadletters/{id}", s.handleRemoveDeadLetter)
+	s.handler = s.authenticate(mux)
+
+	return s
+}
+
+// Handler returns the HTTP handler of the API, which can be served without starting the service.
+func (s *AdminServer) Handler() http.Handler {
+	return s.handler
+}
+
+// Addr returns the address the server listens on, or an empty string if it is not started.
+func (s *AdminServer) Addr() string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.listener == nil {
+		return ""
+	}
+	return s.listener.Addr().String()
+}
+
+// Start starts listening on the configured address and serving the API.
+// Returns ErrAdminTokenRequired if the address is not a loopback address and no token is configured,
+// or an error if the address cannot be listened on.
+func (s *AdminServer) Start(ctx *common.Context) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.System == nil {
+		return types.ErrSystemNotInitialized
+	}
+	if s.server != nil {
+		return nil
+	}
+	if s.options.Token == "" && !isLoopback(s.options.Address) {
+		return fmt.Errorf("%w to listen on %s", types.ErrAdminTokenRequired, s.options.Address)
+	}
+
+	listener, err := net.Listen("tcp", s.options.Address)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", s.options.Address, err)
+	}
+	if err := s.subscribe(); err != nil {
+		listener.Close()
+		return err
+	}
+
+	s.listener = listener
+	s.done = make(chan struct{})
+	s.server = &http.Server{Handler: s.handler, ReadHeaderTimeout: 10 * time.Second}
+	go s.serve(s.server, listener)
+
+	return nil
+}
+
+// Stop closes the event streams and shuts the server down, waiting for the requests in progress
+// until the context is done.
+func (s *AdminServer) Stop(ctx *common.Context) error {
+	s.mutex.Lock()
+	server, done := s.server, s.done
+	s.server, s.listener, s.done = nil, nil, nil
+	s.unsubscribe()
+	s.mutex.Unlock()
+
+	if server == nil {
+		return nil
+	}
+
+	close(done)
+	return server.Shutdown(ctx)
+}
+
+// serve serves the API until the server is shut down.
+func (s *AdminServer) serve(server *http.Server, listener net.Listener) {
+	if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		if logger := s.System.Logger(); logger != nil {
+			logger.Log(common.LevelError, "Error serving admin API:", err)
+		}
+	}
+}
+
+// subscribe subscribes to the event topics, broadcasting the events to the stream clients.
+// The caller must hold the mutex.
+func (s *AdminServer) subscribe() error {
+	eventBus := s.System.EventBus()
+	if eventBus == nil {
+		return nil
+	}
+
+	for _, topic := range s.options.EventTopics {
+		subscription, err := eventBus.Subscribe(common.BusSubscriptionParams{
+			Topic:        topic,
+			EventHandler: s.broadcast,
+		})
+		if err != nil {
+			s.unsubscribe()
+			return fmt.Errorf("failed to subscribe to %s: %w", topic, err)
+		}
+		s.subscriptions = append(s.subscriptions, subscription)
+	}
+	return nil
+}
+
+// unsubscribe removes the subscriptions to the event topics.
+func (s *AdminServer) unsubscribe() {
+	for _, subscription := range s.subscriptions {
+		subscription.Unsubscribe()
+	}
+	s.subscriptions = nil
+}
+
+// broadcast sends an event to the stream clients interested in its topic.
+// Events are dropped for the clients that do not keep up.
+func (s *AdminServer) broadcast(event common.Event) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for client := range s.clients {
+		if !client.wants(event.Type) {
+			continue
+		}
+		select {
+		case client.events <- event:
+		default:
+		}
+	}
+}
+
+// isLoopback checks if a TCP address only accepts the connections of the local host.
+func isLoopback(address string) bool {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// authenticate checks the bearer token of the requests when a token is configured.
+func (s *AdminServer) authenticate(next http.Handler) http.Handler {
+	if s.options.Token == "" {
+		return next
+	}
+
+	expected := []byte("Bearer " + s.options.Token)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), expected) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeError(w, http.StatusUnauthorized, errors.New("invalid or missing token"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// adminSystemStatus represents the status of the system returned by the API.
+type adminSystemStatus struct {
+	Status   string            `json:"status"`
+	Services map[string]string `json:"services"`
+	Jobs     adminJobStats     `json:"jobs"`
+}
+
+// adminJobStats represents the depth of the job queue returned by the API.
+type adminJobStats struct {
+	Pending      int `json:"pending"`
+	Running      int `json:"running"`
+	DeadLettered int `json:"deadLettered"`
+}
+
+// adminComponent represents a component returned by the API.
+type adminComponent struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Type        string `json:"type"`
+}
+
+// adminService represents the status of a service returned by the API.
+type adminService struct {
+	ID     string `json:"id"`
+	Status string `json:"status"`
+}
+
+// adminEvent represents an event returned by the API.
+type adminEvent struct {
+	Type    string            `json:"type"`
+	Data    interface{}       `json:"data,omitempty"`
+	ID      string            `json:"id,omitempty"`
+	Source  string            `json:"source,omitempty"`
+	Time    *time.Time        `json:"time,omitempty"`
+	TraceID string            `json:"traceId,omitempty"`
+	Version int               `json:"version,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+// adminDeadLetter represents a dead letter returned by the API.
+type adminDeadLetter struct {
+	ID       string     `json:"id"`
+	Event    adminEvent `json:"event"`
+	Topic    string     `json:"topic"`
+	Attempts int        `json:"attempts"`
+	Error    string     `json:"error"`
+	FailedAt time.Time  `json:"failedAt"`
+}
+
+// adminJob represents the state of a job returned by the API.
+type adminJob struct {
+	ID           string      `json:"id"`
+	OperationID  string      `json:"operationId"`
+	Status       string      `json:"status"`
+	Progress     float64     `json:"progress"`
+	Message      string      `json:"message,omitempty"`
+	Output       interface{} `json:"output,omitempty"`
+	Error        string      `json:"error,omitempty"`
+	Attempts     int         `json:"attempts"`
+	DeadLettered bool        `json:"deadLettered,omitempty"`
+	SubmittedAt  time.Time   `json:"submittedAt"`
+	StartedAt    *time.Time  `json:"startedAt,omitempty"`
+	CompletedAt  *time.Time  `json:"completedAt,omitempty"`
+}
+
+// handleSystem returns the status of the system, its services and its job queue.
+func (s *AdminServer) handleSystem(w http.ResponseWriter, r *http.Request) {
+	stats := s.System.JobQueueStats()
+	writeJSON(w, http.StatusOK, adminSystemStatus{
+		Status:   s.System.Status().String(),
+		Services: s.serviceStatuses(),
+		Jobs: adminJobStats{
+			Pending:      stats.Pending,
+			Running:      stats.Running,
+			DeadLettered: stats.DeadLettered,
+		},
+	})
+}
+
+// handleComponents returns the registered components, optionally filtered by type.
+func (s *AdminServer) handleComponents(w http.ResponseWriter, r *http.Request) {
+	registry := s.System.ComponentRegistry()
+
+	var components []types.ComponentInterface
+	if name := r.URL.Query().Get("type"); name != "" {
+		componentType, ok := types.ParseComponentType(name)
+		if !ok {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("unknown component type %q", name))
+			return
+		}
+		components = registry.GetComponentsByType(componentType)
+	} else {
+		components = registry.GetAllComponents()
+	}
+
+	result := make([]adminComponent, 0, len(components))
+	for _, component := range components {
+		result = append(result, adminComponent{
+			ID:          component.ID(),
+			Name:        component.Name(),
+			Description: component.Description(),
+			Type:        component.Type().String(),
+		})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+// handleServices returns the status of every service.
+func (s *AdminServer) handleServices(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.serviceStatuses())
+}
+
+// handleService returns the status of a service.
+func (s *AdminServer) handleService(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if !s.hasComponent(w, id, types.ServiceType) {
+		return
+	}
+
+	status, err := s.System.ServiceStatus(id)
+	if err != nil {
+		writeError(w, errorStatus(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, adminService{ID: id, Status: status.String()})
+}
+
+// handleServiceAction starts, stops or restarts a service, then returns its status.
+func (s *AdminServer) handleServiceAction(w http.ResponseWriter, r *http.Request) {
+	id, action := r.PathValue("id"), r.PathValue("action")
+
+	var run func(ctx *common.Context, serviceID string) error
+	switch action {
+	case "start":
+		run = s.System.StartService
+	case "stop":
+		run = s.System.StopService
+	case "restart":
+		run = s.System.RestartService
+	default:
+		writeError(w, http.StatusNotFound, fmt.Errorf("unknown action %q", action))
+		return
+	}
+
+	if !s.hasComponent(w, id, types.ServiceType) {
+		return
+	}
+	if id == s.ID() && action != "start" {
+		// Stopping the server waits for the requests in progress, including this one
+		writeError(w, http.StatusConflict, fmt.Errorf("service %s cannot be stopped through its own API", id))
+		return
+	}
+
+	if err := run(common.WithContext(r.Context()), id); err != nil {
+		writeError(w, errorStatus(err), err)
+		return
+	}
+
+	status, _ := s.System.ServiceStatus(id)
+	writeJSON(w, http.StatusOK, adminService{ID: id, Status: status.String()})
+}
+
+// handleOperation executes an operation with the JSON body of the request as input,
+// or submits it as a job when the async query parameter is true.
+func (s *AdminServer) handleOperation(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if !s.hasComponent(w, id, types.OperationType) {
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("failed to read request body: %w", err))
+		return
+	}
+
+	// The input is given to the operation as raw JSON, which typed operations decode
+	input := &types.SystemOperationInput{}
+	if len(strings.TrimSpace(string(body))) > 0 {
+		if !json.Valid(body) {
+			writeError(w, http.StatusBadRequest, errors.New("request body is not valid JSON"))
+			return
+		}
+		input.Data = json.RawMessage(body)
+	}
+
+	ctx := common.WithContext(r.Context())
+	if r.URL.Query().Get("async") == "true" {
+		jobID, err := s.System.SubmitOperation(ctx, id, input)
+		if err != nil {
+			writeError(w, errorStatus(err), err)
+			return
+		}
+		writeJSON(w, http.StatusAccepted, map[string]string{"jobId": jobID})
+		return
+	}
+
+	output, err := s.System.ExecuteOperation(ctx, id, input)
+	if err != nil {
+		writeError(w, errorStatus(err), err)
+		return
+	}
+
+	var data interface{}
+	if output != nil {
+		data = output.Data
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"data": data})
+}
+
+// handleJob returns the state of a job.
+func (s *AdminServer) handleJob(w http.ResponseWriter, r *http.Request) {
+	info, err := s.System.JobStatus(r.PathValue("id"))
+	if err != nil {
+		writeError(w, errorStatus(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, newAdminJob(info))
+}
+
+// handleCancelJob cancels a job, then returns its state.
+func (s *AdminServer) handleCancelJob(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if err := s.System.CancelJob(id); err != nil {
+		writeError(w, errorStatus(err), err)
+		return
+	}
+
+	info, err := s.System.JobStatus(id)
+	if err != nil {
+		writeError(w, errorStatus(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, newAdminJob(info))
+}
+
+// handleEvents streams the events of the requested topics with Server-Sent Events,
+// until the client disconnects or the server stops.
+func (s *AdminServer) handleEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, errors.New("streaming is not supported"))
+		return
+	}
+
+	client := &adminClient{topics: r.URL.Query()["topic"], events: make(chan common.Event, adminClientBuffer)}
+
+	s.mutex.Lock()
+	done := s.done
+	s.clients[client] = struct{}{}
+	s.mutex.Unlock()
+
+	defer func() {
+		s.mutex.Lock()
+		delete(s.clients, client)
+		s.mutex.Unlock()
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(s.options.HeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-done:
+			return
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+		case event := <-client.events:
+			data, err := json.Marshal(event.Data)
+			if err != nil {
+				data, _ = json.Marshal(fmt.Sprint(event.Data))
+			}
+			if event.ID != "" {
+				fmt.Fprintf(w, "id: %s\n", event.ID)
+			}
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data)
+		}
+		flusher.Flush()
+	}
+}
+
+// handleDeadLetters returns the dead letters, the oldest first.
+func (s *AdminServer) handleDeadLetters(w http.ResponseWriter, r *http.Request) {
+	queue, err := s.deadLetterQueue()
+	if err != nil {
+		writeError(w, errorStatus(err), err)
+		return
+	}
+
+	entries := queue.List()
+	result := make([]adminDeadLetter, 0, len(entries))
+	for _, entry := range entries {
+		result = append(result, newAdminDeadLetter(entry))
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// handleRedriveDeadLetter publishes a dead letter again, then returns the published event.
+func (s *AdminServer) handleRedriveDeadLetter(w http.ResponseWriter, r *http.Request) {
+	queue, err := s.deadLetterQueue()
+	if err != nil {
+		writeError(w, errorStatus(err), err)
+		return
+	}
+
+	event, err := queue.Redrive(r.PathValue("id"))
+	if err != nil {
+		writeError(w, errorStatus(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, newAdminEvent(event))
+}
+
+// handleRemoveDeadLetter removes a dead letter.
+func (s *AdminServer) handleRemoveDeadLetter(w http.ResponseWriter, r *http.Request) {
+	queue, err := s.deadLetterQueue()
+	if err != nil {
+		writeError(w, errorStatus(err), err)
+		return
+	}
+
+	if err := queue.Remove(r.PathValue("id")); err != nil {
+		writeError(w, errorStatus(err), err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// deadLetterQueue returns the dead-letter queue of the system.
+func (s *AdminServer) deadLetterQueue() (*DeadLetterQueue, error) {
+	for _, component := range s.System.ComponentRegistry().GetComponentsByType(types.ServiceType) {
+		if queue, ok := component.(*DeadLetterQueue); ok {
+			return queue, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: the system has no dead-letter queue", types.ErrComponentNotFound)
+}
+
+// hasComponent checks that the component with the given ID exists and has the given type,
+// writing a not found error otherwise.
+func (s *AdminServer) hasComponent(w http.ResponseWriter, id string, componentType types.ComponentType) bool {
+	component, err := s.System.ComponentRegistry().GetComponent(id)
+	if err == nil {
+		switch componentType {
+		case types.ServiceType:
+			_, ok := component.(types.SystemServiceInterface)
+			err = errorIf(!ok, "component %s is not a service", id)
+		case types.OperationType:
+			_, ok := component.(types.SystemOperationInterface)
+			err = errorIf(!ok, "component %s is not an operation", id)
+		}
+	}
+	if err != nil {
+		writeError(w, http.StatusNotFound, fmt.Errorf("%w: %w", types.ErrComponentNotFound, err))
+		return false
+	}
+	return true
+}
+
+// serviceStatuses returns the status of every service keyed by service ID.
+func (s *AdminServer) serviceStatuses() map[string]string {
+	statuses := make(map[string]string)
+	for id, status := range s.System.ListServiceStatuses() {
+		statuses[id] = status.String()
+	}
+	return statuses
+}
+
+// newAdminJob converts the state of a job for the API.
+func newAdminJob(info *types.JobInfo) adminJob {
+	job := adminJob{
+		ID:           info.ID,
+		OperationID:  info.OperationID,
+		Status:       info.Status.String(),
+		Progress:     info.Progress,
+		Message:      info.Message,
+		Attempts:     info.Attempts,
+		DeadLettered: info.DeadLettered,
+		SubmittedAt:  info.SubmittedAt,
+	}
+	if info.Output != nil {
+		job.Output = info.Output.Data
+	}
+	if info.Err != nil {
+		job.Error = info.Err.Error()
+	}
+	if !info.StartedAt.IsZero() {
+		job.StartedAt = &info.StartedAt
+	}
+	if !info.CompletedAt.IsZero() {
+		job.CompletedAt = &info.CompletedAt
+	}
+	return job
+}
+
+// newAdminDeadLetter converts a dead letter for the API.
+func newAdminDeadLetter(entry DeadLetterEntry) adminDeadLetter {
+	return adminDeadLetter{
+		ID:       entry.ID,
+		Event:    newAdminEvent(entry.Event),
+		Topic:    entry.Topic,
+		Attempts: entry.Attempts,
+		Error:    entry.Error,
+		FailedAt: entry.FailedAt,
+	}
+}
+
+// newAdminEvent converts an event for the API. Data that cannot be encoded as JSON is returned as text.
+func newAdminEvent(event common.Event) adminEvent {
+	data := event.Data
+	if _, err := json.Marshal(data); err != nil {
+		data = fmt.Sprint(data)
+	}
+	result := adminEvent{
+		Type:    event.Type,
+		Data:    data,
+		ID:      event.ID,
+		Source:  event.Source,
+		TraceID: event.TraceID,
+		Version: event.Version,
+		Headers: event.Headers,
+	}
+	if !event.Time.IsZero() {
+		result.Time = &event.Time
+	}
+	return result
+}
+
+// errorStatus returns the HTTP status code of an error returned by the system.
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, types.ErrComponentNotFound), errors.Is(err, types.ErrJobNotFound),
+		errors.Is(err, types.ErrDeadLetterNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, types.ErrOperationTypeMismatch):
+		return http.StatusBadRequest
+	case errors.Is(err, types.ErrInvalidStateTransition), errors.Is(err, types.ErrJobCompleted),
+		errors.Is(err, types.ErrSystemNotStarted), errors.Is(err, types.ErrSystemNotInitialized):
+		return http.StatusConflict
+	case errors.Is(err, types.ErrJobQueueFull):
+		return http.StatusServiceUnavailable
+	case errors.Is(err, types.ErrOperationTimeout):
+		return http.StatusGatewayTimeout
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// errorIf returns an error with the given message if the condition holds.
+func errorIf(condition bool, format string, args ...interface{}) error {
+	if !condition {
+		return nil
+	}
+	return fmt.Errorf(format, args...)
+}
+
+// writeJSON writes a JSON response with the given status code.
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(value)
+}
+
+// writeError writes a JSON error response with the given status code.
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+// SystemEventTopics returns the topics of the events published by the system and its built-in services.
+func SystemEventTopics() []string {
+	return []string{
+		EventTypeServiceStateChanged,
+		EventTypeServiceRestarted,
+		EventTypeServiceRestartFailed,
+		EventTypeServiceGaveUp,
+		EventTypeConfigReloaded,
+		EventTypeJobCompleted,
+		EventTypeScheduledRunSucceeded,
+		EventTypeScheduledRunFailed,
+		EventTypeScheduledRunSkipped,
+	}
+}
@@ -0,0 +1,295 @@
+package system_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/ebanfa/skeleton/pkg/common"
+	"github.com/ebanfa/skeleton/pkg/component"
+	"github.com/ebanfa/skeleton/pkg/mocks"
+	systemApi "github.com/ebanfa/skeleton/pkg/system"
+	"github.com/ebanfa/skeleton/pkg/types"
+)
+
+var _ = Describe("AdminServer", func() {
+	type greeting struct {
+		Name string `json:"name"`
+	}
+
+	var (
+		ctx         *common.Context
+		sys         *systemApi.SystemImpl
+		admin       *systemApi.AdminServer
+		deadLetters *systemApi.DeadLetterQueue
+		options     systemApi.AdminServerOptions
+		server      *httptest.Server
+	)
+
+	// request sends a request to the API and decodes the JSON response into result.
+	request := func(method, path, body string, result interface{}) int {
+		req, err := http.NewRequest(method, server.URL+path, strings.NewReader(body))
+		Expect(err).NotTo(HaveOccurred())
+		req.Header.Set("Authorization", "Bearer secret")
+
+		resp, err := http.DefaultClient.Do(req)
+		Expect(err).NotTo(HaveOccurred())
+		defer resp.Body.Close()
+
+		if result != nil {
+			Expect(json.NewDecoder(resp.Body).Decode(result)).To(Succeed())
+		}
+		return resp.StatusCode
+	}
+
+	BeforeEach(func() {
+		ctx = common.Background()
+		options = systemApi.AdminServerOptions{Address: "127.0.0.1:0", Token: "secret"}
+	})
+
+	JustBeforeEach(func() {
+		worker := &mocks.SystemServiceInterface{}
+		worker.On("ID").Return("worker")
+		worker.On("Name").Return("Worker")
+		worker.On("Description").Return("Does the work")
+		worker.On("Type").Return(types.ServiceType)
+		worker.On("Initialize", mock.Anything, mock.Anything).Return(nil)
+		worker.On("Start", mock.Anything).Return(nil)
+		worker.On("Stop", mock.Anything).Return(nil)
+
+		admin = systemApi.NewAdminServer("admin", options)
+		deadLetters = systemApi.NewDeadLetterQueue("deadletters", systemApi.DeadLetterQueueOptions{})
+		components := map[string]types.ComponentInterface{
+			"admin":       admin,
+			"worker":      worker,
+			"deadletters": deadLetters,
+			"greet": systemApi.NewTypedOperation("greet", "Greet", "Greets someone",
+				func(ctx *common.Context, input greeting) (string, error) {
+					return "Hello " + input.Name, nil
+				}),
+		}
+
+		registrar := component.NewComponentRegistrar()
+		factory := &mocks.ComponentFactoryInterface{}
+		factory.On("CreateComponent", mock.Anything).Return(func(config *types.ComponentConfig) (types.ComponentInterface, error) {
+			return components[config.ID], nil
+		})
+		Expect(registrar.RegisterFactory(ctx, "factory", factory)).To(Succeed())
+
+		pluginManager := &mocks.PluginManagerInterface{}
+		pluginManager.On("Initialize", ctx, mock.Anything).Return(nil)
+		pluginManager.On("StartPlugins", ctx).Return(nil)
+		pluginManager.On("StopPlugins", mock.Anything).Return(nil)
+
+		sys = systemApi.NewSystem(nil, common.NewSystemEventBus(), &types.Configuration{
+			Services: []*types.ServiceConfiguration{
+				{ComponentConfig: types.ComponentConfig{ID: "admin", FactoryID: "factory"}},
+				{ComponentConfig: types.ComponentConfig{ID: "worker", FactoryID: "factory"}},
+				{ComponentConfig: types.ComponentConfig{ID: "deadletters", FactoryID: "factory"}},
+			},
+			Operations: []*types.OperationConfiguration{
+				{ComponentConfig: types.ComponentConfig{ID: "greet", FactoryID: "factory"}},
+			},
+		}, pluginManager, registrar, nil)
+		Expect(sys.Initialize(ctx)).To(Succeed())
+		Expect(sys.Start(ctx)).To(Succeed())
+
+		server = httptest.NewServer(admin.Handler())
+	})
+
+	AfterEach(func() {
+		server.Close()
+		Expect(sys.Stop(ctx)).To(Succeed())
+	})
+
+	It("listens on the configured address once started", func() {
+		Expect(admin.Addr()).To(HavePrefix("127.0.0.1:"))
+
+		resp, err := http.Get("http://" + admin.Addr() + "/system")
+		Expect(err).NotTo(HaveOccurred())
+		resp.Body.Close()
+		Expect(resp.StatusCode).To(Equal(http.StatusUnauthorized))
+	})
+
+	It("rejects the requests without the token", func() {
+		resp, err := http.Get(server.URL + "/system")
+		Expect(err).NotTo(HaveOccurred())
+		resp.Body.Close()
+		Expect(resp.StatusCode).To(Equal(http.StatusUnauthorized))
+	})
+
+	It("reports the status of the system", func() {
+		var status map[string]interface{}
+		Expect(request(http.MethodGet, "/system", "", &status)).To(Equal(http.StatusOK))
+		Expect(status).To(HaveKeyWithValue("status", "started"))
+		Expect(status).To(HaveKeyWithValue("services", map[string]interface{}{"admin": "running", "worker": "running", "deadletters": "running"}))
+		Expect(status).To(HaveKey("jobs"))
+	})
+
+	It("lists the components by type", func() {
+		var components []map[string]string
+		Expect(request(http.MethodGet, "/components?type=operation", "", &components)).To(Equal(http.StatusOK))
+		Expect(components).To(Equal([]map[string]string{
+			{"id": "greet", "name": "Greet", "description": "Greets someone", "type": "operation"},
+		}))
+
+		Expect(request(http.MethodGet, "/components?type=unknown", "", nil)).To(Equal(http.StatusBadRequest))
+	})
+
+	It("stops, starts and restarts services", func() {
+		var service map[string]string
+		Expect(request(http.MethodPost, "/services/worker/stop", "", &service)).To(Equal(http.StatusOK))
+		Expect(service).To(Equal(map[string]string{"id": "worker", "status": "stopped"}))
+
+		var failed map[string]string
+		Expect(request(http.MethodPost, "/services/worker/stop", "", &failed)).To(Equal(http.StatusConflict))
+		Expect(failed["error"]).To(ContainSubstring("invalid state transition"))
+
+		var started map[string]string
+		Expect(request(http.MethodPost, "/services/worker/start", "", &started)).To(Equal(http.StatusOK))
+		Expect(started).To(Equal(map[string]string{"id": "worker", "status": "running"}))
+
+		var restarted map[string]string
+		Expect(request(http.MethodPost, "/services/worker/restart", "", nil)).To(Equal(http.StatusOK))
+		Expect(request(http.MethodGet, "/services/worker", "", &restarted)).To(Equal(http.StatusOK))
+		Expect(restarted).To(Equal(map[string]string{"id": "worker", "status": "running"}))
+	})
+
+	It("refuses to stop itself", func() {
+		Expect(request(http.MethodPost, "/services/admin/stop", "", nil)).To(Equal(http.StatusConflict))
+	})
+
+	It("reports the unknown services and operations", func() {
+		Expect(request(http.MethodGet, "/services/unknown", "", nil)).To(Equal(http.StatusNotFound))
+		Expect(request(http.MethodGet, "/services/greet", "", nil)).To(Equal(http.StatusNotFound))
+		Expect(request(http.MethodPost, "/operations/unknown", "", nil)).To(Equal(http.StatusNotFound))
+		Expect(request(http.MethodPost, "/operations/worker", "", nil)).To(Equal(http.StatusNotFound))
+	})
+
+	It("executes operations with the JSON body as input", func() {
+		var result map[string]interface{}
+		Expect(request(http.MethodPost, "/operations/greet", `{"name": "Ada"}`, &result)).To(Equal(http.StatusOK))
+		Expect(result).To(Equal(map[string]interface{}{"data": "Hello Ada"}))
+
+		Expect(request(http.MethodPost, "/operations/greet", `"Ada"`, &result)).To(Equal(http.StatusBadRequest))
+		Expect(request(http.MethodPost, "/operations/greet", `{`, &result)).To(Equal(http.StatusBadRequest))
+	})
+
+	It("submits operations as jobs", func() {
+		var submitted map[string]string
+		Expect(request(http.MethodPost, "/operations/greet?async=true", `{"name": "Ada"}`, &submitted)).To(Equal(http.StatusAccepted))
+		Expect(submitted).To(HaveKey("jobId"))
+
+		Eventually(func() map[string]interface{} {
+			var job map[string]interface{}
+			Expect(request(http.MethodGet, "/jobs/"+submitted["jobId"], "", &job)).To(Equal(http.StatusOK))
+			return job
+		}).Should(And(HaveKeyWithValue("status", "succeeded"), HaveKeyWithValue("output", "Hello Ada")))
+
+		Expect(request(http.MethodGet, "/jobs/unknown", "", nil)).To(Equal(http.StatusNotFound))
+	})
+
+	It("lists, redrives and removes the dead letters", func() {
+		var attempts int
+		Expect(sys.EventBus().Subscribe(common.BusSubscriptionParams{
+			Topic: "order.created",
+			ErrorHandler: func(event common.Event) error {
+				attempts++
+				if attempts == 1 {
+					return errors.New("database unavailable")
+				}
+				return nil
+			},
+		})).Error().NotTo(HaveOccurred())
+		sys.EventBus().Publish(common.NewEvent(nil, "api", "order.created", greeting{Name: "Ada"}))
+		sys.EventBus().Publish(common.NewEvent(nil, "api", "order.created", nil))
+
+		var letters []map[string]interface{}
+		Expect(request(http.MethodGet, "/deadletters", "", &letters)).To(Equal(http.StatusOK))
+		Expect(letters).To(HaveLen(1))
+		Expect(letters[0]).To(HaveKeyWithValue("id", "0"))
+		Expect(letters[0]).To(HaveKeyWithValue("topic", "order.created"))
+		Expect(letters[0]).To(HaveKeyWithValue("attempts", 1.0))
+		Expect(letters[0]).To(HaveKeyWithValue("error", "database unavailable"))
+		Expect(letters[0]["event"]).To(HaveKeyWithValue("data", map[string]interface{}{"name": "Ada"}))
+
+		var event map[string]interface{}
+		Expect(request(http.MethodPost, "/deadletters/0/redrive", "", &event)).To(Equal(http.StatusOK))
+		Expect(event).To(HaveKeyWithValue("headers", map[string]interface{}{systemApi.RedriveCountHeader: "1"}))
+		Expect(attempts).To(Equal(3))
+		Expect(deadLetters.List()).To(BeEmpty())
+
+		Expect(request(http.MethodPost, "/deadletters/0/redrive", "", nil)).To(Equal(http.StatusNotFound))
+		Expect(request(http.MethodDelete, "/deadletters/0", "", nil)).To(Equal(http.StatusNotFound))
+
+		Expect(deadLetters.Add(common.DeadLetter{Event: common.Event{Type: "order.created"}})).Error().NotTo(HaveOccurred())
+		Expect(request(http.MethodDelete, "/deadletters/1", "", nil)).To(Equal(http.StatusNoContent))
+		Expect(deadLetters.List()).To(BeEmpty())
+	})
+
+	Describe("event stream", func() {
+		BeforeEach(func() {
+			options.EventTopics = []string{systemApi.EventTypeServiceStateChanged}
+		})
+
+		It("streams the events of the requested topics", func() {
+			req, err := http.NewRequest(http.MethodGet, server.URL+"/events?topic="+systemApi.EventTypeServiceStateChanged, nil)
+			Expect(err).NotTo(HaveOccurred())
+			req.Header.Set("Authorization", "Bearer secret")
+
+			resp, err := http.DefaultClient.Do(req)
+			Expect(err).NotTo(HaveOccurred())
+			defer resp.Body.Close()
+			Expect(resp.Header.Get("Content-Type")).To(Equal("text/event-stream"))
+
+			lines := make(chan string, 100)
+			go func() {
+				defer GinkgoRecover()
+				scanner := bufio.NewScanner(resp.Body)
+				for scanner.Scan() {
+					lines <- scanner.Text()
+				}
+				close(lines)
+			}()
+
+			Expect(request(http.MethodPost, "/services/worker/stop", "", nil)).To(Equal(http.StatusOK))
+
+			Eventually(lines).Should(Receive(Equal("event: " + systemApi.EventTypeServiceStateChanged)))
+			Eventually(lines).Should(Receive(And(HavePrefix("data: "), ContainSubstring(`"worker"`))))
+		})
+
+		It("ends the streams when the server stops", func() {
+			req, err := http.NewRequest(http.MethodGet, "http://"+admin.Addr()+"/events", nil)
+			Expect(err).NotTo(HaveOccurred())
+			req.Header.Set("Authorization", "Bearer secret")
+
+			resp, err := http.DefaultClient.Do(req)
+			Expect(err).NotTo(HaveOccurred())
+			defer resp.Body.Close()
+
+			Expect(admin.Stop(ctx)).To(Succeed())
+			_, err = io.ReadAll(resp.Body)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(admin.Addr()).To(BeEmpty())
+		})
+	})
+
+	Describe("without a token", func() {
+		It("refuses to listen on a non-loopback address", func() {
+			system := &mocks.SystemInterface{}
+			unprotected := systemApi.NewAdminServer("unprotected", systemApi.AdminServerOptions{Address: "0.0.0.0:0"})
+			Expect(unprotected.Initialize(ctx, system)).To(Succeed())
+
+			Expect(unprotected.Start(ctx)).To(MatchError(types.ErrAdminTokenRequired))
+			Expect(unprotected.Addr()).To(BeEmpty())
+		})
+	})
+})
@@ -0,0 +1,92 @@
+package system
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ebanfa/skeleton/pkg/common"
+	"github.com/ebanfa/skeleton/pkg/types"
+)
+
+// bootstrap creates and initializes the services and operations declared in the system configuration.
+// Returns the errors of all the entries that failed joined together.
+func (s *SystemImpl) bootstrap(ctx *common.Context) error {
+	configuration := s.Configuration()
+	if configuration == nil {
+		return nil
+	}
+
+	var errs []error
+	for i, config := range configuration.Services {
+		if err := s.bootstrapComponent(ctx, serviceComponentConfig(config), types.ServiceType); err != nil {
+			errs = append(errs, fmt.Errorf("services[%d] %s: %w", i, config.ID, err))
+		}
+	}
+
+	for i, config := range configuration.Operations {
+		if err := s.bootstrapComponent(ctx, &config.ComponentConfig, types.OperationType); err != nil {
+			errs = append(errs, fmt.Errorf("operations[%d] %s: %w", i, config.ID, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// bootstrapComponent creates the component described by the given configuration using its factory
+// and initializes it. The component must be a service or an operation as given by componentType.
+// The custom configuration is decoded into the type declared by the factory, if any.
+func (s *SystemImpl) bootstrapComponent(ctx *common.Context, config *types.ComponentConfig, componentType types.ComponentType) error {
+	factory, err := s.ComponentRegistry().GetFactory(config.FactoryID)
+	if err != nil {
+		return fmt.Errorf("%w: %s", types.ErrFactoryNotFound, config.FactoryID)
+	}
+
+	customConfig, err := DecodeCustomConfig(factory, config.CustomConfig)
+	if err != nil {
+		return fmt.Errorf("failed to decode custom configuration: %w", err)
+	}
+	componentConfig := *config
+	componentConfig.CustomConfig = customConfig
+	config = &componentConfig
+
+	component, err := s.ComponentRegistry().CreateComponent(ctx, config)
+	if err != nil {
+		return fmt.Errorf("failed to create component: %w", err)
+	}
+
+	switch componentType {
+	case types.ServiceType:
+		service, ok := component.(types.SystemServiceInterface)
+		if !ok {
+			return fmt.Errorf("component %s is not a service", config.ID)
+		}
+
+		s.lifecycle.track(config.ID, types.ServiceCreatedType)
+		if err := service.Initialize(ctx, s); err != nil {
+			s.transitionService(config.ID, types.ServiceFailedType)
+			return fmt.Errorf("failed to initialize service: %w", err)
+		}
+		return s.transitionService(config.ID, types.ServiceInitializedType)
+
+	default:
+		operation, ok := component.(types.SystemOperationInterface)
+		if !ok {
+			return fmt.Errorf("component %s is not an operation", config.ID)
+		}
+
+		if err := operation.Initialize(ctx, s); err != nil {
+			return fmt.Errorf("failed to initialize operation: %w", err)
+		}
+		return nil
+	}
+}
+
+// serviceComponentConfig returns the component configuration of a service configuration.
+// The custom configuration of the service is used when the component one is not set.
+func serviceComponentConfig(config *types.ServiceConfiguration) *types.ComponentConfig {
+	componentConfig := config.ComponentConfig
+	if componentConfig.CustomConfig == nil {
+		componentConfig.CustomConfig = config.CustomConfig
+	}
+	return &componentConfig
+}
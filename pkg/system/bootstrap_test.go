@@ -0,0 +1,209 @@
+package system_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/ebanfa/skeleton/pkg/common"
+	"github.com/ebanfa/skeleton/pkg/component"
+	"github.com/ebanfa/skeleton/pkg/mocks"
+	systemApi "github.com/ebanfa/skeleton/pkg/system"
+	"github.com/ebanfa/skeleton/pkg/types"
+)
+
+var _ = Describe("System bootstrap", func() {
+	var (
+		ctx               *common.Context
+		sys               *systemApi.SystemImpl
+		registrar         *component.ComponentRegistrar
+		configuration     *types.Configuration
+		serviceFactory    *mocks.ComponentFactoryInterface
+		operationFactory  *mocks.ComponentFactoryInterface
+		mockService       *mocks.SystemServiceInterface
+		mockOperation     *mocks.SystemOperationInterface
+		mockPluginManager *mocks.PluginManagerInterface
+	)
+
+	BeforeEach(func() {
+		ctx = common.Background()
+		registrar = component.NewComponentRegistrar()
+		serviceFactory = &mocks.ComponentFactoryInterface{}
+		operationFactory = &mocks.ComponentFactoryInterface{}
+		mockService = &mocks.SystemServiceInterface{}
+		mockOperation = &mocks.SystemOperationInterface{}
+		mockPluginManager = &mocks.PluginManagerInterface{}
+
+		mockService.On("ID").Return("service")
+		mockService.On("Type").Return(types.ServiceType)
+		mockOperation.On("ID").Return("operation")
+		mockOperation.On("Type").Return(types.OperationType)
+
+		mockPluginManager.On("Initialize", ctx, mock.Anything).Return(nil)
+		mockPluginManager.On("StartPlugins", ctx).Return(nil)
+
+		Expect(registrar.RegisterFactory(ctx, "serviceFactory", serviceFactory)).To(Succeed())
+		Expect(registrar.RegisterFactory(ctx, "operationFactory", operationFactory)).To(Succeed())
+
+		configuration = &types.Configuration{
+			Services: []*types.ServiceConfiguration{
+				{
+					ComponentConfig: types.ComponentConfig{ID: "service", FactoryID: "serviceFactory"},
+					CustomConfig:    map[string]interface{}{"port": 8080},
+				},
+			},
+			Operations: []*types.OperationConfiguration{
+				{ComponentConfig: types.ComponentConfig{ID: "operation", FactoryID: "operationFactory"}},
+			},
+		}
+	})
+
+	JustBeforeEach(func() {
+		sys = systemApi.NewSystem(nil, common.NewSystemEventBus(), configuration, mockPluginManager, registrar, nil)
+	})
+
+	Context("when every entry can be bootstrapped", func() {
+		BeforeEach(func() {
+			serviceFactory.On("CreateComponent", mock.Anything).Return(mockService, nil)
+			operationFactory.On("CreateComponent", mock.Anything).Return(mockOperation, nil)
+			mockService.On("Initialize", ctx, mock.Anything).Return(nil)
+			mockService.On("Start", ctx).Return(nil)
+			mockOperation.On("Initialize", ctx, mock.Anything).Return(nil)
+		})
+
+		It("creates and initializes the configured components", func() {
+			Expect(sys.Initialize(ctx)).To(Succeed())
+
+			Expect(registrar.GetComponent("service")).To(Equal(mockService))
+			Expect(registrar.GetComponent("operation")).To(Equal(mockOperation))
+			mockService.AssertCalled(GinkgoT(), "Initialize", ctx, sys)
+			mockOperation.AssertCalled(GinkgoT(), "Initialize", ctx, sys)
+
+			status, err := sys.ServiceStatus("service")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(status).To(Equal(types.ServiceInitializedType))
+		})
+
+		It("passes the custom configuration of services to their factory", func() {
+			Expect(sys.Initialize(ctx)).To(Succeed())
+
+			serviceFactory.AssertCalled(GinkgoT(), "CreateComponent", mock.MatchedBy(func(config *types.ComponentConfig) bool {
+				return config.ID == "service" && config.CustomConfig != nil
+			}))
+		})
+
+		It("starts the configured services with the system", func() {
+			Expect(sys.Initialize(ctx)).To(Succeed())
+			Expect(sys.Start(ctx)).To(Succeed())
+
+			status, err := sys.ServiceStatus("service")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(status).To(Equal(types.ServiceRunningType))
+		})
+	})
+
+	Context("when a factory declares the type of its custom configuration", func() {
+		type serviceConfig struct {
+			Port int `json:"port"`
+		}
+
+		var typedFactory *customConfigFactory
+
+		BeforeEach(func() {
+			configuration.Operations = nil
+			typedFactory = &customConfigFactory{ComponentFactoryInterface: &mocks.ComponentFactoryInterface{}}
+			typedFactory.CustomConfigFactoryInterface.On("NewCustomConfig").Return(func() interface{} {
+				return &serviceConfig{}
+			})
+			typedFactory.ComponentFactoryInterface.On("CreateComponent", mock.Anything).Return(mockService, nil)
+			mockService.On("Initialize", ctx, mock.Anything).Return(nil)
+			Expect(registrar.RegisterFactory(ctx, "typedFactory", typedFactory)).To(Succeed())
+			configuration.Services[0].FactoryID = "typedFactory"
+		})
+
+		It("passes the decoded custom configuration to the factory", func() {
+			Expect(sys.Initialize(ctx)).To(Succeed())
+
+			typedFactory.ComponentFactoryInterface.AssertCalled(GinkgoT(), "CreateComponent", mock.MatchedBy(func(config *types.ComponentConfig) bool {
+				customConfig, ok := config.CustomConfig.(*serviceConfig)
+				return ok && customConfig.Port == 8080
+			}))
+			Expect(configuration.Services[0].CustomConfig).To(Equal(map[string]interface{}{"port": 8080}))
+		})
+	})
+
+	Context("when the configuration is invalid", func() {
+		BeforeEach(func() {
+			configuration.Services = append(configuration.Services, &types.ServiceConfiguration{
+				ComponentConfig: types.ComponentConfig{ID: "orphan", FactoryID: "unknownFactory"},
+			})
+		})
+
+		It("reports the problems before creating any component", func() {
+			err := sys.Initialize(ctx)
+
+			Expect(err).To(MatchError(types.ErrInvalidConfiguration))
+			Expect(err).To(MatchError(types.ErrFactoryNotFound))
+			Expect(err.Error()).To(ContainSubstring(`/services/1/factoryId: unknown factory "unknownFactory"`))
+			serviceFactory.AssertNotCalled(GinkgoT(), "CreateComponent", mock.Anything)
+		})
+	})
+
+	Context("when entries cannot be bootstrapped", func() {
+		BeforeEach(func() {
+			configuration.Services = append(configuration.Services, &types.ServiceConfiguration{
+				ComponentConfig: types.ComponentConfig{ID: "broken", FactoryID: "serviceFactory"},
+			})
+			serviceFactory.On("CreateComponent", mock.MatchedBy(func(config *types.ComponentConfig) bool {
+				return config.ID == "broken"
+			})).Return(nil, errors.New("create error"))
+			serviceFactory.On("CreateComponent", mock.Anything).Return(mockService, nil)
+			operationFactory.On("CreateComponent", mock.Anything).Return(mockOperation, nil)
+			mockService.On("Initialize", ctx, mock.Anything).Return(nil)
+			mockOperation.On("Initialize", ctx, mock.Anything).Return(errors.New("initialize error"))
+		})
+
+		It("reports every failed entry", func() {
+			err := sys.Initialize(ctx)
+
+			Expect(err.Error()).To(ContainSubstring("services[1] broken: failed to create component"))
+			Expect(err.Error()).To(ContainSubstring("operations[0] operation: failed to initialize operation: initialize error"))
+			Expect(err.Error()).NotTo(ContainSubstring("services[0]"))
+		})
+
+		It("does not initialize the system", func() {
+			Expect(sys.Initialize(ctx)).NotTo(Succeed())
+			Expect(sys.Start(ctx)).To(MatchError(types.ErrSystemNotInitialized))
+		})
+	})
+
+	Context("when a configured service is not a service", func() {
+		BeforeEach(func() {
+			configuration.Operations = nil
+			serviceFactory.On("CreateComponent", mock.Anything).Return(mockOperation, nil)
+		})
+
+		It("returns an error", func() {
+			err := sys.Initialize(ctx)
+			Expect(err).To(MatchError(ContainSubstring("is not a service")))
+		})
+	})
+
+	Context("when a service fails to initialize", func() {
+		BeforeEach(func() {
+			configuration.Operations = nil
+			serviceFactory.On("CreateComponent", mock.Anything).Return(mockService, nil)
+			mockService.On("Initialize", ctx, mock.Anything).Return(errors.New("initialize error"))
+		})
+
+		It("marks the service as failed", func() {
+			Expect(sys.Initialize(ctx)).NotTo(Succeed())
+
+			status, err := sys.ServiceStatus("service")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(status).To(Equal(types.ServiceFailedType))
+		})
+	})
+})
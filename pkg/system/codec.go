@@ -0,0 +1,27 @@
+package system
+
+import (
+	"encoding/json"
+
+	"github.com/ebanfa/skeleton/pkg/types"
+)
+
+// JSONInputCodec encodes operation inputs holding data of type T as JSON.
+type JSONInputCodec[T any] struct{}
+
+// Encode encodes the data of the given input as JSON.
+func (JSONInputCodec[T]) Encode(input *types.SystemOperationInput) ([]byte, error) {
+	if input == nil {
+		return json.Marshal(nil)
+	}
+	return json.Marshal(input.Data)
+}
+
+// Decode decodes an input holding data of type T.
+func (JSONInputCodec[T]) Decode(data []byte) (*types.SystemOperationInput, error) {
+	var value T
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil, err
+	}
+	return &types.SystemOperationInput{Data: value}, nil
+}
@@ -0,0 +1,33 @@
+package system_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	systemApi "github.com/ebanfa/skeleton/pkg/system"
+	"github.com/ebanfa/skeleton/pkg/types"
+)
+
+var _ = Describe("JSONInputCodec", func() {
+	type payload struct {
+		Name  string `json:"name"`
+		Count int    `json:"count"`
+	}
+
+	It("decodes the inputs it encodes", func() {
+		codec := systemApi.JSONInputCodec[payload]{}
+
+		data, err := codec.Encode(&types.SystemOperationInput{Data: payload{Name: "a", Count: 2}})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).To(Equal(`{"name":"a","count":2}`))
+
+		input, err := codec.Decode(data)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(input.Data).To(Equal(payload{Name: "a", Count: 2}))
+	})
+
+	It("returns an error when the data does not decode", func() {
+		_, err := systemApi.JSONInputCodec[payload]{}.Decode([]byte("[]"))
+		Expect(err).To(HaveOccurred())
+	})
+})
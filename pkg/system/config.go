@@ -0,0 +1,393 @@
+package system
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/go-viper/mapstructure/v2"
+	"github.com/spf13/pflag"
+	"gopkg.in/yaml.v3"
+
+	"github.com/ebanfa/skeleton/pkg/common"
+	"github.com/ebanfa/skeleton/pkg/types"
+)
+
+// DefaultEnvPrefix is the prefix of the environment variables overriding the configuration.
+const DefaultEnvPrefix = "SKELETON"
+
+// ConfigFormat represents the format of a configuration file.
+type ConfigFormat string
+
+const (
+	// ConfigFormatJSON represents a JSON configuration file.
+	ConfigFormatJSON ConfigFormat = "json"
+
+	// ConfigFormatYAML represents a YAML configuration file.
+	ConfigFormatYAML ConfigFormat = "yaml"
+
+	// ConfigFormatTOML represents a TOML configuration file.
+	ConfigFormatTOML ConfigFormat = "toml"
+)
+
+// ConfigFormatFromPath returns the format of a configuration file given its extension.
+// Files with another extension or without extension are JSON files.
+func ConfigFormatFromPath(filePath string) ConfigFormat {
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".yaml", ".yml":
+		return ConfigFormatYAML
+	case ".toml":
+		return ConfigFormatTOML
+	default:
+		return ConfigFormatJSON
+	}
+}
+
+// ConfigLoaderOptions represents the sources of a configuration, from the lowest to the highest precedence.
+type ConfigLoaderOptions struct {
+	// Defaults holds the default values, either a struct or a map.
+	Defaults interface{}
+
+	// FilePath is the path of the configuration file, its format is detected from its extension,
+	// JSON when the extension is not known.
+	FilePath string
+
+	// EnvPrefix is the prefix of the environment variables overriding the configuration.
+	// A variable such as PREFIX_SERVICES_0_RETRYINTERVAL sets the retry interval of the first service,
+	// and a double underscore stands for an underscore within a key, as in PREFIX_SERVICES_0_CUSTOMCONFIG_MAX__CONNS.
+	// The variables that cannot be applied are skipped. Environment variables are ignored when the prefix is empty.
+	EnvPrefix string
+
+	// Logger logs the environment variables that were skipped, when given.
+	Logger common.LoggerInterface
+
+	// Flags are the command line flags overriding the configuration.
+	// Each flag that was set on the command line overrides the value at the path given by its name,
+	// such as "debug" or "services.0.retryInterval".
+	Flags *pflag.FlagSet
+}
+
+// LoadConfiguration loads a configuration into the target by merging, in order of precedence,
+// the defaults, the configuration file, the environment variables and the command line flags.
+// Keys are matched case insensitively and durations can be given as strings such as "5s".
+func LoadConfiguration(options ConfigLoaderOptions, target interface{}) error {
+	values, err := toConfigMap(options.Defaults)
+	if err != nil {
+		return fmt.Errorf("failed to read configuration defaults: %w", err)
+	}
+
+	if options.FilePath != "" {
+		fileValues, err := readConfigFile(options.FilePath)
+		if err != nil {
+			return err
+		}
+		values = mergeConfigMaps(values, fileValues)
+	}
+
+	if options.EnvPrefix != "" {
+		for _, override := range envOverrides(options.EnvPrefix, os.Environ()) {
+			// A stray variable with the prefix does not prevent loading the configuration
+			if _, err := setConfigValue(values, override.path, override.value); err != nil && options.Logger != nil {
+				options.Logger.Logf(common.LevelWarn, "Skipping environment variable %s: %v", override.name, err)
+			}
+		}
+	}
+
+	if options.Flags != nil {
+		for _, override := range flagOverrides(options.Flags) {
+			if _, err := setConfigValue(values, override.path, override.value); err != nil {
+				return fmt.Errorf("failed to apply flag --%s: %w", override.name, err)
+			}
+		}
+	}
+
+	if err := decodeConfig(values, target); err != nil {
+		return fmt.Errorf("failed to unmarshal configuration data: %v", err)
+	}
+
+	return nil
+}
+
+// DecodeCustomConfig decodes a custom configuration into the type declared by the factory
+// of its component. The custom configuration is returned as is if the factory does not
+// implement CustomConfigFactoryInterface or if it already has the declared type.
+func DecodeCustomConfig(factory types.ComponentFactoryInterface, customConfig interface{}) (interface{}, error) {
+	typed, ok := factory.(types.CustomConfigFactoryInterface)
+	if !ok {
+		return customConfig, nil
+	}
+
+	target := typed.NewCustomConfig()
+	if customConfig == nil {
+		return target, nil
+	}
+	if reflect.TypeOf(customConfig) == reflect.TypeOf(target) {
+		return customConfig, nil
+	}
+
+	if err := decodeConfig(customConfig, target); err != nil {
+		return nil, err
+	}
+	return target, nil
+}
+
+// readConfigFile reads a configuration file into a map.
+func readConfigFile(filePath string) (map[string]interface{}, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read configuration file: %v", err)
+	}
+
+	values, err := parseConfigData(ConfigFormatFromPath(filePath), data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal configuration data: %v", err)
+	}
+
+	return values, nil
+}
+
+// parseConfigData parses configuration data of the given format into a map.
+func parseConfigData(format ConfigFormat, data []byte) (map[string]interface{}, error) {
+	values := make(map[string]interface{})
+
+	var err error
+	switch format {
+	case ConfigFormatYAML:
+		err = yaml.Unmarshal(data, &values)
+	case ConfigFormatTOML:
+		err = toml.Unmarshal(data, &values)
+	default:
+		err = json.Unmarshal(data, &values)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return normalizeConfigValue(values).(map[string]interface{}), nil
+}
+
+// toConfigMap converts the configuration defaults into a map.
+func toConfigMap(defaults interface{}) (map[string]interface{}, error) {
+	values := make(map[string]interface{})
+	if defaults == nil {
+		return values, nil
+	}
+
+	data, err := json.Marshal(defaults)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &values); err != nil {
+		return nil, err
+	}
+
+	return values, nil
+}
+
+// normalizeConfigValue converts the maps and slices produced by the file decoders
+// into map[string]interface{} and []interface{} values.
+func normalizeConfigValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = normalizeConfigValue(item)
+		}
+		return v
+	case map[interface{}]interface{}:
+		normalized := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			normalized[fmt.Sprint(key)] = normalizeConfigValue(item)
+		}
+		return normalized
+	case []map[string]interface{}:
+		normalized := make([]interface{}, len(v))
+		for i, item := range v {
+			normalized[i] = normalizeConfigValue(item)
+		}
+		return normalized
+	case []interface{}:
+		for i, item := range v {
+			v[i] = normalizeConfigValue(item)
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+// mergeConfigMaps merges the overrides into the base map, matching keys case insensitively.
+// Nested maps are merged, any other value of the overrides replaces the base one.
+func mergeConfigMaps(base, overrides map[string]interface{}) map[string]interface{} {
+	for key, value := range overrides {
+		existingKey := findConfigKey(base, key)
+		baseMap, baseIsMap := base[existingKey].(map[string]interface{})
+		overrideMap, overrideIsMap := value.(map[string]interface{})
+		if existingKey != key {
+			delete(base, existingKey)
+		}
+		if baseIsMap && overrideIsMap {
+			base[key] = mergeConfigMaps(baseMap, overrideMap)
+		} else {
+			base[key] = value
+		}
+	}
+	return base
+}
+
+// findConfigKey returns the key of the map matching the given key case insensitively,
+// or the given key if there is none.
+func findConfigKey(values map[string]interface{}, key string) string {
+	if _, ok := values[key]; ok {
+		return key
+	}
+	for existing := range values {
+		if strings.EqualFold(existing, key) {
+			return existing
+		}
+	}
+	return key
+}
+
+// setConfigValue sets the value at the given path of a configuration node and returns the updated node.
+// Path segments index maps by key and slices by position, missing maps and slices are created.
+func setConfigValue(node interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	switch v := node.(type) {
+	case map[string]interface{}:
+		key := findConfigKey(v, path[0])
+		child, err := setConfigValue(v[key], path[1:], value)
+		if err != nil {
+			return nil, err
+		}
+		v[key] = child
+		return v, nil
+
+	case []interface{}:
+		index, err := strconv.Atoi(path[0])
+		if err != nil || index < 0 || index > len(v) {
+			return nil, fmt.Errorf("invalid index %s for a list of %d items", path[0], len(v))
+		}
+		if index == len(v) {
+			v = append(v, nil)
+		}
+		child, err := setConfigValue(v[index], path[1:], value)
+		if err != nil {
+			return nil, err
+		}
+		v[index] = child
+		return v, nil
+
+	case nil:
+		// Create the missing node, a list when indexed by position
+		if _, err := strconv.Atoi(path[0]); err == nil {
+			return setConfigValue([]interface{}{}, path, value)
+		}
+		return setConfigValue(map[string]interface{}{}, path, value)
+
+	default:
+		return nil, fmt.Errorf("cannot set %s on a %T value", strings.Join(path, "."), node)
+	}
+}
+
+// configOverride represents a configuration value set by an environment variable or a flag.
+type configOverride struct {
+	name  string
+	path  []string
+	value interface{}
+}
+
+// envOverrides returns the configuration overrides of the environment variables with the given prefix,
+// sorted by name so that they are applied in a predictable order. Single underscores separate the keys
+// of the path, double underscores stand for an underscore within a key.
+func envOverrides(prefix string, environ []string) []configOverride {
+	prefix = strings.ToUpper(prefix) + "_"
+
+	var overrides []configOverride
+	for _, variable := range environ {
+		name, value, ok := strings.Cut(variable, "=")
+		if !ok || !strings.HasPrefix(strings.ToUpper(name), prefix) || len(name) == len(prefix) {
+			continue
+		}
+		overrides = append(overrides, configOverride{
+			name:  name,
+			path:  envPath(strings.ToLower(name[len(prefix):])),
+			value: value,
+		})
+	}
+
+	sort.Slice(overrides, func(i, j int) bool {
+		return overrides[i].name < overrides[j].name
+	})
+	return overrides
+}
+
+// envPath splits the name of an environment variable, without its prefix, into a configuration path.
+func envPath(name string) []string {
+	var (
+		path []string
+		key  strings.Builder
+	)
+	for i := 0; i < len(name); i++ {
+		switch {
+		case strings.HasPrefix(name[i:], "__"):
+			key.WriteByte('_')
+			i++
+		case name[i] == '_':
+			path = append(path, key.String())
+			key.Reset()
+		default:
+			key.WriteByte(name[i])
+		}
+	}
+	return append(path, key.String())
+}
+
+// flagOverrides returns the configuration overrides of the flags that were set on the command line.
+func flagOverrides(flags *pflag.FlagSet) []configOverride {
+	var overrides []configOverride
+	// Changed rather than Visit, as the flags may have been set on another flag set
+	flags.VisitAll(func(flag *pflag.Flag) {
+		if !flag.Changed {
+			return
+		}
+		var value interface{} = flag.Value.String()
+		if slice, ok := flag.Value.(pflag.SliceValue); ok {
+			value = slice.GetSlice()
+		}
+		overrides = append(overrides, configOverride{
+			name:  flag.Name,
+			path:  strings.Split(flag.Name, "."),
+			value: value,
+		})
+	})
+	return overrides
+}
+
+// decodeConfig decodes the configuration values into the target, matching keys with
+// the JSON names of the fields case insensitively and converting strings to the field types.
+func decodeConfig(values interface{}, target interface{}) error {
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		DecodeHook: mapstructure.ComposeDecodeHookFunc(
+			mapstructure.StringToTimeDurationHookFunc(),
+			mapstructure.StringToSliceHookFunc(","),
+		),
+		WeaklyTypedInput: true,
+		Squash:           true,
+		TagName:          "json",
+		Result:           target,
+	})
+	if err != nil {
+		return err
+	}
+
+	return decoder.Decode(values)
+}
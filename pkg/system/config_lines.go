@@ -0,0 +1,199 @@
+package system
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// configLines maps the JSON pointers of the values of a configuration file to their line.
+// Pointers are stored in lowercase as keys are matched case insensitively.
+type configLines map[string]int
+
+// line returns the line of the value at the given pointer, or of its closest parent
+// if the value itself cannot be located. Returns 0 if no line is known.
+func (l configLines) line(pointer string) int {
+	pointer = strings.ToLower(pointer)
+	for {
+		if line, ok := l[pointer]; ok {
+			return line
+		}
+		if pointer == "" {
+			return 0
+		}
+		pointer = pointer[:strings.LastIndex(pointer, "/")]
+	}
+}
+
+// set records the line of the value at the given pointer, unless it is already known.
+func (l configLines) set(pointer string, line int) {
+	pointer = strings.ToLower(pointer)
+	if _, ok := l[pointer]; !ok {
+		l[pointer] = line
+	}
+}
+
+// parseConfigLines locates the values of configuration data of the given format.
+// Locations are best effort, the data is expected to be valid.
+func parseConfigLines(format ConfigFormat, data []byte) configLines {
+	lines := make(configLines)
+	switch format {
+	case ConfigFormatYAML:
+		var document yaml.Node
+		if err := yaml.Unmarshal(data, &document); err == nil && len(document.Content) > 0 {
+			yamlLines(lines, document.Content[0], "")
+		}
+	case ConfigFormatTOML:
+		tomlLines(lines, data)
+	default:
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		jsonLines(lines, decoder, data, "")
+	}
+	return lines
+}
+
+// yamlLines records the lines of a YAML node and its children.
+func yamlLines(lines configLines, node *yaml.Node, pointer string) {
+	lines.set(pointer, node.Line)
+
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			child := pointer + "/" + escapePointer(node.Content[i].Value)
+			lines.set(child, node.Content[i].Line)
+			yamlLines(lines, node.Content[i+1], child)
+		}
+	case yaml.SequenceNode:
+		for i, item := range node.Content {
+			yamlLines(lines, item, pointer+"/"+strconv.Itoa(i))
+		}
+	case yaml.AliasNode:
+		if node.Alias != nil {
+			yamlLines(lines, node.Alias, pointer)
+		}
+	}
+}
+
+// jsonLines records the lines of the next JSON value read from the decoder and its children.
+func jsonLines(lines configLines, decoder *json.Decoder, data []byte, pointer string) bool {
+	offset := decoder.InputOffset()
+	token, err := decoder.Token()
+	if err != nil {
+		return false
+	}
+	lines.set(pointer, lineAtOffset(data, offset))
+
+	switch token {
+	case json.Delim('{'):
+		for decoder.More() {
+			offset := decoder.InputOffset()
+			key, err := decoder.Token()
+			if err != nil {
+				return false
+			}
+			child := pointer + "/" + escapePointer(key.(string))
+			lines.set(child, lineAtOffset(data, offset))
+			if !jsonLines(lines, decoder, data, child) {
+				return false
+			}
+		}
+		_, err = decoder.Token()
+	case json.Delim('['):
+		for i := 0; decoder.More(); i++ {
+			if !jsonLines(lines, decoder, data, pointer+"/"+strconv.Itoa(i)) {
+				return false
+			}
+		}
+		_, err = decoder.Token()
+	}
+
+	return err == nil
+}
+
+// lineAtOffset returns the line of the first token at or after the given offset.
+func lineAtOffset(data []byte, offset int64) int {
+	i := int(offset)
+	for i < len(data) && strings.IndexByte(" \t\r\n,:", data[i]) >= 0 {
+		i++
+	}
+	return bytes.Count(data[:i], []byte("\n")) + 1
+}
+
+// tomlLines records the lines of the tables and keys of TOML data.
+// Inline tables and multi-line values are located by their first line.
+func tomlLines(lines configLines, data []byte) {
+	table := ""
+	arrays := make(map[string]int) // Number of elements of each array of tables
+
+	// resolve returns the pointer of a dotted key, selecting the last element of arrays of tables
+	resolve := func(prefix, key string, isArray bool) string {
+		segments := splitTOMLKey(key)
+		pointer := prefix
+		for i, segment := range segments {
+			pointer += "/" + escapePointer(strings.ToLower(segment))
+			if count, ok := arrays[pointer]; ok && !(isArray && i == len(segments)-1) {
+				pointer += "/" + strconv.Itoa(count-1)
+			}
+		}
+		return pointer
+	}
+
+	for i, raw := range strings.Split(string(data), "\n") {
+		text := strings.TrimSpace(raw)
+		switch {
+		case text == "" || strings.HasPrefix(text, "#"):
+			continue
+
+		case strings.HasPrefix(text, "[["):
+			name := strings.TrimSuffix(strings.TrimSpace(strings.TrimPrefix(text, "[[")), "]]")
+			pointer := resolve("", name, true)
+			lines.set(pointer, i+1)
+			table = pointer + "/" + strconv.Itoa(arrays[pointer])
+			arrays[pointer]++
+			lines.set(table, i+1)
+
+		case strings.HasPrefix(text, "["):
+			name := strings.TrimSuffix(strings.TrimSpace(strings.TrimPrefix(text, "[")), "]")
+			table = resolve("", name, false)
+			lines.set(table, i+1)
+
+		default:
+			if key, _, ok := strings.Cut(text, "="); ok {
+				lines.set(resolve(table, strings.TrimSpace(key), false), i+1)
+			}
+		}
+	}
+}
+
+// splitTOMLKey splits a dotted TOML key into its segments, removing quotes.
+func splitTOMLKey(key string) []string {
+	var (
+		segments []string
+		current  strings.Builder
+		quote    rune
+	)
+	for _, r := range key {
+		switch {
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote != 0:
+			current.WriteRune(r)
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '.':
+			segments = append(segments, strings.TrimSpace(current.String()))
+			current.Reset()
+		default:
+			current.WriteRune(r)
+		}
+	}
+	return append(segments, strings.TrimSpace(current.String()))
+}
+
+// escapePointer escapes a key for use in a JSON pointer.
+func escapePointer(key string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(key)
+}
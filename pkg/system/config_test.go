@@ -0,0 +1,312 @@
+package system_test
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/ebanfa/skeleton/pkg/common"
+	"github.com/ebanfa/skeleton/pkg/mocks"
+	"github.com/ebanfa/skeleton/pkg/system"
+	"github.com/ebanfa/skeleton/pkg/types"
+)
+
+var _ = Describe("Configuration", func() {
+	var dir string
+
+	writeFile := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		Expect(os.WriteFile(path, []byte(content), 0o600)).To(Succeed())
+		return path
+	}
+
+	setenv := func(key, value string) {
+		previous, exists := os.LookupEnv(key)
+		Expect(os.Setenv(key, value)).To(Succeed())
+		DeferCleanup(func() {
+			if exists {
+				os.Setenv(key, previous)
+			} else {
+				os.Unsetenv(key)
+			}
+		})
+	}
+
+	BeforeEach(func() {
+		dir = GinkgoT().TempDir()
+	})
+
+	Describe("ConfigFormatFromPath", func() {
+		It("detects the format from the extension", func() {
+			Expect(system.ConfigFormatFromPath("config.json")).To(Equal(system.ConfigFormatJSON))
+			Expect(system.ConfigFormatFromPath("config.yaml")).To(Equal(system.ConfigFormatYAML))
+			Expect(system.ConfigFormatFromPath("config.YML")).To(Equal(system.ConfigFormatYAML))
+			Expect(system.ConfigFormatFromPath("config.toml")).To(Equal(system.ConfigFormatTOML))
+		})
+
+		It("defaults to JSON for the other extensions", func() {
+			Expect(system.ConfigFormatFromPath("config.conf")).To(Equal(system.ConfigFormatJSON))
+			Expect(system.ConfigFormatFromPath("config")).To(Equal(system.ConfigFormatJSON))
+		})
+	})
+
+	Describe("LoadConfigurationFromFile", func() {
+		expected := &types.Configuration{
+			Debug: true,
+			Services: []*types.ServiceConfiguration{
+				{
+					ComponentConfig: types.ComponentConfig{ID: "service", FactoryID: "serviceFactory"},
+					RetryInterval:   5 * time.Second,
+					Dependencies:    []string{"store"},
+				},
+			},
+			Operations: []*types.OperationConfiguration{
+				{ComponentConfig: types.ComponentConfig{ID: "operation", FactoryID: "operationFactory"}},
+			},
+		}
+
+		It("loads a JSON file", func() {
+			path := writeFile("config.json", `{
+				"debug": true,
+				"services": [{"id": "service", "factoryId": "serviceFactory", "retryInterval": "5s", "dependencies": ["store"]}],
+				"operations": [{"id": "operation", "factoryId": "operationFactory"}]
+			}`)
+
+			configuration := &types.Configuration{}
+			Expect(system.LoadConfigurationFromFile(path, configuration)).To(Succeed())
+			Expect(configuration).To(Equal(expected))
+		})
+
+		It("loads a file without a known extension as JSON", func() {
+			path := writeFile("config", `{"debug": true}`)
+
+			configuration := &types.Configuration{}
+			Expect(system.LoadConfigurationFromFile(path, configuration)).To(Succeed())
+			Expect(configuration.Debug).To(BeTrue())
+		})
+
+		It("loads a YAML file", func() {
+			path := writeFile("config.yaml", `
+debug: true
+services:
+  - id: service
+    factoryId: serviceFactory
+    retryInterval: 5s
+    dependencies: [store]
+operations:
+  - id: operation
+    factoryId: operationFactory
+`)
+
+			configuration := &types.Configuration{}
+			Expect(system.LoadConfigurationFromFile(path, configuration)).To(Succeed())
+			Expect(configuration).To(Equal(expected))
+		})
+
+		It("loads a TOML file", func() {
+			path := writeFile("config.toml", `
+debug = true
+
+[[services]]
+id = "service"
+factoryId = "serviceFactory"
+retryInterval = "5s"
+dependencies = ["store"]
+
+[[operations]]
+id = "operation"
+factoryId = "operationFactory"
+`)
+
+			configuration := &types.Configuration{}
+			Expect(system.LoadConfigurationFromFile(path, configuration)).To(Succeed())
+			Expect(configuration).To(Equal(expected))
+		})
+
+		It("returns an error when the file cannot be read", func() {
+			err := system.LoadConfigurationFromFile(filepath.Join(dir, "missing.json"), &types.Configuration{})
+			Expect(err).To(MatchError(ContainSubstring("failed to read configuration file")))
+		})
+
+		It("returns an error when the file is malformed", func() {
+			path := writeFile("config.yaml", "services: [")
+			err := system.LoadConfigurationFromFile(path, &types.Configuration{})
+			Expect(err).To(MatchError(ContainSubstring("failed to unmarshal configuration data")))
+		})
+
+		It("returns an error when a duration is invalid", func() {
+			path := writeFile("config.yaml", "services:\n  - id: service\n    retryInterval: soon\n")
+			err := system.LoadConfigurationFromFile(path, &types.Configuration{})
+			Expect(err).To(MatchError(ContainSubstring("failed to unmarshal configuration data")))
+		})
+	})
+
+	Describe("LoadConfiguration", func() {
+		var (
+			path    string
+			options system.ConfigLoaderOptions
+		)
+
+		BeforeEach(func() {
+			path = writeFile("config.yaml", `
+verbose: true
+services:
+  - id: service
+    retryInterval: 5s
+  - id: other
+`)
+			options = system.ConfigLoaderOptions{
+				Defaults: &types.Configuration{Debug: true, Verbose: false},
+				FilePath: path,
+			}
+		})
+
+		It("merges the file over the defaults", func() {
+			configuration := &types.Configuration{}
+			Expect(system.LoadConfiguration(options, configuration)).To(Succeed())
+
+			Expect(configuration.Debug).To(BeTrue())
+			Expect(configuration.Verbose).To(BeTrue())
+			Expect(configuration.Services).To(HaveLen(2))
+		})
+
+		It("overrides values from the environment", func() {
+			setenv("SKELETON_TEST_SERVICES_0_RETRYINTERVAL", "10s")
+			setenv("SKELETON_TEST_SERVICES_1_DEPENDENCIES", "service,store")
+			setenv("SKELETON_TEST_DEBUG", "false")
+			options.EnvPrefix = "SKELETON_TEST"
+
+			configuration := &types.Configuration{}
+			Expect(system.LoadConfiguration(options, configuration)).To(Succeed())
+
+			Expect(configuration.Debug).To(BeFalse())
+			Expect(configuration.Services[0].RetryInterval).To(Equal(10 * time.Second))
+			Expect(configuration.Services[1].Dependencies).To(Equal([]string{"service", "store"}))
+		})
+
+		It("adds entries from the environment", func() {
+			setenv("SKELETON_TEST_SERVICES_2_ID", "added")
+			options.EnvPrefix = "SKELETON_TEST"
+
+			configuration := &types.Configuration{}
+			Expect(system.LoadConfiguration(options, configuration)).To(Succeed())
+
+			Expect(configuration.Services).To(HaveLen(3))
+			Expect(configuration.Services[2].ID).To(Equal("added"))
+		})
+
+		It("skips and logs the variables that cannot be applied", func() {
+			setenv("SKELETON_TEST_SERVICES_5_ID", "added")
+			setenv("SKELETON_TEST_DEBUG_X", "true")
+			setenv("SKELETON_TEST_VERBOSE", "false")
+			logger := &mocks.LoggerInterface{}
+			logger.On("Logf", common.LevelWarn, mock.Anything, mock.Anything, mock.Anything).Return()
+			options.EnvPrefix = "SKELETON_TEST"
+			options.Logger = logger
+
+			configuration := &types.Configuration{}
+			Expect(system.LoadConfiguration(options, configuration)).To(Succeed())
+
+			Expect(configuration.Services).To(HaveLen(2))
+			Expect(configuration.Debug).To(BeTrue())
+			Expect(configuration.Verbose).To(BeFalse())
+			logger.AssertCalled(GinkgoT(), "Logf", common.LevelWarn, mock.Anything, "SKELETON_TEST_SERVICES_5_ID", mock.Anything)
+			logger.AssertCalled(GinkgoT(), "Logf", common.LevelWarn, mock.Anything, "SKELETON_TEST_DEBUG_X", mock.Anything)
+		})
+
+		It("keeps the underscores escaped by a double underscore", func() {
+			setenv("SKELETON_TEST_SERVICES_0_CUSTOMCONFIG_MAX__CONNS", "10")
+			options.EnvPrefix = "SKELETON_TEST"
+
+			configuration := &types.Configuration{}
+			Expect(system.LoadConfiguration(options, configuration)).To(Succeed())
+
+			Expect(configuration.Services[0].CustomConfig).To(HaveKeyWithValue("max_conns", "10"))
+		})
+
+		It("overrides values from the flags that were set", func() {
+			setenv("SKELETON_TEST_SERVICES_0_RETRYINTERVAL", "10s")
+			options.EnvPrefix = "SKELETON_TEST"
+
+			flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+			flags.Bool("verbose", false, "")
+			flags.Duration("services.0.retryInterval", 0, "")
+			flags.StringSlice("services.1.dependencies", nil, "")
+			Expect(flags.Parse([]string{"--services.0.retryInterval=1m", "--services.1.dependencies=a,b"})).To(Succeed())
+			options.Flags = flags
+
+			configuration := &types.Configuration{}
+			Expect(system.LoadConfiguration(options, configuration)).To(Succeed())
+
+			Expect(configuration.Verbose).To(BeTrue())
+			Expect(configuration.Services[0].RetryInterval).To(Equal(time.Minute))
+			Expect(configuration.Services[1].Dependencies).To(Equal([]string{"a", "b"}))
+		})
+
+		It("overrides values from the flags set on another flag set", func() {
+			commandFlags := pflag.NewFlagSet("command", pflag.ContinueOnError)
+			commandFlags.Bool("debug", false, "")
+			commandFlags.String("data-dir", "", "")
+			Expect(commandFlags.Parse([]string{"--debug", "--data-dir=data"})).To(Succeed())
+
+			// Only the flags mapping to configuration keys are passed
+			flags := pflag.NewFlagSet("config", pflag.ContinueOnError)
+			flags.AddFlag(commandFlags.Lookup("debug"))
+			options.Flags = flags
+
+			configuration := &types.Configuration{}
+			Expect(system.LoadConfiguration(options, configuration)).To(Succeed())
+			Expect(configuration.Debug).To(BeTrue())
+		})
+	})
+
+	Describe("DecodeCustomConfig", func() {
+		type serverConfig struct {
+			Host    string        `json:"host"`
+			Port    int           `json:"port"`
+			Timeout time.Duration `json:"timeout"`
+		}
+
+		var factory *customConfigFactory
+
+		BeforeEach(func() {
+			factory = &customConfigFactory{ComponentFactoryInterface: &mocks.ComponentFactoryInterface{}}
+			factory.CustomConfigFactoryInterface.On("NewCustomConfig").Return(func() interface{} {
+				return &serverConfig{Host: "localhost"}
+			})
+		})
+
+		It("decodes the custom configuration into the declared type", func() {
+			customConfig, err := system.DecodeCustomConfig(factory, map[string]interface{}{"port": "8080", "timeout": "2s"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(customConfig).To(Equal(&serverConfig{Host: "localhost", Port: 8080, Timeout: 2 * time.Second}))
+		})
+
+		It("returns the defaults when there is no custom configuration", func() {
+			customConfig, err := system.DecodeCustomConfig(factory, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(customConfig).To(Equal(&serverConfig{Host: "localhost"}))
+		})
+
+		It("returns an error when the custom configuration does not match the type", func() {
+			_, err := system.DecodeCustomConfig(factory, map[string]interface{}{"port": "http"})
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("leaves the custom configuration of other factories untouched", func() {
+			customConfig := map[string]interface{}{"port": "8080"}
+			Expect(system.DecodeCustomConfig(&mocks.ComponentFactoryInterface{}, customConfig)).To(Equal(customConfig))
+		})
+	})
+})
+
+// customConfigFactory is a component factory declaring the type of its custom configuration.
+type customConfigFactory struct {
+	*mocks.ComponentFactoryInterface
+	mocks.CustomConfigFactoryInterface
+}
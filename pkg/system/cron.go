@@ -0,0 +1,211 @@
+package system
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cronField describes the values allowed in a field of a cron expression.
+type cronField struct {
+	name  string
+	min   int
+	max   int
+	names map[string]int
+}
+
+var (
+	monthNames = map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}
+
+	weekdayNames = map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}
+
+	// cronFields are the fields of a cron expression, in order. Sunday is either 0 or 7.
+	cronFields = [5]cronField{
+		{name: "minute", min: 0, max: 59},
+		{name: "hour", min: 0, max: 23},
+		{name: "day of month", min: 1, max: 31},
+		{name: "month", min: 1, max: 12, names: monthNames},
+		{name: "day of week", min: 0, max: 7, names: weekdayNames},
+	}
+
+	// cronDescriptors are the shorthands of common cron expressions.
+	cronDescriptors = map[string]string{
+		"@yearly":   "0 0 1 1 *",
+		"@annually": "0 0 1 1 *",
+		"@monthly":  "0 0 1 * *",
+		"@weekly":   "0 0 * * 0",
+		"@daily":    "0 0 * * *",
+		"@midnight": "0 0 * * *",
+		"@hourly":   "0 * * * *",
+	}
+)
+
+// maxCronSearch bounds the search of the next activation of a cron schedule,
+// so that expressions that never match, such as "0 0 31 2 *", do not loop forever.
+const maxCronSearch = 5 * 366 * 24 * time.Hour
+
+// CronSchedule represents the activation times described by a cron expression.
+type CronSchedule struct {
+	minutes    uint64
+	hours      uint64
+	days       uint64
+	months     uint64
+	weekdays   uint64
+	anyDay     bool // The day of month field is a wildcard
+	anyWeekday bool // The day of week field is a wildcard
+}
+
+// ParseCronExpression parses a standard cron expression made of five fields: minute, hour, day of month,
+// month and day of week. Fields accept wildcards, lists, ranges, steps, and month and day names.
+// The @yearly, @annually, @monthly, @weekly, @daily, @midnight and @hourly descriptors are also accepted.
+// As with cron, a time matches when either the day of month or the day of week matches if both are restricted.
+func ParseCronExpression(expression string) (*CronSchedule, error) {
+	expression = strings.TrimSpace(expression)
+	if descriptor, ok := cronDescriptors[strings.ToLower(expression)]; ok {
+		expression = descriptor
+	}
+
+	fields := strings.Fields(expression)
+	if len(fields) != len(cronFields) {
+		return nil, fmt.Errorf("cron expression %q must have %d fields, found %d", expression, len(cronFields), len(fields))
+	}
+
+	var (
+		bits      [len(cronFields)]uint64
+		wildcards [len(cronFields)]bool
+	)
+	for i, field := range fields {
+		var err error
+		if bits[i], wildcards[i], err = parseCronField(field, cronFields[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	// Sunday is both 0 and 7
+	if bits[4]&(1<<7) != 0 {
+		bits[4] = bits[4]&^(1<<7) | 1
+	}
+
+	return &CronSchedule{
+		minutes:    bits[0],
+		hours:      bits[1],
+		days:       bits[2],
+		months:     bits[3],
+		weekdays:   bits[4],
+		anyDay:     wildcards[2],
+		anyWeekday: wildcards[4],
+	}, nil
+}
+
+// Next returns the first activation strictly after the given time, in the location of the given time.
+// Returns the zero time if the schedule has no activation within the next five years.
+func (c *CronSchedule) Next(after time.Time) time.Time {
+	loc := after.Location()
+	t := after.Truncate(time.Minute).Add(time.Minute)
+	limit := t.Add(maxCronSearch)
+
+	for t.Before(limit) {
+		if c.months&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if !c.matchDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if c.hours&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+			continue
+		}
+		if c.minutes&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+
+	return time.Time{}
+}
+
+// matchDay checks if the day of the given time matches the day of month and day of week fields.
+func (c *CronSchedule) matchDay(t time.Time) bool {
+	day := c.days&(1<<uint(t.Day())) != 0
+	weekday := c.weekdays&(1<<uint(t.Weekday())) != 0
+	if c.anyDay || c.anyWeekday {
+		return day && weekday
+	}
+	return day || weekday
+}
+
+// parseCronField parses a field of a cron expression into the set of its values.
+// Also returns whether the field is a wildcard.
+func parseCronField(text string, field cronField) (uint64, bool, error) {
+	var bits uint64
+	wildcard := strings.HasPrefix(text, "*")
+
+	for _, part := range strings.Split(text, ",") {
+		rangeText, stepText, hasStep := strings.Cut(part, "/")
+
+		step := 1
+		if hasStep {
+			var err error
+			if step, err = strconv.Atoi(stepText); err != nil || step <= 0 {
+				return 0, false, fmt.Errorf("invalid step %q in %s field", stepText, field.name)
+			}
+		}
+
+		var low, high int
+		if rangeText == "*" {
+			low, high = field.min, field.max
+		} else {
+			lowText, highText, isRange := strings.Cut(rangeText, "-")
+
+			var err error
+			if low, err = parseCronValue(lowText, field); err != nil {
+				return 0, false, err
+			}
+			switch {
+			case isRange:
+				if high, err = parseCronValue(highText, field); err != nil {
+					return 0, false, err
+				}
+			case hasStep:
+				// A single value with a step, such as 5/15, runs until the end of the range
+				high = field.max
+			default:
+				high = low
+			}
+			if low > high {
+				return 0, false, fmt.Errorf("invalid range %q in %s field", rangeText, field.name)
+			}
+		}
+
+		for value := low; value <= high; value += step {
+			bits |= 1 << uint(value)
+		}
+	}
+
+	return bits, wildcard, nil
+}
+
+// parseCronValue parses a number or a name of a cron expression field.
+func parseCronValue(text string, field cronField) (int, error) {
+	if value, ok := field.names[strings.ToLower(text)]; ok {
+		return value, nil
+	}
+
+	value, err := strconv.Atoi(text)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q in %s field", text, field.name)
+	}
+	if value < field.min || value > field.max {
+		return 0, fmt.Errorf("value %d out of range [%d, %d] in %s field", value, field.min, field.max, field.name)
+	}
+	return value, nil
+}
@@ -0,0 +1,57 @@
+package system_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/ebanfa/skeleton/pkg/system"
+)
+
+var _ = Describe("CronSchedule", func() {
+	// at returns the given time of October 2026, in UTC. October 16, 2026 is a Friday.
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2026, time.October, day, hour, minute, 0, 0, time.UTC)
+	}
+
+	next := func(expression string, after time.Time) time.Time {
+		schedule, err := system.ParseCronExpression(expression)
+		Expect(err).NotTo(HaveOccurred())
+		return schedule.Next(after)
+	}
+
+	DescribeTable("Next",
+		func(expression string, after, expected time.Time) {
+			Expect(next(expression, after)).To(Equal(expected))
+		},
+		Entry("every minute", "* * * * *", at(16, 10, 7).Add(30*time.Second), at(16, 10, 8)),
+		Entry("a step", "*/15 * * * *", at(16, 10, 7), at(16, 10, 15)),
+		Entry("a step starting at a value", "5/20 * * * *", at(16, 10, 46), at(16, 11, 5)),
+		Entry("a list", "0 8,20 * * *", at(16, 10, 0), at(16, 20, 0)),
+		Entry("a range of day names", "0 9 * * mon-fri", at(16, 10, 0), at(19, 9, 0)),
+		Entry("Sunday as 7", "0 0 * * 7", at(16, 10, 0), at(18, 0, 0)),
+		Entry("a month name", "0 0 1 jan *", at(16, 10, 0), time.Date(2027, time.January, 1, 0, 0, 0, 0, time.UTC)),
+		Entry("either the day of month or the day of week", "0 0 20 * sat", at(16, 10, 0), at(17, 0, 0)),
+		Entry("a descriptor", "@daily", at(16, 10, 0), at(17, 0, 0)),
+		Entry("a time that never comes", "0 0 31 2 *", at(16, 10, 0), time.Time{}),
+	)
+
+	It("keeps the location of the given time", func() {
+		location := time.FixedZone("UTC+2", 2*60*60)
+		Expect(next("0 12 * * *", time.Date(2026, time.October, 16, 13, 0, 0, 0, location))).
+			To(Equal(time.Date(2026, time.October, 17, 12, 0, 0, 0, location)))
+	})
+
+	DescribeTable("ParseCronExpression rejects invalid expressions",
+		func(expression string) {
+			_, err := system.ParseCronExpression(expression)
+			Expect(err).To(HaveOccurred())
+		},
+		Entry("missing fields", "* * * *"),
+		Entry("a value out of range", "60 * * * *"),
+		Entry("a zero step", "*/0 * * * *"),
+		Entry("a reversed range", "0 5-1 * * *"),
+		Entry("an unknown name", "0 0 * * someday"),
+	)
+})
@@ -0,0 +1,350 @@
+package system
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ebanfa/skeleton/pkg/common"
+	"github.com/ebanfa/skeleton/pkg/types"
+)
+
+// deadLetterStoreName is the namespace of the dead-letter queue in the system multistore.
+const deadLetterStoreName = "deadletters"
+
+const defaultMaxDeadLetters = 1000
+
+// RedriveCountHeader is the header of a redriven event counting how many times it was redriven.
+const RedriveCountHeader = "redriveCount"
+
+var (
+	deadLetterEntryPrefix = []byte("entries/")
+	deadLetterNextKey     = []byte("next")
+)
+
+// DeadLetterQueueOptions represents the options of a dead-letter queue.
+type DeadLetterQueueOptions struct {
+	// Persistent keeps the dead letters in the system multistore, so that they survive restarts.
+	Persistent bool
+
+	// MaxEntries is the number of dead letters kept, the oldest being removed first, 1000 when zero.
+	MaxEntries int
+}
+
+// DeadLetterEntry represents an event in the dead-letter queue.
+type DeadLetterEntry struct {
+	// ID identifies the entry in the queue. IDs are increasing numbers, never reused.
+	ID string
+
+	common.DeadLetter
+}
+
+// deadLetterRecord represents a dead letter persisted in the store.
+type deadLetterRecord struct {
+	Event    eventRecord `json:"event"`
+	Topic    string      `json:"topic"`
+	Attempts int         `json:"attempts"`
+	Error    string      `json:"error"`
+	FailedAt time.Time   `json:"failedAt"`
+}
+
+// DeadLetterQueue is a service collecting the events whose handlers failed every attempt, as published
+// by the event bus on the dead-letter topic, so that operators can inspect them and redrive them once
+// the cause of the failure is fixed. The dead letters are kept in memory, and also in a namespace
+// of the system multistore when persistent.
+type DeadLetterQueue struct {
+	BaseSystemService
+	mutex        sync.Mutex
+	options      DeadLetterQueueOptions
+	store        types.Store
+	next         uint64             // Sequence of the next entry
+	entries      []*DeadLetterEntry // Entries, the oldest first
+	subscription common.Subscription
+}
+
+// NewDeadLetterQueue creates a new instance of DeadLetterQueue.
+func NewDeadLetterQueue(id string, options DeadLetterQueueOptions) *DeadLetterQueue {
+	if options.MaxEntries <= 0 {
+		options.MaxEntries = defaultMaxDeadLetters
+	}
+
+	return &DeadLetterQueue{
+		BaseSystemService: *NewBaseSystemService(id, "DeadLetterQueue", "Collects the events whose handlers failed"),
+		options:           options,
+	}
+}
+
+// Start loads the persisted dead letters, if any, and starts collecting the dead letters.
+// Returns an error if the queue is persistent and the system has no multistore.
+func (q *DeadLetterQueue) Start(ctx *common.Context) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	if q.System == nil {
+		return types.ErrSystemNotInitialized
+	}
+	if q.subscription != nil {
+		return nil
+	}
+
+	if q.options.Persistent {
+		if err := q.open(); err != nil {
+			return err
+		}
+	}
+
+	eventBus := q.System.EventBus()
+	if eventBus == nil {
+		return nil
+	}
+	subscription, err := eventBus.Subscribe(common.BusSubscriptionParams{
+		Topic:        common.DeadLetterTopic,
+		EventHandler: q.collect,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to subscribe to %s: %w", common.DeadLetterTopic, err)
+	}
+	q.subscription = subscription
+
+	return nil
+}
+
+// Stop stops collecting the dead letters. The collected dead letters can still be inspected.
+func (q *DeadLetterQueue) Stop(ctx *common.Context) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	if q.subscription != nil {
+		q.subscription.Unsubscribe()
+		q.subscription = nil
+	}
+	return nil
+}
+
+// Add adds a dead letter to the queue, removing the oldest entries beyond the maximum.
+func (q *DeadLetterQueue) Add(letter common.DeadLetter) (DeadLetterEntry, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	entry := &DeadLetterEntry{ID: strconv.FormatUint(q.next, 10), DeadLetter: letter}
+	if q.store != nil {
+		value, err := json.Marshal(&deadLetterRecord{
+			Event:    newEventRecord(letter.Event),
+			Topic:    letter.Topic,
+			Attempts: letter.Attempts,
+			Error:    letter.Error,
+			FailedAt: letter.FailedAt,
+		})
+		if err != nil {
+			return DeadLetterEntry{}, fmt.Errorf("failed to encode dead letter of event %s: %w", letter.Event.Type, err)
+		}
+		if err := q.store.Set(deadLetterKey(q.next), value); err != nil {
+			return DeadLetterEntry{}, fmt.Errorf("failed to store dead letter of event %s: %w", letter.Event.Type, err)
+		}
+		if err := q.store.Set(deadLetterNextKey, encodeOffset(q.next+1)); err != nil {
+			return DeadLetterEntry{}, fmt.Errorf("failed to store dead letter of event %s: %w", letter.Event.Type, err)
+		}
+	}
+	q.next++
+	q.entries = append(q.entries, entry)
+
+	for len(q.entries) > q.options.MaxEntries {
+		if err := q.delete(0); err != nil {
+			return DeadLetterEntry{}, err
+		}
+	}
+	if err := q.save(); err != nil {
+		return DeadLetterEntry{}, err
+	}
+
+	return *entry, nil
+}
+
+// List returns the dead letters in the queue, the oldest first.
+func (q *DeadLetterQueue) List() []DeadLetterEntry {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	entries := make([]DeadLetterEntry, 0, len(q.entries))
+	for _, entry := range q.entries {
+		entries = append(entries, *entry)
+	}
+	return entries
+}
+
+// Get returns the dead letter with the given ID.
+func (q *DeadLetterQueue) Get(id string) (DeadLetterEntry, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	index, err := q.find(id)
+	if err != nil {
+		return DeadLetterEntry{}, err
+	}
+	return *q.entries[index], nil
+}
+
+// Redrive removes a dead letter from the queue and publishes its event again on the system event bus,
+// incrementing its RedriveCountHeader header. Every subscriber of the topic receives the event again,
+// and a handler failing again dead-letters it under a new ID. The data of the events loaded from the
+// store is a json.RawMessage. Returns the published event.
+func (q *DeadLetterQueue) Redrive(id string) (common.Event, error) {
+	if q.System == nil {
+		return common.Event{}, types.ErrSystemNotInitialized
+	}
+
+	q.mutex.Lock()
+	index, err := q.find(id)
+	var event common.Event
+	if err == nil {
+		event = q.entries[index].Event
+		if err = q.delete(index); err == nil {
+			err = q.save()
+		}
+	}
+	q.mutex.Unlock()
+	if err != nil {
+		return common.Event{}, err
+	}
+
+	headers := make(map[string]string, len(event.Headers)+1)
+	for key, value := range event.Headers {
+		headers[key] = value
+	}
+	count, _ := strconv.Atoi(headers[RedriveCountHeader])
+	headers[RedriveCountHeader] = strconv.Itoa(count + 1)
+	event.Headers = headers
+
+	if eventBus := q.System.EventBus(); eventBus != nil {
+		eventBus.Publish(event)
+	}
+	return event, nil
+}
+
+// Remove removes a dead letter from the queue without redriving it.
+func (q *DeadLetterQueue) Remove(id string) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	index, err := q.find(id)
+	if err != nil {
+		return err
+	}
+	if err := q.delete(index); err != nil {
+		return err
+	}
+	return q.save()
+}
+
+// collect adds a dead letter published on the event bus to the queue.
+func (q *DeadLetterQueue) collect(event common.Event) {
+	letter, err := common.DecodeEvent(common.EventSchema[common.DeadLetter]{Topic: common.DeadLetterTopic}, event)
+	if err == nil {
+		_, err = q.Add(letter)
+	}
+	if err != nil {
+		if logger := q.System.Logger(); logger != nil {
+			logger.Log(common.LevelError, "Error collecting dead letter:", err)
+		}
+	}
+}
+
+// open creates or loads the store of the queue, then loads the persisted dead letters.
+// The caller must hold the mutex.
+func (q *DeadLetterQueue) open() error {
+	if q.store != nil {
+		return nil
+	}
+
+	multiStore := q.System.MultiStore()
+	if multiStore == nil {
+		return fmt.Errorf("%w: the persistent dead-letter queue needs a multistore", types.ErrStoreNotFound)
+	}
+	store, _, err := multiStore.CreateStore(deadLetterStoreName)
+	if err != nil {
+		return fmt.Errorf("failed to create dead-letter store: %w", err)
+	}
+	if _, err := store.Load(); err != nil {
+		return fmt.Errorf("failed to load dead-letter store: %w", err)
+	}
+
+	next, err := store.Get(deadLetterNextKey)
+	if err != nil {
+		return fmt.Errorf("failed to read dead letters: %w", err)
+	}
+	if next != nil {
+		q.next = decodeOffset(next)
+	}
+
+	var (
+		entries   []*DeadLetterEntry
+		decodeErr error
+	)
+	err = store.IterateRange(deadLetterEntryPrefix, prefixEnd(deadLetterEntryPrefix), true, func(key, value []byte) bool {
+		record := &deadLetterRecord{}
+		if decodeErr = json.Unmarshal(value, record); decodeErr != nil {
+			return true
+		}
+		entries = append(entries, &DeadLetterEntry{
+			ID: strconv.FormatUint(decodeOffset(key[len(deadLetterEntryPrefix):]), 10),
+			DeadLetter: common.DeadLetter{
+				Event:    record.Event.event(),
+				Topic:    record.Topic,
+				Attempts: record.Attempts,
+				Error:    record.Error,
+				FailedAt: record.FailedAt,
+			},
+		})
+		return false
+	})
+	if err == nil {
+		err = decodeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read dead letters: %w", err)
+	}
+
+	q.store = store
+	q.entries = entries
+	return nil
+}
+
+// find returns the index of the entry with the given ID. The caller must hold the mutex.
+func (q *DeadLetterQueue) find(id string) (int, error) {
+	for index, entry := range q.entries {
+		if entry.ID == id {
+			return index, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: %s", types.ErrDeadLetterNotFound, id)
+}
+
+// delete removes the entry at the given index, without saving the store. The caller must hold the mutex.
+func (q *DeadLetterQueue) delete(index int) error {
+	if q.store != nil {
+		sequence, _ := strconv.ParseUint(q.entries[index].ID, 10, 64)
+		if err := q.store.Delete(deadLetterKey(sequence)); err != nil {
+			return fmt.Errorf("failed to remove dead letter %s: %w", q.entries[index].ID, err)
+		}
+	}
+	q.entries = append(q.entries[:index], q.entries[index+1:]...)
+	return nil
+}
+
+// save saves the store, if any. The caller must hold the mutex.
+func (q *DeadLetterQueue) save() error {
+	if q.store == nil {
+		return nil
+	}
+	if err := saveVersion(q.store); err != nil {
+		return fmt.Errorf("failed to save dead letters: %w", err)
+	}
+	return nil
+}
+
+// deadLetterKey returns the key of the dead letter with the given sequence.
+func deadLetterKey(sequence uint64) []byte {
+	return append(append([]byte(nil), deadLetterEntryPrefix...), encodeOffset(sequence)...)
+}
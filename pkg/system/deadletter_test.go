@@ -0,0 +1,209 @@
+package system_test
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/ebanfa/skeleton/pkg/common"
+	"github.com/ebanfa/skeleton/pkg/db"
+	"github.com/ebanfa/skeleton/pkg/mocks"
+	"github.com/ebanfa/skeleton/pkg/store"
+	"github.com/ebanfa/skeleton/pkg/system"
+	"github.com/ebanfa/skeleton/pkg/types"
+)
+
+var _ = Describe("DeadLetterQueue", func() {
+	var (
+		ctx        *common.Context
+		dir        string
+		eventBus   common.EventBusInterface
+		multiStore types.MultiStore
+		mockSystem *mocks.SystemInterface
+		options    system.DeadLetterQueueOptions
+		queue      *system.DeadLetterQueue
+		failing    bool
+		received   chan common.Event
+	)
+
+	// start creates and starts a queue, on the store of the data directory when persistent, as after a restart.
+	start := func() {
+		if options.Persistent {
+			if multiStore != nil {
+				Expect(multiStore.Close()).To(Succeed())
+			}
+
+			var err error
+			multiStore, err = store.CreateMultiStore("system", dir, store.NewStoreFactory(dir, db.NewIAVLDatabaseFactory()))
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		queue = system.NewDeadLetterQueue("deadletters", options)
+		Expect(queue.Initialize(ctx, mockSystem)).To(Succeed())
+		Expect(queue.Start(ctx)).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		ctx = common.Background()
+		dir = GinkgoT().TempDir()
+		eventBus = common.NewSystemEventBus()
+		options = system.DeadLetterQueueOptions{}
+		multiStore = nil
+		failing = true
+		received = make(chan common.Event, 10)
+
+		mockSystem = &mocks.SystemInterface{}
+		mockSystem.On("EventBus").Return(func() common.EventBusInterface { return eventBus })
+		mockSystem.On("Logger").Return(nil)
+		mockSystem.On("MultiStore").Return(func() types.MultiStore { return multiStore })
+
+		// The retries of the handler run on their own goroutine, awaited with WaitAsync
+		Expect(eventBus.Subscribe(common.BusSubscriptionParams{
+			Topic: "order.>",
+			ErrorHandler: func(event common.Event) error {
+				if failing {
+					return errors.New("database unavailable")
+				}
+				received <- event
+				return nil
+			},
+			Retry: &common.RetryPolicy{MaxAttempts: 2, Backoff: time.Millisecond},
+		})).Error().NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		if queue != nil {
+			Expect(queue.Stop(ctx)).To(Succeed())
+		}
+		if multiStore != nil {
+			Expect(multiStore.Close()).To(Succeed())
+		}
+	})
+
+	It("requires a system, and a store when persistent", func() {
+		Expect(system.NewDeadLetterQueue("deadletters", options).Start(ctx)).To(MatchError(types.ErrSystemNotInitialized))
+
+		persistent := system.NewDeadLetterQueue("deadletters", system.DeadLetterQueueOptions{Persistent: true})
+		Expect(persistent.Initialize(ctx, mockSystem)).To(Succeed())
+		Expect(persistent.Start(ctx)).To(MatchError(types.ErrStoreNotFound))
+		queue = nil
+	})
+
+	Context("when started", func() {
+		JustBeforeEach(func() {
+			start()
+		})
+
+		It("collects the events whose handlers failed every attempt", func() {
+			published := common.NewEvent(nil, "api", "order.created", "order-1")
+			eventBus.Publish(published)
+			eventBus.WaitAsync()
+
+			entries := queue.List()
+			Expect(entries).To(HaveLen(1))
+			Expect(entries[0].ID).To(Equal("0"))
+			Expect(entries[0].Event.ID).To(Equal(published.ID))
+			Expect(entries[0].Event.Data).To(Equal("order-1"))
+			Expect(entries[0].Topic).To(Equal("order.>"))
+			Expect(entries[0].Attempts).To(Equal(2))
+			Expect(entries[0].Error).To(Equal("database unavailable"))
+
+			entry, err := queue.Get("0")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(entry).To(Equal(entries[0]))
+			Expect(queue.Get("1")).Error().To(MatchError(types.ErrDeadLetterNotFound))
+		})
+
+		It("redrives the dead letters, counting the redrives", func() {
+			eventBus.Publish(common.NewEvent(nil, "api", "order.created", "order-1"))
+			eventBus.WaitAsync()
+
+			redriven, err := queue.Redrive("0")
+			eventBus.WaitAsync()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(redriven.Headers).To(HaveKeyWithValue(system.RedriveCountHeader, "1"))
+
+			// Failing again, the event is dead-lettered under a new ID
+			entries := queue.List()
+			Expect(entries).To(HaveLen(1))
+			Expect(entries[0].ID).To(Equal("1"))
+
+			failing = false
+			redriven, err = queue.Redrive("1")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(redriven.Headers).To(HaveKeyWithValue(system.RedriveCountHeader, "2"))
+			Expect(received).To(Receive(HaveField("Data", "order-1")))
+			Expect(queue.List()).To(BeEmpty())
+
+			Expect(queue.Redrive("1")).Error().To(MatchError(types.ErrDeadLetterNotFound))
+		})
+
+		It("removes the dead letters", func() {
+			eventBus.Publish(common.Event{Type: "order.created"})
+			eventBus.WaitAsync()
+
+			Expect(queue.Remove("0")).To(Succeed())
+			Expect(queue.List()).To(BeEmpty())
+			Expect(queue.Remove("0")).To(MatchError(types.ErrDeadLetterNotFound))
+		})
+
+		It("stops collecting once stopped", func() {
+			Expect(queue.Stop(ctx)).To(Succeed())
+
+			eventBus.Publish(common.Event{Type: "order.created"})
+			eventBus.WaitAsync()
+			Expect(queue.List()).To(BeEmpty())
+		})
+
+		Context("with a maximum number of entries", func() {
+			BeforeEach(func() {
+				options.MaxEntries = 2
+			})
+
+			It("removes the oldest entries", func() {
+				for _, id := range []string{"a", "b", "c"} {
+					eventBus.Publish(common.Event{Type: "order.created", ID: id})
+					eventBus.WaitAsync()
+				}
+
+				entries := queue.List()
+				Expect(entries).To(HaveLen(2))
+				Expect(entries[0].Event.ID).To(Equal("b"))
+				Expect(entries[1].Event.ID).To(Equal("c"))
+			})
+		})
+
+		Context("when persistent", func() {
+			BeforeEach(func() {
+				options.Persistent = true
+			})
+
+			It("keeps the dead letters after a restart", func() {
+				eventBus.Publish(common.NewEvent(nil, "api", "order.created", map[string]string{"order": "1"}))
+				eventBus.WaitAsync()
+				eventBus.Publish(common.Event{Type: "order.cancelled"})
+				eventBus.WaitAsync()
+				Expect(queue.Remove("1")).To(Succeed())
+
+				Expect(queue.Stop(ctx)).To(Succeed())
+				start()
+
+				entries := queue.List()
+				Expect(entries).To(HaveLen(1))
+				Expect(entries[0].ID).To(Equal("0"))
+				Expect(entries[0].Event.Type).To(Equal("order.created"))
+				Expect(entries[0].Event.Source).To(Equal("api"))
+				Expect(entries[0].Event.Data).To(Equal(json.RawMessage(`{"order":"1"}`)))
+				Expect(entries[0].Attempts).To(Equal(2))
+
+				// The IDs are not reused after a restart
+				eventBus.Publish(common.Event{Type: "order.created"})
+				eventBus.WaitAsync()
+				Expect(queue.List()[1].ID).To(Equal("2"))
+			})
+		})
+	})
+})
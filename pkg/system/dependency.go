@@ -0,0 +1,307 @@
+package system
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ebanfa/skeleton/pkg/types"
+)
+
+// DependencyGraph is a directed graph of components and the components they depend on.
+type DependencyGraph struct {
+	mutex sync.RWMutex
+	nodes map[string][]string // Map of node ID to the IDs of its dependencies
+}
+
+// NewDependencyGraph creates a new, empty instance of DependencyGraph.
+func NewDependencyGraph() *DependencyGraph {
+	return &DependencyGraph{
+		nodes: make(map[string][]string),
+	}
+}
+
+// AddNode adds a node with the given dependencies to the graph.
+// Adding an existing node replaces its dependencies.
+func (g *DependencyGraph) AddNode(id string, dependencies ...string) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	g.nodes[id] = append([]string(nil), dependencies...)
+}
+
+// RemoveNode removes the node with the given ID from the graph.
+func (g *DependencyGraph) RemoveNode(id string) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	delete(g.nodes, id)
+}
+
+// HasNode checks if a node with the given ID is part of the graph.
+func (g *DependencyGraph) HasNode(id string) bool {
+	g.mutex.RLock()
+	defer g.mutex.RUnlock()
+
+	_, exists := g.nodes[id]
+	return exists
+}
+
+// Nodes returns the IDs of all nodes in the graph, sorted alphabetically.
+func (g *DependencyGraph) Nodes() []string {
+	g.mutex.RLock()
+	defer g.mutex.RUnlock()
+
+	return g.sortedNodes()
+}
+
+// Dependencies returns the IDs of the nodes the node with the given ID depends on.
+func (g *DependencyGraph) Dependencies(id string) []string {
+	g.mutex.RLock()
+	defer g.mutex.RUnlock()
+
+	return append([]string(nil), g.nodes[id]...)
+}
+
+// Dependents returns the IDs of the nodes that depend on the node with the given ID.
+func (g *DependencyGraph) Dependents(id string) []string {
+	g.mutex.RLock()
+	defer g.mutex.RUnlock()
+
+	return g.dependents()[id]
+}
+
+// Validate checks that every dependency can be resolved and that the graph has no cycles.
+// A dependency that is not a node of the graph is resolved using the exists function,
+// which may be nil if every dependency is expected to be a node.
+func (g *DependencyGraph) Validate(exists func(id string) bool) error {
+	g.mutex.RLock()
+	defer g.mutex.RUnlock()
+
+	// Report every unresolved dependency at once
+	var errs []error
+	for _, id := range g.sortedNodes() {
+		for _, dependency := range g.nodes[id] {
+			if _, ok := g.nodes[dependency]; ok {
+				continue
+			}
+			if exists != nil && exists(dependency) {
+				continue
+			}
+			errs = append(errs, fmt.Errorf("%w: %s depends on %s", types.ErrDependencyNotFound, id, dependency))
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	if cycle := g.findCycle(); cycle != nil {
+		return fmt.Errorf("%w: %s", types.ErrDependencyCycle, strings.Join(cycle, " -> "))
+	}
+
+	return nil
+}
+
+// TopologicalOrder returns the IDs of all nodes ordered so that every node comes after its dependencies.
+// Returns an error if the graph contains a cycle.
+func (g *DependencyGraph) TopologicalOrder() ([]string, error) {
+	g.mutex.RLock()
+	defer g.mutex.RUnlock()
+
+	if cycle := g.findCycle(); cycle != nil {
+		return nil, fmt.Errorf("%w: %s", types.ErrDependencyCycle, strings.Join(cycle, " -> "))
+	}
+
+	order := make([]string, 0, len(g.nodes))
+	visited := make(map[string]bool, len(g.nodes))
+
+	var visit func(id string)
+	visit = func(id string) {
+		if visited[id] {
+			return
+		}
+		visited[id] = true
+		for _, dependency := range g.nodes[id] {
+			if _, ok := g.nodes[dependency]; ok {
+				visit(dependency)
+			}
+		}
+		order = append(order, id)
+	}
+
+	for _, id := range g.sortedNodes() {
+		visit(id)
+	}
+
+	return order, nil
+}
+
+// Walk calls fn for every node of the graph, running independent nodes concurrently.
+// When reverse is false a node is visited once all of its dependencies have been visited
+// successfully; nodes whose dependencies failed are skipped and reported as failed.
+// When reverse is true a node is visited once all of its dependents have been visited,
+// regardless of whether they succeeded.
+// Returns the errors of all failed nodes joined together.
+func (g *DependencyGraph) Walk(reverse bool, fn func(id string) error) error {
+	order, err := g.TopologicalOrder()
+	if err != nil {
+		return err
+	}
+
+	g.mutex.RLock()
+	var prerequisites map[string][]string
+	if reverse {
+		prerequisites = g.dependents()
+	} else {
+		prerequisites = g.resolvedDependencies()
+	}
+	g.mutex.RUnlock()
+
+	done := make(map[string]chan struct{}, len(order))
+	for _, id := range order {
+		done[id] = make(chan struct{})
+	}
+
+	var (
+		mutex sync.Mutex
+		wg    sync.WaitGroup
+	)
+	results := make(map[string]error, len(order))
+
+	for _, id := range order {
+		wg.Add(1)
+		go func(id string) {
+			defer wg.Done()
+			defer close(done[id])
+
+			// Wait for the prerequisites of this node to complete
+			for _, prerequisite := range prerequisites[id] {
+				<-done[prerequisite]
+			}
+
+			if !reverse {
+				mutex.Lock()
+				for _, prerequisite := range prerequisites[id] {
+					if results[prerequisite] != nil {
+						results[id] = fmt.Errorf("%w: %s requires %s", types.ErrDependencyFailed, id, prerequisite)
+						break
+					}
+				}
+				skip := results[id] != nil
+				mutex.Unlock()
+
+				if skip {
+					return
+				}
+			}
+
+			err := fn(id)
+
+			mutex.Lock()
+			results[id] = err
+			mutex.Unlock()
+		}(id)
+	}
+	wg.Wait()
+
+	var errs []error
+	for _, id := range order {
+		if results[id] != nil {
+			errs = append(errs, results[id])
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// sortedNodes returns the node IDs sorted alphabetically. The caller must hold the mutex.
+func (g *DependencyGraph) sortedNodes() []string {
+	ids := make([]string, 0, len(g.nodes))
+	for id := range g.nodes {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// resolvedDependencies returns a map of node ID to the IDs of the nodes it depends on,
+// leaving out dependencies that are not part of the graph. The caller must hold the mutex.
+func (g *DependencyGraph) resolvedDependencies() map[string][]string {
+	dependencies := make(map[string][]string, len(g.nodes))
+	for id, nodeDependencies := range g.nodes {
+		for _, dependency := range nodeDependencies {
+			if _, ok := g.nodes[dependency]; ok {
+				dependencies[id] = append(dependencies[id], dependency)
+			}
+		}
+	}
+	return dependencies
+}
+
+// dependents returns a map of node ID to the IDs of the nodes depending on it.
+// The caller must hold the mutex.
+func (g *DependencyGraph) dependents() map[string][]string {
+	dependents := make(map[string][]string, len(g.nodes))
+	for _, id := range g.sortedNodes() {
+		for _, dependency := range g.nodes[id] {
+			if _, ok := g.nodes[dependency]; ok {
+				dependents[dependency] = append(dependents[dependency], id)
+			}
+		}
+	}
+	return dependents
+}
+
+// findCycle returns the IDs of the nodes forming a cycle, starting and ending with the same node,
+// or nil if the graph is acyclic. The caller must hold the mutex.
+func (g *DependencyGraph) findCycle() []string {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+
+	state := make(map[string]int, len(g.nodes))
+	var path []string
+
+	var visit func(id string) []string
+	visit = func(id string) []string {
+		state[id] = visiting
+		path = append(path, id)
+
+		for _, dependency := range g.nodes[id] {
+			if _, ok := g.nodes[dependency]; !ok {
+				continue
+			}
+			switch state[dependency] {
+			case visiting:
+				// Extract the cycle from the current path
+				for i, node := range path {
+					if node == dependency {
+						return append(append([]string(nil), path[i:]...), dependency)
+					}
+				}
+			case unvisited:
+				if cycle := visit(dependency); cycle != nil {
+					return cycle
+				}
+			}
+		}
+
+		path = path[:len(path)-1]
+		state[id] = visited
+		return nil
+	}
+
+	for _, id := range g.sortedNodes() {
+		if state[id] == unvisited {
+			if cycle := visit(id); cycle != nil {
+				return cycle
+			}
+		}
+	}
+
+	return nil
+}
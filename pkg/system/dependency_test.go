@@ -0,0 +1,191 @@
+package system_test
+
+import (
+	"errors"
+	"sync"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/ebanfa/skeleton/pkg/system"
+	"github.com/ebanfa/skeleton/pkg/types"
+)
+
+var _ = Describe("DependencyGraph", func() {
+	var (
+		graph *system.DependencyGraph
+	)
+
+	BeforeEach(func() {
+		graph = system.NewDependencyGraph()
+	})
+
+	Describe("AddNode and RemoveNode", func() {
+		It("tracks nodes and their dependencies", func() {
+			graph.AddNode("b", "a")
+			graph.AddNode("a")
+
+			Expect(graph.Nodes()).To(Equal([]string{"a", "b"}))
+			Expect(graph.HasNode("a")).To(BeTrue())
+			Expect(graph.Dependencies("b")).To(Equal([]string{"a"}))
+			Expect(graph.Dependents("a")).To(Equal([]string{"b"}))
+
+			graph.RemoveNode("b")
+			Expect(graph.HasNode("b")).To(BeFalse())
+			Expect(graph.Dependents("a")).To(BeEmpty())
+		})
+	})
+
+	Describe("Validate", func() {
+		It("succeeds for an acyclic graph", func() {
+			graph.AddNode("a")
+			graph.AddNode("b", "a")
+			graph.AddNode("c", "a", "b")
+
+			Expect(graph.Validate(nil)).To(Succeed())
+		})
+
+		It("reports every missing dependency", func() {
+			graph.AddNode("a", "x")
+			graph.AddNode("b", "y")
+
+			err := graph.Validate(nil)
+			Expect(err).To(MatchError(types.ErrDependencyNotFound))
+			Expect(err.Error()).To(ContainSubstring("a depends on x"))
+			Expect(err.Error()).To(ContainSubstring("b depends on y"))
+		})
+
+		It("accepts dependencies resolved outside the graph", func() {
+			graph.AddNode("a", "external")
+
+			err := graph.Validate(func(id string) bool { return id == "external" })
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("reports a cycle listing its nodes", func() {
+			graph.AddNode("a", "c")
+			graph.AddNode("b", "a")
+			graph.AddNode("c", "b")
+
+			err := graph.Validate(nil)
+			Expect(err).To(MatchError(types.ErrDependencyCycle))
+			Expect(err.Error()).To(ContainSubstring("a -> c -> b -> a"))
+		})
+
+		It("reports a node depending on itself", func() {
+			graph.AddNode("a", "a")
+
+			err := graph.Validate(nil)
+			Expect(err).To(MatchError(types.ErrDependencyCycle))
+			Expect(err.Error()).To(ContainSubstring("a -> a"))
+		})
+	})
+
+	Describe("TopologicalOrder", func() {
+		It("orders nodes after their dependencies", func() {
+			graph.AddNode("app", "db", "cache")
+			graph.AddNode("cache", "db")
+			graph.AddNode("db")
+
+			order, err := graph.TopologicalOrder()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(order).To(Equal([]string{"db", "cache", "app"}))
+		})
+
+		It("returns an error for a cyclic graph", func() {
+			graph.AddNode("a", "b")
+			graph.AddNode("b", "a")
+
+			_, err := graph.TopologicalOrder()
+			Expect(err).To(MatchError(types.ErrDependencyCycle))
+		})
+	})
+
+	Describe("Walk", func() {
+		var (
+			mutex   sync.Mutex
+			visited []string
+			record  func(id string) error
+		)
+
+		BeforeEach(func() {
+			visited = nil
+			record = func(id string) error {
+				mutex.Lock()
+				defer mutex.Unlock()
+				visited = append(visited, id)
+				return nil
+			}
+
+			graph.AddNode("db")
+			graph.AddNode("cache", "db")
+			graph.AddNode("queue", "db")
+			graph.AddNode("app", "cache", "queue")
+		})
+
+		It("visits nodes after their dependencies", func() {
+			Expect(graph.Walk(false, record)).To(Succeed())
+
+			Expect(visited).To(HaveLen(4))
+			Expect(visited[0]).To(Equal("db"))
+			Expect(visited[1:3]).To(ConsistOf("cache", "queue"))
+			Expect(visited[3]).To(Equal("app"))
+		})
+
+		It("visits nodes after their dependents in reverse", func() {
+			Expect(graph.Walk(true, record)).To(Succeed())
+
+			Expect(visited).To(HaveLen(4))
+			Expect(visited[0]).To(Equal("app"))
+			Expect(visited[1:3]).To(ConsistOf("cache", "queue"))
+			Expect(visited[3]).To(Equal("db"))
+		})
+
+		It("visits independent nodes concurrently", func() {
+			release := make(chan struct{})
+			var entered sync.WaitGroup
+			entered.Add(2)
+
+			done := make(chan error)
+			go func() {
+				done <- graph.Walk(false, func(id string) error {
+					if id == "cache" || id == "queue" {
+						entered.Done()
+						<-release
+					}
+					return nil
+				})
+			}()
+
+			// Both branches must be running at the same time for this to return
+			entered.Wait()
+			close(release)
+			Eventually(done).Should(Receive(BeNil()))
+		})
+
+		It("skips the dependents of a failed node", func() {
+			err := graph.Walk(false, func(id string) error {
+				if id == "cache" {
+					return errors.New("cache failed")
+				}
+				return record(id)
+			})
+
+			Expect(err).To(MatchError(ContainSubstring("cache failed")))
+			Expect(err).To(MatchError(types.ErrDependencyFailed))
+			Expect(visited).To(ConsistOf("db", "queue"))
+		})
+
+		It("keeps visiting dependencies of a failed node in reverse", func() {
+			err := graph.Walk(true, func(id string) error {
+				if id == "app" {
+					return errors.New("app failed")
+				}
+				return record(id)
+			})
+
+			Expect(err).To(MatchError(ContainSubstring("app failed")))
+			Expect(visited).To(ConsistOf("cache", "queue", "db"))
+		})
+	})
+})
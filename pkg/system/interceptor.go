@@ -0,0 +1,124 @@
+package system
+
+import (
+	"fmt"
+	"runtime/debug"
+	"time"
+
+	"github.com/ebanfa/skeleton/pkg/common"
+	"github.com/ebanfa/skeleton/pkg/types"
+)
+
+// ChainOperationInterceptors creates a single interceptor out of a chain of interceptors.
+// The first interceptor is the outermost, the last one calls the operation.
+func ChainOperationInterceptors(interceptors ...types.OperationInterceptor) types.OperationInterceptor {
+	// Copy the interceptors so that the chain is not affected by later additions
+	chain := append([]types.OperationInterceptor(nil), interceptors...)
+
+	return func(ctx *common.Context, input *types.SystemOperationInput, info *types.OperationInfo, handler types.OperationHandler) (*types.SystemOperationOutput, error) {
+		return chainHandler(chain, info, handler)(ctx, input)
+	}
+}
+
+// chainHandler returns the handler calling the interceptors of the chain in order, then the final handler.
+func chainHandler(chain []types.OperationInterceptor, info *types.OperationInfo, handler types.OperationHandler) types.OperationHandler {
+	if len(chain) == 0 {
+		return handler
+	}
+
+	return func(ctx *common.Context, input *types.SystemOperationInput) (*types.SystemOperationOutput, error) {
+		return chain[0](ctx, input, info, chainHandler(chain[1:], info, handler))
+	}
+}
+
+// RecoveryInterceptor returns an interceptor turning the panics of operations into errors wrapping
+// ErrOperationPanicked. The stack of the panic is logged when a logger is given.
+func RecoveryInterceptor(logger common.LoggerInterface) types.OperationInterceptor {
+	return func(ctx *common.Context, input *types.SystemOperationInput, info *types.OperationInfo, handler types.OperationHandler) (output *types.SystemOperationOutput, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				if logger != nil {
+					logger.Logf(common.LevelError, "operation=%s panic=%v\n%s", info.OperationID, r, debug.Stack())
+				}
+				output = nil
+				err = fmt.Errorf("%w: operation %s: %v", types.ErrOperationPanicked, info.OperationID, r)
+			}
+		}()
+
+		return handler(ctx, input)
+	}
+}
+
+// LoggingInterceptor returns an interceptor logging the input, output, error and duration of
+// every operation execution at the given level. Failed executions are logged at the error level.
+func LoggingInterceptor(logger common.LoggerInterface, level common.Level) types.OperationInterceptor {
+	return func(ctx *common.Context, input *types.SystemOperationInput, info *types.OperationInfo, handler types.OperationHandler) (*types.SystemOperationOutput, error) {
+		start := time.Now()
+		output, err := handler(ctx, input)
+		duration := time.Since(start)
+
+		if err != nil {
+			logger.Logf(common.LevelError, "operation=%s duration=%s input=%v error=%q",
+				info.OperationID, duration, operationData(input), err)
+		} else {
+			logger.Logf(level, "operation=%s duration=%s input=%v output=%v",
+				info.OperationID, duration, operationData(input), operationData(output))
+		}
+
+		return output, err
+	}
+}
+
+// TimeoutInterceptor returns an interceptor bounding the execution of operations to the given timeout.
+// The operation receives a context expiring after the timeout; if it has not returned by then, an error
+// wrapping ErrOperationTimeout is returned and its eventual result is discarded.
+func TimeoutInterceptor(timeout time.Duration) types.OperationInterceptor {
+	type result struct {
+		output *types.SystemOperationOutput
+		err    error
+		panic  interface{}
+	}
+
+	return func(ctx *common.Context, input *types.SystemOperationInput, info *types.OperationInfo, handler types.OperationHandler) (*types.SystemOperationOutput, error) {
+		timeoutCtx, cancel := common.WithTimeout(ctx, timeout)
+		defer cancel()
+
+		results := make(chan result, 1)
+		go func() {
+			// Panics are raised again in the calling goroutine, where they can be recovered
+			defer func() {
+				if r := recover(); r != nil {
+					results <- result{panic: r}
+				}
+			}()
+
+			output, err := handler(timeoutCtx, input)
+			results <- result{output: output, err: err}
+		}()
+
+		select {
+		case r := <-results:
+			if r.panic != nil {
+				panic(r.panic)
+			}
+			return r.output, r.err
+		case <-timeoutCtx.Done():
+			return nil, fmt.Errorf("%w: operation %s: %w", types.ErrOperationTimeout, info.OperationID, timeoutCtx.Err())
+		}
+	}
+}
+
+// operationData returns the data of an operation input or output, nil if there is none.
+func operationData(value interface{}) interface{} {
+	switch v := value.(type) {
+	case *types.SystemOperationInput:
+		if v != nil {
+			return v.Data
+		}
+	case *types.SystemOperationOutput:
+		if v != nil {
+			return v.Data
+		}
+	}
+	return nil
+}
@@ -0,0 +1,169 @@
+package system_test
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/ebanfa/skeleton/pkg/common"
+	"github.com/ebanfa/skeleton/pkg/component"
+	"github.com/ebanfa/skeleton/pkg/mocks"
+	systemApi "github.com/ebanfa/skeleton/pkg/system"
+	"github.com/ebanfa/skeleton/pkg/types"
+)
+
+var _ = Describe("Operation interceptors", func() {
+	var (
+		ctx       *common.Context
+		sys       *systemApi.SystemImpl
+		operation *mocks.SystemOperationInterface
+		input     *types.SystemOperationInput
+		calls     []string
+	)
+
+	// recording returns an interceptor recording the calls before and after the operation.
+	recording := func(name string) types.OperationInterceptor {
+		return func(ctx *common.Context, input *types.SystemOperationInput, info *types.OperationInfo, handler types.OperationHandler) (*types.SystemOperationOutput, error) {
+			calls = append(calls, name+" before "+info.OperationID)
+			output, err := handler(ctx, input)
+			calls = append(calls, name+" after")
+			return output, err
+		}
+	}
+
+	BeforeEach(func() {
+		ctx = common.Background()
+		input = &types.SystemOperationInput{Data: "in"}
+		calls = nil
+
+		operation = &mocks.SystemOperationInterface{}
+		operation.On("ID").Return("operation")
+		operation.On("Type").Return(types.OperationType)
+
+		registrar := component.NewComponentRegistrar()
+		factory := &mocks.ComponentFactoryInterface{}
+		factory.On("CreateComponent", mock.Anything).Return(operation, nil)
+		Expect(registrar.RegisterFactory(ctx, "operationFactory", factory)).To(Succeed())
+		_, err := registrar.CreateComponent(ctx, &types.ComponentConfig{ID: "operation", FactoryID: "operationFactory"})
+		Expect(err).NotTo(HaveOccurred())
+
+		sys = systemApi.NewSystem(nil, nil, &types.Configuration{}, nil, registrar, nil)
+	})
+
+	Describe("ExecuteOperation", func() {
+		It("runs the interceptors in the order they are added", func() {
+			operation.On("Execute", ctx, input).Run(func(mock.Arguments) {
+				calls = append(calls, "execute")
+			}).Return(&types.SystemOperationOutput{Data: "out"}, nil)
+
+			sys.AddOperationInterceptors(recording("first"))
+			sys.AddOperationInterceptors(recording("second"))
+
+			output, err := sys.ExecuteOperation(ctx, "operation", input)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(output.Data).To(Equal("out"))
+			Expect(calls).To(Equal([]string{
+				"first before operation", "second before operation", "execute", "second after", "first after",
+			}))
+		})
+
+		It("lets an interceptor skip the operation", func() {
+			sys.AddOperationInterceptors(func(ctx *common.Context, input *types.SystemOperationInput, info *types.OperationInfo, handler types.OperationHandler) (*types.SystemOperationOutput, error) {
+				return nil, errors.New("unauthorized")
+			})
+
+			_, err := sys.ExecuteOperation(ctx, "operation", input)
+			Expect(err).To(MatchError("unauthorized"))
+			operation.AssertNotCalled(GinkgoT(), "Execute", mock.Anything, mock.Anything)
+		})
+	})
+
+	Describe("RecoveryInterceptor", func() {
+		It("turns a panic into an error", func() {
+			operation.On("Execute", ctx, input).Run(func(mock.Arguments) {
+				panic("boom")
+			}).Return(nil, nil)
+
+			logger := &mocks.LoggerInterface{}
+			logger.On("Logf", common.LevelError, mock.Anything, "operation", "boom", mock.Anything).Return()
+			sys.AddOperationInterceptors(systemApi.RecoveryInterceptor(logger))
+
+			output, err := sys.ExecuteOperation(ctx, "operation", input)
+			Expect(output).To(BeNil())
+			Expect(err).To(MatchError(types.ErrOperationPanicked))
+			Expect(err.Error()).To(Equal("operation panicked: operation operation: boom"))
+			logger.AssertCalled(GinkgoT(), "Logf", common.LevelError, mock.Anything, "operation", "boom", mock.Anything)
+		})
+	})
+
+	Describe("LoggingInterceptor", func() {
+		var (
+			logger  *mocks.LoggerInterface
+			entries []string
+		)
+
+		BeforeEach(func() {
+			entries = nil
+			logger = &mocks.LoggerInterface{}
+			logger.On("Logf", mock.Anything, mock.Anything, "operation", mock.AnythingOfType("time.Duration"), "in", mock.Anything).Run(func(args mock.Arguments) {
+				entries = append(entries, args.Get(0).(common.Level).String()+" "+args.String(1))
+			}).Return()
+			sys.AddOperationInterceptors(systemApi.LoggingInterceptor(logger, common.LevelInfo))
+		})
+
+		It("logs the input, output and duration of the operation", func() {
+			operation.On("Execute", ctx, input).Return(&types.SystemOperationOutput{Data: "out"}, nil)
+
+			_, err := sys.ExecuteOperation(ctx, "operation", input)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(entries).To(Equal([]string{common.LevelInfo.String() + " operation=%s duration=%s input=%v output=%v"}))
+			logger.AssertCalled(GinkgoT(), "Logf", common.LevelInfo, mock.Anything, "operation", mock.AnythingOfType("time.Duration"), "in", "out")
+		})
+
+		It("logs the errors at the error level", func() {
+			operation.On("Execute", ctx, input).Return(nil, errors.New("failure"))
+
+			_, err := sys.ExecuteOperation(ctx, "operation", input)
+			Expect(err).To(MatchError("failure"))
+			Expect(entries).To(Equal([]string{common.LevelError.String() + " operation=%s duration=%s input=%v error=%q"}))
+		})
+	})
+
+	Describe("TimeoutInterceptor", func() {
+		BeforeEach(func() {
+			sys.AddOperationInterceptors(systemApi.TimeoutInterceptor(20 * time.Millisecond))
+		})
+
+		It("returns an error when the operation takes too long", func() {
+			operation.On("Execute", mock.Anything, input).Run(func(args mock.Arguments) {
+				<-args.Get(0).(*common.Context).Done()
+				time.Sleep(10 * time.Millisecond)
+			}).Return(&types.SystemOperationOutput{}, nil)
+
+			_, err := sys.ExecuteOperation(ctx, "operation", input)
+			Expect(err).To(MatchError(types.ErrOperationTimeout))
+			Expect(err).To(MatchError(context.DeadlineExceeded))
+		})
+
+		It("returns the result of an operation completing in time", func() {
+			operation.On("Execute", mock.Anything, input).Return(&types.SystemOperationOutput{Data: "out"}, nil)
+
+			output, err := sys.ExecuteOperation(ctx, "operation", input)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(output.Data).To(Equal("out"))
+		})
+
+		It("raises the panics of the operation in the calling goroutine", func() {
+			operation.On("Execute", mock.Anything, input).Run(func(mock.Arguments) {
+				panic("boom")
+			}).Return(nil, nil)
+
+			Expect(func() { sys.ExecuteOperation(ctx, "operation", input) }).To(PanicWith("boom"))
+		})
+	})
+})
@@ -0,0 +1,652 @@
+package system
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ebanfa/skeleton/pkg/common"
+	"github.com/ebanfa/skeleton/pkg/types"
+)
+
+const (
+	// EventTypeJobCompleted represents an event emitted when a job succeeds, fails or is canceled.
+	EventTypeJobCompleted string = "job_completed"
+
+	DefaultJobWorkers     = 4
+	DefaultJobQueueSize   = 100
+	DefaultJobRetention   = time.Hour
+	DefaultJobMaxAttempts = 1
+	DefaultJobRetryDelay  = time.Second
+
+	jobIDPrefix      = "job"
+	jobIDMaxAttempts = 10
+)
+
+// jobContextKey is the key of the running job in the context of its operation.
+type jobContextKey struct{}
+
+// job is an operation submitted for asynchronous execution.
+type job struct {
+	info     types.JobInfo
+	input    *types.SystemOperationInput
+	encoded  []byte          // Encoded input of persistent jobs
+	parent   *common.Context // Values of the submitter, without its cancelation
+	ctx      *common.Context
+	cancel   context.CancelFunc
+	canceled bool        // Canceled with CancelJob
+	retry    *time.Timer // Queues the job again after a failed attempt
+	manager  *jobManager
+}
+
+// record returns the persisted state of the job.
+func (j *job) record() *jobRecord {
+	return &jobRecord{
+		ID:          j.info.ID,
+		OperationID: j.info.OperationID,
+		Input:       j.encoded,
+		Attempts:    j.info.Attempts,
+		SubmittedAt: j.info.SubmittedAt,
+	}
+}
+
+// jobManager runs the submitted operations on a bounded pool of workers.
+// When a store is opened, the unfinished jobs are persisted so that they can be replayed after a restart.
+type jobManager struct {
+	mutex        sync.Mutex
+	config       types.JobConfiguration
+	idGenerator  common.IDGeneratorInterface
+	execute      func(ctx *common.Context, operationID string, input *types.SystemOperationInput) (*types.SystemOperationOutput, error)
+	publish      func(info types.JobInfo)
+	logger       common.LoggerInterface
+	jobs         map[string]*job
+	pending      []*job
+	wake         chan struct{}
+	codecs       map[string]types.InputCodec
+	store        *jobStore
+	deadLettered int
+	ctx          *common.Context
+	cancel       context.CancelFunc
+	wg           sync.WaitGroup
+}
+
+// newJobManager creates a new instance of jobManager, the job configuration defaults are applied.
+func newJobManager(
+	config types.JobConfiguration,
+	execute func(ctx *common.Context, operationID string, input *types.SystemOperationInput) (*types.SystemOperationOutput, error),
+	publish func(info types.JobInfo),
+	logger common.LoggerInterface) *jobManager {
+	if config.Workers <= 0 {
+		config.Workers = DefaultJobWorkers
+	}
+	if config.QueueSize <= 0 {
+		config.QueueSize = DefaultJobQueueSize
+	}
+	if config.Retention <= 0 {
+		config.Retention = DefaultJobRetention
+	}
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = DefaultJobMaxAttempts
+	}
+	if config.RetryDelay <= 0 {
+		config.RetryDelay = DefaultJobRetryDelay
+	}
+
+	return &jobManager{
+		config:      config,
+		idGenerator: common.NewProcessIDGenerator(jobIDPrefix),
+		execute:     execute,
+		publish:     publish,
+		logger:      logger,
+		jobs:        make(map[string]*job),
+		wake:        make(chan struct{}, 1),
+		codecs:      make(map[string]types.InputCodec),
+	}
+}
+
+// open persists the jobs in the job store of the given multistore,
+// and queues the jobs that did not complete before the last shutdown.
+func (m *jobManager) open(multiStore types.MultiStore) error {
+	store, err := openJobStore(multiStore)
+	if err != nil {
+		return err
+	}
+	records, err := store.pendingRecords()
+	if err != nil {
+		return err
+	}
+	deadLettered, err := store.countDeadLetters()
+	if err != nil {
+		return err
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.store = store
+	m.deadLettered = deadLettered
+
+	sort.Slice(records, func(i, k int) bool {
+		return records[i].SubmittedAt.Before(records[k].SubmittedAt)
+	})
+	for _, record := range records {
+		j := &job{
+			info: types.JobInfo{
+				ID:          record.ID,
+				OperationID: record.OperationID,
+				Status:      types.JobPendingType,
+				Attempts:    record.Attempts,
+				SubmittedAt: record.SubmittedAt,
+			},
+			encoded: record.Input,
+			parent:  common.Background(),
+			manager: m,
+		}
+		m.jobs[j.info.ID] = j
+		m.pending = append(m.pending, j)
+	}
+
+	return nil
+}
+
+// registerCodec registers the codec of the inputs of an operation.
+func (m *jobManager) registerCodec(operationID string, codec types.InputCodec) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.codecs[operationID] = codec
+}
+
+// start starts the workers.
+func (m *jobManager) start() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.ctx != nil {
+		return
+	}
+
+	m.ctx, m.cancel = common.WithCancel(common.Background())
+	for i := 0; i < m.config.Workers; i++ {
+		m.wg.Add(1)
+		go m.work(m.ctx)
+	}
+	m.signal()
+}
+
+// stop cancels the running and pending jobs, and waits for the workers to return.
+// Persistent jobs interrupted by the stop are replayed when the store is opened again.
+func (m *jobManager) stop(ctx *common.Context) error {
+	m.mutex.Lock()
+	if m.cancel != nil {
+		m.cancel()
+	}
+	m.mutex.Unlock()
+
+	m.wg.Wait()
+
+	// The jobs still queued or waiting for a retry will not run
+	m.mutex.Lock()
+	waiting := m.pending
+	m.pending = nil
+	for _, j := range m.jobs {
+		if j.retry != nil {
+			waiting = append(waiting, j)
+		}
+	}
+	canceled := make([]types.JobInfo, 0, len(waiting))
+	for _, j := range waiting {
+		if j.info.Status == types.JobPendingType {
+			canceled = append(canceled, m.cancelPending(j))
+		}
+	}
+	m.mutex.Unlock()
+
+	for _, info := range canceled {
+		m.notify(info)
+	}
+
+	return nil
+}
+
+// submit queues an operation for execution, the operation runs with the values of the given context.
+// Returns the ID of the job, or an error if the workers are stopped, the queue is full,
+// or the input cannot be persisted.
+func (m *jobManager) submit(ctx *common.Context, operationID string, input *types.SystemOperationInput) (string, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.ctx == nil || m.ctx.Err() != nil {
+		return "", types.ErrSystemNotStarted
+	}
+	if len(m.pending) >= m.config.QueueSize {
+		return "", fmt.Errorf("%w: %d jobs pending", types.ErrJobQueueFull, len(m.pending))
+	}
+
+	now := time.Now()
+	m.purge(now)
+
+	id, err := m.generateID()
+	if err != nil {
+		return "", err
+	}
+
+	j := &job{
+		info: types.JobInfo{
+			ID:          id,
+			OperationID: operationID,
+			Status:      types.JobPendingType,
+			SubmittedAt: now,
+		},
+		input:   input,
+		parent:  common.WithoutCancel(ctx),
+		manager: m,
+	}
+
+	if m.store != nil {
+		codec, ok := m.codecs[operationID]
+		if !ok {
+			return "", fmt.Errorf("%w: operation %s", types.ErrInputCodecNotFound, operationID)
+		}
+		if j.encoded, err = codec.Encode(input); err != nil {
+			return "", fmt.Errorf("failed to encode input: %w", err)
+		}
+		if err := m.store.save(j.record()); err != nil {
+			return "", fmt.Errorf("failed to persist job: %w", err)
+		}
+	}
+
+	m.jobs[id] = j
+	m.pending = append(m.pending, j)
+	m.signal()
+
+	return id, nil
+}
+
+// status returns a copy of the state of the job with the given ID.
+func (m *jobManager) status(jobID string) (*types.JobInfo, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	j, ok := m.jobs[jobID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", types.ErrJobNotFound, jobID)
+	}
+
+	info := j.info
+	return &info, nil
+}
+
+// stats returns the depth of the job queue.
+func (m *jobManager) stats() types.JobQueueStats {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	stats := types.JobQueueStats{DeadLettered: m.deadLettered}
+	for _, j := range m.jobs {
+		switch j.info.Status {
+		case types.JobPendingType:
+			stats.Pending++
+		case types.JobRunningType:
+			stats.Running++
+		}
+	}
+	return stats
+}
+
+// cancelJob cancels the job with the given ID. A pending job is canceled right away,
+// a running job once its operation returns. A canceled job is not replayed.
+func (m *jobManager) cancelJob(jobID string) error {
+	m.mutex.Lock()
+	j, ok := m.jobs[jobID]
+	if !ok {
+		m.mutex.Unlock()
+		return fmt.Errorf("%w: %s", types.ErrJobNotFound, jobID)
+	}
+	if j.info.Status.IsCompleted() {
+		m.mutex.Unlock()
+		return fmt.Errorf("%w: %s is %s", types.ErrJobCompleted, jobID, j.info.Status)
+	}
+	j.canceled = true
+	if j.info.Status == types.JobRunningType {
+		j.cancel()
+		m.mutex.Unlock()
+		return nil
+	}
+	info := m.cancelPending(j)
+	m.mutex.Unlock()
+
+	m.notify(info)
+	return nil
+}
+
+// work runs the queued jobs until the context is done.
+func (m *jobManager) work(ctx *common.Context) {
+	defer m.wg.Done()
+
+	for {
+		j := m.next()
+		if j == nil {
+			select {
+			case <-ctx.Done():
+				return
+			case <-m.wake:
+				continue
+			}
+		}
+		m.run(j)
+	}
+}
+
+// next removes the next pending job from the queue and marks it as running.
+// Returns nil if there is no pending job or the workers are stopping.
+func (m *jobManager) next() *job {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.ctx.Err() != nil {
+		return nil
+	}
+
+	for len(m.pending) > 0 {
+		j := m.pending[0]
+		m.pending = m.pending[1:]
+		if j.info.Status != types.JobPendingType || j.canceled {
+			continue
+		}
+
+		// The job keeps the values of its submitter and is canceled when the workers stop
+		ctx, cancel := common.WithCancel(j.parent)
+		stop := context.AfterFunc(m.ctx, cancel)
+		j.ctx = ctx.WithValue(jobContextKey{}, j)
+		j.cancel = func() {
+			stop()
+			cancel()
+		}
+		j.info.Status = types.JobRunningType
+		j.info.StartedAt = time.Now()
+		j.info.Attempts++
+
+		// The attempt is persisted before it runs, so that it counts if the process crashes
+		if m.store != nil {
+			if err := m.store.save(j.record()); err != nil {
+				m.logError("Error persisting job:", err)
+			}
+		}
+
+		// Let another worker pick the next job
+		if len(m.pending) > 0 {
+			m.signal()
+		}
+		return j
+	}
+
+	return nil
+}
+
+// run executes the operation of a running job and records its result.
+func (m *jobManager) run(j *job) {
+	input, err := m.decodeInput(j)
+	permanent := err != nil
+	if err == nil {
+		var output *types.SystemOperationOutput
+		output, err = m.execute(j.ctx, j.info.OperationID, input)
+		if err == nil {
+			m.mutex.Lock()
+			j.info.Status = types.JobSucceededType
+			j.info.Output = output
+			j.info.Err = nil
+			m.forget(j)
+			info := m.complete(j)
+			m.mutex.Unlock()
+
+			m.notify(info)
+			return
+		}
+	}
+
+	m.mutex.Lock()
+	if permanent {
+		// The input will never decode, the job is not retried
+		j.info.Attempts = max(j.info.Attempts, m.config.MaxAttempts)
+	}
+	switch {
+	case j.canceled:
+		j.info.Status = types.JobCanceledType
+		j.info.Err = fmt.Errorf("%w: %w", types.ErrJobCanceled, err)
+		m.forget(j)
+	case m.ctx.Err() != nil:
+		// Interrupted by the stop, the job is replayed if it is persistent
+		j.info.Status = types.JobCanceledType
+		j.info.Err = fmt.Errorf("%w: %w", types.ErrJobCanceled, err)
+	case j.info.Attempts >= m.config.MaxAttempts:
+		j.info.Status = types.JobFailedType
+		j.info.Err = err
+		j.info.DeadLettered = true
+		m.deadLettered++
+		if m.store != nil {
+			if storeErr := m.store.deadLetter(j.record(), err); storeErr != nil {
+				m.logError("Error dead-lettering job:", storeErr)
+			}
+		}
+	default:
+		j.info.Status = types.JobPendingType
+		j.info.Err = err
+		j.cancel()
+		j.retry = time.AfterFunc(m.config.RetryDelay, func() { m.requeue(j) })
+		m.mutex.Unlock()
+		return
+	}
+	info := m.complete(j)
+	m.mutex.Unlock()
+
+	m.notify(info)
+}
+
+// requeue queues a job again once its retry delay elapsed.
+func (m *jobManager) requeue(j *job) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if j.retry == nil || j.info.Status != types.JobPendingType || m.ctx.Err() != nil {
+		return
+	}
+	j.retry = nil
+	m.pending = append(m.pending, j)
+	m.signal()
+}
+
+// decodeInput returns the input of a job, decoding the input of replayed jobs.
+func (m *jobManager) decodeInput(j *job) (*types.SystemOperationInput, error) {
+	if j.input != nil || j.encoded == nil {
+		return j.input, nil
+	}
+
+	m.mutex.Lock()
+	codec, ok := m.codecs[j.info.OperationID]
+	m.mutex.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: operation %s", types.ErrInputCodecNotFound, j.info.OperationID)
+	}
+
+	input, err := codec.Decode(j.encoded)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode input: %w", err)
+	}
+	return input, nil
+}
+
+// cancelPending marks a pending job as canceled, removing it from the queue or stopping its retry.
+// A job canceled with CancelJob is removed from the store. The caller must hold the mutex.
+// Returns the state of the job to publish once the mutex is released.
+func (m *jobManager) cancelPending(j *job) types.JobInfo {
+	if j.retry != nil {
+		j.retry.Stop()
+		j.retry = nil
+	}
+	for i, queued := range m.pending {
+		if queued == j {
+			m.pending = append(m.pending[:i:i], m.pending[i+1:]...)
+			break
+		}
+	}
+
+	j.info.Status = types.JobCanceledType
+	j.info.Err = types.ErrJobCanceled
+	if j.canceled {
+		m.forget(j)
+	}
+	return m.complete(j)
+}
+
+// forget removes a job from the store. The caller must hold the mutex.
+func (m *jobManager) forget(j *job) {
+	if m.store == nil {
+		return
+	}
+	if err := m.store.remove(j.info.ID); err != nil {
+		m.logError("Error removing job:", err)
+	}
+}
+
+// complete records the completion of a job. The caller must hold the mutex.
+// Returns the state of the job to publish once the mutex is released.
+func (m *jobManager) complete(j *job) types.JobInfo {
+	j.info.CompletedAt = time.Now()
+	if j.cancel != nil {
+		j.cancel()
+	}
+	return j.info
+}
+
+// notify publishes the completion of a job. The caller must not hold the mutex.
+func (m *jobManager) notify(info types.JobInfo) {
+	if m.publish != nil {
+		m.publish(info)
+	}
+}
+
+// reportProgress records the progress of a running job.
+func (m *jobManager) reportProgress(j *job, progress float64, message string) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if j.info.Status != types.JobRunningType {
+		return
+	}
+	j.info.Progress = min(max(progress, 0), 1)
+	j.info.Message = message
+}
+
+// signal wakes up a worker. The caller must hold the mutex.
+func (m *jobManager) signal() {
+	select {
+	case m.wake <- struct{}{}:
+	default:
+	}
+}
+
+// purge removes the jobs completed for longer than the retention. The caller must hold the mutex.
+func (m *jobManager) purge(now time.Time) {
+	for id, j := range m.jobs {
+		if j.info.Status.IsCompleted() && now.Sub(j.info.CompletedAt) > m.config.Retention {
+			delete(m.jobs, id)
+		}
+	}
+}
+
+// generateID generates an ID that is not used by another job. The caller must hold the mutex.
+func (m *jobManager) generateID() (string, error) {
+	for i := 0; i < jobIDMaxAttempts; i++ {
+		id, err := m.idGenerator.GenerateID()
+		if err != nil {
+			return "", fmt.Errorf("failed to generate job ID: %w", err)
+		}
+		if _, exists := m.jobs[id]; exists {
+			continue
+		}
+		if m.store != nil && m.store.has(id) {
+			continue
+		}
+		return id, nil
+	}
+	return "", fmt.Errorf("failed to generate job ID: %d attempts collided with existing jobs", jobIDMaxAttempts)
+}
+
+// logError logs an error of the job store, which does not fail the job.
+func (m *jobManager) logError(args ...interface{}) {
+	if m.logger != nil {
+		m.logger.Log(common.LevelError, args...)
+	}
+}
+
+// ReportProgress reports the progress, between 0 and 1, of the job executing an operation.
+// It is meant to be called from Execute with the context the operation received,
+// and does nothing when the operation is not executed by a job.
+func ReportProgress(ctx *common.Context, progress float64, message string) {
+	if j, ok := ctx.Value(jobContextKey{}).(*job); ok {
+		j.manager.reportProgress(j, progress, message)
+	}
+}
+
+// SubmitOperation queues the operation with the given ID for asynchronous execution on the job workers.
+// The operation goes through the operation interceptors when it runs, with the values of the given
+// context such as the trace ID; the job is not canceled along with the context.
+// Returns the ID of the job, or an error if the operation is not found, the system is not started,
+// or too many jobs are pending.
+func (s *SystemImpl) SubmitOperation(ctx *common.Context, operationID string, data *types.SystemOperationInput) (string, error) {
+	s.mutex.RLock()
+	component, err := s.ComponentRegistry().GetComponent(operationID)
+	s.mutex.RUnlock()
+	if err != nil {
+		return "", err
+	}
+	if _, ok := component.(types.SystemOperationInterface); !ok {
+		return "", fmt.Errorf("failed to submit operation: component %s is not an operation", operationID)
+	}
+
+	return s.jobs.submit(ctx, operationID, data)
+}
+
+// JobStatus returns the state of the job with the given ID.
+// Completed jobs are kept for the job retention.
+func (s *SystemImpl) JobStatus(jobID string) (*types.JobInfo, error) {
+	return s.jobs.status(jobID)
+}
+
+// CancelJob cancels the job with the given ID, by canceling the context of its operation.
+// Returns an error if the job is not found or already completed.
+func (s *SystemImpl) CancelJob(jobID string) error {
+	return s.jobs.cancelJob(jobID)
+}
+
+// JobQueueStats returns the depth of the job queue.
+func (s *SystemImpl) JobQueueStats() types.JobQueueStats {
+	return s.jobs.stats()
+}
+
+// RegisterInputCodec registers the codec of the inputs of the operation with the given ID.
+// A codec is required to submit an operation when jobs are persistent.
+func (s *SystemImpl) RegisterInputCodec(operationID string, codec types.InputCodec) {
+	s.jobs.registerCodec(operationID, codec)
+}
+
+// initializeJobs opens the job store when jobs are persistent, so that the unfinished jobs are replayed.
+func (s *SystemImpl) initializeJobs() error {
+	if configuration := s.Configuration(); configuration == nil || !configuration.Jobs.Persistent {
+		return nil
+	}
+	if s.store == nil {
+		return fmt.Errorf("%w: persistent jobs require a system store", types.ErrStoreNotFound)
+	}
+	return s.jobs.open(s.store)
+}
+
+// publishJob publishes the completion of a job on the event bus.
+func (s *SystemImpl) publishJob(info types.JobInfo) {
+	if s.eventBus != nil {
+		s.eventBus.Publish(common.NewEvent(nil, SystemEventSource, EventTypeJobCompleted, info))
+	}
+}
@@ -0,0 +1,166 @@
+package system
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/ebanfa/skeleton/pkg/types"
+)
+
+// jobStoreName is the namespace of the job store in the system multistore.
+const jobStoreName = "jobs"
+
+// storePruneInterval is the number of versions saved between two deletions of the older versions
+// of the stores that only read their latest version.
+const storePruneInterval = 100
+
+var (
+	pendingJobPrefix = []byte("pending/")
+	deadJobPrefix    = []byte("dead/")
+)
+
+// jobRecord represents a job persisted in the job store.
+type jobRecord struct {
+	ID          string    `json:"id"`
+	OperationID string    `json:"operationId"`
+	Input       []byte    `json:"input"`
+	Attempts    int       `json:"attempts"`
+	SubmittedAt time.Time `json:"submittedAt"`
+	Error       string    `json:"error,omitempty"`
+	FailedAt    time.Time `json:"failedAt,omitempty"`
+}
+
+// jobStore persists the unfinished and dead-lettered jobs. Every change is saved as a new version
+// of the store, so that it survives a crash, and the older versions are pruned.
+// The caller is responsible for synchronization.
+type jobStore struct {
+	store types.Store
+}
+
+// openJobStore creates or loads the job store of the given multistore.
+func openJobStore(multiStore types.MultiStore) (*jobStore, error) {
+	store, _, err := multiStore.CreateStore(jobStoreName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create job store: %w", err)
+	}
+	if _, err := store.Load(); err != nil {
+		return nil, fmt.Errorf("failed to load job store: %w", err)
+	}
+
+	return &jobStore{store: store}, nil
+}
+
+// save persists a pending or running job.
+func (s *jobStore) save(record *jobRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	if err := s.store.Set(jobKey(pendingJobPrefix, record.ID), data); err != nil {
+		return err
+	}
+	return s.commit()
+}
+
+// remove removes a job that completed or was canceled.
+func (s *jobStore) remove(jobID string) error {
+	if err := s.store.Delete(jobKey(pendingJobPrefix, jobID)); err != nil {
+		return err
+	}
+	return s.commit()
+}
+
+// deadLetter moves a job that failed every attempt to the dead-letter queue.
+func (s *jobStore) deadLetter(record *jobRecord, cause error) error {
+	record.Error = cause.Error()
+	record.FailedAt = time.Now()
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	if err := s.store.Delete(jobKey(pendingJobPrefix, record.ID)); err != nil {
+		return err
+	}
+	if err := s.store.Set(jobKey(deadJobPrefix, record.ID), data); err != nil {
+		return err
+	}
+	return s.commit()
+}
+
+// has checks if a job with the given ID is persisted, pending or dead-lettered.
+func (s *jobStore) has(jobID string) bool {
+	for _, prefix := range [][]byte{pendingJobPrefix, deadJobPrefix} {
+		if exists, err := s.store.Has(jobKey(prefix, jobID)); err == nil && exists {
+			return true
+		}
+	}
+	return false
+}
+
+// pendingRecords returns the jobs that did not complete.
+func (s *jobStore) pendingRecords() ([]*jobRecord, error) {
+	return s.records(pendingJobPrefix)
+}
+
+// countDeadLetters returns the number of jobs in the dead-letter queue.
+func (s *jobStore) countDeadLetters() (int, error) {
+	records, err := s.records(deadJobPrefix)
+	return len(records), err
+}
+
+// records returns the jobs persisted under the given prefix.
+func (s *jobStore) records(prefix []byte) ([]*jobRecord, error) {
+	var (
+		records   []*jobRecord
+		decodeErr error
+	)
+
+	err := s.store.IterateRange(prefix, prefixEnd(prefix), true, func(key, value []byte) bool {
+		record := &jobRecord{}
+		if decodeErr = json.Unmarshal(value, record); decodeErr != nil {
+			decodeErr = fmt.Errorf("failed to decode job %s: %w", key, decodeErr)
+			return true
+		}
+		records = append(records, record)
+		return false
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read job store: %w", err)
+	}
+
+	return records, decodeErr
+}
+
+// commit saves the changes as a new version of the store.
+func (s *jobStore) commit() error {
+	return saveVersion(s.store)
+}
+
+// saveVersion saves the changes of a store as a new version. Only the latest version being read,
+// the older versions are deleted every storePruneInterval versions.
+func saveVersion(store types.Store) error {
+	_, version, err := store.SaveVersion()
+	if err != nil {
+		return err
+	}
+	if version > 1 && version%storePruneInterval == 0 {
+		if err := store.DeleteVersionsTo(version - 1); err != nil {
+			return fmt.Errorf("failed to prune versions: %w", err)
+		}
+	}
+	return nil
+}
+
+// jobKey returns the key of a job under the given prefix.
+func jobKey(prefix []byte, jobID string) []byte {
+	return append(append([]byte(nil), prefix...), jobID...)
+}
+
+// prefixEnd returns the smallest key greater than every key starting with the prefix.
+func prefixEnd(prefix []byte) []byte {
+	end := append([]byte(nil), prefix...)
+	end[len(end)-1]++
+	return end
+}
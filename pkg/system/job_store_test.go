@@ -0,0 +1,209 @@
+package system_test
+
+import (
+	"sync/atomic"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/ebanfa/skeleton/pkg/common"
+	"github.com/ebanfa/skeleton/pkg/component"
+	"github.com/ebanfa/skeleton/pkg/db"
+	"github.com/ebanfa/skeleton/pkg/mocks"
+	"github.com/ebanfa/skeleton/pkg/store"
+	systemApi "github.com/ebanfa/skeleton/pkg/system"
+	"github.com/ebanfa/skeleton/pkg/types"
+)
+
+var _ = Describe("Persistent jobs", func() {
+	var (
+		ctx      *common.Context
+		dir      string
+		blocking atomic.Bool
+		executed chan string
+		systems  []*systemApi.SystemImpl
+		stores   types.MultiStore
+	)
+
+	// newSystem creates a system persisting its jobs in the data directory, as after a restart.
+	newSystem := func(registerCodec bool) *systemApi.SystemImpl {
+		multiStore, err := store.CreateMultiStore("system", dir, store.NewStoreFactory(dir, db.NewIAVLDatabaseFactory()))
+		Expect(err).NotTo(HaveOccurred())
+		stores = multiStore
+
+		operation := &mocks.SystemOperationInterface{}
+		operation.On("ID").Return("operation")
+		operation.On("Type").Return(types.OperationType)
+		operation.On("Initialize", ctx, mock.Anything).Return(nil)
+		operation.On("Execute", mock.Anything, mock.Anything).Return(func(ctx *common.Context, input *types.SystemOperationInput) (*types.SystemOperationOutput, error) {
+			executed <- input.Data.(string)
+			if input.Data == "fail" {
+				return nil, types.ErrServiceFailed
+			}
+			if blocking.Load() {
+				<-ctx.Done()
+				return nil, ctx.Err()
+			}
+			return &types.SystemOperationOutput{Data: input.Data}, nil
+		})
+
+		registrar := component.NewComponentRegistrar()
+		factory := &mocks.ComponentFactoryInterface{}
+		factory.On("CreateComponent", mock.Anything).Return(operation, nil)
+		Expect(registrar.RegisterFactory(ctx, "operationFactory", factory)).To(Succeed())
+
+		pluginManager := &mocks.PluginManagerInterface{}
+		pluginManager.On("Initialize", ctx, mock.Anything).Return(nil)
+		pluginManager.On("StartPlugins", ctx).Return(nil)
+		pluginManager.On("StopPlugins", mock.Anything).Return(nil)
+
+		sys := systemApi.NewSystem(nil, nil, &types.Configuration{
+			Operations: []*types.OperationConfiguration{
+				{ComponentConfig: types.ComponentConfig{ID: "operation", FactoryID: "operationFactory"}},
+			},
+			Jobs: types.JobConfiguration{
+				Workers:     1,
+				Persistent:  true,
+				MaxAttempts: 2,
+				RetryDelay:  10 * time.Millisecond,
+			},
+		}, pluginManager, registrar, multiStore)
+		if registerCodec {
+			sys.RegisterInputCodec("operation", systemApi.JSONInputCodec[string]{})
+		}
+		systems = append(systems, sys)
+
+		Expect(sys.Initialize(ctx)).To(Succeed())
+		return sys
+	}
+
+	waitStatus := func(sys *systemApi.SystemImpl, jobID string, status types.JobStatusType) *types.JobInfo {
+		var info *types.JobInfo
+		Eventually(func() types.JobStatusType {
+			var err error
+			info, err = sys.JobStatus(jobID)
+			Expect(err).NotTo(HaveOccurred())
+			return info.Status
+		}).Should(Equal(status))
+		return info
+	}
+
+	BeforeEach(func() {
+		ctx = common.Background()
+		dir = GinkgoT().TempDir()
+		blocking.Store(false)
+		executed = make(chan string, 10)
+		systems = nil
+	})
+
+	AfterEach(func() {
+		for _, sys := range systems {
+			sys.Stop(ctx)
+		}
+	})
+
+	It("replays the jobs interrupted by a restart", func() {
+		blocking.Store(true)
+		sys := newSystem(true)
+		Expect(sys.Start(ctx)).To(Succeed())
+
+		jobID, err := sys.SubmitOperation(ctx, "operation", &types.SystemOperationInput{Data: "replayed"})
+		Expect(err).NotTo(HaveOccurred())
+		Eventually(executed).Should(Receive())
+		Expect(sys.Stop(ctx)).To(Succeed())
+
+		blocking.Store(false)
+		sys = newSystem(true)
+		info, err := sys.JobStatus(jobID)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info.Status).To(Equal(types.JobPendingType))
+		Expect(info.Attempts).To(Equal(1))
+		Expect(sys.JobQueueStats()).To(Equal(types.JobQueueStats{Pending: 1}))
+
+		Expect(sys.Start(ctx)).To(Succeed())
+		info = waitStatus(sys, jobID, types.JobSucceededType)
+		Expect(info.Output.Data).To(Equal("replayed"))
+		Expect(info.Attempts).To(Equal(2))
+		Expect(sys.Stop(ctx)).To(Succeed())
+
+		sys = newSystem(true)
+		_, err = sys.JobStatus(jobID)
+		Expect(err).To(MatchError(types.ErrJobNotFound))
+	})
+
+	It("retries the failed jobs, then dead-letters them", func() {
+		sys := newSystem(true)
+		Expect(sys.Start(ctx)).To(Succeed())
+
+		jobID, err := sys.SubmitOperation(ctx, "operation", &types.SystemOperationInput{Data: "fail"})
+		Expect(err).NotTo(HaveOccurred())
+
+		info := waitStatus(sys, jobID, types.JobFailedType)
+		Expect(info.Attempts).To(Equal(2))
+		Expect(info.DeadLettered).To(BeTrue())
+		Expect(info.Err).To(MatchError(types.ErrServiceFailed))
+		Expect(executed).To(HaveLen(2))
+		Expect(sys.JobQueueStats()).To(Equal(types.JobQueueStats{DeadLettered: 1}))
+		Expect(sys.Stop(ctx)).To(Succeed())
+
+		sys = newSystem(true)
+		Expect(sys.JobQueueStats()).To(Equal(types.JobQueueStats{DeadLettered: 1}))
+		_, err = sys.JobStatus(jobID)
+		Expect(err).To(MatchError(types.ErrJobNotFound))
+	})
+
+	It("does not replay the canceled jobs", func() {
+		blocking.Store(true)
+		sys := newSystem(true)
+		Expect(sys.Start(ctx)).To(Succeed())
+
+		jobID, err := sys.SubmitOperation(ctx, "operation", &types.SystemOperationInput{Data: "canceled"})
+		Expect(err).NotTo(HaveOccurred())
+		Eventually(executed).Should(Receive())
+		Expect(sys.CancelJob(jobID)).To(Succeed())
+		waitStatus(sys, jobID, types.JobCanceledType)
+		Expect(sys.Stop(ctx)).To(Succeed())
+
+		sys = newSystem(true)
+		_, err = sys.JobStatus(jobID)
+		Expect(err).To(MatchError(types.ErrJobNotFound))
+	})
+
+	It("prunes the older versions of the job store", func() {
+		sys := newSystem(true)
+		Expect(sys.Start(ctx)).To(Succeed())
+
+		// Every job saves three versions: submitted, running and completed
+		for i := 0; i < 40; i++ {
+			jobID, err := sys.SubmitOperation(ctx, "operation", &types.SystemOperationInput{Data: "succeed"})
+			Expect(err).NotTo(HaveOccurred())
+			Eventually(executed).Should(Receive())
+			waitStatus(sys, jobID, types.JobSucceededType)
+		}
+
+		jobStore, _, err := stores.CreateStore("jobs")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(jobStore.Version()).To(BeNumerically(">=", 120))
+		Expect(len(jobStore.AvailableVersions())).To(BeNumerically("<", 100))
+	})
+
+	It("requires a codec for the inputs", func() {
+		sys := newSystem(false)
+		Expect(sys.Start(ctx)).To(Succeed())
+
+		_, err := sys.SubmitOperation(ctx, "operation", &types.SystemOperationInput{Data: "input"})
+		Expect(err).To(MatchError(types.ErrInputCodecNotFound))
+	})
+
+	It("requires a system store", func() {
+		pluginManager := &mocks.PluginManagerInterface{}
+		pluginManager.On("Initialize", ctx, mock.Anything).Return(nil)
+		sys := systemApi.NewSystem(nil, nil, &types.Configuration{
+			Jobs: types.JobConfiguration{Persistent: true},
+		}, pluginManager, component.NewComponentRegistrar(), nil)
+
+		Expect(sys.Initialize(ctx)).To(MatchError(types.ErrStoreNotFound))
+	})
+})
@@ -0,0 +1,293 @@
+package system_test
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/ebanfa/skeleton/pkg/common"
+	"github.com/ebanfa/skeleton/pkg/component"
+	"github.com/ebanfa/skeleton/pkg/mocks"
+	systemApi "github.com/ebanfa/skeleton/pkg/system"
+	"github.com/ebanfa/skeleton/pkg/types"
+)
+
+var _ = Describe("Jobs", func() {
+	var (
+		ctx               *common.Context
+		sys               *systemApi.SystemImpl
+		configuration     *types.Configuration
+		registrar         types.ComponentRegistrarInterface
+		eventBus          common.EventBusInterface
+		operation         *mocks.SystemOperationInterface
+		mockPluginManager *mocks.PluginManagerInterface
+		events            chan types.JobInfo
+		release           chan struct{}
+		executed          chan string
+	)
+
+	input := func(behavior string) *types.SystemOperationInput {
+		return &types.SystemOperationInput{Data: behavior}
+	}
+
+	// waitStatus waits for the job with the given ID to reach the given status.
+	waitStatus := func(jobID string, status types.JobStatusType) *types.JobInfo {
+		var info *types.JobInfo
+		Eventually(func() types.JobStatusType {
+			var err error
+			info, err = sys.JobStatus(jobID)
+			Expect(err).NotTo(HaveOccurred())
+			return info.Status
+		}).Should(Equal(status))
+		return info
+	}
+
+	BeforeEach(func() {
+		ctx = common.Background()
+		events = make(chan types.JobInfo, 10)
+		release = make(chan struct{})
+		executed = make(chan string, 10)
+
+		// The behavior of the operation is given by its input
+		operation = &mocks.SystemOperationInterface{}
+		operation.On("ID").Return("operation")
+		operation.On("Type").Return(types.OperationType)
+		operation.On("Initialize", ctx, mock.Anything).Return(nil)
+		operation.On("Execute", mock.Anything, mock.Anything).Return(func(ctx *common.Context, input *types.SystemOperationInput) (*types.SystemOperationOutput, error) {
+			executed <- input.Data.(string)
+			switch input.Data {
+			case "progress":
+				systemApi.ReportProgress(ctx, 0.5, "halfway")
+				<-release
+			case "block":
+				<-ctx.Done()
+				return nil, ctx.Err()
+			case "fail":
+				return nil, errors.New("failure")
+			case "trace":
+				return &types.SystemOperationOutput{Data: ctx.Value(common.TraceIDKey)}, nil
+			}
+			return &types.SystemOperationOutput{Data: "done"}, nil
+		})
+
+		registrar = component.NewComponentRegistrar()
+		factory := &mocks.ComponentFactoryInterface{}
+		factory.On("CreateComponent", mock.Anything).Return(operation, nil)
+		Expect(registrar.RegisterFactory(ctx, "operationFactory", factory)).To(Succeed())
+
+		eventBus = common.NewSystemEventBus()
+		Expect(eventBus.Subscribe(common.BusSubscriptionParams{
+			Topic:        systemApi.EventTypeJobCompleted,
+			EventHandler: func(event common.Event) { events <- event.Data.(types.JobInfo) },
+		})).Error().NotTo(HaveOccurred())
+
+		mockPluginManager = &mocks.PluginManagerInterface{}
+		mockPluginManager.On("Initialize", ctx, mock.Anything).Return(nil)
+		mockPluginManager.On("StartPlugins", ctx).Return(nil)
+		mockPluginManager.On("StopPlugins", mock.Anything).Return(nil)
+
+		configuration = &types.Configuration{
+			Operations: []*types.OperationConfiguration{
+				{ComponentConfig: types.ComponentConfig{ID: "operation", FactoryID: "operationFactory"}},
+			},
+			Jobs: types.JobConfiguration{Workers: 1, QueueSize: 1},
+		}
+	})
+
+	JustBeforeEach(func() {
+		sys = systemApi.NewSystem(nil, eventBus, configuration, mockPluginManager, registrar, nil)
+		Expect(sys.Initialize(ctx)).To(Succeed())
+	})
+
+	It("rejects submissions until the system is started", func() {
+		_, err := sys.SubmitOperation(ctx, "operation", input("succeed"))
+		Expect(err).To(MatchError(types.ErrSystemNotStarted))
+	})
+
+	Context("when the system is started", func() {
+		JustBeforeEach(func() {
+			Expect(sys.Start(ctx)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			close(release)
+			sys.Stop(ctx)
+		})
+
+		It("executes the operation and publishes its completion", func() {
+			jobID, err := sys.SubmitOperation(ctx, "operation", input("succeed"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(jobID).To(HavePrefix("job-"))
+
+			info := waitStatus(jobID, types.JobSucceededType)
+			Expect(info.OperationID).To(Equal("operation"))
+			Expect(info.Output.Data).To(Equal("done"))
+			Expect(info.CompletedAt).NotTo(BeZero())
+
+			var event types.JobInfo
+			Eventually(events).Should(Receive(&event))
+			Expect(event.ID).To(Equal(jobID))
+			Expect(event.Status).To(Equal(types.JobSucceededType))
+		})
+
+		It("runs the operation with the values of the submitter context", func() {
+			submitCtx, cancel := common.WithCancel(ctx.WithTraceID("trace-1"))
+			jobID, err := sys.SubmitOperation(submitCtx, "operation", input("trace"))
+			Expect(err).NotTo(HaveOccurred())
+			// The job outlives the request that submitted it
+			cancel()
+
+			Expect(waitStatus(jobID, types.JobSucceededType).Output.Data).To(Equal("trace-1"))
+		})
+
+		It("records the failures of the operation", func() {
+			jobID, err := sys.SubmitOperation(ctx, "operation", input("fail"))
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(waitStatus(jobID, types.JobFailedType).Err).To(MatchError("failure"))
+		})
+
+		It("reports the progress of the operation", func() {
+			jobID, err := sys.SubmitOperation(ctx, "operation", input("progress"))
+			Expect(err).NotTo(HaveOccurred())
+
+			Eventually(func() string {
+				info, _ := sys.JobStatus(jobID)
+				return info.Message
+			}).Should(Equal("halfway"))
+			info, _ := sys.JobStatus(jobID)
+			Expect(info.Status).To(Equal(types.JobRunningType))
+			Expect(info.Progress).To(Equal(0.5))
+		})
+
+		It("bounds the number of running and pending jobs", func() {
+			running, err := sys.SubmitOperation(ctx, "operation", input("block"))
+			Expect(err).NotTo(HaveOccurred())
+			Eventually(executed).Should(Receive())
+
+			pending, err := sys.SubmitOperation(ctx, "operation", input("succeed"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(waitStatus(pending, types.JobPendingType)).NotTo(BeNil())
+
+			_, err = sys.SubmitOperation(ctx, "operation", input("succeed"))
+			Expect(err).To(MatchError(types.ErrJobQueueFull))
+
+			Expect(sys.CancelJob(running)).To(Succeed())
+			waitStatus(pending, types.JobSucceededType)
+		})
+
+		It("cancels a running job through its context", func() {
+			jobID, err := sys.SubmitOperation(ctx, "operation", input("block"))
+			Expect(err).NotTo(HaveOccurred())
+			Eventually(executed).Should(Receive())
+
+			Expect(sys.CancelJob(jobID)).To(Succeed())
+
+			info := waitStatus(jobID, types.JobCanceledType)
+			Expect(info.Err).To(MatchError(types.ErrJobCanceled))
+			Expect(info.Err).To(MatchError(context.Canceled))
+		})
+
+		It("cancels a pending job without executing it", func() {
+			running, err := sys.SubmitOperation(ctx, "operation", input("block"))
+			Expect(err).NotTo(HaveOccurred())
+			Eventually(executed).Should(Receive())
+			pending, err := sys.SubmitOperation(ctx, "operation", input("succeed"))
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(sys.CancelJob(pending)).To(Succeed())
+			info, err := sys.JobStatus(pending)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info.Status).To(Equal(types.JobCanceledType))
+
+			Expect(sys.CancelJob(running)).To(Succeed())
+			waitStatus(running, types.JobCanceledType)
+			Consistently(executed).ShouldNot(Receive())
+		})
+
+		It("returns an error when the job cannot be canceled", func() {
+			jobID, err := sys.SubmitOperation(ctx, "operation", input("succeed"))
+			Expect(err).NotTo(HaveOccurred())
+			waitStatus(jobID, types.JobSucceededType)
+
+			Expect(sys.CancelJob(jobID)).To(MatchError(types.ErrJobCompleted))
+			Expect(sys.CancelJob("unknown")).To(MatchError(types.ErrJobNotFound))
+		})
+
+		It("returns an error when the operation is not found", func() {
+			registry := sys.ComponentRegistry()
+			_, err := sys.SubmitOperation(ctx, "unknown", input("succeed"))
+			Expect(err).To(HaveOccurred())
+			Expect(registry.GetComponentsByType(types.OperationType)).To(HaveLen(1))
+		})
+
+		It("cancels the running jobs when the system stops", func() {
+			jobID, err := sys.SubmitOperation(ctx, "operation", input("block"))
+			Expect(err).NotTo(HaveOccurred())
+			Eventually(executed).Should(Receive())
+
+			Expect(sys.Stop(ctx)).To(Succeed())
+			Expect(waitStatus(jobID, types.JobCanceledType).Err).To(MatchError(context.Canceled))
+
+			_, err = sys.SubmitOperation(ctx, "operation", input("succeed"))
+			Expect(err).To(MatchError(types.ErrSystemNotStarted))
+		})
+
+		It("cancels the queued jobs when the system stops", func() {
+			running, err := sys.SubmitOperation(ctx, "operation", input("block"))
+			Expect(err).NotTo(HaveOccurred())
+			Eventually(executed).Should(Receive())
+			pending, err := sys.SubmitOperation(ctx, "operation", input("succeed"))
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(sys.Stop(ctx)).To(Succeed())
+			waitStatus(running, types.JobCanceledType)
+			Expect(waitStatus(pending, types.JobCanceledType).Err).To(MatchError(types.ErrJobCanceled))
+			Consistently(executed).ShouldNot(Receive())
+		})
+
+		Context("when the failed jobs are retried", func() {
+			BeforeEach(func() {
+				configuration.Jobs.MaxAttempts = 2
+				configuration.Jobs.RetryDelay = time.Hour
+			})
+
+			// waitRetry submits a failing job and waits for its first attempt to fail.
+			waitRetry := func() string {
+				jobID, err := sys.SubmitOperation(ctx, "operation", input("fail"))
+				Expect(err).NotTo(HaveOccurred())
+				Eventually(executed).Should(Receive())
+				Eventually(func() error {
+					info, _ := sys.JobStatus(jobID)
+					return info.Err
+				}).Should(MatchError("failure"))
+				return jobID
+			}
+
+			It("cancels a job waiting for its retry", func() {
+				jobID := waitRetry()
+				Expect(waitStatus(jobID, types.JobPendingType).Attempts).To(Equal(1))
+
+				Expect(sys.CancelJob(jobID)).To(Succeed())
+				Expect(waitStatus(jobID, types.JobCanceledType).Err).To(MatchError(types.ErrJobCanceled))
+				Expect(sys.JobQueueStats().Pending).To(BeZero())
+			})
+
+			It("cancels the jobs waiting for their retry when the system stops", func() {
+				jobID := waitRetry()
+
+				Expect(sys.Stop(ctx)).To(Succeed())
+				Expect(waitStatus(jobID, types.JobCanceledType).Err).To(MatchError(types.ErrJobCanceled))
+
+				var event types.JobInfo
+				Eventually(events).Should(Receive(&event))
+				Expect(event.ID).To(Equal(jobID))
+				Expect(event.Status).To(Equal(types.JobCanceledType))
+			})
+		})
+	})
+})
@@ -0,0 +1,693 @@
+package system
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/ebanfa/skeleton/pkg/common"
+	"github.com/ebanfa/skeleton/pkg/types"
+)
+
+// journalStoreName is the namespace of the event journal in the system multistore.
+const journalStoreName = "journal"
+
+const (
+	defaultRetentionInterval = time.Minute
+	defaultFlushInterval     = 100 * time.Millisecond
+	defaultFlushChanges      = 100
+	journalReadBatch         = 256
+)
+
+var (
+	journalEventPrefix = []byte("events/")
+	journalGroupPrefix = []byte("groups/")
+	journalNextKey     = []byte("next")
+)
+
+// EventJournalOptions represents the options of an event journal.
+type EventJournalOptions struct {
+	// Topics are the topics or topic patterns of the journaled events, every topic when nil.
+	Topics []string
+
+	// MaxAge is the age after which the events are removed from the journal, zero keeping them forever.
+	MaxAge time.Duration
+
+	// MaxEvents is the number of events kept in the journal, the oldest being removed first.
+	// Zero means no limit.
+	MaxEvents int
+
+	// MaxBytes is the total size of the encoded events kept in the journal, the oldest being removed first.
+	// The newest event is always kept. Zero means no limit.
+	MaxBytes int
+
+	// RetentionInterval is the interval between two removals of the expired events, one minute when zero.
+	RetentionInterval time.Duration
+
+	// FlushInterval is the maximum time the journaled events and committed offsets wait before
+	// they are saved, 100 milliseconds when zero. The changes not saved yet are lost on a crash.
+	FlushInterval time.Duration
+
+	// FlushChanges is the number of journaled events and committed offsets after which they are saved
+	// without waiting for the flush interval, 100 when zero.
+	FlushChanges int
+}
+
+// JournalEntry represents an event read from the journal.
+type JournalEntry struct {
+	// Offset is the position of the event in the journal. Offsets increase monotonically
+	// and are never reused, even when the events are removed by the retention.
+	Offset uint64
+
+	// Time is the time the event was journaled.
+	Time time.Time
+
+	// Event is the journaled event with its envelope, its data being the JSON encoding of the published
+	// data as a json.RawMessage.
+	Event common.Event
+}
+
+// JournalHandler defines the signature for a handler of journal entries.
+type JournalHandler func(entry JournalEntry)
+
+// journalRecord represents an event persisted in the journal.
+type journalRecord struct {
+	Time time.Time `json:"time"`
+	eventRecord
+}
+
+// eventRecord represents an event with its envelope persisted in a store, its data encoded as JSON.
+type eventRecord struct {
+	Type      string            `json:"type"`
+	Data      json.RawMessage   `json:"data,omitempty"`
+	ID        string            `json:"id,omitempty"`
+	Source    string            `json:"source,omitempty"`
+	EventTime time.Time         `json:"eventTime"`
+	TraceID   string            `json:"traceId,omitempty"`
+	Version   int               `json:"version,omitempty"`
+	Headers   map[string]string `json:"headers,omitempty"`
+}
+
+// newEventRecord returns the record of an event. Data that cannot be encoded as JSON is recorded as text.
+func newEventRecord(event common.Event) eventRecord {
+	data, err := json.Marshal(event.Data)
+	if err != nil {
+		data, _ = json.Marshal(fmt.Sprint(event.Data))
+	}
+	return eventRecord{
+		Type:      event.Type,
+		Data:      data,
+		ID:        event.ID,
+		Source:    event.Source,
+		EventTime: event.Time,
+		TraceID:   event.TraceID,
+		Version:   event.Version,
+		Headers:   event.Headers,
+	}
+}
+
+// event returns the recorded event, its data being a json.RawMessage.
+func (r *eventRecord) event() common.Event {
+	event := common.Event{
+		Type:    r.Type,
+		ID:      r.ID,
+		Source:  r.Source,
+		Time:    r.EventTime,
+		TraceID: r.TraceID,
+		Version: r.Version,
+		Headers: r.Headers,
+	}
+	if len(r.Data) > 0 && string(r.Data) != "null" {
+		event.Data = r.Data
+	}
+	return event
+}
+
+// EventJournal is a service persisting the events published on the system event bus in a namespace
+// of the system multistore, so that the subscribers can replay the events they missed. Every event
+// gets an offset, and consumer groups commit the offset they reached to resume from it after a restart.
+// The oldest events are removed according to the retention options. The changes are saved in batches,
+// according to the flush options, and the older versions of the store are pruned.
+type EventJournal struct {
+	BaseSystemService
+	mutex         sync.Mutex
+	options       EventJournalOptions
+	store         types.Store
+	first         uint64        // Offset of the oldest event in the journal
+	next          uint64        // Offset of the next event
+	size          int           // Total size of the encoded events in the journal
+	unsaved       int           // Number of changes not saved yet
+	appended      chan struct{} // Closed and replaced when an event is appended
+	done          chan struct{} // Closed when the journal stops
+	subscriptions []common.Subscription
+}
+
+// NewEventJournal creates a new instance of EventJournal.
+func NewEventJournal(id string, options EventJournalOptions) *EventJournal {
+	if options.Topics == nil {
+		options.Topics = []string{common.TopicTailWildcard}
+	}
+	if options.RetentionInterval <= 0 {
+		options.RetentionInterval = defaultRetentionInterval
+	}
+	if options.FlushInterval <= 0 {
+		options.FlushInterval = defaultFlushInterval
+	}
+	if options.FlushChanges <= 0 {
+		options.FlushChanges = defaultFlushChanges
+	}
+
+	return &EventJournal{
+		BaseSystemService: *NewBaseSystemService(id, "EventJournal", "Persists the events for replay"),
+		options:           options,
+	}
+}
+
+// Start opens the journal in the system multistore and starts journaling the events.
+// Returns an error if the system has no multistore or the journal cannot be loaded.
+func (j *EventJournal) Start(ctx *common.Context) error {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	if j.System == nil {
+		return types.ErrSystemNotInitialized
+	}
+	if j.done != nil {
+		return nil
+	}
+
+	if err := j.open(); err != nil {
+		return err
+	}
+
+	j.done = make(chan struct{})
+	j.appended = make(chan struct{})
+	if err := j.subscribe(); err != nil {
+		j.done = nil
+		return err
+	}
+	go j.maintain(j.done)
+
+	return nil
+}
+
+// Stop stops journaling the events, saves the changes and ends the subscriptions to the journal.
+func (j *EventJournal) Stop(ctx *common.Context) error {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	if j.done == nil {
+		return nil
+	}
+
+	j.unsubscribe()
+	close(j.done)
+	j.done = nil
+	return j.flush()
+}
+
+// Append journals an event, returning its offset.
+func (j *EventJournal) Append(event common.Event) (uint64, error) {
+	value, err := json.Marshal(&journalRecord{Time: time.Now(), eventRecord: newEventRecord(event)})
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode event %s: %w", event.Type, err)
+	}
+
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	if j.done == nil {
+		return 0, types.ErrJournalNotStarted
+	}
+
+	offset := j.next
+	if err := j.store.Set(journalEventKey(offset), value); err != nil {
+		return 0, fmt.Errorf("failed to journal event %s: %w", event.Type, err)
+	}
+	if err := j.store.Set(journalNextKey, encodeOffset(offset+1)); err != nil {
+		return 0, fmt.Errorf("failed to journal event %s: %w", event.Type, err)
+	}
+	j.next++
+	j.size += len(value)
+
+	if err := j.trim(time.Now()); err != nil {
+		return 0, err
+	}
+	if err := j.changed(); err != nil {
+		return 0, err
+	}
+
+	// Wake up the subscribers waiting for new events
+	close(j.appended)
+	j.appended = make(chan struct{})
+
+	return offset, nil
+}
+
+// Offsets returns the offset of the oldest event in the journal and the offset of the next event.
+// The journal is empty when they are equal.
+func (j *EventJournal) Offsets() (first, next uint64) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	return j.first, j.next
+}
+
+// SubscribeFrom subscribes to the events of a topic or topic pattern from the given offset. The journaled
+// events are replayed, then the new events are delivered as they are journaled, in offset order, on
+// the goroutine of the subscription. The events removed by the retention are skipped.
+// Returns an error if the journal is not started.
+func (j *EventJournal) SubscribeFrom(topic string, offset uint64, handler JournalHandler) (common.Subscription, error) {
+	return j.subscribeReader(topic, offset, "", handler)
+}
+
+// SubscribeGroup subscribes a consumer group to the events of a topic or topic pattern, from the offset
+// committed by the group, or from the oldest event if the group never committed. The offset following
+// each handled event is committed, so that the group resumes after it. A group should have a single
+// subscription at a time.
+func (j *EventJournal) SubscribeGroup(group, topic string, handler JournalHandler) (common.Subscription, error) {
+	offset, _, err := j.CommittedOffset(group)
+	if err != nil {
+		return nil, err
+	}
+	return j.subscribeReader(topic, offset, group, handler)
+}
+
+// Commit commits the offset of the next event to handle by a consumer group.
+func (j *EventJournal) Commit(group string, offset uint64) error {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	if j.done == nil {
+		return types.ErrJournalNotStarted
+	}
+	if err := j.store.Set(journalGroupKey(group), encodeOffset(offset)); err != nil {
+		return fmt.Errorf("failed to commit offset of group %s: %w", group, err)
+	}
+	return j.changed()
+}
+
+// CommittedOffset returns the offset committed by a consumer group, and false if the group never committed.
+func (j *EventJournal) CommittedOffset(group string) (uint64, bool, error) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	if j.done == nil {
+		return 0, false, types.ErrJournalNotStarted
+	}
+	data, err := j.store.Get(journalGroupKey(group))
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to read offset of group %s: %w", group, err)
+	}
+	if data == nil {
+		return 0, false, nil
+	}
+	return decodeOffset(data), true, nil
+}
+
+// open creates or loads the journal store, then reads the offsets and size of the journal.
+// The caller must hold the mutex.
+func (j *EventJournal) open() error {
+	if j.store != nil {
+		return nil
+	}
+
+	multiStore := j.System.MultiStore()
+	if multiStore == nil {
+		return fmt.Errorf("%w: the event journal needs a multistore", types.ErrStoreNotFound)
+	}
+	store, _, err := multiStore.CreateStore(journalStoreName)
+	if err != nil {
+		return fmt.Errorf("failed to create journal store: %w", err)
+	}
+	if _, err := store.Load(); err != nil {
+		return fmt.Errorf("failed to load journal store: %w", err)
+	}
+
+	next, err := store.Get(journalNextKey)
+	if err != nil {
+		return fmt.Errorf("failed to read journal offsets: %w", err)
+	}
+	if next != nil {
+		j.next = decodeOffset(next)
+	}
+	j.first, j.size = j.next, 0
+	err = store.IterateRange(journalEventPrefix, prefixEnd(journalEventPrefix), true, func(key, value []byte) bool {
+		if offset := decodeOffset(key[len(journalEventPrefix):]); offset < j.first {
+			j.first = offset
+		}
+		j.size += len(value)
+		return false
+	})
+	if err != nil {
+		return fmt.Errorf("failed to read journal store: %w", err)
+	}
+
+	j.store = store
+	return nil
+}
+
+// subscribe subscribes to the journaled topics. The caller must hold the mutex.
+func (j *EventJournal) subscribe() error {
+	eventBus := j.System.EventBus()
+	if eventBus == nil {
+		return nil
+	}
+
+	for _, topic := range j.options.Topics {
+		subscription, err := eventBus.Subscribe(common.BusSubscriptionParams{
+			Topic:        topic,
+			EventHandler: j.journal,
+		})
+		if err != nil {
+			j.unsubscribe()
+			return fmt.Errorf("failed to subscribe to %s: %w", topic, err)
+		}
+		j.subscriptions = append(j.subscriptions, subscription)
+	}
+	return nil
+}
+
+// unsubscribe removes the subscriptions to the journaled topics. The caller must hold the mutex.
+func (j *EventJournal) unsubscribe() {
+	for _, subscription := range j.subscriptions {
+		subscription.Unsubscribe()
+	}
+	j.subscriptions = nil
+}
+
+// journal appends a published event to the journal.
+func (j *EventJournal) journal(event common.Event) {
+	if _, err := j.Append(event); err != nil {
+		j.logError("Error journaling event "+event.Type+":", err)
+	}
+}
+
+// maintain periodically saves the changes and removes the expired events until the journal stops.
+func (j *EventJournal) maintain(done chan struct{}) {
+	retention := time.NewTicker(j.options.RetentionInterval)
+	defer retention.Stop()
+	flush := time.NewTicker(j.options.FlushInterval)
+	defer flush.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case now := <-retention.C:
+			if err := j.expire(now); err != nil {
+				j.logError("Error removing expired events:", err)
+			}
+		case <-flush.C:
+			if err := j.save(); err != nil {
+				j.logError("Error saving journal:", err)
+			}
+		}
+	}
+}
+
+// expire removes the events older than the maximum age.
+func (j *EventJournal) expire(now time.Time) error {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	if j.done == nil {
+		return nil
+	}
+	first := j.first
+	if err := j.trim(now); err != nil || j.first == first {
+		return err
+	}
+	return j.changed()
+}
+
+// save saves the changes not saved yet.
+func (j *EventJournal) save() error {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	if j.done == nil {
+		return nil
+	}
+	return j.flush()
+}
+
+// changed records a change, saving the changes once there are enough of them.
+// The caller must hold the mutex.
+func (j *EventJournal) changed() error {
+	j.unsaved++
+	if j.unsaved < j.options.FlushChanges {
+		return nil
+	}
+	return j.flush()
+}
+
+// flush saves the changes not saved yet as a new version of the store. The caller must hold the mutex.
+func (j *EventJournal) flush() error {
+	if j.unsaved == 0 {
+		return nil
+	}
+	if err := saveVersion(j.store); err != nil {
+		return fmt.Errorf("failed to save journal: %w", err)
+	}
+	j.unsaved = 0
+	return nil
+}
+
+// trim removes the oldest events exceeding the retention limits, without saving the store.
+// The caller must hold the mutex.
+func (j *EventJournal) trim(now time.Time) error {
+	for j.first < j.next {
+		key := journalEventKey(j.first)
+		value, err := j.store.Get(key)
+		if err != nil {
+			return fmt.Errorf("failed to read journal: %w", err)
+		}
+
+		if value != nil && !j.exceeds(value, now) {
+			return nil
+		}
+		if value != nil {
+			if err := j.store.Delete(key); err != nil {
+				return fmt.Errorf("failed to remove event %d: %w", j.first, err)
+			}
+			j.size -= len(value)
+		}
+		j.first++
+	}
+	return nil
+}
+
+// exceeds checks if the oldest event, with the given encoding, exceeds the retention limits.
+// The caller must hold the mutex.
+func (j *EventJournal) exceeds(value []byte, now time.Time) bool {
+	if j.options.MaxEvents > 0 && j.next-j.first > uint64(j.options.MaxEvents) {
+		return true
+	}
+	// The newest event is kept even if it exceeds the maximum size on its own
+	if j.options.MaxBytes > 0 && j.size > j.options.MaxBytes && j.next-j.first > 1 {
+		return true
+	}
+	if j.options.MaxAge > 0 {
+		record := &journalRecord{}
+		if err := json.Unmarshal(value, record); err != nil || now.Sub(record.Time) > j.options.MaxAge {
+			return true
+		}
+	}
+	return false
+}
+
+// read returns the entries of a topic from the given offset, at most journalReadBatch events being read,
+// and the offset following the events read. When the end of the journal is reached, it also returns
+// a channel closed when the next event is appended.
+func (j *EventJournal) read(topic string, offset uint64) ([]JournalEntry, uint64, <-chan struct{}, error) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	if j.done == nil {
+		return nil, offset, nil, types.ErrJournalNotStarted
+	}
+	if offset < j.first {
+		offset = j.first
+	}
+	end := min(j.next, offset+journalReadBatch)
+
+	var (
+		entries   []JournalEntry
+		decodeErr error
+	)
+	err := j.store.IterateRange(journalEventKey(offset), journalEventKey(end), true, func(key, value []byte) bool {
+		record := &journalRecord{}
+		if decodeErr = json.Unmarshal(value, record); decodeErr != nil {
+			decodeErr = fmt.Errorf("failed to decode event %d: %w", decodeOffset(key[len(journalEventPrefix):]), decodeErr)
+			return true
+		}
+		if common.MatchTopic(topic, record.Type) {
+			entries = append(entries, JournalEntry{
+				Offset: decodeOffset(key[len(journalEventPrefix):]),
+				Time:   record.Time,
+				Event:  record.event(),
+			})
+		}
+		return false
+	})
+	if err == nil {
+		err = decodeErr
+	}
+	if err != nil {
+		return nil, offset, nil, fmt.Errorf("failed to read journal: %w", err)
+	}
+
+	if end == j.next {
+		return entries, end, j.appended, nil
+	}
+	return entries, end, nil, nil
+}
+
+// subscribeReader starts a reader of the journal from the given offset.
+func (j *EventJournal) subscribeReader(topic string, offset uint64, group string, handler JournalHandler) (common.Subscription, error) {
+	if handler == nil {
+		return nil, fmt.Errorf("no handler given for topic %s", topic)
+	}
+	if err := common.ValidateTopicPattern(topic); err != nil {
+		return nil, err
+	}
+
+	j.mutex.Lock()
+	done := j.done
+	j.mutex.Unlock()
+	if done == nil {
+		return nil, types.ErrJournalNotStarted
+	}
+
+	reader := &journalReader{
+		journal:  j,
+		topic:    topic,
+		group:    group,
+		handler:  handler,
+		position: offset,
+		stopped:  make(chan struct{}),
+	}
+	go reader.run(done)
+
+	return reader, nil
+}
+
+// logError logs an error if the system has a logger.
+func (j *EventJournal) logError(message string, err error) {
+	if logger := j.System.Logger(); logger != nil {
+		logger.Log(common.LevelError, message, err)
+	}
+}
+
+// journalReader represents a subscription to the journal, delivering the events on its own goroutine.
+type journalReader struct {
+	journal  *EventJournal
+	topic    string
+	group    string // Consumer group committing the offsets, if any
+	handler  JournalHandler
+	position uint64 // Offset of the next event to read
+	stop     sync.Once
+	stopped  chan struct{}
+}
+
+// Topic returns the topic or topic pattern of the subscription.
+func (r *journalReader) Topic() string {
+	return r.topic
+}
+
+// Unsubscribe ends the subscription. Returns an error if it already ended.
+func (r *journalReader) Unsubscribe() error {
+	unsubscribed := false
+	r.stop.Do(func() {
+		close(r.stopped)
+		unsubscribed = true
+	})
+	if !unsubscribed {
+		return fmt.Errorf("%w: topic %s", common.ErrSubscriptionNotFound, r.topic)
+	}
+	return nil
+}
+
+// run delivers the events of the journal until the subscription ends or the journal stops.
+func (r *journalReader) run(done chan struct{}) {
+	for {
+		entries, next, appended, err := r.journal.read(r.topic, r.position)
+		if err != nil {
+			r.journal.logError("Error reading journal:", err)
+			return
+		}
+
+		for _, entry := range entries {
+			select {
+			case <-r.stopped:
+				return
+			case <-done:
+				return
+			default:
+			}
+			r.handle(entry)
+		}
+		r.position = next
+
+		if appended == nil {
+			continue
+		}
+		select {
+		case <-appended:
+		case <-r.stopped:
+			return
+		case <-done:
+			return
+		}
+	}
+}
+
+// handle calls the handler with an entry, recovering its panics, then commits the offset of the group.
+// The offset is not committed when the handler panics.
+func (r *journalReader) handle(entry JournalEntry) {
+	panicked := func() (panicked bool) {
+		defer func() {
+			if p := recover(); p != nil {
+				panicked = true
+				if logger := r.journal.System.Logger(); logger != nil {
+					logger.Logf(common.LevelError, "topic=%s offset=%d panic=%v\n%s", r.topic, entry.Offset, p, debug.Stack())
+				}
+			}
+		}()
+		r.handler(entry)
+		return false
+	}()
+
+	if r.group != "" && !panicked {
+		if err := r.journal.Commit(r.group, entry.Offset+1); err != nil {
+			r.journal.logError("Error committing offset of group "+r.group+":", err)
+		}
+	}
+}
+
+// journalEventKey returns the key of the event with the given offset, the keys sorting in offset order.
+func journalEventKey(offset uint64) []byte {
+	return append(append([]byte(nil), journalEventPrefix...), encodeOffset(offset)...)
+}
+
+// journalGroupKey returns the key of the offset committed by a consumer group.
+func journalGroupKey(group string) []byte {
+	return append(append([]byte(nil), journalGroupPrefix...), group...)
+}
+
+// encodeOffset encodes an offset as big-endian bytes.
+func encodeOffset(offset uint64) []byte {
+	return binary.BigEndian.AppendUint64(nil, offset)
+}
+
+// decodeOffset decodes an offset encoded as big-endian bytes.
+func decodeOffset(data []byte) uint64 {
+	if len(data) != 8 {
+		return 0
+	}
+	return binary.BigEndian.Uint64(data)
+}
@@ -0,0 +1,351 @@
+package system_test
+
+import (
+	"encoding/json"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/ebanfa/skeleton/pkg/common"
+	"github.com/ebanfa/skeleton/pkg/db"
+	"github.com/ebanfa/skeleton/pkg/mocks"
+	"github.com/ebanfa/skeleton/pkg/store"
+	"github.com/ebanfa/skeleton/pkg/system"
+	"github.com/ebanfa/skeleton/pkg/types"
+)
+
+var _ = Describe("EventJournal", func() {
+	var (
+		ctx        *common.Context
+		dir        string
+		eventBus   common.EventBusInterface
+		multiStore types.MultiStore
+		mockSystem *mocks.SystemInterface
+		options    system.EventJournalOptions
+		journal    *system.EventJournal
+		entries    chan system.JournalEntry
+	)
+
+	// open creates a journal on the store of the data directory, as after a restart.
+	open := func() {
+		if multiStore != nil {
+			Expect(multiStore.Close()).To(Succeed())
+		}
+
+		var err error
+		multiStore, err = store.CreateMultiStore("system", dir, store.NewStoreFactory(dir, db.NewIAVLDatabaseFactory()))
+		Expect(err).NotTo(HaveOccurred())
+
+		journal = system.NewEventJournal("journal", options)
+		Expect(journal.Initialize(ctx, mockSystem)).To(Succeed())
+		Expect(journal.Start(ctx)).To(Succeed())
+	}
+
+	handler := func(entry system.JournalEntry) {
+		entries <- entry
+	}
+
+	nextEntry := func() system.JournalEntry {
+		var entry system.JournalEntry
+		Eventually(entries).Should(Receive(&entry))
+		return entry
+	}
+
+	publish := func(topics ...string) {
+		for i, topic := range topics {
+			eventBus.Publish(common.Event{Type: topic, Data: i})
+		}
+	}
+
+	BeforeEach(func() {
+		ctx = common.Background()
+		dir = GinkgoT().TempDir()
+		eventBus = common.NewSystemEventBus()
+		options = system.EventJournalOptions{}
+		entries = make(chan system.JournalEntry, 100)
+		multiStore = nil
+
+		mockSystem = &mocks.SystemInterface{}
+		mockSystem.On("EventBus").Return(func() common.EventBusInterface { return eventBus })
+		mockSystem.On("Logger").Return(nil)
+		mockSystem.On("MultiStore").Return(func() types.MultiStore { return multiStore })
+	})
+
+	AfterEach(func() {
+		if journal != nil {
+			Expect(journal.Stop(ctx)).To(Succeed())
+		}
+		journal = nil
+		if multiStore != nil {
+			Expect(multiStore.Close()).To(Succeed())
+		}
+	})
+
+	It("requires a system and a store", func() {
+		Expect(system.NewEventJournal("journal", options).Start(ctx)).To(MatchError(types.ErrSystemNotInitialized))
+
+		withoutStore := system.NewEventJournal("journal", options)
+		Expect(withoutStore.Initialize(ctx, mockSystem)).To(Succeed())
+		Expect(withoutStore.Start(ctx)).To(MatchError(types.ErrStoreNotFound))
+
+		_, err := withoutStore.Append(common.Event{Type: "topic"})
+		Expect(err).To(MatchError(types.ErrJournalNotStarted))
+		_, err = withoutStore.SubscribeFrom(">", 0, handler)
+		Expect(err).To(MatchError(types.ErrJournalNotStarted))
+	})
+
+	Context("when started", func() {
+		JustBeforeEach(func() {
+			open()
+		})
+
+		It("journals the published events with increasing offsets", func() {
+			publish("service.started", "service.stopped")
+
+			first, next := journal.Offsets()
+			Expect(first).To(BeZero())
+			Expect(next).To(Equal(uint64(2)))
+
+			offset, err := journal.Append(common.Event{Type: "direct", Data: map[string]string{"key": "value"}})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(offset).To(Equal(uint64(2)))
+		})
+
+		It("replays the journaled events, then delivers the new ones", func() {
+			publish("service.started", "store.opened")
+
+			subscription, err := journal.SubscribeFrom(">", 0, handler)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(subscription.Topic()).To(Equal(">"))
+
+			entry := nextEntry()
+			Expect(entry.Offset).To(BeZero())
+			Expect(entry.Event.Type).To(Equal("service.started"))
+			Expect(entry.Event.Data).To(Equal(json.RawMessage("0")))
+			Expect(entry.Time).NotTo(BeZero())
+			Expect(nextEntry().Offset).To(Equal(uint64(1)))
+
+			publish("service.stopped")
+			entry = nextEntry()
+			Expect(entry.Offset).To(Equal(uint64(2)))
+			Expect(entry.Event.Type).To(Equal("service.stopped"))
+
+			Expect(subscription.Unsubscribe()).To(Succeed())
+			Expect(subscription.Unsubscribe()).To(MatchError(common.ErrSubscriptionNotFound))
+			publish("service.started")
+			Consistently(entries).ShouldNot(Receive())
+		})
+
+		It("keeps the envelope of the events", func() {
+			published := common.NewEvent(common.Background().WithTraceID("trace"), "api", "service.started", nil)
+			published.Version = 3
+			published.Headers = map[string]string{"key": "value"}
+			eventBus.Publish(published)
+
+			Expect(journal.SubscribeFrom(">", 0, handler)).Error().NotTo(HaveOccurred())
+			event := nextEntry().Event
+			Expect(event.ID).To(Equal(published.ID))
+			Expect(event.Source).To(Equal("api"))
+			Expect(event.Time).To(BeTemporally("==", published.Time))
+			Expect(event.TraceID).To(Equal("trace"))
+			Expect(event.Version).To(Equal(3))
+			Expect(event.Headers).To(Equal(published.Headers))
+		})
+
+		It("replays the events of a topic from an offset", func() {
+			publish("service.started", "store.opened", "service.stopped", "service.started")
+
+			Expect(journal.SubscribeFrom("service.>", 1, handler)).Error().NotTo(HaveOccurred())
+
+			Expect(nextEntry().Offset).To(Equal(uint64(2)))
+			Expect(nextEntry().Offset).To(Equal(uint64(3)))
+			Consistently(entries).ShouldNot(Receive())
+		})
+
+		It("rejects the invalid topic patterns", func() {
+			Expect(journal.SubscribeFrom("store.>.opened", 0, handler)).Error().To(MatchError(common.ErrInvalidTopicPattern))
+		})
+
+		It("resumes the consumer groups from their committed offset after a restart", func() {
+			publish("a", "b")
+
+			subscription, err := journal.SubscribeGroup("group", ">", handler)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(nextEntry().Offset).To(Equal(uint64(0)))
+			Expect(nextEntry().Offset).To(Equal(uint64(1)))
+			Eventually(func() uint64 {
+				offset, _, _ := journal.CommittedOffset("group")
+				return offset
+			}).Should(Equal(uint64(2)))
+			Expect(subscription.Unsubscribe()).To(Succeed())
+
+			publish("c")
+			Expect(journal.Stop(ctx)).To(Succeed())
+			open()
+
+			first, next := journal.Offsets()
+			Expect(first).To(BeZero())
+			Expect(next).To(Equal(uint64(3)))
+
+			offset, committed, err := journal.CommittedOffset("group")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(committed).To(BeTrue())
+			Expect(offset).To(Equal(uint64(2)))
+
+			Expect(journal.SubscribeGroup("group", ">", handler)).Error().NotTo(HaveOccurred())
+			entry := nextEntry()
+			Expect(entry.Offset).To(Equal(uint64(2)))
+			Expect(entry.Event.Type).To(Equal("c"))
+			Consistently(entries).ShouldNot(Receive())
+		})
+
+		It("does not commit the offset of a group when the handler panics", func() {
+			publish("a", "b")
+
+			Expect(journal.SubscribeGroup("group", ">", func(entry system.JournalEntry) {
+				entries <- entry
+				if entry.Event.Type == "b" {
+					panic("handler failed")
+				}
+			})).Error().NotTo(HaveOccurred())
+			Expect(nextEntry().Event.Type).To(Equal("a"))
+			Expect(nextEntry().Event.Type).To(Equal("b"))
+
+			Consistently(func() uint64 {
+				offset, _, _ := journal.CommittedOffset("group")
+				return offset
+			}).Should(Equal(uint64(1)))
+		})
+
+		It("reports the groups that never committed", func() {
+			_, committed, err := journal.CommittedOffset("unknown")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(committed).To(BeFalse())
+		})
+
+		It("ends the subscriptions when stopped", func() {
+			Expect(journal.SubscribeFrom(">", 0, handler)).Error().NotTo(HaveOccurred())
+			Expect(journal.Stop(ctx)).To(Succeed())
+			journal = nil
+
+			publish("a")
+			Consistently(entries).ShouldNot(Receive())
+		})
+
+		Context("when saving the changes", func() {
+			// journalVersion returns the saved version of the journal store.
+			journalVersion := func() int64 {
+				journalStore, _, err := multiStore.CreateStore("journal")
+				Expect(err).NotTo(HaveOccurred())
+				return journalStore.Version()
+			}
+
+			BeforeEach(func() {
+				options.FlushInterval = time.Hour
+				options.FlushChanges = 10
+			})
+
+			It("saves the changes in batches", func() {
+				for i := 0; i < 25; i++ {
+					publish("a")
+				}
+				Expect(journalVersion()).To(Equal(int64(2)))
+
+				Expect(journal.Stop(ctx)).To(Succeed())
+				journal = nil
+				Expect(journalVersion()).To(Equal(int64(3)))
+			})
+
+			It("prunes the older versions of the store", func() {
+				for i := 0; i < 1500; i++ {
+					publish("a")
+				}
+
+				journalStore, _, err := multiStore.CreateStore("journal")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(journalStore.Version()).To(Equal(int64(150)))
+				Expect(len(journalStore.AvailableVersions())).To(BeNumerically("<", 100))
+			})
+
+			Context("with a flush interval", func() {
+				BeforeEach(func() {
+					options.FlushInterval = 10 * time.Millisecond
+				})
+
+				It("saves the changes once the interval elapsed", func() {
+					publish("a")
+					Eventually(journalVersion).Should(Equal(int64(1)))
+				})
+			})
+		})
+
+		Context("with only some topics", func() {
+			BeforeEach(func() {
+				options.Topics = []string{"store.>"}
+			})
+
+			It("journals the events of the topics", func() {
+				publish("service.started", "store.opened")
+
+				Expect(journal.SubscribeFrom(">", 0, handler)).Error().NotTo(HaveOccurred())
+				entry := nextEntry()
+				Expect(entry.Offset).To(BeZero())
+				Expect(entry.Event.Type).To(Equal("store.opened"))
+			})
+		})
+
+		Context("with a maximum number of events", func() {
+			BeforeEach(func() {
+				options.MaxEvents = 2
+			})
+
+			It("removes the oldest events", func() {
+				publish("a", "b", "c", "d")
+
+				first, next := journal.Offsets()
+				Expect(first).To(Equal(uint64(2)))
+				Expect(next).To(Equal(uint64(4)))
+
+				Expect(journal.SubscribeFrom(">", 0, handler)).Error().NotTo(HaveOccurred())
+				Expect(nextEntry().Event.Type).To(Equal("c"))
+				Expect(nextEntry().Event.Type).To(Equal("d"))
+			})
+		})
+
+		Context("with a maximum size", func() {
+			BeforeEach(func() {
+				options.MaxBytes = 1
+			})
+
+			It("keeps only the newest event", func() {
+				publish("a", "b", "c")
+
+				first, next := journal.Offsets()
+				Expect(first).To(Equal(uint64(2)))
+				Expect(next).To(Equal(uint64(3)))
+			})
+		})
+
+		Context("with a maximum age", func() {
+			BeforeEach(func() {
+				options.MaxAge = 50 * time.Millisecond
+				options.RetentionInterval = 10 * time.Millisecond
+			})
+
+			It("removes the expired events", func() {
+				publish("a", "b")
+
+				Eventually(func() uint64 {
+					first, _ := journal.Offsets()
+					return first
+				}).Should(Equal(uint64(2)))
+
+				// The offsets are not reused once the journal is empty
+				offset, err := journal.Append(common.Event{Type: "c"})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(offset).To(Equal(uint64(2)))
+			})
+		})
+	})
+})
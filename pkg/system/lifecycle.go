@@ -0,0 +1,108 @@
+package system
+
+import (
+	"sync"
+
+	"github.com/ebanfa/skeleton/pkg/types"
+)
+
+const (
+	// EventTypeServiceStateChanged represents an event emitted when a service changes lifecycle status.
+	EventTypeServiceStateChanged string = "service_state_changed"
+)
+
+// serviceTransitions lists the statuses a service can move to from each status.
+var serviceTransitions = map[types.ServiceStatusType][]types.ServiceStatusType{
+	types.ServiceCreatedType:     {types.ServiceInitializedType, types.ServiceFailedType},
+	types.ServiceInitializedType: {types.ServiceStartingType},
+	types.ServiceStartingType:    {types.ServiceRunningType, types.ServiceFailedType},
+	types.ServiceRunningType:     {types.ServiceStoppingType, types.ServiceFailedType},
+	types.ServiceStoppingType:    {types.ServiceStoppedType, types.ServiceFailedType},
+	types.ServiceStoppedType:     {types.ServiceStartingType},
+	types.ServiceFailedType:      {types.ServiceStartingType, types.ServiceStoppingType},
+}
+
+// ServiceStateChange represents the data of a service state changed event.
+type ServiceStateChange struct {
+	// ServiceID is the ID of the service.
+	ServiceID string
+
+	// From is the previous status of the service.
+	From types.ServiceStatusType
+
+	// To is the new status of the service.
+	To types.ServiceStatusType
+}
+
+// IsValidServiceTransition checks if a service can move from one lifecycle status to another.
+func IsValidServiceTransition(from, to types.ServiceStatusType) bool {
+	for _, status := range serviceTransitions[from] {
+		if status == to {
+			return true
+		}
+	}
+	return false
+}
+
+// serviceLifecycle keeps track of the lifecycle status of services.
+// Services that are not tracked yet are considered initialized, as components
+// are initialized when registered with the system.
+type serviceLifecycle struct {
+	mutex    sync.RWMutex
+	statuses map[string]types.ServiceStatusType
+}
+
+// newServiceLifecycle creates a new instance of serviceLifecycle.
+func newServiceLifecycle() *serviceLifecycle {
+	return &serviceLifecycle{
+		statuses: make(map[string]types.ServiceStatusType),
+	}
+}
+
+// status returns the status of the service with the given ID.
+func (l *serviceLifecycle) status(id string) types.ServiceStatusType {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+
+	status, ok := l.statuses[id]
+	if !ok {
+		return types.ServiceInitializedType
+	}
+	return status
+}
+
+// track starts tracking the service with the given ID with the given status,
+// regardless of its current status.
+func (l *serviceLifecycle) track(id string, status types.ServiceStatusType) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.statuses[id] = status
+}
+
+// untrack stops tracking the service with the given ID.
+func (l *serviceLifecycle) untrack(id string) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	delete(l.statuses, id)
+}
+
+// transition moves the service with the given ID to the given status.
+// Returns the state change, or an error if the transition is not valid.
+func (l *serviceLifecycle) transition(id string, to types.ServiceStatusType) (ServiceStateChange, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	from, ok := l.statuses[id]
+	if !ok {
+		from = types.ServiceInitializedType
+	}
+
+	if !IsValidServiceTransition(from, to) {
+		return ServiceStateChange{}, &types.StateTransitionError{ServiceID: id, From: from, To: to}
+	}
+
+	l.statuses[id] = to
+	return ServiceStateChange{ServiceID: id, From: from, To: to}, nil
+}
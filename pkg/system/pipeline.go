@@ -0,0 +1,244 @@
+package system
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ebanfa/skeleton/pkg/common"
+	"github.com/ebanfa/skeleton/pkg/types"
+)
+
+// DefaultPipelineMaxSteps is the maximum number of steps run by a pipeline execution when none is configured.
+const DefaultPipelineMaxSteps = 1000
+
+// completedStep represents a step completed during a pipeline execution, along with its input and output.
+type completedStep struct {
+	step   *types.PipelineStep
+	input  interface{}
+	output interface{}
+}
+
+// Pipeline is an operation running other operations in steps, the output of each step being the input
+// of the next one. Steps run in order unless they declare a next step or conditional branches, which
+// allows pipelines to form graphs. When a step fails, the compensating operations of the completed steps
+// are run in reverse order, so that a pipeline can undo its changes as a saga.
+type Pipeline struct {
+	BaseSystemOperation
+	config types.PipelineConfiguration
+	steps  map[string]int // Map of step ID to the index of the step
+}
+
+// NewPipeline creates a new instance of Pipeline.
+// Returns an error if the steps are invalid or refer to unknown steps.
+func NewPipeline(id, name, description string, config types.PipelineConfiguration) (*Pipeline, error) {
+	if config.MaxSteps <= 0 {
+		config.MaxSteps = DefaultPipelineMaxSteps
+	}
+	if len(config.Steps) == 0 {
+		return nil, fmt.Errorf("%w: pipeline %s has no steps", types.ErrInvalidPipeline, id)
+	}
+
+	steps := make(map[string]int, len(config.Steps))
+	for i, step := range config.Steps {
+		switch {
+		case step.ID == "" || step.ID == types.PipelineEndStep:
+			return nil, fmt.Errorf("%w: pipeline %s: step %d has an invalid ID %q", types.ErrInvalidPipeline, id, i, step.ID)
+		case step.OperationID == "":
+			return nil, fmt.Errorf("%w: pipeline %s: step %s has no operation", types.ErrInvalidPipeline, id, step.ID)
+		}
+		if _, exists := steps[step.ID]; exists {
+			return nil, fmt.Errorf("%w: pipeline %s: duplicate step %s", types.ErrInvalidPipeline, id, step.ID)
+		}
+		steps[step.ID] = i
+	}
+
+	// Check the targets once every step is known, so that steps can go back to previous steps
+	for _, step := range config.Steps {
+		targets := []string{step.Next}
+		for _, branch := range step.Branches {
+			if branch.Condition == "" {
+				return nil, fmt.Errorf("%w: pipeline %s: branch of step %s has no condition", types.ErrInvalidPipeline, id, step.ID)
+			}
+			targets = append(targets, branch.Next)
+		}
+		for _, target := range targets {
+			if _, exists := steps[target]; !exists && target != "" && target != types.PipelineEndStep {
+				return nil, fmt.Errorf("%w: pipeline %s: step %s goes to unknown step %s", types.ErrInvalidPipeline, id, step.ID, target)
+			}
+		}
+	}
+
+	return &Pipeline{
+		BaseSystemOperation: *NewBaseSystemOperation(id, name, description),
+		config:              config,
+		steps:               steps,
+	}, nil
+}
+
+// Type returns the type of the component.
+func (p *Pipeline) Type() types.ComponentType {
+	return types.OperationType
+}
+
+// Execute runs the steps of the pipeline with the given input, and returns the output of the last step.
+// When a step fails, the completed steps are compensated and the error of the step is returned
+// joined with the errors of the compensations that failed.
+func (p *Pipeline) Execute(ctx *common.Context, input *types.SystemOperationInput) (*types.SystemOperationOutput, error) {
+	if p.System == nil {
+		return nil, types.ErrSystemNotInitialized
+	}
+
+	var data interface{}
+	if input != nil {
+		data = input.Data
+	}
+
+	var completed []completedStep
+	for index, count := 0, 0; index < len(p.config.Steps); count++ {
+		step := p.config.Steps[index]
+
+		var err error
+		if count >= p.config.MaxSteps {
+			err = fmt.Errorf("pipeline %s exceeded %d steps", p.ID(), p.config.MaxSteps)
+		}
+
+		var output interface{}
+		if err == nil {
+			output, err = p.runStep(ctx, step, data)
+		}
+		if err == nil {
+			completed = append(completed, completedStep{step: step, input: data, output: output})
+			data = output
+			index, err = p.next(ctx, step, index, data)
+		}
+
+		if err != nil {
+			err = fmt.Errorf("%w: step %s: %w", types.ErrPipelineStepFailed, step.ID, err)
+			return nil, errors.Join(err, p.compensate(ctx, completed))
+		}
+	}
+
+	return &types.SystemOperationOutput{Data: data}, nil
+}
+
+// runStep runs the operation of a step until it succeeds or the step runs out of attempts.
+func (p *Pipeline) runStep(ctx *common.Context, step *types.PipelineStep, data interface{}) (interface{}, error) {
+	attempts := max(step.MaxAttempts, 1)
+
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if err = ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		var output *types.SystemOperationOutput
+		output, err = p.System.ExecuteOperation(ctx, step.OperationID, &types.SystemOperationInput{Data: data})
+		if err == nil {
+			if output == nil {
+				return nil, nil
+			}
+			return output.Data, nil
+		}
+
+		if attempt < attempts && step.RetryDelay > 0 {
+			select {
+			case <-ctx.Done():
+				return nil, errors.Join(err, ctx.Err())
+			case <-time.After(step.RetryDelay):
+			}
+		}
+	}
+
+	return nil, err
+}
+
+// next returns the index of the step following the given step, depending on its branches.
+// The index of the end of the pipeline is the number of steps.
+func (p *Pipeline) next(ctx *common.Context, step *types.PipelineStep, index int, data interface{}) (int, error) {
+	for _, branch := range step.Branches {
+		holds, err := p.condition(ctx, branch.Condition, data)
+		if err != nil {
+			return 0, fmt.Errorf("condition %s: %w", branch.Condition, err)
+		}
+		if holds {
+			return p.stepIndex(branch.Next), nil
+		}
+	}
+
+	if step.Next != "" {
+		return p.stepIndex(step.Next), nil
+	}
+	return index + 1, nil
+}
+
+// condition runs the operation of a branch condition, which must return a bool.
+func (p *Pipeline) condition(ctx *common.Context, operationID string, data interface{}) (bool, error) {
+	output, err := p.System.ExecuteOperation(ctx, operationID, &types.SystemOperationInput{Data: data})
+	if err != nil {
+		return false, err
+	}
+	if output == nil || output.Data == nil {
+		return false, nil
+	}
+	return typedValue[bool](operationID, "output", output.Data)
+}
+
+// stepIndex returns the index of the step with the given ID, or the number of steps for the end of the pipeline.
+func (p *Pipeline) stepIndex(stepID string) int {
+	if index, ok := p.steps[stepID]; ok {
+		return index
+	}
+	return len(p.config.Steps)
+}
+
+// compensate runs the compensating operations of the completed steps in reverse order.
+// Compensations run even if the execution was canceled, and a failing compensation does not stop the others.
+// Returns the errors of the compensations that failed joined together.
+func (p *Pipeline) compensate(ctx *common.Context, completed []completedStep) error {
+	ctx = common.WithoutCancel(ctx)
+
+	var errs []error
+	for i := len(completed) - 1; i >= 0; i-- {
+		step := completed[i].step
+		if step.Compensation == "" {
+			continue
+		}
+
+		input := &types.SystemOperationInput{Data: types.PipelineCompensationInput{
+			StepID: step.ID,
+			Input:  completed[i].input,
+			Output: completed[i].output,
+		}}
+		if _, err := p.System.ExecuteOperation(ctx, step.Compensation, input); err != nil {
+			errs = append(errs, fmt.Errorf("%w: step %s: %w", types.ErrCompensationFailed, step.ID, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// PipelineFactory creates pipelines from the pipeline configuration held in their custom configuration.
+type PipelineFactory struct{}
+
+// NewPipelineFactory creates a new instance of PipelineFactory.
+func NewPipelineFactory() *PipelineFactory {
+	return &PipelineFactory{}
+}
+
+// NewCustomConfig returns a new pipeline configuration, the type of the custom configuration of pipelines.
+func (f *PipelineFactory) NewCustomConfig() interface{} {
+	return &types.PipelineConfiguration{}
+}
+
+// CreateComponent creates a pipeline from the given configuration.
+// Returns an error if the custom configuration is not a valid pipeline configuration.
+func (f *PipelineFactory) CreateComponent(config *types.ComponentConfig) (types.ComponentInterface, error) {
+	pipelineConfig, ok := config.CustomConfig.(*types.PipelineConfiguration)
+	if !ok {
+		return nil, fmt.Errorf("%w: custom configuration of type %T, expected *types.PipelineConfiguration",
+			types.ErrInvalidPipeline, config.CustomConfig)
+	}
+
+	return NewPipeline(config.ID, config.Name, config.Description, *pipelineConfig)
+}
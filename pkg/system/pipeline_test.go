@@ -0,0 +1,259 @@
+package system_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/ebanfa/skeleton/pkg/common"
+	"github.com/ebanfa/skeleton/pkg/mocks"
+	"github.com/ebanfa/skeleton/pkg/system"
+	"github.com/ebanfa/skeleton/pkg/types"
+)
+
+var _ = Describe("Pipeline", func() {
+	var (
+		ctx        *common.Context
+		mockSystem *mocks.SystemInterface
+		operations map[string]func(data interface{}) (interface{}, error)
+		mutex      sync.Mutex
+		calls      []string
+	)
+
+	newPipeline := func(steps ...*types.PipelineStep) *system.Pipeline {
+		pipeline, err := system.NewPipeline("pipeline", "Pipeline", "", types.PipelineConfiguration{Steps: steps})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(pipeline.Initialize(ctx, mockSystem)).To(Succeed())
+		return pipeline
+	}
+
+	recorded := func() []string {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return append([]string(nil), calls...)
+	}
+
+	BeforeEach(func() {
+		ctx = common.Background()
+		mockSystem = &mocks.SystemInterface{}
+		calls = nil
+		operations = map[string]func(data interface{}) (interface{}, error){
+			"increment": func(data interface{}) (interface{}, error) { return data.(int) + 1, nil },
+			"double":    func(data interface{}) (interface{}, error) { return data.(int) * 2, nil },
+			"isEven":    func(data interface{}) (interface{}, error) { return data.(int)%2 == 0, nil },
+			"fail":      func(data interface{}) (interface{}, error) { return nil, errors.New("step failed") },
+			"undo":      func(data interface{}) (interface{}, error) { return nil, nil },
+		}
+
+		mockSystem.On("ExecuteOperation", mock.Anything, mock.Anything, mock.Anything).Return(
+			func(ctx *common.Context, operationID string, input *types.SystemOperationInput) (*types.SystemOperationOutput, error) {
+				mutex.Lock()
+				calls = append(calls, operationID)
+				operation, ok := operations[operationID]
+				mutex.Unlock()
+
+				if !ok {
+					return nil, types.ErrComponentNotFound
+				}
+				output, err := operation(input.Data)
+				if err != nil {
+					return nil, err
+				}
+				return &types.SystemOperationOutput{Data: output}, nil
+			})
+	})
+
+	DescribeTable("NewPipeline rejects invalid steps",
+		func(steps ...*types.PipelineStep) {
+			_, err := system.NewPipeline("pipeline", "Pipeline", "", types.PipelineConfiguration{Steps: steps})
+			Expect(err).To(MatchError(types.ErrInvalidPipeline))
+		},
+		Entry("no steps"),
+		Entry("a step without ID", &types.PipelineStep{OperationID: "increment"}),
+		Entry("a step named after the end", &types.PipelineStep{ID: types.PipelineEndStep, OperationID: "increment"}),
+		Entry("a step without operation", &types.PipelineStep{ID: "a"}),
+		Entry("duplicate steps", &types.PipelineStep{ID: "a", OperationID: "increment"}, &types.PipelineStep{ID: "a", OperationID: "double"}),
+		Entry("an unknown next step", &types.PipelineStep{ID: "a", OperationID: "increment", Next: "b"}),
+		Entry("a branch without condition", &types.PipelineStep{ID: "a", OperationID: "increment", Branches: []*types.PipelineBranch{{Next: "a"}}}),
+	)
+
+	It("requires a system", func() {
+		pipeline, err := system.NewPipeline("pipeline", "Pipeline", "", types.PipelineConfiguration{
+			Steps: []*types.PipelineStep{{ID: "a", OperationID: "increment"}},
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = pipeline.Execute(ctx, &types.SystemOperationInput{Data: 1})
+		Expect(err).To(MatchError(types.ErrSystemNotInitialized))
+	})
+
+	It("feeds the output of each step into the next one", func() {
+		pipeline := newPipeline(
+			&types.PipelineStep{ID: "a", OperationID: "increment"},
+			&types.PipelineStep{ID: "b", OperationID: "double"},
+			&types.PipelineStep{ID: "c", OperationID: "increment"},
+		)
+
+		output, err := pipeline.Execute(ctx, &types.SystemOperationInput{Data: 1})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(output.Data).To(Equal(5))
+		Expect(recorded()).To(Equal([]string{"increment", "double", "increment"}))
+	})
+
+	It("retries the failing steps", func() {
+		attempts := 0
+		operations["flaky"] = func(data interface{}) (interface{}, error) {
+			if attempts++; attempts < 3 {
+				return nil, errors.New("not yet")
+			}
+			return data, nil
+		}
+		pipeline := newPipeline(&types.PipelineStep{ID: "a", OperationID: "flaky", MaxAttempts: 3})
+
+		output, err := pipeline.Execute(ctx, &types.SystemOperationInput{Data: 1})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(output.Data).To(Equal(1))
+		Expect(attempts).To(Equal(3))
+	})
+
+	Describe("branches", func() {
+		var pipeline *system.Pipeline
+
+		BeforeEach(func() {
+			pipeline = newPipeline(
+				&types.PipelineStep{ID: "start", OperationID: "increment", Branches: []*types.PipelineBranch{
+					{Condition: "isEven", Next: "even"},
+				}},
+				&types.PipelineStep{ID: "odd", OperationID: "increment", Next: types.PipelineEndStep},
+				&types.PipelineStep{ID: "even", OperationID: "double"},
+			)
+		})
+
+		It("goes to the step of the first branch whose condition holds", func() {
+			output, err := pipeline.Execute(ctx, &types.SystemOperationInput{Data: 1})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(output.Data).To(Equal(4))
+			Expect(recorded()).To(Equal([]string{"increment", "isEven", "double"}))
+		})
+
+		It("goes to the next step when no condition holds", func() {
+			output, err := pipeline.Execute(ctx, &types.SystemOperationInput{Data: 2})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(output.Data).To(Equal(4))
+			Expect(recorded()).To(Equal([]string{"increment", "isEven", "increment"}))
+		})
+
+		It("fails when a condition does not return a bool", func() {
+			operations["isEven"] = operations["increment"]
+
+			_, err := pipeline.Execute(ctx, &types.SystemOperationInput{Data: 1})
+			Expect(err).To(MatchError(types.ErrPipelineStepFailed))
+			Expect(err).To(MatchError(types.ErrOperationTypeMismatch))
+		})
+	})
+
+	It("stops the executions running more than the maximum number of steps", func() {
+		pipeline, err := system.NewPipeline("pipeline", "Pipeline", "", types.PipelineConfiguration{
+			Steps:    []*types.PipelineStep{{ID: "loop", OperationID: "increment", Next: "loop"}},
+			MaxSteps: 10,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(pipeline.Initialize(ctx, mockSystem)).To(Succeed())
+
+		_, err = pipeline.Execute(ctx, &types.SystemOperationInput{Data: 0})
+		Expect(err).To(MatchError(ContainSubstring("exceeded 10 steps")))
+		Expect(recorded()).To(HaveLen(10))
+	})
+
+	Describe("compensation", func() {
+		var compensations []types.PipelineCompensationInput
+
+		BeforeEach(func() {
+			compensations = nil
+			operations["undo"] = func(data interface{}) (interface{}, error) {
+				compensations = append(compensations, data.(types.PipelineCompensationInput))
+				return nil, nil
+			}
+		})
+
+		It("compensates the completed steps in reverse order when a step fails", func() {
+			pipeline := newPipeline(
+				&types.PipelineStep{ID: "a", OperationID: "increment", Compensation: "undo"},
+				&types.PipelineStep{ID: "b", OperationID: "double"},
+				&types.PipelineStep{ID: "c", OperationID: "increment", Compensation: "undo"},
+				&types.PipelineStep{ID: "d", OperationID: "fail", Compensation: "undo"},
+			)
+
+			_, err := pipeline.Execute(ctx, &types.SystemOperationInput{Data: 1})
+			Expect(err).To(MatchError(types.ErrPipelineStepFailed))
+			Expect(err).To(MatchError(ContainSubstring("step d: step failed")))
+			Expect(compensations).To(Equal([]types.PipelineCompensationInput{
+				{StepID: "c", Input: 4, Output: 5},
+				{StepID: "a", Input: 1, Output: 2},
+			}))
+		})
+
+		It("reports the compensations that failed", func() {
+			pipeline := newPipeline(
+				&types.PipelineStep{ID: "a", OperationID: "increment", Compensation: "undo"},
+				&types.PipelineStep{ID: "b", OperationID: "increment", Compensation: "unknown"},
+				&types.PipelineStep{ID: "c", OperationID: "fail"},
+			)
+
+			_, err := pipeline.Execute(ctx, &types.SystemOperationInput{Data: 1})
+			Expect(err).To(MatchError(types.ErrPipelineStepFailed))
+			Expect(err).To(MatchError(types.ErrCompensationFailed))
+			Expect(err).To(MatchError(types.ErrComponentNotFound))
+			Expect(compensations).To(HaveLen(1))
+		})
+
+		It("compensates the completed steps when the execution is canceled", func() {
+			cancelCtx, cancel := common.WithCancel(ctx)
+			operations["cancel"] = func(data interface{}) (interface{}, error) {
+				cancel()
+				return data, nil
+			}
+			pipeline := newPipeline(
+				&types.PipelineStep{ID: "a", OperationID: "cancel", Compensation: "undo"},
+				&types.PipelineStep{ID: "b", OperationID: "increment"},
+			)
+
+			_, err := pipeline.Execute(cancelCtx, &types.SystemOperationInput{Data: 1})
+			Expect(err).To(MatchError(context.Canceled))
+			Expect(compensations).To(Equal([]types.PipelineCompensationInput{{StepID: "a", Input: 1, Output: 1}}))
+		})
+	})
+
+	Describe("PipelineFactory", func() {
+		It("creates pipelines from their decoded custom configuration", func() {
+			factory := system.NewPipelineFactory()
+			customConfig, err := system.DecodeCustomConfig(factory, map[string]interface{}{
+				"steps": []interface{}{
+					map[string]interface{}{"id": "a", "operationId": "increment", "maxAttempts": 2, "retryDelay": "10ms"},
+					map[string]interface{}{"id": "b", "operationId": "double"},
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			component, err := factory.CreateComponent(&types.ComponentConfig{ID: "pipeline", CustomConfig: customConfig})
+			Expect(err).NotTo(HaveOccurred())
+
+			pipeline := component.(*system.Pipeline)
+			Expect(pipeline.Type()).To(Equal(types.OperationType))
+			Expect(pipeline.Initialize(ctx, mockSystem)).To(Succeed())
+
+			output, err := pipeline.Execute(ctx, &types.SystemOperationInput{Data: 1})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(output.Data).To(Equal(4))
+		})
+
+		It("rejects the components without a pipeline configuration", func() {
+			_, err := system.NewPipelineFactory().CreateComponent(&types.ComponentConfig{ID: "pipeline"})
+			Expect(err).To(MatchError(types.ErrInvalidPipeline))
+		})
+	})
+})
@@ -0,0 +1,422 @@
+package system
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/ebanfa/skeleton/pkg/common"
+	"github.com/ebanfa/skeleton/pkg/types"
+)
+
+const (
+	// EventTypeConfigReloaded represents an event emitted when a new configuration was applied to the system.
+	EventTypeConfigReloaded string = "config_reloaded"
+)
+
+// ConfigDiff represents the service and operation changes between two configurations.
+type ConfigDiff struct {
+	// Added are the IDs of the services only declared in the new configuration.
+	Added []string
+
+	// Removed are the IDs of the services only declared in the current configuration.
+	Removed []string
+
+	// Changed are the IDs of the services whose configuration changed.
+	Changed []string
+
+	// AddedOperations are the IDs of the operations only declared in the new configuration.
+	AddedOperations []string
+
+	// RemovedOperations are the IDs of the operations only declared in the current configuration.
+	RemovedOperations []string
+
+	// ChangedOperations are the IDs of the operations whose configuration changed.
+	ChangedOperations []string
+}
+
+// IsEmpty checks if the diff has no changes.
+func (d ConfigDiff) IsEmpty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0 &&
+		len(d.AddedOperations) == 0 && len(d.RemovedOperations) == 0 && len(d.ChangedOperations) == 0
+}
+
+// ConfigReloadResult represents the data of a configuration reloaded event.
+type ConfigReloadResult struct {
+	// Diff is the diff between the previous and the new configuration.
+	Diff ConfigDiff
+
+	// Reconfigured are the IDs of the changed services that applied the new configuration in place.
+	Reconfigured []string
+
+	// Restarted are the IDs of the changed services that were recreated with the new configuration,
+	// followed by the IDs of the running services depending on them, which were stopped and started again.
+	Restarted []string
+
+	// Err is the error that occurred while applying the configuration, if any.
+	Err error
+}
+
+// DiffConfigurations returns the services and operations added, removed and changed between
+// two configurations, in the order they are declared.
+func DiffConfigurations(current, next *types.Configuration) ConfigDiff {
+	var diff ConfigDiff
+
+	var currentServices, nextServices []*types.ServiceConfiguration
+	var currentOperations, nextOperations []*types.OperationConfiguration
+	if current != nil {
+		currentServices, currentOperations = current.Services, current.Operations
+	}
+	if next != nil {
+		nextServices, nextOperations = next.Services, next.Operations
+	}
+
+	serviceID := func(config *types.ServiceConfiguration) string { return config.ID }
+	diff.Added, diff.Removed, diff.Changed = diffByID(currentServices, nextServices, serviceID)
+
+	operationID := func(config *types.OperationConfiguration) string { return config.ID }
+	diff.AddedOperations, diff.RemovedOperations, diff.ChangedOperations = diffByID(currentOperations, nextOperations, operationID)
+
+	return diff
+}
+
+// diffByID returns the IDs of the entries added, removed and changed between two lists of entries.
+func diffByID[T any](current, next []T, id func(T) string) (added, removed, changed []string) {
+	currentEntries := make(map[string]T, len(current))
+	for _, entry := range current {
+		currentEntries[id(entry)] = entry
+	}
+	nextEntries := make(map[string]T, len(next))
+	for _, entry := range next {
+		nextEntries[id(entry)] = entry
+	}
+
+	for _, entry := range current {
+		if _, exists := nextEntries[id(entry)]; !exists {
+			removed = append(removed, id(entry))
+		}
+	}
+	for _, entry := range next {
+		existing, exists := currentEntries[id(entry)]
+		switch {
+		case !exists:
+			added = append(added, id(entry))
+		case !reflect.DeepEqual(existing, entry):
+			changed = append(changed, id(entry))
+		}
+	}
+
+	return added, removed, changed
+}
+
+// Reload applies a new configuration to the system. Removed services are stopped and removed,
+// added services are created and changed services are either reconfigured, if they implement
+// ReconfigurableInterface, or recreated with the new configuration. Added and recreated services
+// are started if the system is started. The running services depending on a recreated service,
+// directly or not, are stopped before it and started again after it. Removed operations are
+// removed, added and changed operations are created with the new configuration; their schedules
+// only apply once the scheduler restarts.
+// The result is published on the event bus.
+//
+// The configuration and the resulting service dependencies are validated before any change, and the
+// configuration is only replaced once applied. The services are stopped, reconfigured and started
+// without holding the system lock, as they may wait on goroutines calling back into the system.
+// Returns an error if the configuration is invalid or could not be fully applied.
+func (s *SystemImpl) Reload(ctx *common.Context, configuration *types.Configuration) error {
+	s.reloadMutex.Lock()
+	result := s.applyConfiguration(ctx, configuration)
+	s.reloadMutex.Unlock()
+
+	// Publish once the system is unlocked, so that handlers can use the system
+	s.publishReloadResult(result)
+	return result.Err
+}
+
+// reloadPlan represents the changes of the services applied by a reload.
+type reloadPlan struct {
+	services    *DependencyGraph // Service dependency graph before the reload
+	remove      []string         // Services removed, or changed and recreated
+	recreate    []string         // Changed services recreated with the new configuration
+	reconfigure []string         // Changed services reconfigured in place
+	restart     []string         // Running services depending on the recreated services
+}
+
+// applyConfiguration applies a new configuration to the system and returns the result.
+func (s *SystemImpl) applyConfiguration(ctx *common.Context, configuration *types.Configuration) ConfigReloadResult {
+	plan, result := s.planReload(configuration)
+	if result.Err != nil {
+		return result
+	}
+
+	var errs []error
+	nextServices := serviceConfigurations(configuration)
+
+	// Stop the removed and recreated services along with their dependents, dependents first,
+	// then reconfigure the others
+	if err := s.stopServices(ctx, plan.services, append(append([]string(nil), plan.remove...), plan.restart...)); err != nil {
+		errs = append(errs, err)
+	}
+	for _, id := range plan.reconfigure {
+		if err := s.reconfigureService(ctx, id, nextServices[id]); err != nil {
+			errs = append(errs, fmt.Errorf("failed to reconfigure service %s: %w", id, err))
+			continue
+		}
+		result.Reconfigured = append(result.Reconfigured, id)
+	}
+
+	s.mutex.Lock()
+	if s.stopping {
+		s.mutex.Unlock()
+		result.Err = errors.Join(append(errs, types.ErrSystemStopping)...)
+		return result
+	}
+
+	for _, id := range plan.remove {
+		if err := s.ComponentRegistry().RemoveComponent(ctx, id); err != nil {
+			errs = append(errs, fmt.Errorf("failed to remove service %s: %w", id, err))
+		}
+		s.lifecycle.untrack(id)
+	}
+	if err := s.applyOperations(ctx, configuration, result.Diff); err != nil {
+		errs = append(errs, err)
+	}
+
+	// Create the added and recreated services
+	var created []string
+	start := make(map[string]bool)
+	for _, id := range append(append([]string(nil), result.Diff.Added...), plan.recreate...) {
+		if err := s.bootstrapComponent(ctx, serviceComponentConfig(nextServices[id]), types.ServiceType); err != nil {
+			errs = append(errs, fmt.Errorf("failed to create service %s: %w", id, err))
+			continue
+		}
+		created = append(created, id)
+		start[id] = true
+	}
+	for _, id := range plan.recreate {
+		if start[id] {
+			result.Restarted = append(result.Restarted, id)
+		}
+	}
+	for _, id := range plan.restart {
+		start[id] = true
+	}
+
+	// The created services may declare dependencies of their own
+	services, err := s.buildServiceGraph(configuration, nil, nil)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("failed to resolve service dependencies: %w", err))
+
+		// Leave the system as if the created services were only removed, keeping the configuration
+		for _, id := range created {
+			s.ComponentRegistry().RemoveComponent(ctx, id)
+			s.lifecycle.untrack(id)
+		}
+		for _, id := range append(plan.remove, created...) {
+			s.services.RemoveNode(id)
+		}
+		s.mutex.Unlock()
+
+		result.Restarted = nil
+		result.Err = errors.Join(errs...)
+		return result
+	}
+	s.configuration.Store(configuration)
+	s.services = services
+	started := s.status == types.SystemStartedType
+	s.mutex.Unlock()
+
+	// Start the created services and the stopped dependents in dependency order when the system is running
+	if started {
+		result.Restarted = append(result.Restarted, plan.restart...)
+	}
+	if started && len(start) > 0 {
+		err := services.Walk(false, func(id string) error {
+			if !start[id] {
+				return nil
+			}
+			return s.startService(ctx, id)
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to start services: %w", err))
+		}
+	}
+
+	result.Err = errors.Join(errs...)
+	return result
+}
+
+// planReload validates a new configuration along with the service dependencies it results in,
+// and returns the changes to apply to the services. The result holds the error if the
+// configuration cannot be applied, in which case nothing changed.
+func (s *SystemImpl) planReload(configuration *types.Configuration) (reloadPlan, ConfigReloadResult) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	result := ConfigReloadResult{Diff: DiffConfigurations(s.Configuration(), configuration)}
+	plan := reloadPlan{services: s.services}
+
+	if s.status != types.SystemInitializedType && s.status != types.SystemStartedType {
+		result.Err = types.ErrSystemNotInitialized
+		return plan, result
+	}
+	if s.stopping {
+		result.Err = types.ErrSystemStopping
+		return plan, result
+	}
+
+	if err := NewConfigValidator(s.componentReg).Validate(configuration); err != nil {
+		result.Err = fmt.Errorf("failed to validate configuration: %w", err)
+		return plan, result
+	}
+
+	// Changed services that cannot be reconfigured in place are recreated
+	for _, id := range result.Diff.Changed {
+		service, err := s.getService(id)
+		if err != nil {
+			result.Err = err
+			return plan, result
+		}
+		if _, ok := service.(types.ReconfigurableInterface); ok {
+			plan.reconfigure = append(plan.reconfigure, id)
+		} else {
+			plan.recreate = append(plan.recreate, id)
+		}
+	}
+	plan.remove = append(append(plan.remove, result.Diff.Removed...), plan.recreate...)
+	plan.restart = s.runningDependents(plan.recreate, plan.remove)
+
+	// Validate the dependencies the services will have, before any change
+	excluded := make(map[string]bool)
+	for _, id := range append(append([]string(nil), plan.remove...), result.Diff.RemovedOperations...) {
+		excluded[id] = true
+	}
+	pending := append(append([]string(nil), result.Diff.Added...), plan.recreate...)
+	if _, err := s.buildServiceGraph(configuration, excluded, pending); err != nil {
+		result.Err = fmt.Errorf("failed to resolve service dependencies: %w", err)
+	}
+
+	return plan, result
+}
+
+// runningDependents returns the running services depending on the given services, directly or not,
+// leaving out the excluded services. The caller must hold the system mutex.
+func (s *SystemImpl) runningDependents(serviceIDs, excluded []string) []string {
+	visited := make(map[string]bool)
+	for _, id := range excluded {
+		visited[id] = true
+	}
+
+	var dependents []string
+	queue := append([]string(nil), serviceIDs...)
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		for _, dependent := range s.services.Dependents(id) {
+			if visited[dependent] {
+				continue
+			}
+			visited[dependent] = true
+			queue = append(queue, dependent)
+			if s.lifecycle.status(dependent) == types.ServiceRunningType {
+				dependents = append(dependents, dependent)
+			}
+		}
+	}
+	return dependents
+}
+
+// stopServices stops the running services with the given IDs in reverse dependency order.
+func (s *SystemImpl) stopServices(ctx *common.Context, services *DependencyGraph, serviceIDs []string) error {
+	if len(serviceIDs) == 0 {
+		return nil
+	}
+
+	stop := make(map[string]bool, len(serviceIDs))
+	for _, id := range serviceIDs {
+		stop[id] = true
+	}
+
+	err := services.Walk(true, func(id string) error {
+		if !stop[id] {
+			return nil
+		}
+		if status := s.lifecycle.status(id); status != types.ServiceRunningType && status != types.ServiceFailedType {
+			return nil
+		}
+		return s.stopService(ctx, id)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to stop services: %w", err)
+	}
+	return nil
+}
+
+// applyOperations removes the removed and changed operations of a diff, then creates the added
+// and changed operations with the new configuration. The caller must hold the system mutex.
+func (s *SystemImpl) applyOperations(ctx *common.Context, configuration *types.Configuration, diff ConfigDiff) error {
+	var errs []error
+	for _, id := range append(append([]string(nil), diff.RemovedOperations...), diff.ChangedOperations...) {
+		if err := s.ComponentRegistry().RemoveComponent(ctx, id); err != nil {
+			errs = append(errs, fmt.Errorf("failed to remove operation %s: %w", id, err))
+		}
+	}
+
+	create := make(map[string]bool)
+	for _, id := range append(append([]string(nil), diff.AddedOperations...), diff.ChangedOperations...) {
+		create[id] = true
+	}
+	for _, config := range configuration.Operations {
+		if !create[config.ID] {
+			continue
+		}
+		if err := s.bootstrapComponent(ctx, &config.ComponentConfig, types.OperationType); err != nil {
+			errs = append(errs, fmt.Errorf("failed to create operation %s: %w", config.ID, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// reconfigureService passes the new configuration of a service to the service,
+// decoding its custom configuration into the type declared by its factory.
+func (s *SystemImpl) reconfigureService(ctx *common.Context, serviceID string, config *types.ServiceConfiguration) error {
+	service, err := s.getService(serviceID)
+	if err != nil {
+		return err
+	}
+	reconfigurable, ok := service.(types.ReconfigurableInterface)
+	if !ok {
+		return fmt.Errorf("service %s cannot be reconfigured", serviceID)
+	}
+	componentConfig := serviceComponentConfig(config)
+
+	if factory, err := s.ComponentRegistry().GetFactory(componentConfig.FactoryID); err == nil {
+		customConfig, err := DecodeCustomConfig(factory, componentConfig.CustomConfig)
+		if err != nil {
+			return fmt.Errorf("failed to decode custom configuration: %w", err)
+		}
+		componentConfig.CustomConfig = customConfig
+	}
+
+	return reconfigurable.Reconfigure(ctx, componentConfig)
+}
+
+// publishReloadResult publishes the result of a configuration reload on the event bus.
+func (s *SystemImpl) publishReloadResult(result ConfigReloadResult) {
+	if s.eventBus == nil {
+		return
+	}
+
+	s.eventBus.Publish(common.NewEvent(nil, SystemEventSource, EventTypeConfigReloaded, result))
+}
+
+// serviceConfigurations returns the service configurations of a configuration keyed by service ID.
+func serviceConfigurations(configuration *types.Configuration) map[string]*types.ServiceConfiguration {
+	services := make(map[string]*types.ServiceConfiguration)
+	if configuration != nil {
+		for _, config := range configuration.Services {
+			services[config.ID] = config
+		}
+	}
+	return services
+}
@@ -0,0 +1,309 @@
+package system_test
+
+import (
+	"sync"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/ebanfa/skeleton/pkg/common"
+	"github.com/ebanfa/skeleton/pkg/component"
+	"github.com/ebanfa/skeleton/pkg/mocks"
+	systemApi "github.com/ebanfa/skeleton/pkg/system"
+	"github.com/ebanfa/skeleton/pkg/types"
+)
+
+var _ = Describe("Configuration reload", func() {
+	var (
+		ctx               *common.Context
+		sys               *systemApi.SystemImpl
+		registrar         *component.ComponentRegistrar
+		eventBus          common.EventBusInterface
+		events            chan common.Event
+		factory           *mocks.ComponentFactoryInterface
+		mockPluginManager *mocks.PluginManagerInterface
+		mutex             sync.Mutex
+		calls             []string
+		reconfigured      []*types.ComponentConfig
+	)
+
+	record := func(call string) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		calls = append(calls, call)
+	}
+
+	serviceConfig := func(id string, version int, dependencies ...string) *types.ServiceConfiguration {
+		return &types.ServiceConfiguration{
+			ComponentConfig: types.ComponentConfig{ID: id, FactoryID: "serviceFactory"},
+			Dependencies:    dependencies,
+			CustomConfig:    map[string]interface{}{"version": version},
+		}
+	}
+
+	// newService creates a service recording its lifecycle calls, services with an ID
+	// starting with "r" can be reconfigured.
+	newService := func(config *types.ComponentConfig) types.ComponentInterface {
+		service := &mocks.SystemServiceInterface{}
+		service.On("ID").Return(config.ID)
+		service.On("Type").Return(types.ServiceType)
+		service.On("Initialize", ctx, mock.Anything).Return(nil)
+		service.On("Start", ctx).Run(func(mock.Arguments) {
+			record("start " + config.ID)
+		}).Return(nil)
+		service.On("Stop", ctx).Run(func(mock.Arguments) {
+			record("stop " + config.ID)
+			// Services may call back into the system while stopping
+			sys.Status()
+		}).Return(nil)
+
+		if config.ID[0] != 'r' {
+			return service
+		}
+		return &reconfigurableService{
+			SystemServiceInterface: service,
+			reconfigure: func(config *types.ComponentConfig) error {
+				mutex.Lock()
+				defer mutex.Unlock()
+				reconfigured = append(reconfigured, config)
+				return nil
+			},
+		}
+	}
+
+	BeforeEach(func() {
+		ctx = common.Background()
+		registrar = component.NewComponentRegistrar()
+		eventBus = common.NewSystemEventBus()
+		events = make(chan common.Event, 10)
+		factory = &mocks.ComponentFactoryInterface{}
+		mockPluginManager = &mocks.PluginManagerInterface{}
+		calls = nil
+		reconfigured = nil
+
+		factory.On("CreateComponent", mock.Anything).Return(func(config *types.ComponentConfig) (types.ComponentInterface, error) {
+			record("create " + config.ID)
+			return newService(config), nil
+		})
+
+		operationFactory := &mocks.ComponentFactoryInterface{}
+		operationFactory.On("CreateComponent", mock.Anything).Return(func(config *types.ComponentConfig) (types.ComponentInterface, error) {
+			record("create " + config.ID)
+			operation := &mocks.SystemOperationInterface{}
+			operation.On("ID").Return(config.ID)
+			operation.On("Type").Return(types.OperationType)
+			operation.On("Initialize", ctx, mock.Anything).Return(nil)
+			return operation, nil
+		})
+		Expect(registrar.RegisterFactory(ctx, "operationFactory", operationFactory)).To(Succeed())
+		mockPluginManager.On("Initialize", ctx, mock.Anything).Return(nil)
+		mockPluginManager.On("StartPlugins", ctx).Return(nil)
+
+		Expect(registrar.RegisterFactory(ctx, "serviceFactory", factory)).To(Succeed())
+		Expect(eventBus.Subscribe(common.BusSubscriptionParams{
+			Topic:        systemApi.EventTypeConfigReloaded,
+			EventHandler: func(event common.Event) { events <- event },
+		})).Error().NotTo(HaveOccurred())
+
+		sys = systemApi.NewSystem(nil, eventBus, &types.Configuration{
+			Services: []*types.ServiceConfiguration{
+				serviceConfig("a", 1),
+				serviceConfig("reconfigurable", 1),
+				serviceConfig("c", 1, "a"),
+			},
+		}, mockPluginManager, registrar, nil)
+	})
+
+	Describe("DiffConfigurations", func() {
+		It("returns the services added, removed and changed", func() {
+			diff := systemApi.DiffConfigurations(
+				&types.Configuration{Services: []*types.ServiceConfiguration{
+					serviceConfig("a", 1), serviceConfig("b", 1), serviceConfig("c", 1),
+				}},
+				&types.Configuration{Services: []*types.ServiceConfiguration{
+					serviceConfig("d", 1), serviceConfig("b", 2), serviceConfig("a", 1, "d"),
+				}},
+			)
+
+			Expect(diff).To(Equal(systemApi.ConfigDiff{
+				Added:   []string{"d"},
+				Removed: []string{"c"},
+				Changed: []string{"b", "a"},
+			}))
+			Expect(diff.IsEmpty()).To(BeFalse())
+		})
+
+		It("returns an empty diff for identical configurations", func() {
+			configuration := &types.Configuration{Services: []*types.ServiceConfiguration{serviceConfig("a", 1)}}
+			Expect(systemApi.DiffConfigurations(configuration, configuration).IsEmpty()).To(BeTrue())
+		})
+	})
+
+	It("returns an error when the system is not initialized", func() {
+		Expect(sys.Reload(ctx, &types.Configuration{})).To(MatchError(types.ErrSystemNotInitialized))
+	})
+
+	Context("when the system is started", func() {
+		BeforeEach(func() {
+			Expect(sys.Initialize(ctx)).To(Succeed())
+			Expect(sys.Start(ctx)).To(Succeed())
+			calls = nil
+		})
+
+		It("applies the diff and publishes the result", func() {
+			next := &types.Configuration{
+				Services: []*types.ServiceConfiguration{
+					serviceConfig("a", 2),
+					serviceConfig("reconfigurable", 2),
+					serviceConfig("d", 1, "a"),
+				},
+			}
+			Expect(sys.Reload(ctx, next)).To(Succeed())
+
+			// The removed service is stopped before the changed one it depends on is recreated
+			Expect(calls).To(Equal([]string{"stop c", "stop a", "create d", "create a", "start a", "start d"}))
+			Expect(reconfigured).To(HaveLen(1))
+			Expect(reconfigured[0].CustomConfig).To(Equal(map[string]interface{}{"version": 2}))
+
+			Expect(sys.Configuration()).To(Equal(next))
+			Expect(sys.ListServiceStatuses()).To(Equal(map[string]types.ServiceStatusType{
+				"a":              types.ServiceRunningType,
+				"reconfigurable": types.ServiceRunningType,
+				"d":              types.ServiceRunningType,
+			}))
+
+			var event common.Event
+			Eventually(events).Should(Receive(&event))
+			Expect(event.Data).To(Equal(systemApi.ConfigReloadResult{
+				Diff: systemApi.ConfigDiff{
+					Added:   []string{"d"},
+					Removed: []string{"c"},
+					Changed: []string{"a", "reconfigurable"},
+				},
+				Reconfigured: []string{"reconfigurable"},
+				Restarted:    []string{"a"},
+			}))
+		})
+
+		It("restarts the running dependents of the recreated services", func() {
+			next := &types.Configuration{
+				Services: []*types.ServiceConfiguration{
+					serviceConfig("a", 2),
+					serviceConfig("reconfigurable", 1),
+					serviceConfig("c", 1, "a"),
+				},
+			}
+			Expect(sys.Reload(ctx, next)).To(Succeed())
+
+			Expect(calls).To(Equal([]string{"stop c", "stop a", "create a", "start a", "start c"}))
+			Expect(sys.ListServiceStatuses()).To(HaveKeyWithValue("c", types.ServiceRunningType))
+
+			var event common.Event
+			Eventually(events).Should(Receive(&event))
+			Expect(event.Data.(systemApi.ConfigReloadResult).Restarted).To(Equal([]string{"a", "c"}))
+		})
+
+		It("rejects the configurations with unresolved dependencies before any change", func() {
+			current := sys.Configuration()
+			next := &types.Configuration{
+				Services: []*types.ServiceConfiguration{
+					serviceConfig("reconfigurable", 2),
+					serviceConfig("c", 1, "a"),
+				},
+			}
+
+			Expect(sys.Reload(ctx, next)).To(MatchError(types.ErrDependencyNotFound))
+			Expect(calls).To(BeEmpty())
+			Expect(reconfigured).To(BeEmpty())
+			Expect(sys.Configuration()).To(Equal(current))
+			Expect(sys.ServiceStatus("a")).To(Equal(types.ServiceRunningType))
+		})
+
+		It("reloads the operations", func() {
+			operationConfig := func(id string, version int) *types.OperationConfiguration {
+				return &types.OperationConfiguration{ComponentConfig: types.ComponentConfig{
+					ID:           id,
+					FactoryID:    "operationFactory",
+					CustomConfig: map[string]interface{}{"version": version},
+				}}
+			}
+			services := sys.Configuration().Services
+
+			Expect(sys.Reload(ctx, &types.Configuration{
+				Services:   services,
+				Operations: []*types.OperationConfiguration{operationConfig("o1", 1), operationConfig("o2", 1)},
+			})).To(Succeed())
+			Expect(calls).To(Equal([]string{"create o1", "create o2"}))
+			calls = nil
+
+			Expect(sys.Reload(ctx, &types.Configuration{
+				Services:   services,
+				Operations: []*types.OperationConfiguration{operationConfig("o2", 2), operationConfig("o3", 1)},
+			})).To(Succeed())
+			Expect(calls).To(Equal([]string{"create o2", "create o3"}))
+			Expect(registrar.GetComponent("o1")).Error().To(HaveOccurred())
+			Expect(registrar.GetComponent("o3")).Error().NotTo(HaveOccurred())
+
+			Eventually(events).Should(Receive())
+			var event common.Event
+			Eventually(events).Should(Receive(&event))
+			Expect(event.Data.(systemApi.ConfigReloadResult).Diff).To(Equal(systemApi.ConfigDiff{
+				AddedOperations:   []string{"o3"},
+				RemovedOperations: []string{"o1"},
+				ChangedOperations: []string{"o2"},
+			}))
+		})
+
+		It("rejects an invalid configuration", func() {
+			current := sys.Configuration()
+			next := &types.Configuration{
+				Services: []*types.ServiceConfiguration{
+					{ComponentConfig: types.ComponentConfig{ID: "a", FactoryID: "unknownFactory"}},
+				},
+			}
+
+			err := sys.Reload(ctx, next)
+			Expect(err).To(MatchError(types.ErrInvalidConfiguration))
+			Expect(calls).To(BeEmpty())
+			Expect(sys.Configuration()).To(Equal(current))
+
+			var event common.Event
+			Eventually(events).Should(Receive(&event))
+			Expect(event.Data.(systemApi.ConfigReloadResult).Err).To(MatchError(types.ErrInvalidConfiguration))
+		})
+	})
+
+	Context("when the system is only initialized", func() {
+		BeforeEach(func() {
+			Expect(sys.Initialize(ctx)).To(Succeed())
+			calls = nil
+		})
+
+		It("creates the added services without starting them", func() {
+			next := &types.Configuration{
+				Services: []*types.ServiceConfiguration{
+					serviceConfig("a", 1),
+					serviceConfig("reconfigurable", 1),
+					serviceConfig("c", 1, "a"),
+					serviceConfig("d", 1),
+				},
+			}
+			Expect(sys.Reload(ctx, next)).To(Succeed())
+
+			Expect(calls).To(Equal([]string{"create d"}))
+			Expect(sys.ServiceStatus("d")).To(Equal(types.ServiceInitializedType))
+		})
+	})
+})
+
+// reconfigurableService is a service that can apply a new configuration in place.
+type reconfigurableService struct {
+	*mocks.SystemServiceInterface
+	reconfigure func(config *types.ComponentConfig) error
+}
+
+// Reconfigure applies the given configuration to the service.
+func (r *reconfigurableService) Reconfigure(ctx *common.Context, config *types.ComponentConfig) error {
+	return r.reconfigure(config)
+}
@@ -0,0 +1,377 @@
+package system
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/ebanfa/skeleton/pkg/common"
+	"github.com/ebanfa/skeleton/pkg/types"
+)
+
+const (
+	// EventTypeScheduledRunSucceeded represents an event emitted when a scheduled operation run succeeds.
+	EventTypeScheduledRunSucceeded string = "scheduled_run_succeeded"
+
+	// EventTypeScheduledRunFailed represents an event emitted when a scheduled operation run fails.
+	EventTypeScheduledRunFailed string = "scheduled_run_failed"
+
+	// EventTypeScheduledRunSkipped represents an event emitted when a scheduled operation run is skipped
+	// because the previous run is still in progress.
+	EventTypeScheduledRunSkipped string = "scheduled_run_skipped"
+)
+
+// schedulerStoreName is the namespace of the last run times in the system multistore.
+const schedulerStoreName = "scheduler"
+
+// SchedulerOptions represents the options of a scheduler.
+type SchedulerOptions struct {
+	// Location is the time zone of the cron expressions, the local time zone when nil.
+	Location *time.Location
+}
+
+// ScheduledRunEvent represents the data of the events published by a scheduler.
+type ScheduledRunEvent struct {
+	// OperationID is the ID of the scheduled operation.
+	OperationID string
+
+	// ScheduledAt is the time the run was due, before jitter.
+	ScheduledAt time.Time
+
+	// CatchUp is true if the run replaces the runs missed while the system was down.
+	CatchUp bool
+
+	// Duration is the execution time of the operation.
+	Duration time.Duration
+
+	// Output is the output of the operation, if it succeeded.
+	Output *types.SystemOperationOutput
+
+	// Error is the message of the error returned by the operation, if it failed.
+	Error string
+}
+
+// scheduledOperation represents an operation run by the scheduler along with the state of its runs.
+type scheduledOperation struct {
+	id      string
+	config  types.ScheduleConfiguration
+	next    func(after time.Time) time.Time
+	running int // Number of runs in progress
+	queued  int // Number of runs waiting for the previous ones to complete
+}
+
+// Scheduler is a service that runs the operations with a schedule in their configuration,
+// either on a cron expression or at a fixed interval. The time of the last run of each operation
+// is kept in the system store when there is one, so that missed runs can be caught up after a restart.
+type Scheduler struct {
+	BaseSystemService
+	mutex   sync.Mutex
+	options SchedulerOptions
+	store   types.Store
+	cancel  func()
+	stop    chan struct{}
+	wg      sync.WaitGroup
+}
+
+// NewScheduler creates a new instance of Scheduler.
+func NewScheduler(id string, options SchedulerOptions) *Scheduler {
+	if options.Location == nil {
+		options.Location = time.Local
+	}
+
+	return &Scheduler{
+		BaseSystemService: *NewBaseSystemService(id, "Scheduler", "Runs operations on a schedule"),
+		options:           options,
+	}
+}
+
+// Start starts running the scheduled operations of the system configuration.
+// Returns an error if a schedule is invalid or the store of the last run times cannot be opened.
+func (s *Scheduler) Start(ctx *common.Context) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.System == nil {
+		return types.ErrSystemNotInitialized
+	}
+	if s.stop != nil {
+		return nil
+	}
+
+	operations, err := s.scheduledOperations()
+	if err != nil {
+		return err
+	}
+	if err := s.openStore(); err != nil {
+		return err
+	}
+
+	runCtx, cancel := common.WithCancel(ctx)
+	s.cancel = cancel
+	s.stop = make(chan struct{})
+	for _, operation := range operations {
+		s.wg.Add(1)
+		go s.schedule(runCtx, operation, s.stop)
+	}
+
+	return nil
+}
+
+// Stop stops running the scheduled operations, canceling the runs in progress.
+func (s *Scheduler) Stop(ctx *common.Context) error {
+	s.mutex.Lock()
+	stop, cancel := s.stop, s.cancel
+	s.stop, s.cancel = nil, nil
+	s.mutex.Unlock()
+
+	if stop != nil {
+		close(stop)
+		cancel()
+		s.wg.Wait()
+	}
+
+	return nil
+}
+
+// scheduledOperations returns the operations with a schedule in the system configuration.
+func (s *Scheduler) scheduledOperations() ([]*scheduledOperation, error) {
+	configuration := s.System.Configuration()
+	if configuration == nil {
+		return nil, nil
+	}
+
+	var operations []*scheduledOperation
+	for _, config := range configuration.Operations {
+		if config.Schedule == nil {
+			continue
+		}
+
+		next, err := s.nextRunFunc(config.Schedule)
+		if err != nil {
+			return nil, fmt.Errorf("%w: operation %s: %w", types.ErrInvalidSchedule, config.ID, err)
+		}
+		operations = append(operations, &scheduledOperation{
+			id:     config.ID,
+			config: *config.Schedule,
+			next:   next,
+		})
+	}
+	return operations, nil
+}
+
+// nextRunFunc returns the function computing the next run of a schedule after a given time.
+func (s *Scheduler) nextRunFunc(schedule *types.ScheduleConfiguration) (func(time.Time) time.Time, error) {
+	switch {
+	case schedule.Cron != "" && schedule.Interval != 0:
+		return nil, errors.New("cron expression and interval are mutually exclusive")
+	case schedule.Cron != "":
+		cron, err := ParseCronExpression(schedule.Cron)
+		if err != nil {
+			return nil, err
+		}
+		return func(after time.Time) time.Time {
+			return cron.Next(after.In(s.options.Location))
+		}, nil
+	case schedule.Interval > 0:
+		return func(after time.Time) time.Time {
+			return after.Add(schedule.Interval)
+		}, nil
+	default:
+		return nil, errors.New("a cron expression or a positive interval is required")
+	}
+}
+
+// schedule runs an operation on its schedule until the stop channel is closed.
+func (s *Scheduler) schedule(ctx *common.Context, operation *scheduledOperation, stop <-chan struct{}) {
+	defer s.wg.Done()
+
+	from := time.Now()
+
+	// Run once for the runs missed since the last run, whatever their number
+	if operation.config.CatchUp {
+		if last := s.lastRun(operation.id); !last.IsZero() {
+			if missed := operation.next(last); !missed.IsZero() && missed.Before(from) {
+				s.trigger(ctx, operation, missed, true)
+			}
+		}
+	}
+
+	for {
+		next := operation.next(from)
+		if now := time.Now(); !next.IsZero() && next.Before(now) {
+			// The runs due while the scheduler was held up are skipped
+			next = operation.next(now)
+		}
+		if next.IsZero() {
+			return
+		}
+
+		timer := time.NewTimer(time.Until(next) + s.jitter(operation))
+		select {
+		case <-stop:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		s.trigger(ctx, operation, next, false)
+		from = next
+	}
+}
+
+// trigger runs an operation that is due according to its overlap policy.
+func (s *Scheduler) trigger(ctx *common.Context, operation *scheduledOperation, scheduledAt time.Time, catchUp bool) {
+	s.mutex.Lock()
+	if operation.running > 0 {
+		switch operation.config.Overlap {
+		case types.OverlapAllow:
+		case types.OverlapQueue:
+			operation.queued++
+			s.mutex.Unlock()
+			return
+		default:
+			s.mutex.Unlock()
+			s.publish(EventTypeScheduledRunSkipped, ScheduledRunEvent{
+				OperationID: operation.id,
+				ScheduledAt: scheduledAt,
+				CatchUp:     catchUp,
+			})
+			return
+		}
+	}
+	operation.running++
+	s.mutex.Unlock()
+
+	s.wg.Add(1)
+	go s.run(ctx, operation, scheduledAt, catchUp)
+}
+
+// run executes an operation, then the runs queued while it was in progress.
+func (s *Scheduler) run(ctx *common.Context, operation *scheduledOperation, scheduledAt time.Time, catchUp bool) {
+	defer s.wg.Done()
+
+	for {
+		start := time.Now()
+		output, err := s.System.ExecuteOperation(ctx, operation.id, &types.SystemOperationInput{Data: operation.config.Input})
+		event := ScheduledRunEvent{
+			OperationID: operation.id,
+			ScheduledAt: scheduledAt,
+			CatchUp:     catchUp,
+			Duration:    time.Since(start),
+			Output:      output,
+		}
+		if err != nil {
+			event.Error = err.Error()
+		}
+
+		s.mutex.Lock()
+		s.saveLastRun(operation.id, scheduledAt)
+		more := operation.queued > 0 && ctx.Err() == nil
+		if more {
+			operation.queued--
+		} else {
+			operation.running--
+			operation.queued = 0
+		}
+		s.mutex.Unlock()
+
+		if err != nil {
+			s.publish(EventTypeScheduledRunFailed, event)
+		} else {
+			s.publish(EventTypeScheduledRunSucceeded, event)
+		}
+		if !more {
+			return
+		}
+
+		// The queued run is reported as due when it starts
+		scheduledAt, catchUp = time.Now(), false
+	}
+}
+
+// jitter returns a random delay within the jitter of an operation.
+func (s *Scheduler) jitter(operation *scheduledOperation) time.Duration {
+	if operation.config.Jitter <= 0 {
+		return 0
+	}
+	return rand.N(operation.config.Jitter)
+}
+
+// openStore creates or loads the store of the last run times, if the system has a store.
+func (s *Scheduler) openStore() error {
+	multiStore := s.System.MultiStore()
+	if multiStore == nil || s.store != nil {
+		return nil
+	}
+
+	store, _, err := multiStore.CreateStore(schedulerStoreName)
+	if err != nil {
+		return fmt.Errorf("failed to create scheduler store: %w", err)
+	}
+	if _, err := store.Load(); err != nil {
+		return fmt.Errorf("failed to load scheduler store: %w", err)
+	}
+
+	s.store = store
+	return nil
+}
+
+// lastRun returns the time the last run of an operation was due, or the zero time if it is unknown.
+func (s *Scheduler) lastRun(operationID string) time.Time {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.store == nil {
+		return time.Time{}
+	}
+
+	data, err := s.store.Get([]byte(operationID))
+	if err != nil || data == nil {
+		return time.Time{}
+	}
+
+	var last time.Time
+	if err := last.UnmarshalText(data); err != nil {
+		s.logError("Error reading last run of operation "+operationID+":", err)
+		return time.Time{}
+	}
+	return last
+}
+
+// saveLastRun persists the time the last run of an operation was due.
+// The caller must hold the mutex.
+func (s *Scheduler) saveLastRun(operationID string, scheduledAt time.Time) {
+	if s.store == nil {
+		return
+	}
+
+	data, err := scheduledAt.MarshalText()
+	if err == nil {
+		err = s.store.Set([]byte(operationID), data)
+	}
+	if err == nil {
+		err = saveVersion(s.store)
+	}
+	if err != nil {
+		s.logError("Error saving last run of operation "+operationID+":", err)
+	}
+}
+
+// publish publishes a scheduler event on the system event bus.
+func (s *Scheduler) publish(eventType string, event ScheduledRunEvent) {
+	eventBus := s.System.EventBus()
+	if eventBus == nil {
+		return
+	}
+
+	eventBus.Publish(common.NewEvent(nil, s.ID(), eventType, event))
+}
+
+// logError logs an error of the scheduler.
+func (s *Scheduler) logError(message string, err error) {
+	if logger := s.System.Logger(); logger != nil {
+		logger.Log(common.LevelError, message, err)
+	}
+}
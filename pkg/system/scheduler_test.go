@@ -0,0 +1,250 @@
+package system_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/ebanfa/skeleton/pkg/common"
+	"github.com/ebanfa/skeleton/pkg/db"
+	"github.com/ebanfa/skeleton/pkg/mocks"
+	"github.com/ebanfa/skeleton/pkg/store"
+	"github.com/ebanfa/skeleton/pkg/system"
+	"github.com/ebanfa/skeleton/pkg/types"
+)
+
+var _ = Describe("Scheduler", func() {
+	var (
+		ctx        *common.Context
+		mockSystem *mocks.SystemInterface
+		multiStore types.MultiStore
+		schedule   *types.ScheduleConfiguration
+		events     chan common.Event
+		release    chan struct{}
+		running    atomic.Int32
+		maxRunning atomic.Int32
+		scheduler  *system.Scheduler
+	)
+
+	// execute records the runs of the operation, blocking until released if the input is "block".
+	execute := func(ctx *common.Context, operationID string, input *types.SystemOperationInput) (*types.SystemOperationOutput, error) {
+		current := running.Add(1)
+		defer running.Add(-1)
+		for peak := maxRunning.Load(); current > peak && !maxRunning.CompareAndSwap(peak, current); peak = maxRunning.Load() {
+		}
+
+		switch input.Data {
+		case "block":
+			select {
+			case <-release:
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		case "fail":
+			return nil, errors.New("operation failed")
+		}
+		return &types.SystemOperationOutput{Data: input.Data}, nil
+	}
+
+	nextEvent := func() common.Event {
+		var event common.Event
+		Eventually(events).Should(Receive(&event))
+		return event
+	}
+
+	BeforeEach(func() {
+		ctx = common.Background()
+		mockSystem = &mocks.SystemInterface{}
+		schedule = &types.ScheduleConfiguration{Interval: 10 * time.Millisecond}
+		events = make(chan common.Event, 100)
+		release = make(chan struct{})
+		running.Store(0)
+		maxRunning.Store(0)
+		multiStore = nil
+
+		eventBus := common.NewSystemEventBus()
+		for _, topic := range []string{system.EventTypeScheduledRunSucceeded, system.EventTypeScheduledRunFailed, system.EventTypeScheduledRunSkipped} {
+			Expect(eventBus.Subscribe(common.BusSubscriptionParams{
+				Topic:        topic,
+				EventHandler: func(event common.Event) { events <- event },
+			})).Error().NotTo(HaveOccurred())
+		}
+
+		mockSystem.On("EventBus").Return(eventBus)
+		mockSystem.On("Logger").Return(nil)
+		mockSystem.On("MultiStore").Return(func() types.MultiStore { return multiStore })
+		mockSystem.On("Configuration").Return(func() *types.Configuration {
+			return &types.Configuration{
+				Operations: []*types.OperationConfiguration{
+					{ComponentConfig: types.ComponentConfig{ID: "operation", FactoryID: "operationFactory"}},
+					{ComponentConfig: types.ComponentConfig{ID: "scheduled", FactoryID: "operationFactory"}, Schedule: schedule},
+				},
+			}
+		})
+		mockSystem.On("ExecuteOperation", mock.Anything, "scheduled", mock.Anything).Return(execute)
+	})
+
+	JustBeforeEach(func() {
+		scheduler = system.NewScheduler("scheduler", system.SchedulerOptions{})
+		Expect(scheduler.Initialize(ctx, mockSystem)).To(Succeed())
+	})
+
+	AfterEach(func() {
+		Expect(scheduler.Stop(ctx)).To(Succeed())
+	})
+
+	It("requires a system", func() {
+		Expect(system.NewScheduler("scheduler", system.SchedulerOptions{}).Start(ctx)).To(MatchError(types.ErrSystemNotInitialized))
+	})
+
+	It("runs the operations at their interval", func() {
+		schedule.Input = "input"
+		Expect(scheduler.Start(ctx)).To(Succeed())
+
+		for i := 0; i < 3; i++ {
+			event := nextEvent()
+			Expect(event.Type).To(Equal(system.EventTypeScheduledRunSucceeded))
+
+			data := event.Data.(system.ScheduledRunEvent)
+			Expect(data.OperationID).To(Equal("scheduled"))
+			Expect(data.Output.Data).To(Equal("input"))
+			Expect(data.CatchUp).To(BeFalse())
+		}
+		mockSystem.AssertNotCalled(GinkgoT(), "ExecuteOperation", mock.Anything, "operation", mock.Anything)
+	})
+
+	It("publishes the failed runs", func() {
+		schedule.Input = "fail"
+		Expect(scheduler.Start(ctx)).To(Succeed())
+
+		event := nextEvent()
+		Expect(event.Type).To(Equal(system.EventTypeScheduledRunFailed))
+		Expect(event.Data.(system.ScheduledRunEvent).Error).To(Equal("operation failed"))
+		Expect(json.Marshal(event.Data)).To(ContainSubstring(`"Error":"operation failed"`))
+	})
+
+	It("rejects the invalid schedules", func() {
+		schedule.Interval = 0
+		schedule.Cron = "* * *"
+		Expect(scheduler.Start(ctx)).To(MatchError(types.ErrInvalidSchedule))
+	})
+
+	It("cancels the runs in progress when stopped", func() {
+		schedule.Input = "block"
+		Expect(scheduler.Start(ctx)).To(Succeed())
+		Eventually(running.Load).Should(BeEquivalentTo(1))
+
+		Expect(scheduler.Stop(ctx)).To(Succeed())
+		Expect(running.Load()).To(BeEquivalentTo(0))
+
+		var event common.Event
+		Eventually(events).Should(Receive(&event, HaveField("Type", system.EventTypeScheduledRunFailed)))
+		Expect(event.Data.(system.ScheduledRunEvent).Error).To(Equal(context.Canceled.Error()))
+	})
+
+	Describe("overlap policies", func() {
+		BeforeEach(func() {
+			schedule.Input = "block"
+		})
+
+		It("skips the runs due while the previous run is in progress by default", func() {
+			Expect(scheduler.Start(ctx)).To(Succeed())
+
+			Expect(nextEvent().Type).To(Equal(system.EventTypeScheduledRunSkipped))
+			Expect(nextEvent().Type).To(Equal(system.EventTypeScheduledRunSkipped))
+			Expect(maxRunning.Load()).To(BeEquivalentTo(1))
+
+			close(release)
+			Eventually(events).Should(Receive(HaveField("Type", system.EventTypeScheduledRunSucceeded)))
+		})
+
+		It("queues the runs due while the previous run is in progress", func() {
+			schedule.Overlap = types.OverlapQueue
+			Expect(scheduler.Start(ctx)).To(Succeed())
+			Eventually(running.Load).Should(BeEquivalentTo(1))
+			time.Sleep(50 * time.Millisecond)
+
+			close(release)
+			for i := 0; i < 3; i++ {
+				Expect(nextEvent().Type).To(Equal(system.EventTypeScheduledRunSucceeded))
+			}
+			Expect(maxRunning.Load()).To(BeEquivalentTo(1))
+		})
+
+		It("allows the runs to overlap", func() {
+			schedule.Overlap = types.OverlapAllow
+			Expect(scheduler.Start(ctx)).To(Succeed())
+
+			Eventually(running.Load).Should(BeNumerically(">=", 3))
+			close(release)
+		})
+	})
+
+	Describe("catch-up", func() {
+		var dir string
+
+		// restart runs the scheduler until it completes a run, then stops it and waits
+		// for the interval to elapse, so that a run is missed.
+		restart := func() {
+			Expect(scheduler.Start(ctx)).To(Succeed())
+			Expect(nextEvent().Type).To(Equal(system.EventTypeScheduledRunSucceeded))
+			Expect(scheduler.Stop(ctx)).To(Succeed())
+
+			time.Sleep(2 * schedule.Interval)
+			for len(events) > 0 {
+				<-events
+			}
+			scheduler = system.NewScheduler("scheduler", system.SchedulerOptions{})
+			Expect(scheduler.Initialize(ctx, mockSystem)).To(Succeed())
+		}
+
+		BeforeEach(func() {
+			dir = GinkgoT().TempDir()
+			var err error
+			multiStore, err = store.CreateMultiStore("system", dir, store.NewStoreFactory(dir, db.NewIAVLDatabaseFactory()))
+			Expect(err).NotTo(HaveOccurred())
+			DeferCleanup(func() { multiStore.Close() })
+
+			schedule.Interval = 50 * time.Millisecond
+		})
+
+		It("runs once at start when runs were missed", func() {
+			schedule.CatchUp = true
+			restart()
+			Expect(scheduler.Start(ctx)).To(Succeed())
+
+			event := nextEvent().Data.(system.ScheduledRunEvent)
+			Expect(event.CatchUp).To(BeTrue())
+			Expect(time.Since(event.ScheduledAt)).To(BeNumerically(">", schedule.Interval))
+			Expect(nextEvent().Data.(system.ScheduledRunEvent).CatchUp).To(BeFalse())
+		})
+
+		It("loads the last runs from a store created before the scheduler started", func() {
+			schedule.CatchUp = true
+			restart()
+
+			// Reopen the multistore, another component creating the scheduler store first
+			Expect(multiStore.Close()).To(Succeed())
+			var err error
+			multiStore, err = store.CreateMultiStore("system", dir, store.NewStoreFactory(dir, db.NewIAVLDatabaseFactory()))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(multiStore.CreateStore("scheduler")).Error().NotTo(HaveOccurred())
+
+			Expect(scheduler.Start(ctx)).To(Succeed())
+			Expect(nextEvent().Data.(system.ScheduledRunEvent).CatchUp).To(BeTrue())
+		})
+
+		It("does not catch up when disabled", func() {
+			restart()
+			Expect(scheduler.Start(ctx)).To(Succeed())
+
+			Expect(nextEvent().Data.(system.ScheduledRunEvent).CatchUp).To(BeFalse())
+		})
+	})
+})
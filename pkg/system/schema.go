@@ -0,0 +1,145 @@
+package system
+
+import (
+	"reflect"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/ebanfa/skeleton/pkg/types"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// SchemaOf returns the schema of a configuration value derived from its type.
+// Struct fields are named after their JSON tag, or their name starting with a lowercase letter,
+// and are required when tagged with `schema:"required"`. Embedded structs are flattened.
+func SchemaOf(value interface{}) *types.ConfigSchema {
+	if value == nil {
+		return &types.ConfigSchema{}
+	}
+	return schemaOfType(reflect.TypeOf(value), make(map[reflect.Type]bool))
+}
+
+// schemaOfType returns the schema of the given type. The visiting set guards against recursive types.
+func schemaOfType(t reflect.Type, visiting map[reflect.Type]bool) *types.ConfigSchema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == durationType {
+		return &types.ConfigSchema{Type: types.SchemaDurationType}
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		if visiting[t] {
+			return &types.ConfigSchema{}
+		}
+		visiting[t] = true
+		defer delete(visiting, t)
+
+		schema := &types.ConfigSchema{
+			Type:       types.SchemaObjectType,
+			Properties: make(map[string]*types.ConfigSchema),
+		}
+		addStructProperties(schema, t, visiting)
+		return schema
+
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return &types.ConfigSchema{Type: types.SchemaObjectType, AdditionalProperties: &types.ConfigSchema{}}
+		}
+		return &types.ConfigSchema{
+			Type:                 types.SchemaObjectType,
+			AdditionalProperties: schemaOfType(t.Elem(), visiting),
+		}
+
+	case reflect.Slice, reflect.Array:
+		return &types.ConfigSchema{
+			Type:  types.SchemaArrayType,
+			Items: schemaOfType(t.Elem(), visiting),
+		}
+
+	case reflect.String:
+		return &types.ConfigSchema{Type: types.SchemaStringType}
+
+	case reflect.Bool:
+		return &types.ConfigSchema{Type: types.SchemaBooleanType}
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &types.ConfigSchema{Type: types.SchemaIntegerType}
+
+	case reflect.Float32, reflect.Float64:
+		return &types.ConfigSchema{Type: types.SchemaNumberType}
+
+	default:
+		return &types.ConfigSchema{}
+	}
+}
+
+// addStructProperties adds the properties of the exported fields of a struct to the schema.
+// The fields of embedded structs are added first so that the outer fields shadow them.
+func addStructProperties(schema *types.ConfigSchema, t reflect.Type, visiting map[reflect.Type]bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct && field.Tag.Get("json") == "" {
+			addStructProperties(schema, field.Type, visiting)
+		}
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() || (field.Anonymous && field.Tag.Get("json") == "") {
+			continue
+		}
+
+		name := schemaPropertyName(field)
+		if name == "" {
+			continue
+		}
+
+		schema.Properties[name] = schemaOfType(field.Type, visiting)
+		if field.Tag.Get("schema") == "required" && !containsString(schema.Required, name) {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+}
+
+// schemaPropertyName returns the name of the property of a struct field, or an empty string if it is ignored.
+func schemaPropertyName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "-" {
+		return ""
+	}
+	if name != "" {
+		return name
+	}
+
+	first, size := utf8.DecodeRuneInString(field.Name)
+	return string(unicode.ToLower(first)) + field.Name[size:]
+}
+
+// schemaProperty returns the name and schema of the property matching the given key case insensitively.
+func schemaProperty(schema *types.ConfigSchema, key string) (string, *types.ConfigSchema, bool) {
+	if property, ok := schema.Properties[key]; ok {
+		return key, property, true
+	}
+	for name, property := range schema.Properties {
+		if strings.EqualFold(name, key) {
+			return name, property, true
+		}
+	}
+	return "", nil, false
+}
+
+// containsString checks if a list of strings contains the given string.
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
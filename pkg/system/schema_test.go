@@ -0,0 +1,59 @@
+package system_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/ebanfa/skeleton/pkg/system"
+	"github.com/ebanfa/skeleton/pkg/types"
+)
+
+var _ = Describe("SchemaOf", func() {
+	type embedded struct {
+		Name string `json:"name" schema:"required"`
+	}
+
+	type node struct {
+		embedded
+		Timeout  time.Duration     `json:"timeout"`
+		Ratio    float64           `json:"ratio,omitempty"`
+		Labels   map[string]string `json:"labels"`
+		Children []*node           `json:"children"`
+		Enabled  bool
+		Ignored  string `json:"-"`
+		Extra    interface{}
+	}
+
+	It("derives the schema of a struct", func() {
+		schema := system.SchemaOf(&node{})
+
+		Expect(schema.Type).To(Equal(types.SchemaObjectType))
+		Expect(schema.Required).To(Equal([]string{"name"}))
+		Expect(schema.Properties).To(HaveLen(7))
+		Expect(schema.Properties["name"].Type).To(Equal(types.SchemaStringType))
+		Expect(schema.Properties["timeout"].Type).To(Equal(types.SchemaDurationType))
+		Expect(schema.Properties["ratio"].Type).To(Equal(types.SchemaNumberType))
+		Expect(schema.Properties["labels"].AdditionalProperties.Type).To(Equal(types.SchemaStringType))
+		Expect(schema.Properties["enabled"].Type).To(Equal(types.SchemaBooleanType))
+		Expect(schema.Properties["extra"].Type).To(Equal(types.SchemaAnyType))
+	})
+
+	It("stops at recursive types", func() {
+		schema := system.SchemaOf(node{})
+
+		children := schema.Properties["children"]
+		Expect(children.Type).To(Equal(types.SchemaArrayType))
+		Expect(children.Items.Type).To(Equal(types.SchemaAnyType))
+	})
+
+	It("requires the ID and factory ID of the configured components", func() {
+		schema := system.SchemaOf(types.Configuration{})
+
+		services := schema.Properties["services"].Items
+		Expect(services.Required).To(ConsistOf("id", "factoryId"))
+		Expect(services.Properties).To(HaveKey("retryInterval"))
+		Expect(services.Properties).To(HaveKey("customConfig"))
+	})
+})
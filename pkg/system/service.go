@@ -10,7 +10,6 @@ import (
 
 // BaseSystemService.
 type BaseSystemService struct {
-	types.SystemServiceInterface
 	BaseSystemComponent
 }
 
@@ -0,0 +1,202 @@
+package system
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/ebanfa/skeleton/pkg/common"
+	"github.com/ebanfa/skeleton/pkg/types"
+)
+
+const (
+	// DefaultStopTimeout is the time a service or the plugins are given to stop
+	// when no stop timeout is configured.
+	DefaultStopTimeout = 10 * time.Second
+
+	// DefaultShutdownTimeout is the time the whole system is given to shut down.
+	DefaultShutdownTimeout = 30 * time.Second
+)
+
+// ShutdownOptions represents the options of a shutdown coordinator.
+type ShutdownOptions struct {
+	// Signals are the signals triggering the shutdown, SIGINT and SIGTERM when nil.
+	Signals []os.Signal
+
+	// Timeout bounds the whole shutdown, DefaultShutdownTimeout when zero.
+	Timeout time.Duration
+}
+
+// ShutdownCoordinator stops a system when the process receives a shutdown signal.
+type ShutdownCoordinator struct {
+	system  types.SystemInterface
+	options ShutdownOptions
+}
+
+// NewShutdownCoordinator creates a new instance of ShutdownCoordinator.
+func NewShutdownCoordinator(system types.SystemInterface, options ShutdownOptions) *ShutdownCoordinator {
+	if options.Signals == nil {
+		options.Signals = []os.Signal{syscall.SIGINT, syscall.SIGTERM}
+	}
+	if options.Timeout <= 0 {
+		options.Timeout = DefaultShutdownTimeout
+	}
+
+	return &ShutdownCoordinator{
+		system:  system,
+		options: options,
+	}
+}
+
+// Wait blocks until a shutdown signal is received or the context is done, then stops the system.
+// A second signal received during the shutdown abandons the services that are still stopping.
+// Returns the error describing what did not stop cleanly, if any.
+func (c *ShutdownCoordinator) Wait(ctx *common.Context) error {
+	signals := make(chan os.Signal, 2)
+	if len(c.options.Signals) > 0 {
+		signal.Notify(signals, c.options.Signals...)
+	}
+	defer signal.Stop(signals)
+
+	select {
+	case sig := <-signals:
+		c.log(common.LevelInfo, "Received signal", sig, "shutting down")
+	case <-ctx.Done():
+		c.log(common.LevelInfo, "Shutting down")
+	}
+
+	return c.shutdown(signals)
+}
+
+// Shutdown stops the system within the shutdown timeout.
+func (c *ShutdownCoordinator) Shutdown() error {
+	return c.shutdown(nil)
+}
+
+// shutdown stops the system within the shutdown timeout, or until a signal is received.
+func (c *ShutdownCoordinator) shutdown(signals <-chan os.Signal) error {
+	// The context the system ran with may already be done
+	stopCtx, cancel := common.WithTimeout(common.Background(), c.options.Timeout)
+	defer cancel()
+
+	go func() {
+		select {
+		case sig := <-signals:
+			c.log(common.LevelWarn, "Received signal", sig, "abandoning the services still stopping")
+			cancel()
+		case <-stopCtx.Done():
+		}
+	}()
+
+	return c.system.Stop(stopCtx)
+}
+
+// log logs a message with the system logger, if any.
+func (c *ShutdownCoordinator) log(level common.Level, args ...interface{}) {
+	if logger := c.system.Logger(); logger != nil {
+		logger.Log(level, args...)
+	}
+}
+
+// shutdown stops the services in reverse dependency order, each within its stop timeout,
+// then stops the plugins and closes the store. The store is left open when services, jobs or
+// plugins were abandoned, as they may still be writing to it. The caller must have marked the system as stopping,
+// so that the services, the dependency graph and the configuration are left alone meanwhile.
+// Returns the errors of everything that did not stop cleanly, including the jobs, joined together.
+func (s *SystemImpl) shutdown(ctx *common.Context, jobsErr error) error {
+	var errs []error
+	if jobsErr != nil {
+		errs = append(errs, fmt.Errorf("failed to stop jobs: %w", jobsErr))
+	}
+
+	// Stop each service once all the services depending on it have stopped
+	err := s.services.Walk(true, func(id string) error {
+		// Services may have been stopped individually or never started
+		if status := s.lifecycle.status(id); status != types.ServiceRunningType && status != types.ServiceFailedType {
+			return nil
+		}
+		return s.shutdownService(ctx, id)
+	})
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	if s.pluginManager != nil {
+		if err := stopWithDeadline(ctx, DefaultStopTimeout, s.pluginManager.StopPlugins); err != nil {
+			errs = append(errs, fmt.Errorf("failed to stop plugins: %w", err))
+		}
+	}
+
+	if s.store != nil {
+		if abandoned := errors.Join(errs...); errors.Is(abandoned, types.ErrStopTimeout) {
+			errs = append(errs, errors.New("store left open, as abandoned components may still be writing to it"))
+		} else if err := s.store.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to close store: %w", err))
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("%w: %w", types.ErrShutdownIncomplete, errors.Join(errs...))
+	}
+	return nil
+}
+
+// shutdownService stops the service with the given ID within its stop timeout.
+// A service that does not stop in time is marked as failed and abandoned.
+func (s *SystemImpl) shutdownService(ctx *common.Context, serviceID string) error {
+	timeout := s.stopTimeout(serviceID)
+
+	err := stopWithDeadline(ctx, timeout, func(ctx *common.Context) error {
+		return s.stopService(ctx, serviceID)
+	})
+	if err == nil {
+		return nil
+	}
+
+	if errors.Is(err, types.ErrStopTimeout) {
+		// The service may still complete its stop, its late transition is then rejected
+		s.transitionService(serviceID, types.ServiceFailedType)
+		if s.logger != nil {
+			s.logger.Log(common.LevelWarn, "Abandoned service", serviceID, "after", timeout)
+		}
+	} else if s.logger != nil {
+		s.logger.Log(common.LevelError, "Error stopping service:", err)
+	}
+
+	return fmt.Errorf("service %s: %w", serviceID, err)
+}
+
+// stopTimeout returns the stop timeout of the service with the given ID.
+func (s *SystemImpl) stopTimeout(serviceID string) time.Duration {
+	if configuration := s.Configuration(); configuration != nil {
+		for _, config := range configuration.Services {
+			if config.ID == serviceID && config.StopTimeout > 0 {
+				return config.StopTimeout
+			}
+		}
+	}
+	return DefaultStopTimeout
+}
+
+// stopWithDeadline calls the stop function with a context expiring after the timeout,
+// or earlier if the parent context expires first.
+// Returns ErrStopTimeout if the function does not return before the context expires.
+func stopWithDeadline(ctx *common.Context, timeout time.Duration, stop func(ctx *common.Context) error) error {
+	stopCtx, cancel := common.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	result := make(chan error, 1)
+	go func() {
+		result <- stop(stopCtx)
+	}()
+
+	select {
+	case err := <-result:
+		return err
+	case <-stopCtx.Done():
+		return fmt.Errorf("%w: %w", types.ErrStopTimeout, stopCtx.Err())
+	}
+}
@@ -0,0 +1,211 @@
+package system_test
+
+import (
+	"errors"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/ebanfa/skeleton/pkg/common"
+	"github.com/ebanfa/skeleton/pkg/component"
+	"github.com/ebanfa/skeleton/pkg/mocks"
+	systemApi "github.com/ebanfa/skeleton/pkg/system"
+	"github.com/ebanfa/skeleton/pkg/types"
+)
+
+var _ = Describe("Shutdown", func() {
+	var (
+		ctx               *common.Context
+		sys               *systemApi.SystemImpl
+		registrar         *component.ComponentRegistrar
+		mockPluginManager *mocks.PluginManagerInterface
+		mockMultiStore    *mocks.MultiStore
+		release           chan struct{}
+		stopped           chan string
+	)
+
+	newService := func(id string, hang bool) types.ComponentInterface {
+		// Abandoned services outlive the test, they keep the channels of their own test
+		release, stopped := release, stopped
+		service := &mocks.SystemServiceInterface{}
+		service.On("ID").Return(id)
+		service.On("Type").Return(types.ServiceType)
+		service.On("Initialize", ctx, mock.Anything).Return(nil)
+		service.On("Start", ctx).Return(nil)
+		service.On("Stop", mock.Anything).Run(func(mock.Arguments) {
+			if hang {
+				<-release
+			}
+			stopped <- id
+		}).Return(nil)
+		return service
+	}
+
+	BeforeEach(func() {
+		ctx = common.Background()
+		registrar = component.NewComponentRegistrar()
+		mockPluginManager = &mocks.PluginManagerInterface{}
+		mockMultiStore = &mocks.MultiStore{}
+		release = make(chan struct{})
+		stopped = make(chan string, 10)
+
+		factory := &mocks.ComponentFactoryInterface{}
+		factory.On("CreateComponent", mock.Anything).Return(func(config *types.ComponentConfig) (types.ComponentInterface, error) {
+			return newService(config.ID, config.ID == "hung"), nil
+		})
+		Expect(registrar.RegisterFactory(ctx, "serviceFactory", factory)).To(Succeed())
+
+		mockPluginManager.On("Initialize", ctx, mock.Anything).Return(nil)
+		mockPluginManager.On("StartPlugins", ctx).Return(nil)
+
+		sys = systemApi.NewSystem(nil, nil, &types.Configuration{
+			Services: []*types.ServiceConfiguration{
+				{
+					ComponentConfig: types.ComponentConfig{ID: "hung", FactoryID: "serviceFactory"},
+					StopTimeout:     20 * time.Millisecond,
+				},
+				{
+					ComponentConfig: types.ComponentConfig{ID: "db", FactoryID: "serviceFactory"},
+				},
+				{
+					ComponentConfig: types.ComponentConfig{ID: "app", FactoryID: "serviceFactory"},
+					Dependencies:    []string{"hung", "db"},
+				},
+			},
+		}, mockPluginManager, registrar, mockMultiStore)
+
+		Expect(sys.Initialize(ctx)).To(Succeed())
+		Expect(sys.Start(ctx)).To(Succeed())
+	})
+
+	AfterEach(func() {
+		close(release)
+	})
+
+	It("abandons the services that do not stop in time", func() {
+		mockPluginManager.On("StopPlugins", mock.Anything).Return(nil)
+		mockMultiStore.On("Close").Return(nil)
+
+		err := sys.Stop(ctx)
+		Expect(err).To(MatchError(types.ErrShutdownIncomplete))
+		Expect(err).To(MatchError(types.ErrStopTimeout))
+		Expect(err.Error()).To(ContainSubstring("service hung"))
+		Expect(err.Error()).To(ContainSubstring("store left open"))
+
+		Expect(sys.ListServiceStatuses()).To(Equal(map[string]types.ServiceStatusType{
+			"hung": types.ServiceFailedType,
+			"db":   types.ServiceStoppedType,
+			"app":  types.ServiceStoppedType,
+		}))
+		mockPluginManager.AssertCalled(GinkgoT(), "StopPlugins", mock.Anything)
+		mockMultiStore.AssertNotCalled(GinkgoT(), "Close")
+	})
+
+	It("rejects starting and stopping services while stopping, without blocking", func() {
+		mockPluginManager.On("StopPlugins", mock.Anything).Return(nil)
+		mockMultiStore.On("Close").Return(nil)
+
+		result := make(chan error, 1)
+		go func() { result <- sys.Stop(ctx) }()
+
+		// The hung service is stopping once its dependent stopped
+		Eventually(stopped).Should(Receive(Equal("app")))
+		Expect(sys.StartService(ctx, "app")).To(MatchError(types.ErrSystemStopping))
+		Expect(sys.StopService(ctx, "db")).To(MatchError(types.ErrSystemStopping))
+
+		Eventually(result).Should(Receive(MatchError(types.ErrShutdownIncomplete)))
+	})
+
+	It("reports the plugins and the store that do not stop cleanly", func() {
+		mockPluginManager.On("StopPlugins", mock.Anything).Return(errors.New("plugin error"))
+		mockMultiStore.On("Close").Return(errors.New("store error"))
+
+		// The store is only closed when no service was abandoned
+		close(release)
+		release = make(chan struct{})
+
+		err := sys.Stop(ctx)
+		Expect(err).To(MatchError(types.ErrShutdownIncomplete))
+		Expect(err.Error()).To(ContainSubstring("failed to stop plugins: plugin error"))
+		Expect(err.Error()).To(ContainSubstring("failed to close store: store error"))
+	})
+
+	It("stops the services within the deadline of the context", func() {
+		mockPluginManager.On("StopPlugins", mock.Anything).Return(nil)
+		mockMultiStore.On("Close").Return(nil)
+		stopCtx, cancel := common.WithTimeout(ctx, 5*time.Millisecond)
+		defer cancel()
+
+		Expect(sys.Stop(stopCtx)).To(MatchError(types.ErrStopTimeout))
+		Expect(sys.ServiceStatus("hung")).To(Equal(types.ServiceFailedType))
+	})
+})
+
+var _ = Describe("ShutdownCoordinator", func() {
+	var (
+		mockSystem  *mocks.SystemInterface
+		coordinator *systemApi.ShutdownCoordinator
+		stopCtx     chan *common.Context
+		received    chan os.Signal
+	)
+
+	BeforeEach(func() {
+		mockSystem = &mocks.SystemInterface{}
+		mockSystem.On("Logger").Return(nil)
+		stopCtx = make(chan *common.Context, 1)
+		coordinator = systemApi.NewShutdownCoordinator(mockSystem, systemApi.ShutdownOptions{
+			Signals: []os.Signal{syscall.SIGUSR1},
+			Timeout: time.Second,
+		})
+
+		// Keep the signals sent before the coordinator waits from terminating the process
+		received = make(chan os.Signal, 10)
+		signal.Notify(received, syscall.SIGUSR1)
+	})
+
+	AfterEach(func() {
+		signal.Stop(received)
+	})
+
+	sendSignal := func() {
+		process, err := os.FindProcess(os.Getpid())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(process.Signal(syscall.SIGUSR1)).To(Succeed())
+	}
+
+	It("stops the system when the context is done", func() {
+		mockSystem.On("Stop", mock.Anything).Return(nil)
+		ctx, cancel := common.WithTimeout(common.Background(), time.Millisecond)
+		defer cancel()
+
+		Expect(coordinator.Wait(ctx)).To(Succeed())
+		mockSystem.AssertCalled(GinkgoT(), "Stop", mock.Anything)
+	})
+
+	It("stops the system when a signal is received and abandons it on a second signal", func() {
+		mockSystem.On("Stop", mock.Anything).Run(func(args mock.Arguments) {
+			ctx := args.Get(0).(*common.Context)
+			stopCtx <- ctx
+			<-ctx.Done()
+		}).Return(types.ErrShutdownIncomplete)
+
+		done := make(chan error, 1)
+		go func() {
+			done <- coordinator.Wait(common.Background())
+		}()
+
+		// The handler is registered once Wait is running
+		Eventually(func() chan *common.Context {
+			sendSignal()
+			return stopCtx
+		}).Should(Receive())
+
+		sendSignal()
+		Eventually(done).Should(Receive(MatchError(types.ErrShutdownIncomplete)))
+	})
+})
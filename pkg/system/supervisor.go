@@ -0,0 +1,425 @@
+package system
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ebanfa/skeleton/pkg/common"
+	"github.com/ebanfa/skeleton/pkg/types"
+)
+
+const (
+	// EventTypeServiceRestarted represents an event emitted when a supervisor restarted a failing service.
+	EventTypeServiceRestarted string = "service_restarted"
+
+	// EventTypeServiceRestartFailed represents an event emitted when a supervisor failed to restart a service.
+	EventTypeServiceRestartFailed string = "service_restart_failed"
+
+	// EventTypeServiceGaveUp represents an event emitted when a supervisor exhausted its restart budget.
+	EventTypeServiceGaveUp string = "service_gave_up"
+)
+
+const (
+	defaultPollInterval  = 5 * time.Second
+	defaultRetryInterval = time.Second
+	defaultMaxBackoff    = time.Minute
+)
+
+// RestartStrategy determines which services of a group are restarted when one of them fails.
+type RestartStrategy int
+
+const (
+	// OneForOne restarts only the failed service.
+	OneForOne RestartStrategy = iota
+
+	// OneForAll restarts every service of the group.
+	OneForAll
+
+	// RestForOne restarts the failed service and the services declared after it in the group.
+	RestForOne
+)
+
+// String returns the string representation of the restart strategy.
+func (r RestartStrategy) String() string {
+	switch r {
+	case OneForOne:
+		return "one_for_one"
+	case OneForAll:
+		return "one_for_all"
+	case RestForOne:
+		return "rest_for_one"
+	default:
+		return "unknown"
+	}
+}
+
+// SupervisionGroup represents a group of services supervised together.
+type SupervisionGroup struct {
+	// ID is the unique identifier of the group.
+	ID string
+
+	// Strategy determines which services are restarted when one of them fails.
+	Strategy RestartStrategy
+
+	// ServiceIDs are the IDs of the supervised services, in start order.
+	ServiceIDs []string
+}
+
+// SupervisorOptions represents the options of a supervisor.
+type SupervisorOptions struct {
+	// PollInterval is the interval between two health checks.
+	PollInterval time.Duration
+
+	// RetryInterval is the initial delay before a restart, used for services
+	// without a RetryInterval in their configuration.
+	RetryInterval time.Duration
+
+	// MaxBackoff caps the exponentially growing delay between restarts.
+	MaxBackoff time.Duration
+
+	// MaxRestarts is the number of restarts allowed per group within RestartWindow.
+	// Zero means unlimited restarts.
+	MaxRestarts int
+
+	// RestartWindow is the period over which restarts are counted.
+	// Zero means restarts are counted over the lifetime of the supervisor.
+	RestartWindow time.Duration
+}
+
+// SupervisorEvent represents the data of the events published by a supervisor.
+type SupervisorEvent struct {
+	// GroupID is the ID of the supervision group.
+	GroupID string
+
+	// ServiceID is the ID of the service that failed its health check.
+	ServiceID string
+
+	// Restarts is the number of restarts performed within the restart window.
+	Restarts int
+
+	// Err is the error that caused the event, if any.
+	Err error
+}
+
+// Supervisor is a service that polls the health of other services and restarts them when they fail.
+type Supervisor struct {
+	BaseSystemService
+	mutex      sync.RWMutex
+	options    SupervisorOptions
+	groups     []SupervisionGroup
+	discovered bool               // Groups were discovered on Initialize rather than added
+	cancel     context.CancelFunc // Stops the supervising goroutines, nil when not started
+	wg         sync.WaitGroup
+}
+
+// NewSupervisor creates a new instance of Supervisor.
+func NewSupervisor(id string, options SupervisorOptions) *Supervisor {
+	if options.PollInterval <= 0 {
+		options.PollInterval = defaultPollInterval
+	}
+	if options.RetryInterval <= 0 {
+		options.RetryInterval = defaultRetryInterval
+	}
+	if options.MaxBackoff <= 0 {
+		options.MaxBackoff = defaultMaxBackoff
+	}
+
+	return &Supervisor{
+		BaseSystemService: *NewBaseSystemService(id, "Supervisor", "Restarts services failing their health checks"),
+		options:           options,
+	}
+}
+
+// Supervise adds a group of services to the supervisor, replacing the groups discovered on Initialize.
+// Returns an error if the group is invalid or a group with the same ID already exists.
+func (s *Supervisor) Supervise(group SupervisionGroup) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if group.ID == "" || len(group.ServiceIDs) == 0 {
+		return errors.New("supervision group requires an ID and at least one service")
+	}
+	if s.discovered {
+		s.groups, s.discovered = nil, false
+	}
+	for _, existing := range s.groups {
+		if existing.ID == group.ID {
+			return fmt.Errorf("supervision group with ID %s already exists", group.ID)
+		}
+	}
+
+	group.ServiceIDs = append([]string(nil), group.ServiceIDs...)
+	s.groups = append(s.groups, group)
+	return nil
+}
+
+// Dependencies returns the IDs of the supervised services, so that the supervisor
+// is started after and stopped before them.
+func (s *Supervisor) Dependencies() []string {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	var dependencies []string
+	for _, group := range s.groups {
+		dependencies = append(dependencies, group.ServiceIDs...)
+	}
+	return dependencies
+}
+
+// Initialize initializes the supervisor. If no group was added, every service implementing
+// HealthCheckInterface registered so far is supervised on its own, so that the supervised services
+// are declared as dependencies when the system builds its dependency graph. The services registered
+// after the supervisor are not discovered, they have to be added with Supervise.
+func (s *Supervisor) Initialize(ctx *common.Context, system types.SystemInterface) error {
+	if err := s.BaseSystemService.Initialize(ctx, system); err != nil {
+		return err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if len(s.groups) > 0 || system == nil {
+		return nil
+	}
+	for _, component := range system.ComponentRegistry().GetComponentsByType(types.ServiceType) {
+		if _, ok := component.(types.HealthCheckInterface); ok && component.ID() != s.ID() {
+			s.groups = append(s.groups, SupervisionGroup{
+				ID:         component.ID(),
+				Strategy:   OneForOne,
+				ServiceIDs: []string{component.ID()},
+			})
+		}
+	}
+	s.discovered = len(s.groups) > 0
+
+	return nil
+}
+
+// Start starts supervising the service groups.
+func (s *Supervisor) Start(ctx *common.Context) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.System == nil {
+		return types.ErrSystemNotInitialized
+	}
+	if s.cancel != nil {
+		return nil
+	}
+
+	// The supervising goroutines stop calling back into the system once the context is canceled
+	runCtx, cancel := common.WithCancel(ctx)
+	s.cancel = cancel
+	for _, group := range s.groups {
+		s.wg.Add(1)
+		go s.supervise(runCtx, group)
+	}
+
+	return nil
+}
+
+// Stop stops supervising the service groups, waiting for the restarts in progress.
+func (s *Supervisor) Stop(ctx *common.Context) error {
+	s.mutex.Lock()
+	cancel := s.cancel
+	s.cancel = nil
+	s.mutex.Unlock()
+
+	if cancel != nil {
+		cancel()
+		s.wg.Wait()
+	}
+
+	return nil
+}
+
+// supervise polls the health of the services of a group until the context is canceled
+// or the restart budget of the group is exhausted.
+func (s *Supervisor) supervise(ctx *common.Context, group SupervisionGroup) {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(s.options.PollInterval)
+	defer ticker.Stop()
+
+	var (
+		failures int
+		restarts []time.Time
+	)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		failed, err := s.checkHealth(ctx, group)
+		if ctx.Err() != nil {
+			return
+		}
+		if failed < 0 {
+			failures = 0
+			continue
+		}
+		serviceID := group.ServiceIDs[failed]
+
+		// Only count the restarts within the restart window
+		restarts = s.pruneRestarts(restarts, time.Now())
+		if s.options.MaxRestarts > 0 && len(restarts) >= s.options.MaxRestarts {
+			s.publish(EventTypeServiceGaveUp, group, serviceID, len(restarts), err)
+			return
+		}
+
+		// Wait before restarting, doubling the delay after each consecutive failure
+		failures++
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(s.backoff(serviceID, failures)):
+		}
+
+		// Both cases of the select may be ready once the supervisor is stopped
+		if ctx.Err() != nil {
+			return
+		}
+		restarts = append(restarts, time.Now())
+		if err := s.restart(ctx, group, failed); err != nil {
+			s.publish(EventTypeServiceRestartFailed, group, serviceID, len(restarts), err)
+			continue
+		}
+		s.publish(EventTypeServiceRestarted, group, serviceID, len(restarts), err)
+	}
+}
+
+// checkHealth checks the health of each running service of a group in order.
+// Returns the index of the first unhealthy or failed service and its error, or -1 if all services are healthy.
+func (s *Supervisor) checkHealth(ctx *common.Context, group SupervisionGroup) (int, error) {
+	for i, id := range group.ServiceIDs {
+		status, err := s.System.ServiceStatus(id)
+		if err != nil {
+			return i, err
+		}
+
+		switch status {
+		case types.ServiceFailedType:
+			return i, fmt.Errorf("%w: %s", types.ErrServiceFailed, id)
+		case types.ServiceRunningType:
+		default:
+			// Services that were stopped or not started yet are left alone
+			continue
+		}
+
+		component, err := s.System.ComponentRegistry().GetComponent(id)
+		if err != nil {
+			return i, err
+		}
+
+		checker, ok := component.(types.HealthCheckInterface)
+		if !ok {
+			continue
+		}
+
+		checkCtx, cancel := common.WithTimeout(ctx, s.options.PollInterval)
+		err = checker.HealthCheck(checkCtx)
+		cancel()
+		if err != nil {
+			return i, err
+		}
+	}
+
+	return -1, nil
+}
+
+// restart restarts the services of a group according to its strategy.
+func (s *Supervisor) restart(ctx *common.Context, group SupervisionGroup, failed int) error {
+	var serviceIDs []string
+	switch group.Strategy {
+	case OneForAll:
+		serviceIDs = group.ServiceIDs
+	case RestForOne:
+		serviceIDs = group.ServiceIDs[failed:]
+	default:
+		return s.System.RestartService(ctx, group.ServiceIDs[failed])
+	}
+
+	// Stop the running services in reverse order, then start them all in order
+	var errs []error
+	for i := len(serviceIDs) - 1; i >= 0; i-- {
+		status, _ := s.System.ServiceStatus(serviceIDs[i])
+		if status != types.ServiceRunningType && status != types.ServiceFailedType {
+			continue
+		}
+		if err := s.System.StopService(ctx, serviceIDs[i]); err != nil {
+			errs = append(errs, fmt.Errorf("failed to stop service %s: %w", serviceIDs[i], err))
+		}
+	}
+	for _, id := range serviceIDs {
+		// The services are left stopped once the supervisor is stopped
+		if ctx.Err() != nil {
+			errs = append(errs, ctx.Err())
+			break
+		}
+		if err := s.System.StartService(ctx, id); err != nil {
+			errs = append(errs, fmt.Errorf("failed to start service %s: %w", id, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// backoff returns the delay before the given consecutive restart of a service.
+func (s *Supervisor) backoff(serviceID string, failures int) time.Duration {
+	delay := s.retryInterval(serviceID)
+	for i := 1; i < failures && delay < s.options.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > s.options.MaxBackoff {
+		delay = s.options.MaxBackoff
+	}
+	return delay
+}
+
+// retryInterval returns the configured retry interval of a service.
+func (s *Supervisor) retryInterval(serviceID string) time.Duration {
+	if configuration := s.System.Configuration(); configuration != nil {
+		for _, config := range configuration.Services {
+			if config.ID == serviceID && config.RetryInterval > 0 {
+				return config.RetryInterval
+			}
+		}
+	}
+	return s.options.RetryInterval
+}
+
+// pruneRestarts removes the restarts that happened before the restart window.
+func (s *Supervisor) pruneRestarts(restarts []time.Time, now time.Time) []time.Time {
+	if s.options.RestartWindow <= 0 {
+		return restarts
+	}
+
+	pruned := restarts[:0]
+	for _, restart := range restarts {
+		if now.Sub(restart) < s.options.RestartWindow {
+			pruned = append(pruned, restart)
+		}
+	}
+	return pruned
+}
+
+// publish publishes a supervisor event on the system event bus.
+func (s *Supervisor) publish(eventType string, group SupervisionGroup, serviceID string, restarts int, err error) {
+	eventBus := s.System.EventBus()
+	if eventBus == nil {
+		return
+	}
+
+	eventBus.Publish(common.NewEvent(nil, s.ID(), eventType, SupervisorEvent{
+		GroupID:   group.ID,
+		ServiceID: serviceID,
+		Restarts:  restarts,
+		Err:       err,
+	}))
+}
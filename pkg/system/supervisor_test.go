@@ -0,0 +1,287 @@
+package system_test
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/ebanfa/skeleton/pkg/common"
+	"github.com/ebanfa/skeleton/pkg/mocks"
+	"github.com/ebanfa/skeleton/pkg/system"
+	"github.com/ebanfa/skeleton/pkg/types"
+)
+
+var _ = Describe("Supervisor", func() {
+	var (
+		ctx        *common.Context
+		mockSystem *mocks.SystemInterface
+		registrar  *mocks.ComponentRegistrarInterface
+		eventBus   common.EventBusInterface
+		events     chan common.Event
+		supervisor *system.Supervisor
+		options    system.SupervisorOptions
+		mutex      sync.Mutex
+		calls      []string
+	)
+
+	newService := func(id string, healthy bool) *healthCheckedService {
+		service := &healthCheckedService{SystemServiceInterface: &mocks.SystemServiceInterface{}}
+		service.healthy.Store(healthy)
+		service.On("ID").Return(id)
+		service.On("Type").Return(types.ServiceType)
+		registrar.On("GetComponent", id).Return(service, nil)
+		return service
+	}
+
+	record := func(call string) func(mock.Arguments) {
+		return func(mock.Arguments) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			calls = append(calls, call)
+		}
+	}
+
+	recorded := func() []string {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return append([]string(nil), calls...)
+	}
+
+	BeforeEach(func() {
+		ctx = common.Background()
+		mockSystem = &mocks.SystemInterface{}
+		registrar = &mocks.ComponentRegistrarInterface{}
+		eventBus = common.NewSystemEventBus()
+		events = make(chan common.Event, 10)
+		calls = nil
+		options = system.SupervisorOptions{
+			PollInterval:  5 * time.Millisecond,
+			RetryInterval: time.Millisecond,
+		}
+
+		for _, topic := range []string{system.EventTypeServiceRestarted, system.EventTypeServiceRestartFailed, system.EventTypeServiceGaveUp} {
+			Expect(eventBus.Subscribe(common.BusSubscriptionParams{
+				Topic:        topic,
+				EventHandler: func(event common.Event) { events <- event },
+			})).Error().NotTo(HaveOccurred())
+		}
+
+		mockSystem.On("ComponentRegistry").Return(registrar)
+		mockSystem.On("EventBus").Return(eventBus)
+		mockSystem.On("Configuration").Return(&types.Configuration{})
+		mockSystem.On("ServiceStatus", mock.Anything).Return(types.ServiceRunningType, nil)
+	})
+
+	JustBeforeEach(func() {
+		supervisor = system.NewSupervisor("supervisor", options)
+	})
+
+	AfterEach(func() {
+		Expect(supervisor.Stop(ctx)).To(Succeed())
+	})
+
+	Describe("Supervise", func() {
+		It("rejects a group without services", func() {
+			Expect(supervisor.Supervise(system.SupervisionGroup{ID: "group"})).NotTo(Succeed())
+		})
+
+		It("rejects a duplicate group", func() {
+			group := system.SupervisionGroup{ID: "group", ServiceIDs: []string{"a"}}
+			Expect(supervisor.Supervise(group)).To(Succeed())
+			Expect(supervisor.Supervise(group)).NotTo(Succeed())
+		})
+
+		It("declares the supervised services as dependencies", func() {
+			Expect(supervisor.Supervise(system.SupervisionGroup{ID: "g1", ServiceIDs: []string{"a", "b"}})).To(Succeed())
+			Expect(supervisor.Supervise(system.SupervisionGroup{ID: "g2", ServiceIDs: []string{"c"}})).To(Succeed())
+			Expect(supervisor.Dependencies()).To(Equal([]string{"a", "b", "c"}))
+		})
+	})
+
+	Describe("Start", func() {
+		It("returns an error when not initialized", func() {
+			Expect(supervisor.Start(ctx)).To(MatchError(types.ErrSystemNotInitialized))
+		})
+	})
+
+	Context("with the one for one strategy", func() {
+		var service *healthCheckedService
+
+		BeforeEach(func() {
+			service = newService("a", false)
+			mockSystem.On("RestartService", mock.Anything, "a").Run(func(args mock.Arguments) {
+				record("restart a")(args)
+				service.healthy.Store(true)
+			}).Return(nil)
+		})
+
+		It("restarts the failing service and publishes an event", func() {
+			Expect(supervisor.Supervise(system.SupervisionGroup{ID: "group", ServiceIDs: []string{"a"}})).To(Succeed())
+			Expect(supervisor.Initialize(ctx, mockSystem)).To(Succeed())
+			Expect(supervisor.Start(ctx)).To(Succeed())
+
+			var event common.Event
+			Eventually(events).Should(Receive(&event))
+			Expect(event.Type).To(Equal(system.EventTypeServiceRestarted))
+			Expect(event.Data).To(Equal(system.SupervisorEvent{
+				GroupID:   "group",
+				ServiceID: "a",
+				Restarts:  1,
+				Err:       errUnhealthy,
+			}))
+			Consistently(recorded, 50*time.Millisecond).Should(Equal([]string{"restart a"}))
+		})
+
+		It("supervises health checked services by default", func() {
+			registrar.On("GetComponentsByType", types.ServiceType).Return([]types.ComponentInterface{service})
+
+			Expect(supervisor.Initialize(ctx, mockSystem)).To(Succeed())
+			Expect(supervisor.Dependencies()).To(Equal([]string{"a"}))
+			Expect(supervisor.Start(ctx)).To(Succeed())
+
+			Eventually(events).Should(Receive())
+			Expect(recorded()).To(Equal([]string{"restart a"}))
+		})
+
+		It("replaces the discovered services with the supervised groups", func() {
+			registrar.On("GetComponentsByType", types.ServiceType).Return([]types.ComponentInterface{service})
+
+			Expect(supervisor.Initialize(ctx, mockSystem)).To(Succeed())
+			Expect(supervisor.Supervise(system.SupervisionGroup{ID: "group", ServiceIDs: []string{"b"}})).To(Succeed())
+			Expect(supervisor.Dependencies()).To(Equal([]string{"b"}))
+		})
+
+		Context("when stopped while waiting to restart", func() {
+			BeforeEach(func() {
+				options.RetryInterval = time.Hour
+			})
+
+			It("stops without restarting", func() {
+				Expect(supervisor.Supervise(system.SupervisionGroup{ID: "group", ServiceIDs: []string{"a"}})).To(Succeed())
+				Expect(supervisor.Initialize(ctx, mockSystem)).To(Succeed())
+				Expect(supervisor.Start(ctx)).To(Succeed())
+
+				// Wait for a health check to fail
+				time.Sleep(20 * time.Millisecond)
+				Expect(supervisor.Stop(ctx)).To(Succeed())
+				Expect(recorded()).To(BeEmpty())
+				Consistently(events, 20*time.Millisecond).ShouldNot(Receive())
+			})
+		})
+	})
+
+	Context("when the restart budget is exhausted", func() {
+		BeforeEach(func() {
+			options.MaxRestarts = 2
+			options.RestartWindow = time.Minute
+			newService("a", false)
+			mockSystem.On("RestartService", mock.Anything, "a").Run(record("restart a")).Return(nil)
+		})
+
+		It("gives up and publishes an event", func() {
+			Expect(supervisor.Supervise(system.SupervisionGroup{ID: "group", ServiceIDs: []string{"a"}})).To(Succeed())
+			Expect(supervisor.Initialize(ctx, mockSystem)).To(Succeed())
+			Expect(supervisor.Start(ctx)).To(Succeed())
+
+			Eventually(events).Should(Receive(HaveField("Type", system.EventTypeServiceRestarted)))
+			Eventually(events).Should(Receive(HaveField("Type", system.EventTypeServiceRestarted)))
+			Eventually(events).Should(Receive(HaveField("Type", system.EventTypeServiceGaveUp)))
+			Consistently(recorded, 50*time.Millisecond).Should(HaveLen(2))
+		})
+	})
+
+	Context("when the restart fails", func() {
+		BeforeEach(func() {
+			options.MaxRestarts = 1
+			newService("a", false)
+			mockSystem.On("RestartService", mock.Anything, "a").Return(errors.New("restart error"))
+		})
+
+		It("publishes a restart failed event", func() {
+			Expect(supervisor.Supervise(system.SupervisionGroup{ID: "group", ServiceIDs: []string{"a"}})).To(Succeed())
+			Expect(supervisor.Initialize(ctx, mockSystem)).To(Succeed())
+			Expect(supervisor.Start(ctx)).To(Succeed())
+
+			var event common.Event
+			Eventually(events).Should(Receive(&event))
+			Expect(event.Type).To(Equal(system.EventTypeServiceRestartFailed))
+			Expect(event.Data.(system.SupervisorEvent).Err).To(MatchError("restart error"))
+		})
+	})
+
+	Context("with the one for all strategy", func() {
+		BeforeEach(func() {
+			newService("a", true)
+			b := newService("b", false)
+			mockSystem.On("StopService", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+				record("stop " + args.String(1))(args)
+			}).Return(nil)
+			mockSystem.On("StartService", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+				record("start " + args.String(1))(args)
+				b.healthy.Store(true)
+			}).Return(nil)
+		})
+
+		It("restarts every service of the group", func() {
+			Expect(supervisor.Supervise(system.SupervisionGroup{
+				ID:         "group",
+				Strategy:   system.OneForAll,
+				ServiceIDs: []string{"a", "b"},
+			})).To(Succeed())
+			Expect(supervisor.Initialize(ctx, mockSystem)).To(Succeed())
+			Expect(supervisor.Start(ctx)).To(Succeed())
+
+			Eventually(events).Should(Receive(HaveField("Data.ServiceID", "b")))
+			Expect(recorded()).To(Equal([]string{"stop b", "stop a", "start a", "start b"}))
+		})
+	})
+
+	Context("with the rest for one strategy", func() {
+		BeforeEach(func() {
+			newService("a", true)
+			b := newService("b", false)
+			newService("c", true)
+			mockSystem.On("StopService", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+				record("stop " + args.String(1))(args)
+			}).Return(nil)
+			mockSystem.On("StartService", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+				record("start " + args.String(1))(args)
+				b.healthy.Store(true)
+			}).Return(nil)
+		})
+
+		It("restarts the failed service and the services after it", func() {
+			Expect(supervisor.Supervise(system.SupervisionGroup{
+				ID:         "group",
+				Strategy:   system.RestForOne,
+				ServiceIDs: []string{"a", "b", "c"},
+			})).To(Succeed())
+			Expect(supervisor.Initialize(ctx, mockSystem)).To(Succeed())
+			Expect(supervisor.Start(ctx)).To(Succeed())
+
+			Eventually(events).Should(Receive(HaveField("Data.ServiceID", "b")))
+			Expect(recorded()).To(Equal([]string{"stop c", "stop b", "start b", "start c"}))
+		})
+	})
+})
+
+var errUnhealthy = errors.New("service unhealthy")
+
+// healthCheckedService is a service reporting its health.
+type healthCheckedService struct {
+	*mocks.SystemServiceInterface
+	healthy atomic.Bool
+}
+
+// HealthCheck returns an error if the service is unhealthy.
+func (h *healthCheckedService) HealthCheck(ctx *common.Context) error {
+	if h.healthy.Load() {
+		return nil
+	}
+	return errUnhealthy
+}
@@ -3,22 +3,32 @@ package system
 import (
 	"fmt"
 	"sync"
+	"sync/atomic"
 
 	"github.com/ebanfa/skeleton/pkg/common"
 	"github.com/ebanfa/skeleton/pkg/types"
 )
 
+// SystemEventSource is the source of the events published by the system itself.
+const SystemEventSource = "system"
+
 // SystemImpl represents the core system in the application.
 type SystemImpl struct {
 	types.SystemInterface
 	mutex         sync.RWMutex
-	configuration *types.Configuration
+	reloadMutex   sync.Mutex                          // Serializes the configuration reloads
+	configuration atomic.Pointer[types.Configuration] // Replaced by the reloads
 	componentReg  types.ComponentRegistrarInterface
 	logger        common.LoggerInterface
 	eventBus      common.EventBusInterface
 	pluginManager types.PluginManagerInterface
 	status        types.SystemStatusType
+	stopping      bool // Services can no longer be started or stopped individually
 	store         types.MultiStore
+	services      *DependencyGraph
+	lifecycle     *serviceLifecycle
+	interceptors  []types.OperationInterceptor
+	jobs          *jobManager
 }
 
 // NewSystem creates a new instance of the SystemImpl.
@@ -29,15 +39,25 @@ func NewSystem(
 	pluginManager types.PluginManagerInterface,
 	componentReg types.ComponentRegistrarInterface,
 	store types.MultiStore) *SystemImpl {
-	return &SystemImpl{
+	s := &SystemImpl{
 		logger:        logger,
 		eventBus:      eventBus,
 		componentReg:  componentReg,
-		configuration: configuration,
 		pluginManager: pluginManager,
 		status:        types.SystemStoppedType,
 		store:         store,
+		services:      NewDependencyGraph(),
+		lifecycle:     newServiceLifecycle(),
+	}
+	s.configuration.Store(configuration)
+
+	var jobConfig types.JobConfiguration
+	if configuration != nil {
+		jobConfig = configuration.Jobs
 	}
+	s.jobs = newJobManager(jobConfig, s.ExecuteOperation, s.publishJob, logger)
+
+	return s
 }
 
 // Logger returns the system logger.
@@ -52,7 +72,7 @@ func (s *SystemImpl) EventBus() common.EventBusInterface {
 
 // Configuration returns the system configuration.
 func (s *SystemImpl) Configuration() *types.Configuration {
-	return s.configuration
+	return s.configuration.Load()
 }
 
 // ComponentRegistry returns the component registry.
@@ -71,18 +91,49 @@ func (s *SystemImpl) PluginManager() types.PluginManagerInterface {
 }
 
 // Initialize initializes the system component by executing the initialize operation.
+// The services and operations declared in the configuration are created and initialized,
+// the services are started along with the other registered services when the system starts.
+// Returns an error if the configuration is invalid, if a configured component cannot be
+// bootstrapped, or if the service dependencies are missing or form a cycle.
 func (s *SystemImpl) Initialize(ctx *common.Context) error {
 	// Override this function to customize system initialization
 
+	if err := s.pluginManager.Initialize(ctx, s); err != nil {
+		return err
+	}
+
+	// Validate the configuration once the plugins registered their factories
+	if err := NewConfigValidator(s.componentReg).Validate(s.Configuration()); err != nil {
+		return fmt.Errorf("failed to validate configuration: %w", err)
+	}
+
+	// Create the components declared in the configuration
+	if err := s.bootstrap(ctx); err != nil {
+		return fmt.Errorf("failed to bootstrap components: %w", err)
+	}
+
+	// Replay the jobs that did not complete, once the operations are created
+	if err := s.initializeJobs(); err != nil {
+		return fmt.Errorf("failed to initialize jobs: %w", err)
+	}
+
+	// Build the service dependency graph
+	services, err := s.buildServiceGraph(s.Configuration(), nil, nil)
+	if err != nil {
+		return fmt.Errorf("failed to resolve service dependencies: %w", err)
+	}
+	s.services = services
+
 	s.status = types.SystemInitializedType
 
-	return s.pluginManager.Initialize(ctx, s)
+	return nil
 }
 
 // Start starts the system component along with all registered services.
+// Services are started in dependency order, independent services are started concurrently.
 func (s *SystemImpl) Start(ctx *common.Context) error {
-	s.mutex.RLock()
-	defer s.mutex.RUnlock()
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
 
 	if s.status != types.SystemInitializedType {
 		return types.ErrSystemNotInitialized
@@ -93,111 +144,293 @@ func (s *SystemImpl) Start(ctx *common.Context) error {
 		s.logger.Log(common.LevelError, "Error starting plugin:", err)
 		return err
 	}
+
+	// Start the services, keeping track of those that started
+	var startedMutex sync.Mutex
+	started := make(map[string]bool)
+	err := s.services.Walk(false, func(id string) error {
+		// Services may have been started individually
+		if s.lifecycle.status(id) == types.ServiceRunningType {
+			return nil
+		}
+		if err := s.startService(ctx, id); err != nil {
+			return err
+		}
+		startedMutex.Lock()
+		started[id] = true
+		startedMutex.Unlock()
+		return nil
+	})
+
+	if err != nil {
+		// Roll back the services that were started
+		s.services.Walk(true, func(id string) error {
+			if !started[id] {
+				return nil
+			}
+			return s.stopService(ctx, id)
+		})
+		return fmt.Errorf("failed to start services: %w", err)
+	}
+
+	// Run the submitted operations once the services they may rely on are started
+	s.jobs.start()
+
 	s.status = types.SystemStartedType
 	return nil
 }
 
 // Stop stops the system component along with all registered services.
+// Services are stopped in reverse dependency order, each within its stop timeout; services that
+// do not stop in time are abandoned. The plugins are then stopped and the store is closed, unless
+// something was abandoned.
+// While the system is stopping, services can no longer be started, stopped or reloaded.
+// Returns an error wrapping ErrShutdownIncomplete describing what did not stop cleanly.
 func (s *SystemImpl) Stop(ctx *common.Context) error {
-	s.mutex.RLock()
-	defer s.mutex.RUnlock()
-
-	if s.status != types.SystemStartedType {
+	s.mutex.Lock()
+	if s.status != types.SystemStartedType || s.stopping {
+		s.mutex.Unlock()
 		return types.ErrSystemNotStarted
 	}
-	// Retrieve all components of type ServiceType
-	components := s.ComponentRegistry().GetComponentsByType(types.ServiceType)
+	s.stopping = true
+	s.mutex.Unlock()
 
-	// Iterate over each service component and start it
-	for _, service := range components {
-		// Check if the component implements SystemServiceInterface
-		systemService, ok := service.(types.SystemServiceInterface)
-		if !ok {
-			return fmt.Errorf("failed to start service: component %v is not a service", service)
-		}
+	// The system is stopped without holding the lock, as running jobs execute operations and
+	// stopping services may wait on goroutines calling back into the system, such as a supervisor
+	jobsErr := stopWithDeadline(ctx, DefaultStopTimeout, s.jobs.stop)
+	err := s.shutdown(ctx, jobsErr)
 
-		// Stop the service
-		if err := systemService.Stop(ctx); err != nil {
-			// Log the error, but continue stopping other services
-			s.logger.Log(common.LevelError, "Error stopping service:", err)
-		}
-	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
 
+	s.stopping = false
 	s.status = types.SystemStoppedType
-	return nil
+	return err
 }
 
-// ExecuteOperation executes the operation with the given ID and input data.
+// ExecuteOperation executes the operation with the given ID and input data through the operation interceptors.
 // Returns the output of the operation and an error if the operation is not found or if execution fails.
 func (s *SystemImpl) ExecuteOperation(ctx *common.Context, operationID string, data *types.SystemOperationInput) (*types.SystemOperationOutput, error) {
 	s.mutex.RLock()
-	defer s.mutex.RUnlock()
 
 	// Retrieve the operation by its ID
 	component, err := s.ComponentRegistry().GetComponent(operationID)
 	if err != nil {
+		s.mutex.RUnlock()
 		return nil, err
 	}
 
 	// Check if the component implements Operation interface
 	operation, ok := component.(types.SystemOperationInterface)
 	if !ok {
+		s.mutex.RUnlock()
 		return nil, fmt.Errorf("failed to execute operation: component %v is not an operation", operation)
 	}
+	interceptor := ChainOperationInterceptors(s.interceptors...)
+
+	// The operation runs without the lock, so that a long operation does not hold up the system
+	s.mutex.RUnlock()
+
 	// Execute the operation
-	return operation.Execute(ctx, data)
+	info := &types.OperationInfo{OperationID: operationID, Operation: operation}
+	return interceptor(ctx, data, info, operation.Execute)
+}
+
+// AddOperationInterceptors adds interceptors wrapping every operation execution.
+// Interceptors run in the order they are added, the first one being the outermost.
+func (s *SystemImpl) AddOperationInterceptors(interceptors ...types.OperationInterceptor) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.interceptors = append(s.interceptors, interceptors...)
 }
 
 // StartService starts the service with the given ID.
-// Returns an error if the service ID is not found or other error
+// Returns ErrSystemStopping while the system is stopping, an error if the service ID is not found or other error
 func (s *SystemImpl) StartService(ctx *common.Context, serviceID string) error {
 	s.mutex.RLock()
 	defer s.mutex.RUnlock()
 
-	// Retrieve the service by its ID
-	component, err := s.ComponentRegistry().GetComponent(serviceID)
-	if err != nil {
-		return err
+	if s.stopping {
+		return types.ErrSystemStopping
 	}
-	// Check if the component implements SystemServiceInterface interface
-	service, ok := component.(types.SystemServiceInterface)
-	if !ok {
-		return fmt.Errorf("failed to start service: component %v is not a service", service)
+	return s.startService(ctx, serviceID)
+}
+
+// StopService stops the service with the given ID.
+// Returns ErrSystemStopping while the system is stopping, an error if the service ID is not found or other error.
+func (s *SystemImpl) StopService(ctx *common.Context, serviceID string) error {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	if s.stopping {
+		return types.ErrSystemStopping
+	}
+	return s.stopService(ctx, serviceID)
+}
+
+// RestartService restarts the service with the given ID.
+// A service that is not running is only started.
+// Returns an error if the service ID is not found or other error.
+func (s *SystemImpl) RestartService(ctx *common.Context, serviceID string) error {
+	// Stop the service first
+	if s.lifecycle.status(serviceID) == types.ServiceRunningType {
+		if err := s.StopService(ctx, serviceID); err != nil {
+			return err
+		}
 	}
 
 	// Start the service
-	return service.Start(ctx)
+	return s.StartService(ctx, serviceID)
 }
 
-// StopService stops the service with the given ID.
-// Returns an error if the service ID is not found or other error.
-func (s *SystemImpl) StopService(ctx *common.Context, serviceID string) error {
+// Status returns the status of the system.
+func (s *SystemImpl) Status() types.SystemStatusType {
 	s.mutex.RLock()
 	defer s.mutex.RUnlock()
 
+	return s.status
+}
+
+// ServiceStatus returns the lifecycle status of the service with the given ID.
+// Returns an error if the service ID is not found or other error.
+func (s *SystemImpl) ServiceStatus(serviceID string) (types.ServiceStatusType, error) {
+	if _, err := s.getService(serviceID); err != nil {
+		return types.ServiceFailedType, err
+	}
+
+	return s.lifecycle.status(serviceID), nil
+}
+
+// ListServiceStatuses returns the lifecycle status of every registered service, keyed by service ID.
+func (s *SystemImpl) ListServiceStatuses() map[string]types.ServiceStatusType {
+	statuses := make(map[string]types.ServiceStatusType)
+	for _, component := range s.ComponentRegistry().GetComponentsByType(types.ServiceType) {
+		statuses[component.ID()] = s.lifecycle.status(component.ID())
+	}
+
+	return statuses
+}
+
+// getService retrieves the service with the given ID from the component registry.
+func (s *SystemImpl) getService(serviceID string) (types.SystemServiceInterface, error) {
 	// Retrieve the service by its ID
 	component, err := s.ComponentRegistry().GetComponent(serviceID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	// Check if the component implements SystemServiceInterface interface
 	service, ok := component.(types.SystemServiceInterface)
 	if !ok {
-		return fmt.Errorf("failed to start service: component %v is not a service", service)
+		return nil, fmt.Errorf("component %s is not a service", serviceID)
 	}
 
-	// Start the service
-	return service.Stop(ctx)
+	return service, nil
 }
 
-// RestartService restarts the service with the given ID.
-// Returns an error if the service ID is not found or other error.
-func (s *SystemImpl) RestartService(ctx *common.Context, serviceID string) error {
-	// Stop the service first
-	if err := s.StopService(ctx, serviceID); err != nil {
+// startService starts the service with the given ID without acquiring the system mutex.
+func (s *SystemImpl) startService(ctx *common.Context, serviceID string) error {
+	service, err := s.getService(serviceID)
+	if err != nil {
+		return fmt.Errorf("failed to start service: %w", err)
+	}
+
+	if err := s.transitionService(serviceID, types.ServiceStartingType); err != nil {
 		return err
 	}
 
 	// Start the service
-	return s.StartService(ctx, serviceID)
+	if err := service.Start(ctx); err != nil {
+		s.transitionService(serviceID, types.ServiceFailedType)
+		return err
+	}
+
+	return s.transitionService(serviceID, types.ServiceRunningType)
+}
+
+// stopService stops the service with the given ID without acquiring the system mutex.
+func (s *SystemImpl) stopService(ctx *common.Context, serviceID string) error {
+	service, err := s.getService(serviceID)
+	if err != nil {
+		return fmt.Errorf("failed to stop service: %w", err)
+	}
+
+	if err := s.transitionService(serviceID, types.ServiceStoppingType); err != nil {
+		return err
+	}
+
+	// Stop the service
+	if err := service.Stop(ctx); err != nil {
+		s.transitionService(serviceID, types.ServiceFailedType)
+		return err
+	}
+
+	return s.transitionService(serviceID, types.ServiceStoppedType)
+}
+
+// transitionService moves the service with the given ID to the given lifecycle status
+// and publishes the state change on the event bus.
+func (s *SystemImpl) transitionService(serviceID string, status types.ServiceStatusType) error {
+	change, err := s.lifecycle.transition(serviceID, status)
+	if err != nil {
+		return err
+	}
+
+	if s.eventBus != nil {
+		s.eventBus.Publish(common.NewEvent(nil, SystemEventSource, EventTypeServiceStateChanged, change))
+	}
+
+	return nil
+}
+
+// buildServiceGraph builds the dependency graph of the registered services with the dependencies
+// declared in the given configuration. The excluded components are left out as if they were not
+// registered, while the pending services, yet to be created, are added with the dependencies declared
+// in the configuration only. The operations of the configuration are expected to exist.
+// Returns an error if a dependency cannot be found or the dependencies form a cycle.
+func (s *SystemImpl) buildServiceGraph(configuration *types.Configuration, excluded map[string]bool, pending []string) (*DependencyGraph, error) {
+	graph := NewDependencyGraph()
+
+	// Dependencies declared in the service configurations
+	configured := make(map[string][]string)
+	operations := make(map[string]bool)
+	if configuration != nil {
+		for _, config := range configuration.Services {
+			configured[config.ID] = append(configured[config.ID], config.Dependencies...)
+		}
+		for _, config := range configuration.Operations {
+			operations[config.ID] = true
+		}
+	}
+
+	for _, component := range s.ComponentRegistry().GetComponentsByType(types.ServiceType) {
+		if excluded[component.ID()] {
+			continue
+		}
+		dependencies := append([]string(nil), configured[component.ID()]...)
+
+		// Dependencies declared by the service itself
+		if dependent, ok := component.(types.DependentInterface); ok {
+			dependencies = append(dependencies, dependent.Dependencies()...)
+		}
+
+		graph.AddNode(component.ID(), dependencies...)
+	}
+	for _, id := range pending {
+		graph.AddNode(id, configured[id]...)
+	}
+
+	// Dependencies on components other than services only need to exist
+	err := graph.Validate(func(id string) bool {
+		if operations[id] {
+			return true
+		}
+		_, err := s.ComponentRegistry().GetComponent(id)
+		return err == nil && !excluded[id]
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return graph, nil
 }
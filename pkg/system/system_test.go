@@ -2,6 +2,7 @@ package system_test
 
 import (
 	"errors"
+	"sync"
 
 	"github.com/ebanfa/skeleton/pkg/common"
 	"github.com/ebanfa/skeleton/pkg/mocks"
@@ -31,6 +32,7 @@ var _ = Describe("System", func() {
 		ctx = common.Background()
 		logger = &mocks.LoggerInterface{}
 		eventBus := &mocks.EventBusInterface{}
+		eventBus.On("Publish", mock.Anything).Return()
 		registrar = &mocks.ComponentRegistrarInterface{}
 		serviceFactory = &mocks.ComponentFactoryInterface{}
 		operationFactory = &mocks.ComponentFactoryInterface{}
@@ -43,16 +45,18 @@ var _ = Describe("System", func() {
 			Services: []*types.ServiceConfiguration{
 				{
 					ComponentConfig: types.ComponentConfig{
-						ID:   "Service1_ID",
-						Name: "Service1",
+						ID:        "Service1_ID",
+						Name:      "Service1",
+						FactoryID: "Service1Factory",
 					},
 				},
 			},
 			Operations: []*types.OperationConfiguration{
 				{
 					ComponentConfig: types.ComponentConfig{
-						ID:   "Operation1_ID",
-						Name: "Operation1",
+						ID:        "Operation1_ID",
+						Name:      "Operation1",
+						FactoryID: "Operation1Factory",
 					},
 				},
 			},
@@ -60,6 +64,7 @@ var _ = Describe("System", func() {
 
 		sys = systemApi.NewSystem(logger, eventBus, configuration, mockPluginManager, registrar, mockMultiStore)
 
+		mockServiceComponent.On("ID").Return("Service1_ID")
 		mockServiceComponent.On("Type").Return(types.ServiceType)
 		mockServiceComponent.On("Initialize", ctx, mock.Anything).Return(nil)
 
@@ -69,15 +74,23 @@ var _ = Describe("System", func() {
 		serviceFactory.On("CreateComponent", mock.Anything).Return(mockServiceComponent, nil)
 		operationFactory.On("CreateComponent", mock.Anything).Return(mockOperationComponent, nil)
 
+		registrar.On("GetFactory", "Service1Factory").Return(serviceFactory, nil)
+		registrar.On("GetFactory", "Operation1Factory").Return(operationFactory, nil)
+		registrar.On("CreateComponent", ctx, hasComponentID("Service1_ID")).Return(mockServiceComponent, nil)
+		registrar.On("CreateComponent", ctx, hasComponentID("Operation1_ID")).Return(mockOperationComponent, nil)
+
 		mockPluginManager.On("Initialize", ctx, mock.Anything).Return(nil)
 		mockPluginManager.On("StartPlugins", ctx).Return(nil)
+		mockPluginManager.On("StopPlugins", mock.Anything).Return(nil)
+		mockMultiStore.On("Close").Return(nil)
 	})
 
 	Describe("Initialize", func() {
 		Context("when initialization is successful", func() {
 			BeforeEach(func() {
-				registrar.On("GetComponentFactory", "Service1Factory").Return(serviceFactory, nil)
-				registrar.On("GetComponentFactory", "Operation1Factory").Return(operationFactory, nil)
+				registrar.On("GetFactory", "Service1Factory").Return(serviceFactory, nil)
+				registrar.On("GetFactory", "Operation1Factory").Return(operationFactory, nil)
+				registrar.On("GetComponentsByType", types.ServiceType).Return([]types.ComponentInterface{mockServiceComponent})
 			})
 
 			It("should initialize without error", func() {
@@ -102,13 +115,140 @@ var _ = Describe("System", func() {
 		})
 	})
 
+	Describe("Service dependencies", func() {
+		var (
+			started []string
+			stopped []string
+			mutex   sync.Mutex
+		)
+
+		newService := func(id string, dependencies ...string) *dependentService {
+			service := &dependentService{
+				SystemServiceInterface: &mocks.SystemServiceInterface{},
+				dependencies:           dependencies,
+			}
+			service.On("ID").Return(id)
+			service.On("Type").Return(types.ServiceType)
+			service.On("Start", ctx).Run(func(mock.Arguments) {
+				mutex.Lock()
+				defer mutex.Unlock()
+				started = append(started, id)
+			}).Return(nil)
+			service.On("Stop", mock.Anything).Run(func(mock.Arguments) {
+				mutex.Lock()
+				defer mutex.Unlock()
+				stopped = append(stopped, id)
+			}).Return(nil)
+			registrar.On("GetComponent", id).Return(service, nil)
+			return service
+		}
+
+		BeforeEach(func() {
+			started = nil
+			stopped = nil
+		})
+
+		Context("when the dependencies are valid", func() {
+			BeforeEach(func() {
+				registrar.On("GetComponentsByType", types.ServiceType).Return([]types.ComponentInterface{
+					newService("app", "db"),
+					newService("db"),
+				})
+			})
+
+			It("should start services in dependency order and stop them in reverse", func() {
+				Expect(sys.Initialize(ctx)).To(Succeed())
+
+				Expect(sys.Start(ctx)).To(Succeed())
+				Expect(started).To(Equal([]string{"db", "app"}))
+
+				Expect(sys.Stop(ctx)).To(Succeed())
+				Expect(stopped).To(Equal([]string{"app", "db"}))
+			})
+		})
+
+		Context("when dependencies are declared in the configuration", func() {
+			BeforeEach(func() {
+				configuration.Services[0].Dependencies = []string{"db"}
+				registrar.On("GetComponentsByType", types.ServiceType).Return([]types.ComponentInterface{
+					newService("Service1_ID"),
+					newService("db"),
+				})
+			})
+
+			It("should start the dependencies first", func() {
+				Expect(sys.Initialize(ctx)).To(Succeed())
+				Expect(sys.Start(ctx)).To(Succeed())
+				Expect(started).To(Equal([]string{"db", "Service1_ID"}))
+			})
+		})
+
+		Context("when a dependency is missing", func() {
+			BeforeEach(func() {
+				registrar.On("GetComponentsByType", types.ServiceType).Return([]types.ComponentInterface{
+					newService("app", "db"),
+				})
+				registrar.On("GetComponent", "db").Return(nil, types.ErrComponentNotFound)
+			})
+
+			It("should return an error on initialize", func() {
+				err := sys.Initialize(ctx)
+				Expect(err).To(MatchError(types.ErrDependencyNotFound))
+				Expect(err.Error()).To(ContainSubstring("app depends on db"))
+			})
+		})
+
+		Context("when the dependencies form a cycle", func() {
+			BeforeEach(func() {
+				registrar.On("GetComponentsByType", types.ServiceType).Return([]types.ComponentInterface{
+					newService("a", "b"),
+					newService("b", "a"),
+				})
+			})
+
+			It("should return an error listing the cycle on initialize", func() {
+				err := sys.Initialize(ctx)
+				Expect(err).To(MatchError(types.ErrDependencyCycle))
+				Expect(err.Error()).To(ContainSubstring("a -> b -> a"))
+			})
+		})
+
+		Context("when a service fails to start", func() {
+			BeforeEach(func() {
+				failing := &dependentService{
+					SystemServiceInterface: &mocks.SystemServiceInterface{},
+					dependencies:           []string{"db"},
+				}
+				failing.On("ID").Return("app")
+				failing.On("Type").Return(types.ServiceType)
+				failing.On("Start", ctx).Return(errors.New("Error starting service"))
+				registrar.On("GetComponent", "app").Return(failing, nil)
+
+				registrar.On("GetComponentsByType", types.ServiceType).Return([]types.ComponentInterface{
+					failing,
+					newService("db"),
+				})
+			})
+
+			It("should stop the services that were started", func() {
+				Expect(sys.Initialize(ctx)).To(Succeed())
+
+				err := sys.Start(ctx)
+				Expect(err).To(HaveOccurred())
+				Expect(started).To(Equal([]string{"db"}))
+				Expect(stopped).To(Equal([]string{"db"}))
+			})
+		})
+	})
+
 	Describe("Start", func() {
 		Context("when start is successful", func() {
 			BeforeEach(func() {
-				registrar.On("GetComponentFactory", "Service1Factory").Return(serviceFactory, nil)
-				registrar.On("GetComponentFactory", "Operation1Factory").Return(operationFactory, nil)
+				registrar.On("GetFactory", "Service1Factory").Return(serviceFactory, nil)
+				registrar.On("GetFactory", "Operation1Factory").Return(operationFactory, nil)
 				registrar.On("GetComponentsByType", types.ServiceType).Return([]types.ComponentInterface{mockServiceComponent}, nil)
 				registrar.On("GetComponentsByType", types.OperationType).Return([]types.ComponentInterface{mockOperationComponent}, nil)
+				registrar.On("GetComponent", "Service1_ID").Return(mockServiceComponent, nil)
 				mockServiceComponent.On("Start", ctx).Return(nil)
 			})
 
@@ -124,8 +264,8 @@ var _ = Describe("System", func() {
 
 		Context("when start fails", func() {
 			BeforeEach(func() {
-				registrar.On("GetComponentFactory", "Service1Factory").Return(serviceFactory, nil)
-				registrar.On("GetComponentFactory", "Operation1Factory").Return(operationFactory, nil)
+				registrar.On("GetFactory", "Service1Factory").Return(serviceFactory, nil)
+				registrar.On("GetFactory", "Operation1Factory").Return(operationFactory, nil)
 				registrar.On("GetComponentsByType", types.ServiceType).Return([]types.ComponentInterface{mockServiceComponent}, nil)
 				registrar.On("GetComponentsByType", types.OperationType).Return([]types.ComponentInterface{mockOperationComponent}, nil)
 				sys = systemApi.NewSystem(nil, nil, &types.Configuration{}, mockPluginManager, registrar, mockMultiStore)
@@ -141,7 +281,7 @@ var _ = Describe("System", func() {
 	Describe("Stop", func() {
 		Context("when stop is successful", func() {
 			BeforeEach(func() {
-				registrar.On("GetComponentFactory", "testFactoryInitializeServiceSuccess").Return(serviceFactory, nil)
+				registrar.On("GetFactory", "testFactoryInitializeServiceSuccess").Return(serviceFactory, nil)
 				registrar.On("GetComponentsByType", types.ServiceType).Return([]types.ComponentInterface{}, nil)
 				mockServiceComponent.On("Stop", ctx).Return(nil)
 			})
@@ -252,17 +392,38 @@ var _ = Describe("System", func() {
 		Context("when stop is successful", func() {
 			BeforeEach(func() {
 				componentReg := &mocks.ComponentRegistrarInterface{}
+				mockServiceComponent.On("Start", mock.Anything).Return(nil)
 				mockServiceComponent.On("Stop", mock.Anything).Return(nil)
 				componentReg.On("GetComponent", "service_id").Return(mockServiceComponent, nil)
 				sys = systemApi.NewSystem(nil, nil, &types.Configuration{}, mockPluginManager, componentReg, mockMultiStore)
 			})
 
 			It("should stop without error", func() {
+				Expect(sys.StartService(ctx, "service_id")).To(Succeed())
+
 				err := sys.StopService(ctx, "service_id")
 				Expect(err).NotTo(HaveOccurred())
 			})
 		})
 
+		Context("when the service is not running", func() {
+			BeforeEach(func() {
+				componentReg := &mocks.ComponentRegistrarInterface{}
+				componentReg.On("GetComponent", "service_id").Return(mockServiceComponent, nil)
+				sys = systemApi.NewSystem(nil, nil, &types.Configuration{}, mockPluginManager, componentReg, mockMultiStore)
+			})
+
+			It("should return a state transition error without stopping the service", func() {
+				err := sys.StopService(ctx, "service_id")
+
+				var transitionErr *types.StateTransitionError
+				Expect(errors.As(err, &transitionErr)).To(BeTrue())
+				Expect(transitionErr.From).To(Equal(types.ServiceInitializedType))
+				Expect(transitionErr.To).To(Equal(types.ServiceStoppingType))
+				mockServiceComponent.AssertNotCalled(GinkgoT(), "Stop", mock.Anything)
+			})
+		})
+
 		Context("when component is not found", func() {
 			BeforeEach(func() {
 				componentReg := &mocks.ComponentRegistrarInterface{}
@@ -288,8 +449,18 @@ var _ = Describe("System", func() {
 			})
 
 			It("should restart without error", func() {
+				Expect(sys.StartService(ctx, "service_id")).To(Succeed())
+
 				err := sys.RestartService(ctx, "service_id")
 				Expect(err).NotTo(HaveOccurred())
+				mockServiceComponent.AssertNumberOfCalls(GinkgoT(), "Stop", 1)
+				mockServiceComponent.AssertNumberOfCalls(GinkgoT(), "Start", 2)
+			})
+
+			It("should only start a service that is not running", func() {
+				err := sys.RestartService(ctx, "service_id")
+				Expect(err).NotTo(HaveOccurred())
+				mockServiceComponent.AssertNotCalled(GinkgoT(), "Stop", mock.Anything)
 			})
 		})
 
@@ -297,11 +468,14 @@ var _ = Describe("System", func() {
 			BeforeEach(func() {
 				componentReg := &mocks.ComponentRegistrarInterface{}
 				componentReg.On("GetComponent", "service_id").Return(mockServiceComponent, nil)
+				mockServiceComponent.On("Start", ctx).Return(nil)
 				mockServiceComponent.On("Stop", ctx).Return(errors.New("Error stopping service"))
 				sys = systemApi.NewSystem(nil, nil, &types.Configuration{}, mockPluginManager, componentReg, mockMultiStore)
 			})
 
 			It("should return an error", func() {
+				Expect(sys.StartService(ctx, "service_id")).To(Succeed())
+
 				err := sys.RestartService(ctx, "service_id")
 				Expect(err).To(HaveOccurred())
 			})
@@ -322,4 +496,131 @@ var _ = Describe("System", func() {
 			})
 		})
 	})
+
+	Describe("Service lifecycle", func() {
+		var (
+			componentReg *mocks.ComponentRegistrarInterface
+			eventBus     common.EventBusInterface
+			changes      chan systemApi.ServiceStateChange
+		)
+
+		BeforeEach(func() {
+			componentReg = &mocks.ComponentRegistrarInterface{}
+			componentReg.On("GetComponent", "service_id").Return(mockServiceComponent, nil)
+			componentReg.On("GetComponent", "operation_id").Return(mockOperationComponent, nil)
+			componentReg.On("GetComponent", "unknown_id").Return(nil, types.ErrComponentNotFound)
+
+			changes = make(chan systemApi.ServiceStateChange, 10)
+			eventBus = common.NewSystemEventBus()
+			Expect(eventBus.Subscribe(common.BusSubscriptionParams{
+				Topic: systemApi.EventTypeServiceStateChanged,
+				EventHandler: func(event common.Event) {
+					changes <- event.Data.(systemApi.ServiceStateChange)
+				},
+			})).Error().NotTo(HaveOccurred())
+
+			sys = systemApi.NewSystem(nil, eventBus, &types.Configuration{}, mockPluginManager, componentReg, mockMultiStore)
+		})
+
+		Context("when the service starts and stops", func() {
+			BeforeEach(func() {
+				mockServiceComponent.On("Start", ctx).Return(nil)
+				mockServiceComponent.On("Stop", ctx).Return(nil)
+			})
+
+			It("should report the status of the service", func() {
+				status, err := sys.ServiceStatus("service_id")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(status).To(Equal(types.ServiceInitializedType))
+
+				Expect(sys.StartService(ctx, "service_id")).To(Succeed())
+				status, err = sys.ServiceStatus("service_id")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(status).To(Equal(types.ServiceRunningType))
+
+				Expect(sys.StopService(ctx, "service_id")).To(Succeed())
+				status, err = sys.ServiceStatus("service_id")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(status).To(Equal(types.ServiceStoppedType))
+			})
+
+			It("should publish every state change", func() {
+				Expect(sys.StartService(ctx, "service_id")).To(Succeed())
+				Expect(sys.StopService(ctx, "service_id")).To(Succeed())
+
+				Expect(changes).To(Receive(Equal(systemApi.ServiceStateChange{ServiceID: "service_id", From: types.ServiceInitializedType, To: types.ServiceStartingType})))
+				Expect(changes).To(Receive(Equal(systemApi.ServiceStateChange{ServiceID: "service_id", From: types.ServiceStartingType, To: types.ServiceRunningType})))
+				Expect(changes).To(Receive(Equal(systemApi.ServiceStateChange{ServiceID: "service_id", From: types.ServiceRunningType, To: types.ServiceStoppingType})))
+				Expect(changes).To(Receive(Equal(systemApi.ServiceStateChange{ServiceID: "service_id", From: types.ServiceStoppingType, To: types.ServiceStoppedType})))
+			})
+
+			It("should reject starting a running service", func() {
+				Expect(sys.StartService(ctx, "service_id")).To(Succeed())
+
+				err := sys.StartService(ctx, "service_id")
+				Expect(err).To(MatchError(types.ErrInvalidStateTransition))
+				mockServiceComponent.AssertNumberOfCalls(GinkgoT(), "Start", 1)
+			})
+
+			It("should list the status of every service", func() {
+				otherService := &mocks.SystemServiceInterface{}
+				otherService.On("ID").Return("Service2_ID")
+				componentReg.On("GetComponent", "Service1_ID").Return(mockServiceComponent, nil)
+				componentReg.On("GetComponentsByType", types.ServiceType).Return([]types.ComponentInterface{mockServiceComponent, otherService})
+				Expect(sys.StartService(ctx, "Service1_ID")).To(Succeed())
+
+				Expect(sys.ListServiceStatuses()).To(Equal(map[string]types.ServiceStatusType{
+					"Service1_ID": types.ServiceRunningType,
+					"Service2_ID": types.ServiceInitializedType,
+				}))
+			})
+		})
+
+		Context("when the service fails to start", func() {
+			BeforeEach(func() {
+				mockServiceComponent.On("Start", ctx).Return(errors.New("Error starting service")).Once()
+				mockServiceComponent.On("Start", ctx).Return(nil)
+			})
+
+			It("should mark the service as failed and allow starting it again", func() {
+				Expect(sys.StartService(ctx, "service_id")).NotTo(Succeed())
+				status, err := sys.ServiceStatus("service_id")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(status).To(Equal(types.ServiceFailedType))
+
+				Expect(sys.StartService(ctx, "service_id")).To(Succeed())
+				status, err = sys.ServiceStatus("service_id")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(status).To(Equal(types.ServiceRunningType))
+			})
+		})
+
+		Context("when the component is not a registered service", func() {
+			It("should return an error", func() {
+				_, err := sys.ServiceStatus("unknown_id")
+				Expect(err).To(HaveOccurred())
+
+				_, err = sys.ServiceStatus("operation_id")
+				Expect(err).To(HaveOccurred())
+			})
+		})
+	})
 })
+
+// dependentService is a service declaring its dependencies.
+type dependentService struct {
+	*mocks.SystemServiceInterface
+	dependencies []string
+}
+
+// Dependencies returns the IDs of the components the service depends on.
+func (d *dependentService) Dependencies() []string {
+	return d.dependencies
+}
+
+// hasComponentID matches a component configuration with the given ID.
+func hasComponentID(id string) interface{} {
+	return mock.MatchedBy(func(config *types.ComponentConfig) bool {
+		return config.ID == id
+	})
+}
@@ -0,0 +1,111 @@
+package system
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/ebanfa/skeleton/pkg/common"
+	"github.com/ebanfa/skeleton/pkg/types"
+)
+
+// TypedOperationFunc performs a typed operation.
+type TypedOperationFunc[In, Out any] func(ctx *common.Context, input In) (Out, error)
+
+// TypedOperation is an operation with typed input and output. It adapts a typed function
+// to SystemOperationInterface, checking the type of the input before calling the function.
+// Inputs given as JSON, either []byte or json.RawMessage, are decoded into In.
+type TypedOperation[In, Out any] struct {
+	BaseSystemOperation
+	handler TypedOperationFunc[In, Out]
+}
+
+// NewTypedOperation creates a new instance of TypedOperation performed by the given function.
+func NewTypedOperation[In, Out any](id, name, description string, handler TypedOperationFunc[In, Out]) *TypedOperation[In, Out] {
+	return &TypedOperation[In, Out]{
+		BaseSystemOperation: *NewBaseSystemOperation(id, name, description),
+		handler:             handler,
+	}
+}
+
+// Type returns the type of the component.
+func (o *TypedOperation[In, Out]) Type() types.ComponentType {
+	return types.OperationType
+}
+
+// Execute performs the operation with the typed input.
+// Returns a *types.OperationTypeError if the input does not have the expected type.
+func (o *TypedOperation[In, Out]) Execute(ctx *common.Context, input *types.SystemOperationInput) (*types.SystemOperationOutput, error) {
+	var data interface{}
+	if input != nil {
+		data = input.Data
+	}
+
+	in, err := typedValue[In](o.ID(), "input", data)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := o.handler(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	return &types.SystemOperationOutput{Data: out}, nil
+}
+
+// ExecuteTyped executes the operation with the given ID on a local or remote system with a typed input
+// and returns its typed output.
+// Outputs given as JSON, either []byte or json.RawMessage, are decoded into Out.
+// Returns a *types.OperationTypeError if the output does not have the expected type.
+func ExecuteTyped[In, Out any](system types.SystemControllerInterface, ctx *common.Context, operationID string, input In) (Out, error) {
+	var zero Out
+
+	output, err := system.ExecuteOperation(ctx, operationID, &types.SystemOperationInput{Data: input})
+	if err != nil {
+		return zero, err
+	}
+
+	var data interface{}
+	if output != nil {
+		data = output.Data
+	}
+	return typedValue[Out](operationID, "output", data)
+}
+
+// typedValue converts the input or output of an operation to the type T. A nil value is converted
+// to the zero value of T, and JSON is decoded into T.
+func typedValue[T any](operationID, kind string, data interface{}) (T, error) {
+	var value T
+	if data == nil {
+		return value, nil
+	}
+	if typed, ok := data.(T); ok {
+		return typed, nil
+	}
+
+	var raw []byte
+	switch data := data.(type) {
+	case json.RawMessage:
+		raw = data
+	case []byte:
+		raw = data
+	default:
+		return value, &types.OperationTypeError{
+			OperationID: operationID,
+			Value:       kind,
+			Expected:    reflect.TypeFor[T]().String(),
+			Actual:      fmt.Sprintf("%T", data),
+		}
+	}
+
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return value, &types.OperationTypeError{
+			OperationID: operationID,
+			Value:       kind,
+			Expected:    reflect.TypeFor[T]().String(),
+			Actual:      fmt.Sprintf("%T", data),
+			Err:         err,
+		}
+	}
+	return value, nil
+}
@@ -0,0 +1,141 @@
+package system_test
+
+import (
+	"encoding/json"
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/ebanfa/skeleton/pkg/common"
+	"github.com/ebanfa/skeleton/pkg/mocks"
+	"github.com/ebanfa/skeleton/pkg/system"
+	"github.com/ebanfa/skeleton/pkg/types"
+)
+
+type greeting struct {
+	Name  string `json:"name"`
+	Times int    `json:"times"`
+}
+
+var _ = Describe("TypedOperation", func() {
+	var (
+		ctx       *common.Context
+		operation *system.TypedOperation[greeting, string]
+	)
+
+	BeforeEach(func() {
+		ctx = common.Background()
+		operation = system.NewTypedOperation("greet", "Greet", "Greets someone",
+			func(ctx *common.Context, input greeting) (string, error) {
+				if input.Name == "" {
+					return "", errors.New("name is required")
+				}
+				return "hello " + input.Name, nil
+			})
+	})
+
+	It("is an operation", func() {
+		var _ types.SystemOperationInterface = operation
+		Expect(operation.ID()).To(Equal("greet"))
+		Expect(operation.Type()).To(Equal(types.OperationType))
+	})
+
+	It("executes the function with the typed input", func() {
+		output, err := operation.Execute(ctx, &types.SystemOperationInput{Data: greeting{Name: "world"}})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(output.Data).To(Equal("hello world"))
+	})
+
+	It("returns the error of the function", func() {
+		_, err := operation.Execute(ctx, &types.SystemOperationInput{})
+		Expect(err).To(MatchError("name is required"))
+	})
+
+	It("decodes the inputs given as JSON", func() {
+		output, err := operation.Execute(ctx, &types.SystemOperationInput{Data: []byte(`{"name":"json"}`)})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(output.Data).To(Equal("hello json"))
+
+		output, err = operation.Execute(ctx, &types.SystemOperationInput{Data: json.RawMessage(`{"name":"raw"}`)})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(output.Data).To(Equal("hello raw"))
+	})
+
+	It("returns a type error when the input does not have the expected type", func() {
+		_, err := operation.Execute(ctx, &types.SystemOperationInput{Data: "world"})
+		Expect(err).To(MatchError(types.ErrOperationTypeMismatch))
+
+		var typeError *types.OperationTypeError
+		Expect(errors.As(err, &typeError)).To(BeTrue())
+		Expect(*typeError).To(Equal(types.OperationTypeError{
+			OperationID: "greet",
+			Value:       "input",
+			Expected:    "system_test.greeting",
+			Actual:      "string",
+		}))
+		Expect(err.Error()).To(Equal("operation type mismatch: operation greet: input of type string, expected system_test.greeting"))
+	})
+
+	It("returns a type error when the JSON input cannot be decoded", func() {
+		_, err := operation.Execute(ctx, &types.SystemOperationInput{Data: []byte(`{"times":"twice"}`)})
+		Expect(err).To(MatchError(types.ErrOperationTypeMismatch))
+
+		var unmarshalError *json.UnmarshalTypeError
+		Expect(errors.As(err, &unmarshalError)).To(BeTrue())
+	})
+})
+
+var _ = Describe("ExecuteTyped", func() {
+	var (
+		ctx        *common.Context
+		mockSystem *mocks.SystemInterface
+	)
+
+	BeforeEach(func() {
+		ctx = common.Background()
+		mockSystem = &mocks.SystemInterface{}
+	})
+
+	It("executes the operation with the input and returns the typed output", func() {
+		mockSystem.On("ExecuteOperation", ctx, "greet", &types.SystemOperationInput{Data: greeting{Name: "world"}}).
+			Return(&types.SystemOperationOutput{Data: "hello world"}, nil)
+
+		output, err := system.ExecuteTyped[greeting, string](mockSystem, ctx, "greet", greeting{Name: "world"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(output).To(Equal("hello world"))
+	})
+
+	It("decodes the outputs given as JSON", func() {
+		mockSystem.On("ExecuteOperation", ctx, "greet", mock.Anything).
+			Return(&types.SystemOperationOutput{Data: []byte(`{"name":"world","times":2}`)}, nil)
+
+		output, err := system.ExecuteTyped[string, greeting](mockSystem, ctx, "greet", "world")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(output).To(Equal(greeting{Name: "world", Times: 2}))
+	})
+
+	It("returns the zero value for a nil output", func() {
+		mockSystem.On("ExecuteOperation", ctx, "greet", mock.Anything).Return(nil, nil)
+
+		output, err := system.ExecuteTyped[string, *greeting](mockSystem, ctx, "greet", "world")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(output).To(BeNil())
+	})
+
+	It("returns a type error when the output does not have the expected type", func() {
+		mockSystem.On("ExecuteOperation", ctx, "greet", mock.Anything).Return(&types.SystemOperationOutput{Data: 42}, nil)
+
+		_, err := system.ExecuteTyped[string, string](mockSystem, ctx, "greet", "world")
+		Expect(err).To(MatchError(types.ErrOperationTypeMismatch))
+		Expect(err.Error()).To(Equal("operation type mismatch: operation greet: output of type int, expected string"))
+	})
+
+	It("returns the error of the operation", func() {
+		mockSystem.On("ExecuteOperation", ctx, "greet", mock.Anything).Return(nil, types.ErrComponentNotFound)
+
+		_, err := system.ExecuteTyped[string, string](mockSystem, ctx, "greet", "world")
+		Expect(err).To(MatchError(types.ErrComponentNotFound))
+	})
+})
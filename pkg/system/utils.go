@@ -1,29 +1,17 @@
 package system
 
 import (
-	"encoding/json"
 	"errors"
 	"fmt"
-	"io/ioutil"
 
 	"github.com/ebanfa/skeleton/pkg/common"
 	"github.com/ebanfa/skeleton/pkg/types"
 )
 
 // LoadConfigurationFromFile loads the configuration from a file at the given path.
+// The format of the file, JSON, YAML or TOML, is detected from its extension, JSON being the default.
 func LoadConfigurationFromFile(filePath string, target interface{}) error {
-	// Read the configuration file
-	data, err := ioutil.ReadFile(filePath)
-	if err != nil {
-		return fmt.Errorf("failed to read configuration file: %v", err)
-	}
-
-	// Unmarshal the JSON data into the target struct
-	if err := json.Unmarshal(data, target); err != nil {
-		return fmt.Errorf("failed to unmarshal configuration data: %v", err)
-	}
-
-	return nil
+	return LoadConfiguration(ConfigLoaderOptions{FilePath: filePath}, target)
 }
 
 func StartService(
@@ -0,0 +1,364 @@
+package system
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/ebanfa/skeleton/pkg/common"
+	"github.com/ebanfa/skeleton/pkg/types"
+)
+
+// configurationSchema is the schema of the system configuration.
+var configurationSchema = SchemaOf(types.Configuration{})
+
+// ConfigValidator validates configurations against the schema of the system configuration
+// and the schemas of the custom configurations exposed by the registered factories.
+type ConfigValidator struct {
+	registrar types.ComponentRegistrarInterface
+}
+
+// NewConfigValidator creates a new instance of ConfigValidator.
+// Factory IDs and custom configurations are only checked when a registrar is given.
+func NewConfigValidator(registrar types.ComponentRegistrarInterface) *ConfigValidator {
+	return &ConfigValidator{
+		registrar: registrar,
+	}
+}
+
+// ValidateFile validates the configuration file at the given path.
+// Returns the problems found as *types.ConfigError values joined together, each one
+// locating the problem by its line in the file and its JSON pointer.
+func (v *ConfigValidator) ValidateFile(filePath string) error {
+	format := ConfigFormatFromPath(filePath)
+
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to read configuration file: %v", err)
+	}
+
+	values, err := parseConfigData(format, data)
+	if err != nil {
+		return &types.ConfigError{File: filePath, Message: err.Error()}
+	}
+
+	return v.validate(values, filePath, parseConfigLines(format, data))
+}
+
+// Validate validates a decoded configuration.
+// Returns the problems found as *types.ConfigError values joined together, each one
+// locating the problem by its JSON pointer.
+func (v *ConfigValidator) Validate(configuration *types.Configuration) error {
+	if configuration == nil {
+		return nil
+	}
+
+	values, err := toConfigMap(configuration)
+	if err != nil {
+		return &types.ConfigError{Message: err.Error()}
+	}
+
+	return v.validate(values, "", nil)
+}
+
+// validate validates the configuration values, locating the problems using the given file and lines.
+func (v *ConfigValidator) validate(values map[string]interface{}, file string, lines configLines) error {
+	validation := &configValidation{}
+	validation.value(values, configurationSchema, "")
+	v.validateComponents(validation, values)
+	v.validateSchedules(validation, values)
+	v.validateEventBus(validation, values)
+
+	problems := validation.problems
+	for _, problem := range problems {
+		problem.File = file
+		problem.Line = lines.line(problem.Pointer)
+	}
+	sort.SliceStable(problems, func(i, j int) bool {
+		return problems[i].Line < problems[j].Line
+	})
+
+	errs := make([]error, len(problems))
+	for i, problem := range problems {
+		errs[i] = problem
+	}
+	return errors.Join(errs...)
+}
+
+// validateComponents checks that the IDs of the configured components are unique, that their factories
+// are registered and that their custom configurations match the schemas exposed by the factories.
+func (v *ConfigValidator) validateComponents(validation *configValidation, values map[string]interface{}) {
+	declared := make(map[string]string) // Map of component ID to the pointer of its first declaration
+
+	for _, list := range []string{"services", "operations"} {
+		components, _ := values[findConfigKey(values, list)].([]interface{})
+		for i, item := range components {
+			component, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			pointer := "/" + list + "/" + strconv.Itoa(i)
+
+			if id, ok := component[findConfigKey(component, "id")].(string); ok && id != "" {
+				if first, exists := declared[id]; exists {
+					validation.addError(pointer+"/id", types.ErrComponentAlreadyExist,
+						"duplicate component ID %q, first declared at %s", id, first)
+				} else {
+					declared[id] = pointer + "/id"
+				}
+			}
+
+			factoryID, ok := component[findConfigKey(component, "factoryId")].(string)
+			if !ok || factoryID == "" || v.registrar == nil {
+				continue
+			}
+
+			factory, err := v.registrar.GetFactory(factoryID)
+			if err != nil {
+				validation.addError(pointer+"/factoryId", types.ErrFactoryNotFound, "unknown factory %q", factoryID)
+				continue
+			}
+
+			if schema := customConfigSchema(factory); schema != nil {
+				customConfig := component[findConfigKey(component, "customConfig")]
+				if customConfig == nil {
+					customConfig = map[string]interface{}{}
+				}
+				validation.value(customConfig, schema, pointer+"/customConfig")
+			}
+		}
+	}
+}
+
+// validateSchedules checks that the scheduled operations have either a valid cron expression
+// or an interval, and a known overlap policy.
+func (v *ConfigValidator) validateSchedules(validation *configValidation, values map[string]interface{}) {
+	operations, _ := values[findConfigKey(values, "operations")].([]interface{})
+	for i, item := range operations {
+		operation, _ := item.(map[string]interface{})
+		schedule, ok := operation[findConfigKey(operation, "schedule")].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		pointer := "/operations/" + strconv.Itoa(i) + "/schedule"
+
+		cron, _ := schedule[findConfigKey(schedule, "cron")].(string)
+		interval := schedule[findConfigKey(schedule, "interval")]
+		hasInterval := interval != nil && interval != "" && interval != "0s"
+		if number, ok := toFloat(interval); ok {
+			hasInterval = number != 0
+		}
+
+		switch {
+		case cron != "" && hasInterval:
+			validation.addError(pointer, types.ErrInvalidSchedule, "cron expression and interval are mutually exclusive")
+		case cron != "":
+			if _, err := ParseCronExpression(cron); err != nil {
+				validation.addError(pointer+"/cron", types.ErrInvalidSchedule, "%v", err)
+			}
+		case !hasInterval:
+			validation.addError(pointer, types.ErrInvalidSchedule, "a cron expression or an interval is required")
+		}
+
+		if overlap, ok := schedule[findConfigKey(schedule, "overlap")].(string); ok {
+			switch types.OverlapPolicy(overlap) {
+			case "", types.OverlapSkip, types.OverlapQueue, types.OverlapAllow:
+			default:
+				validation.addError(pointer+"/overlap", types.ErrInvalidSchedule,
+					"unknown overlap policy %q, expected %s, %s or %s", overlap, types.OverlapSkip, types.OverlapQueue, types.OverlapAllow)
+			}
+		}
+	}
+}
+
+// validateEventBus checks that the overflow policy of the event bus is known.
+func (v *ConfigValidator) validateEventBus(validation *configValidation, values map[string]interface{}) {
+	eventBus, _ := values[findConfigKey(values, "eventBus")].(map[string]interface{})
+	if overflow, ok := eventBus[findConfigKey(eventBus, "overflow")].(string); ok {
+		if _, err := common.ParseOverflowPolicy(overflow); err != nil {
+			validation.addError("/eventBus/overflow", common.ErrUnknownOverflowPolicy, "%v", err)
+		}
+	}
+}
+
+// customConfigSchema returns the schema of the custom configuration of the components created by a factory,
+// either exposed by the factory or derived from the type it declares. Returns nil if there is none.
+func customConfigSchema(factory types.ComponentFactoryInterface) *types.ConfigSchema {
+	if provider, ok := factory.(types.ConfigSchemaFactoryInterface); ok {
+		return provider.ConfigSchema()
+	}
+	if typed, ok := factory.(types.CustomConfigFactoryInterface); ok {
+		return SchemaOf(typed.NewCustomConfig())
+	}
+	return nil
+}
+
+// configValidation collects the problems found while validating a configuration.
+type configValidation struct {
+	problems []*types.ConfigError
+}
+
+// add records a problem at the given pointer.
+func (c *configValidation) add(pointer string, format string, args ...interface{}) {
+	c.problems = append(c.problems, &types.ConfigError{
+		Pointer: pointer,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// addError records a problem caused by the given error at the given pointer.
+func (c *configValidation) addError(pointer string, err error, format string, args ...interface{}) {
+	c.problems = append(c.problems, &types.ConfigError{
+		Pointer: pointer,
+		Message: fmt.Sprintf(format, args...),
+		Err:     err,
+	})
+}
+
+// value validates a value against its schema. Null values are considered absent.
+func (c *configValidation) value(value interface{}, schema *types.ConfigSchema, pointer string) {
+	if schema == nil || value == nil {
+		return
+	}
+
+	if len(schema.Enum) > 0 && !containsValue(schema.Enum, value) {
+		c.add(pointer, "value %v is not one of %v", value, schema.Enum)
+		return
+	}
+
+	switch schema.Type {
+	case types.SchemaObjectType:
+		c.object(value, schema, pointer)
+
+	case types.SchemaArrayType:
+		items, ok := value.([]interface{})
+		if !ok {
+			c.mismatch(value, schema, pointer)
+			return
+		}
+		for i, item := range items {
+			c.value(item, schema.Items, pointer+"/"+strconv.Itoa(i))
+		}
+
+	case types.SchemaStringType:
+		if _, ok := value.(string); !ok {
+			c.mismatch(value, schema, pointer)
+		}
+
+	case types.SchemaBooleanType:
+		if _, ok := value.(bool); !ok {
+			c.mismatch(value, schema, pointer)
+		}
+
+	case types.SchemaIntegerType:
+		if number, ok := toFloat(value); !ok || number != math.Trunc(number) {
+			c.mismatch(value, schema, pointer)
+		}
+
+	case types.SchemaNumberType:
+		if _, ok := toFloat(value); !ok {
+			c.mismatch(value, schema, pointer)
+		}
+
+	case types.SchemaDurationType:
+		if text, ok := value.(string); ok {
+			if _, err := time.ParseDuration(text); err != nil {
+				c.add(pointer, "invalid duration %q", text)
+			}
+		} else if number, ok := toFloat(value); !ok || number != math.Trunc(number) {
+			c.mismatch(value, schema, pointer)
+		}
+	}
+}
+
+// object validates an object value against its schema, reporting missing required and unknown keys.
+func (c *configValidation) object(value interface{}, schema *types.ConfigSchema, pointer string) {
+	object, ok := value.(map[string]interface{})
+	if !ok {
+		c.mismatch(value, schema, pointer)
+		return
+	}
+
+	present := make(map[string]bool)
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		name, property, known := schemaProperty(schema, key)
+		switch {
+		case known:
+			present[name] = object[key] != nil && object[key] != ""
+			c.value(object[key], property, pointer+"/"+escapePointer(name))
+		case schema.AdditionalProperties != nil:
+			c.value(object[key], schema.AdditionalProperties, pointer+"/"+escapePointer(key))
+		default:
+			c.add(pointer+"/"+escapePointer(key), "unknown key %q", key)
+		}
+	}
+
+	for _, name := range schema.Required {
+		if !present[name] {
+			c.add(pointer+"/"+escapePointer(name), "missing required key %q", name)
+		}
+	}
+}
+
+// mismatch records a type mismatch.
+func (c *configValidation) mismatch(value interface{}, schema *types.ConfigSchema, pointer string) {
+	c.add(pointer, "expected %s, got %s", schema.Type, valueTypeName(value))
+}
+
+// toFloat returns the numeric value of a decoded number.
+func toFloat(value interface{}) (float64, bool) {
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	default:
+		return 0, false
+	}
+}
+
+// valueTypeName returns the schema type name of a decoded value.
+func valueTypeName(value interface{}) string {
+	switch value.(type) {
+	case map[string]interface{}:
+		return string(types.SchemaObjectType)
+	case []interface{}:
+		return string(types.SchemaArrayType)
+	case string:
+		return string(types.SchemaStringType)
+	case bool:
+		return string(types.SchemaBooleanType)
+	}
+	if _, ok := toFloat(value); ok {
+		return string(types.SchemaNumberType)
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// containsValue checks if a list of values contains the given value, comparing numbers by value.
+func containsValue(values []interface{}, value interface{}) bool {
+	number, isNumber := toFloat(value)
+	for _, v := range values {
+		if other, ok := toFloat(v); ok && isNumber && other == number {
+			return true
+		}
+		if reflect.DeepEqual(v, value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,237 @@
+package system_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/ebanfa/skeleton/pkg/common"
+	"github.com/ebanfa/skeleton/pkg/component"
+	"github.com/ebanfa/skeleton/pkg/mocks"
+	"github.com/ebanfa/skeleton/pkg/system"
+	"github.com/ebanfa/skeleton/pkg/types"
+)
+
+var _ = Describe("ConfigValidator", func() {
+	var (
+		dir       string
+		registrar *component.ComponentRegistrar
+		validator *system.ConfigValidator
+	)
+
+	writeFile := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		Expect(os.WriteFile(path, []byte(content), 0o600)).To(Succeed())
+		return path
+	}
+
+	// problems returns the configuration errors joined in the given error.
+	problems := func(err error) []*types.ConfigError {
+		var joined interface{ Unwrap() []error }
+		if !errors.As(err, &joined) {
+			return nil
+		}
+		var configErrors []*types.ConfigError
+		for _, err := range joined.Unwrap() {
+			var configError *types.ConfigError
+			Expect(errors.As(err, &configError)).To(BeTrue())
+			configErrors = append(configErrors, configError)
+		}
+		return configErrors
+	}
+
+	BeforeEach(func() {
+		ctx := common.Background()
+		dir = GinkgoT().TempDir()
+		registrar = component.NewComponentRegistrar()
+
+		schemaFactory := &schemaFactory{ComponentFactoryInterface: &mocks.ComponentFactoryInterface{}}
+		schemaFactory.ConfigSchemaFactoryInterface.On("ConfigSchema").Return(&types.ConfigSchema{
+			Type: types.SchemaObjectType,
+			Properties: map[string]*types.ConfigSchema{
+				"port": {Type: types.SchemaIntegerType},
+				"mode": {Type: types.SchemaStringType, Enum: []interface{}{"fast", "safe"}},
+			},
+			Required: []string{"port"},
+		})
+
+		Expect(registrar.RegisterFactory(ctx, "serviceFactory", schemaFactory)).To(Succeed())
+		Expect(registrar.RegisterFactory(ctx, "operationFactory", &mocks.ComponentFactoryInterface{})).To(Succeed())
+		validator = system.NewConfigValidator(registrar)
+	})
+
+	Describe("ValidateFile", func() {
+		It("accepts a valid configuration", func() {
+			path := writeFile("config.yaml", `
+debug: true
+services:
+  - id: service
+    factoryId: serviceFactory
+    retryInterval: 5s
+    customConfig:
+      port: 8080
+      mode: fast
+operations:
+  - id: operation
+    factoryId: operationFactory
+`)
+			Expect(validator.ValidateFile(path)).To(Succeed())
+		})
+
+		It("reports the line and pointer of each problem in a YAML file", func() {
+			path := writeFile("config.yaml", `
+debug: yes please
+services:
+  - id: service
+    factoryId: serviceFactory
+    retryInterval: soon
+    customConfig:
+      port: http
+      mode: slow
+  - factoryId: missingFactory
+    timeout: 5s
+operations:
+  - id: service
+    factoryId: operationFactory
+`)
+			err := validator.ValidateFile(path)
+			Expect(err).To(MatchError(types.ErrInvalidConfiguration))
+
+			var locations []string
+			for _, problem := range problems(err) {
+				Expect(problem.File).To(Equal(path))
+				locations = append(locations, problem.Error())
+			}
+			Expect(locations).To(Equal([]string{
+				path + `:2: /debug: expected boolean, got string`,
+				path + `:6: /services/0/retryInterval: invalid duration "soon"`,
+				path + `:8: /services/0/customConfig/port: expected integer, got string`,
+				path + `:9: /services/0/customConfig/mode: value slow is not one of [fast safe]`,
+				path + `:10: /services/1/id: missing required key "id"`,
+				path + `:10: /services/1/factoryId: unknown factory "missingFactory"`,
+				path + `:11: /services/1/timeout: unknown key "timeout"`,
+				path + `:13: /operations/0/id: duplicate component ID "service", first declared at /services/0/id`,
+			}))
+		})
+
+		It("reports the line of each problem in a JSON file", func() {
+			path := writeFile("config.json", `{
+  "services": [
+    {
+      "id": "service",
+      "factoryId": "serviceFactory",
+      "customConfig": {}
+    }
+  ],
+  "verbose": 1
+}`)
+			err := validator.ValidateFile(path)
+			Expect(err).To(MatchError(ContainSubstring(path + `:6: /services/0/customConfig/port: missing required key "port"`)))
+			Expect(err).To(MatchError(ContainSubstring(path + `:9: /verbose: expected boolean, got number`)))
+		})
+
+		It("reports the line of each problem in a TOML file", func() {
+			path := writeFile("config.toml", `
+[[services]]
+id = "service"
+factoryId = "serviceFactory"
+
+[services.customConfig]
+port = 8080
+
+[[services]]
+id = "other"
+factoryId = "unknownFactory"
+`)
+			err := validator.ValidateFile(path)
+			Expect(problems(err)).To(HaveLen(1))
+			Expect(err).To(MatchError(types.ErrFactoryNotFound))
+			Expect(err).To(MatchError(ContainSubstring(path + `:11: /services/1/factoryId: unknown factory "unknownFactory"`)))
+		})
+
+		It("returns an error when the file is malformed", func() {
+			path := writeFile("config.json", `{"services": [`)
+			Expect(validator.ValidateFile(path)).To(MatchError(types.ErrInvalidConfiguration))
+		})
+	})
+
+	Describe("Validate", func() {
+		It("reports the pointer of each problem in a decoded configuration", func() {
+			err := validator.Validate(&types.Configuration{
+				Services: []*types.ServiceConfiguration{
+					{ComponentConfig: types.ComponentConfig{ID: "service", FactoryID: "serviceFactory"}},
+				},
+				Operations: []*types.OperationConfiguration{
+					{ComponentConfig: types.ComponentConfig{ID: "operation"}},
+				},
+			})
+
+			Expect(err).To(MatchError(types.ErrInvalidConfiguration))
+			Expect(err.Error()).To(Equal(
+				"/operations/0/factoryId: missing required key \"factoryId\"\n" +
+					"/services/0/customConfig/port: missing required key \"port\"",
+			))
+		})
+
+		It("skips the factory checks without a registrar", func() {
+			err := system.NewConfigValidator(nil).Validate(&types.Configuration{
+				Services: []*types.ServiceConfiguration{
+					{ComponentConfig: types.ComponentConfig{ID: "service", FactoryID: "unknownFactory"}},
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("reports the invalid schedules", func() {
+			scheduled := func(id string, schedule *types.ScheduleConfiguration) *types.OperationConfiguration {
+				return &types.OperationConfiguration{
+					ComponentConfig: types.ComponentConfig{ID: id, FactoryID: "operationFactory"},
+					Schedule:        schedule,
+				}
+			}
+
+			err := system.NewConfigValidator(nil).Validate(&types.Configuration{
+				Operations: []*types.OperationConfiguration{
+					scheduled("cron", &types.ScheduleConfiguration{Cron: "*/5 * * * *", Overlap: types.OverlapQueue}),
+					scheduled("interval", &types.ScheduleConfiguration{Interval: time.Minute}),
+					scheduled("invalid", &types.ScheduleConfiguration{Cron: "61 * * * *"}),
+					scheduled("both", &types.ScheduleConfiguration{Cron: "@daily", Interval: time.Minute}),
+					scheduled("none", &types.ScheduleConfiguration{Overlap: "wait"}),
+				},
+			})
+
+			Expect(err).To(MatchError(types.ErrInvalidSchedule))
+			Expect(err.Error()).To(Equal(
+				"/operations/2/schedule/cron: value 61 out of range [0, 59] in minute field\n" +
+					"/operations/3/schedule: cron expression and interval are mutually exclusive\n" +
+					"/operations/4/schedule: a cron expression or an interval is required\n" +
+					"/operations/4/schedule/overlap: unknown overlap policy \"wait\", expected skip, queue or allow",
+			))
+		})
+
+		It("reports an unknown overflow policy of the event bus", func() {
+			validator := system.NewConfigValidator(nil)
+			Expect(validator.Validate(&types.Configuration{
+				EventBus: types.EventBusConfiguration{BufferSize: 16, Overflow: "drop-oldest"},
+			})).To(Succeed())
+
+			err := validator.Validate(&types.Configuration{
+				EventBus: types.EventBusConfiguration{Overflow: "drop"},
+			})
+			Expect(err).To(MatchError(common.ErrUnknownOverflowPolicy))
+			Expect(err.Error()).To(Equal(
+				"/eventBus/overflow: unknown overflow policy \"drop\", expected block, drop-oldest or drop-newest",
+			))
+		})
+	})
+})
+
+// schemaFactory is a component factory exposing the schema of its custom configuration.
+type schemaFactory struct {
+	*mocks.ComponentFactoryInterface
+	mocks.ConfigSchemaFactoryInterface
+}
@@ -0,0 +1,181 @@
+package system
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/ebanfa/skeleton/pkg/common"
+	"github.com/ebanfa/skeleton/pkg/types"
+)
+
+const defaultWatchInterval = 2 * time.Second
+
+// ConfigWatcherOptions represents the options of a configuration watcher.
+type ConfigWatcherOptions struct {
+	// Loader are the sources of the configuration, the configuration file is watched for changes.
+	Loader ConfigLoaderOptions
+
+	// PollInterval is the interval between two checks of the configuration file.
+	// The file is not watched when negative.
+	PollInterval time.Duration
+
+	// Signals are the signals triggering a reload, SIGHUP when nil.
+	Signals []os.Signal
+}
+
+// ConfigWatcher is a service that reloads the system configuration when the configuration file
+// changes or when the process receives a reload signal.
+type ConfigWatcher struct {
+	BaseSystemService
+	mutex    sync.Mutex
+	options  ConfigWatcherOptions
+	checksum []byte
+	stop     chan struct{}
+	wg       sync.WaitGroup
+}
+
+// NewConfigWatcher creates a new instance of ConfigWatcher.
+func NewConfigWatcher(id string, options ConfigWatcherOptions) *ConfigWatcher {
+	if options.PollInterval == 0 {
+		options.PollInterval = defaultWatchInterval
+	}
+	if options.Signals == nil {
+		options.Signals = []os.Signal{syscall.SIGHUP}
+	}
+
+	return &ConfigWatcher{
+		BaseSystemService: *NewBaseSystemService(id, "ConfigWatcher", "Reloads the configuration when it changes"),
+		options:           options,
+	}
+}
+
+// Start starts watching the configuration file and the reload signals.
+func (w *ConfigWatcher) Start(ctx *common.Context) error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.System == nil {
+		return types.ErrSystemNotInitialized
+	}
+	if w.stop != nil {
+		return nil
+	}
+
+	// Changes are detected against the configuration file as it is when the watcher starts
+	w.checksum, _ = fileChecksum(w.options.Loader.FilePath)
+
+	signals := make(chan os.Signal, 1)
+	if len(w.options.Signals) > 0 {
+		signal.Notify(signals, w.options.Signals...)
+	}
+
+	w.stop = make(chan struct{})
+	w.wg.Add(1)
+	go w.watch(ctx, signals, w.stop)
+
+	return nil
+}
+
+// Stop stops watching the configuration file and the reload signals.
+func (w *ConfigWatcher) Stop(ctx *common.Context) error {
+	w.mutex.Lock()
+	stop := w.stop
+	w.stop = nil
+	w.mutex.Unlock()
+
+	if stop != nil {
+		close(stop)
+		w.wg.Wait()
+	}
+
+	return nil
+}
+
+// Reload loads the configuration from its sources and applies it to the system.
+// Returns an error if the configuration cannot be loaded or applied.
+func (w *ConfigWatcher) Reload(ctx *common.Context) error {
+	configuration := &types.Configuration{}
+	if err := LoadConfiguration(w.options.Loader, configuration); err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	return w.System.Reload(ctx, configuration)
+}
+
+// watch reloads the configuration when the configuration file changes or a signal is received,
+// until the stop channel is closed.
+func (w *ConfigWatcher) watch(ctx *common.Context, signals chan os.Signal, stop <-chan struct{}) {
+	defer w.wg.Done()
+	defer signal.Stop(signals)
+
+	var ticks <-chan time.Time
+	if w.options.PollInterval > 0 && w.options.Loader.FilePath != "" {
+		ticker := time.NewTicker(w.options.PollInterval)
+		defer ticker.Stop()
+		ticks = ticker.C
+	}
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-signals:
+			w.fileChanged()
+		case <-ticks:
+			if !w.fileChanged() {
+				continue
+			}
+		}
+
+		if err := w.Reload(ctx); err != nil {
+			w.reportError(err)
+		}
+	}
+}
+
+// fileChanged checks if the content of the configuration file changed since the last check.
+// A file that cannot be read, for instance while it is being replaced, is considered unchanged.
+func (w *ConfigWatcher) fileChanged() bool {
+	checksum, err := fileChecksum(w.options.Loader.FilePath)
+	if err != nil {
+		return false
+	}
+
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if bytes.Equal(checksum, w.checksum) {
+		return false
+	}
+	w.checksum = checksum
+	return true
+}
+
+// reportError logs an error that occurred while reloading the configuration.
+// Errors raised when applying the configuration are also published by the system.
+func (w *ConfigWatcher) reportError(err error) {
+	if logger := w.System.Logger(); logger != nil {
+		logger.Log(common.LevelError, "Error reloading configuration:", err)
+	}
+}
+
+// fileChecksum returns the checksum of the content of a file.
+func fileChecksum(filePath string) ([]byte, error) {
+	if filePath == "" {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	checksum := sha256.Sum256(data)
+	return checksum[:], nil
+}
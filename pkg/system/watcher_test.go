@@ -0,0 +1,109 @@
+package system_test
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/ebanfa/skeleton/pkg/common"
+	"github.com/ebanfa/skeleton/pkg/mocks"
+	"github.com/ebanfa/skeleton/pkg/system"
+	"github.com/ebanfa/skeleton/pkg/types"
+)
+
+var _ = Describe("ConfigWatcher", func() {
+	var (
+		ctx        *common.Context
+		path       string
+		mockSystem *mocks.SystemInterface
+		watcher    *system.ConfigWatcher
+		options    system.ConfigWatcherOptions
+		reloaded   chan *types.Configuration
+	)
+
+	writeConfig := func(debug string) {
+		Expect(os.WriteFile(path, []byte("debug: "+debug+"\n"), 0o600)).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		ctx = common.Background()
+		path = filepath.Join(GinkgoT().TempDir(), "config.yaml")
+		mockSystem = &mocks.SystemInterface{}
+		reloaded = make(chan *types.Configuration, 10)
+		options = system.ConfigWatcherOptions{
+			Loader:       system.ConfigLoaderOptions{FilePath: path},
+			PollInterval: 5 * time.Millisecond,
+			Signals:      []os.Signal{},
+		}
+
+		writeConfig("false")
+		mockSystem.On("Reload", ctx, mock.Anything).Run(func(args mock.Arguments) {
+			reloaded <- args.Get(1).(*types.Configuration)
+		}).Return(nil)
+	})
+
+	JustBeforeEach(func() {
+		watcher = system.NewConfigWatcher("watcher", options)
+	})
+
+	AfterEach(func() {
+		Expect(watcher.Stop(ctx)).To(Succeed())
+	})
+
+	It("returns an error when not initialized", func() {
+		Expect(watcher.Start(ctx)).To(MatchError(types.ErrSystemNotInitialized))
+	})
+
+	It("reloads the configuration when the file changes", func() {
+		Expect(watcher.Initialize(ctx, mockSystem)).To(Succeed())
+		Expect(watcher.Start(ctx)).To(Succeed())
+		Consistently(reloaded, 30*time.Millisecond).ShouldNot(Receive())
+
+		writeConfig("true")
+
+		var configuration *types.Configuration
+		Eventually(reloaded).Should(Receive(&configuration))
+		Expect(configuration.Debug).To(BeTrue())
+		Consistently(reloaded, 30*time.Millisecond).ShouldNot(Receive())
+	})
+
+	It("logs the configurations that cannot be loaded", func() {
+		logger := &mocks.LoggerInterface{}
+		logged := make(chan struct{}, 10)
+		logger.On("Log", common.LevelError, mock.Anything, mock.Anything).Run(func(mock.Arguments) {
+			logged <- struct{}{}
+		}).Return()
+		mockSystem.On("Logger").Return(logger)
+
+		Expect(watcher.Initialize(ctx, mockSystem)).To(Succeed())
+		Expect(watcher.Start(ctx)).To(Succeed())
+
+		writeConfig("[")
+
+		Eventually(logged).Should(Receive())
+		mockSystem.AssertNotCalled(GinkgoT(), "Reload", ctx, mock.Anything)
+	})
+
+	Context("with a reload signal", func() {
+		BeforeEach(func() {
+			options.PollInterval = -1
+			options.Signals = []os.Signal{syscall.SIGHUP}
+		})
+
+		It("reloads the configuration when the signal is received", func() {
+			Expect(watcher.Initialize(ctx, mockSystem)).To(Succeed())
+			Expect(watcher.Start(ctx)).To(Succeed())
+
+			process, err := os.FindProcess(os.Getpid())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(process.Signal(syscall.SIGHUP)).To(Succeed())
+
+			Eventually(reloaded).Should(Receive())
+		})
+	})
+})
@@ -24,6 +24,34 @@ const (
 	ApplicationComponentType
 )
 
+// String returns the string representation of the component type.
+func (t ComponentType) String() string {
+	switch t {
+	case BasicComponentType:
+		return "basic"
+	case SystemComponentType:
+		return "system"
+	case OperationType:
+		return "operation"
+	case ServiceType:
+		return "service"
+	case ApplicationComponentType:
+		return "application"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseComponentType returns the component type with the given string representation.
+func ParseComponentType(name string) (ComponentType, bool) {
+	for t := BasicComponentType; t <= ApplicationComponentType; t++ {
+		if t.String() == name {
+			return t, true
+		}
+	}
+	return 0, false
+}
+
 // ComponentInterface represents a generic component in the system.
 type ComponentInterface interface {
 	// ID returns the unique identifier of the component.
@@ -64,6 +92,21 @@ type ComponentFactoryInterface interface {
 	CreateComponent(config *ComponentConfig) (ComponentInterface, error)
 }
 
+// CustomConfigFactoryInterface represents a factory that declares the type of the custom configuration
+// of the components it creates.
+type CustomConfigFactoryInterface interface {
+	// NewCustomConfig returns a pointer to a new custom configuration holding the default values.
+	// The custom configuration of a component is decoded into it before the component is created.
+	NewCustomConfig() interface{}
+}
+
+// ConfigSchemaFactoryInterface represents a factory that exposes the schema of the custom configuration
+// of the components it creates, so that configurations can be validated before the components are created.
+type ConfigSchemaFactoryInterface interface {
+	// ConfigSchema returns the schema of the custom configuration.
+	ConfigSchema() *ConfigSchema
+}
+
 // Utility type to help with component registration
 type FactoryConfig struct {
 	FactoryId    string
@@ -88,6 +131,9 @@ type ComponentRegistrarInterface interface {
 	// It returns the factory and an error if the factory ID is not found or other error.
 	GetFactory(id string) (ComponentFactoryInterface, error)
 
+	// GetFactoryIDs returns the IDs of all registered factories.
+	GetFactoryIDs() []string
+
 	// RegisterFactory registers a factory with the given ID.
 	// It returns an error if the registration fails.
 	RegisterFactory(ctx *common.Context, id string, factory ComponentFactoryInterface) error
@@ -4,16 +4,18 @@ import "time"
 
 // ComponentConfig represents the configuration for a component.
 type ComponentConfig struct {
-	ID           string      `json:"id"`
+	ID           string      `json:"id" schema:"required"`
 	Name         string      `json:"name"`
 	Description  string      `json:"description"`
-	FactoryID    string      `json:"factoryId"`
+	FactoryID    string      `json:"factoryId" schema:"required"`
 	CustomConfig interface{} // Custom configuration
 }
 
 type ServiceConfiguration struct {
 	ComponentConfig
 	RetryInterval time.Duration // Interval between retries
+	StopTimeout   time.Duration // Time the service is given to stop before it is abandoned
+	Dependencies  []string      // IDs of the components this service depends on
 	// Other service-specific configuration optionsetl.ErrScheduledProcessNotFound
 	CustomConfig interface{} // Custom configuration
 }
@@ -21,6 +23,7 @@ type ServiceConfiguration struct {
 // OperationConfiguration represents the configuration for an operation.
 type OperationConfiguration struct {
 	ComponentConfig
+	Schedule *ScheduleConfiguration // Schedule of the operation, if it runs periodically
 }
 
 // Configuration represents the system configuration.
@@ -29,5 +32,13 @@ type Configuration struct {
 	Verbose      bool
 	Services     []*ServiceConfiguration   // Service configurations
 	Operations   []*OperationConfiguration // Operation configurations
+	Jobs         JobConfiguration          // Asynchronous operation execution
+	EventBus     EventBusConfiguration     // Delivery of the system events
 	CustomConfig interface{}
 }
+
+// EventBusConfiguration represents the configuration of the system event bus.
+type EventBusConfiguration struct {
+	BufferSize int    // Number of events buffered per subscriber, 64 when zero
+	Overflow   string // Policy applied when the buffer of a subscriber is full: block (default), drop-oldest or drop-newest
+}
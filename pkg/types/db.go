@@ -60,6 +60,9 @@ type VersionedDatabase interface {
 
 	// Rollback resets the working database to the latest saved version, discarding any unsaved modifications.
 	Rollback()
+
+	// DeleteVersionsTo deletes the saved versions up to the given version, which must not be the latest.
+	DeleteVersionsTo(toVersion int64) error
 }
 
 // Database combines all the interfaces for a complete database interface.
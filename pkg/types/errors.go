@@ -2,6 +2,7 @@ package types
 
 import (
 	"errors"
+	"fmt"
 )
 
 // Create a new error
@@ -24,5 +25,103 @@ var (
 	ErrSystemNotInitialized          = errors.New("system not initialized")
 	ErrSystemNotStarted              = errors.New("system not started")
 	ErrSystemNotStopped              = errors.New("system not stopped")
+	ErrSystemStopping                = errors.New("system is stopping")
 	ErrComponentTypeNotFound         = errors.New("component type not found")
+	ErrDependencyNotFound            = errors.New("dependency not found")
+	ErrDependencyCycle               = errors.New("dependency cycle detected")
+	ErrDependencyFailed              = errors.New("dependency failed")
+	ErrInvalidStateTransition        = errors.New("invalid state transition")
+	ErrServiceFailed                 = errors.New("service failed")
+	ErrInvalidConfiguration          = errors.New("invalid configuration")
+	ErrStopTimeout                   = errors.New("stop deadline exceeded")
+	ErrShutdownIncomplete            = errors.New("system did not shut down cleanly")
+	ErrOperationPanicked             = errors.New("operation panicked")
+	ErrOperationTimeout              = errors.New("operation timed out")
+	ErrJobNotFound                   = errors.New("job not found")
+	ErrJobQueueFull                  = errors.New("job queue is full")
+	ErrJobCompleted                  = errors.New("job already completed")
+	ErrJobCanceled                   = errors.New("job canceled")
+	ErrInputCodecNotFound            = errors.New("input codec not found")
+	ErrInvalidSchedule               = errors.New("invalid schedule")
+	ErrOperationTypeMismatch         = errors.New("operation type mismatch")
+	ErrInvalidPipeline               = errors.New("invalid pipeline")
+	ErrPipelineStepFailed            = errors.New("pipeline step failed")
+	ErrCompensationFailed            = errors.New("compensation failed")
+	ErrJournalNotStarted             = errors.New("event journal not started")
+	ErrDeadLetterNotFound            = errors.New("dead letter not found")
+	ErrAdminTokenRequired            = errors.New("admin token required")
 )
+
+// StateTransitionError is returned when a service cannot move from its current status to the requested one.
+type StateTransitionError struct {
+	ServiceID string
+	From      ServiceStatusType
+	To        ServiceStatusType
+}
+
+// Error returns the error message.
+func (e *StateTransitionError) Error() string {
+	return fmt.Sprintf("%v: service %s cannot go from %s to %s", ErrInvalidStateTransition, e.ServiceID, e.From, e.To)
+}
+
+// Unwrap returns ErrInvalidStateTransition so that the error can be matched with errors.Is.
+func (e *StateTransitionError) Unwrap() error {
+	return ErrInvalidStateTransition
+}
+
+// OperationTypeError is returned when the input or output of a typed operation does not have the expected type.
+type OperationTypeError struct {
+	OperationID string
+	Value       string // Either "input" or "output"
+	Expected    string // Expected type
+	Actual      string // Actual type of the value
+	Err         error  // Error decoding the value from JSON, if any
+}
+
+// Error returns the error message.
+func (e *OperationTypeError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%v: operation %s: failed to decode %s as %s: %v", ErrOperationTypeMismatch, e.OperationID, e.Value, e.Expected, e.Err)
+	}
+	return fmt.Sprintf("%v: operation %s: %s of type %s, expected %s", ErrOperationTypeMismatch, e.OperationID, e.Value, e.Actual, e.Expected)
+}
+
+// Unwrap returns ErrOperationTypeMismatch and the decoding error so that the error can be matched with errors.Is.
+func (e *OperationTypeError) Unwrap() []error {
+	if e.Err == nil {
+		return []error{ErrOperationTypeMismatch}
+	}
+	return []error{ErrOperationTypeMismatch, e.Err}
+}
+
+// ConfigError is returned for each problem found when validating a configuration.
+type ConfigError struct {
+	File    string // Path of the configuration file, if any
+	Line    int    // Line of the problem in the file, if known
+	Pointer string // JSON pointer to the value, such as /services/0/factoryId
+	Message string
+	Err     error // Underlying error, if any
+}
+
+// Error returns the error message, prefixed with the location of the problem.
+func (e *ConfigError) Error() string {
+	location := e.Pointer
+	if location == "" {
+		location = "/"
+	}
+	switch {
+	case e.File != "" && e.Line > 0:
+		location = fmt.Sprintf("%s:%d: %s", e.File, e.Line, location)
+	case e.File != "":
+		location = fmt.Sprintf("%s: %s", e.File, location)
+	}
+	return fmt.Sprintf("%s: %s", location, e.Message)
+}
+
+// Unwrap returns ErrInvalidConfiguration and the underlying error so that the error can be matched with errors.Is.
+func (e *ConfigError) Unwrap() []error {
+	if e.Err == nil {
+		return []error{ErrInvalidConfiguration}
+	}
+	return []error{ErrInvalidConfiguration, e.Err}
+}
@@ -0,0 +1,20 @@
+package types
+
+import "github.com/ebanfa/skeleton/pkg/common"
+
+// OperationInfo describes the operation executed through an interceptor chain.
+type OperationInfo struct {
+	// OperationID is the ID of the operation.
+	OperationID string
+
+	// Operation is the operation being executed.
+	Operation SystemOperationInterface
+}
+
+// OperationHandler executes an operation with the given context and input.
+type OperationHandler func(ctx *common.Context, input *SystemOperationInput) (*SystemOperationOutput, error)
+
+// OperationInterceptor intercepts the execution of an operation.
+// It calls handler to continue the execution, and may change the context, the input,
+// the output or the error, or skip the operation entirely by not calling handler.
+type OperationInterceptor func(ctx *common.Context, input *SystemOperationInput, info *OperationInfo, handler OperationHandler) (*SystemOperationOutput, error)
@@ -0,0 +1,103 @@
+package types
+
+import "time"
+
+// Job status.
+type JobStatusType int
+
+const (
+	JobPendingType JobStatusType = iota
+	JobRunningType
+	JobSucceededType
+	JobFailedType
+	JobCanceledType
+)
+
+// String returns the name of the job status.
+func (s JobStatusType) String() string {
+	switch s {
+	case JobPendingType:
+		return "pending"
+	case JobRunningType:
+		return "running"
+	case JobSucceededType:
+		return "succeeded"
+	case JobFailedType:
+		return "failed"
+	case JobCanceledType:
+		return "canceled"
+	default:
+		return "unknown"
+	}
+}
+
+// IsCompleted checks if a job with the status is done running.
+func (s JobStatusType) IsCompleted() bool {
+	return s == JobSucceededType || s == JobFailedType || s == JobCanceledType
+}
+
+// JobInfo represents the state of an operation submitted for asynchronous execution.
+type JobInfo struct {
+	// ID is the ID of the job.
+	ID string
+
+	// OperationID is the ID of the operation executed by the job.
+	OperationID string
+
+	// Status is the status of the job.
+	Status JobStatusType
+
+	// Progress is the progress of the job between 0 and 1, as reported by the operation.
+	Progress float64
+
+	// Message is the last progress message reported by the operation.
+	Message string
+
+	// Output is the output of the operation once the job succeeded.
+	Output *SystemOperationOutput
+
+	// Err is the error of the operation once the job failed or was canceled.
+	Err error
+
+	// Attempts is the number of times the operation was executed.
+	Attempts int
+
+	// DeadLettered tells if the job failed every attempt and was moved to the dead-letter queue.
+	DeadLettered bool
+
+	// SubmittedAt, StartedAt and CompletedAt are the times the job went through its lifecycle.
+	SubmittedAt time.Time
+	StartedAt   time.Time
+	CompletedAt time.Time
+}
+
+// JobConfiguration represents the configuration of the asynchronous execution of operations.
+type JobConfiguration struct {
+	Workers     int           // Number of jobs running concurrently
+	QueueSize   int           // Number of jobs waiting for a worker before submissions are rejected
+	Retention   time.Duration // Time completed jobs are kept for status queries
+	Persistent  bool          // Persist the unfinished jobs in the system store, so that they survive a restart
+	MaxAttempts int           // Number of times a failed operation is executed before it is dead-lettered
+	RetryDelay  time.Duration // Time before a failed operation is executed again
+}
+
+// JobQueueStats represents the depth of the job queue.
+type JobQueueStats struct {
+	// Pending is the number of jobs waiting for a worker, including the jobs waiting for a retry.
+	Pending int
+
+	// Running is the number of jobs being executed.
+	Running int
+
+	// DeadLettered is the number of jobs in the dead-letter queue.
+	DeadLettered int
+}
+
+// InputCodec encodes the inputs of an operation, so that they can be persisted.
+type InputCodec interface {
+	// Encode encodes the given input.
+	Encode(input *SystemOperationInput) ([]byte, error)
+
+	// Decode decodes an input encoded with Encode.
+	Decode(data []byte) (*SystemOperationInput, error)
+}
@@ -0,0 +1,42 @@
+package types
+
+import "time"
+
+// PipelineEndStep is the step ID ending a pipeline when used as the next step.
+const PipelineEndStep = "end"
+
+// PipelineConfiguration represents the custom configuration of a pipeline, an operation
+// running other operations in steps.
+type PipelineConfiguration struct {
+	Steps    []*PipelineStep `json:"steps" schema:"required"`
+	MaxSteps int             `json:"maxSteps"` // Maximum number of steps run by an execution, guarding against loops
+}
+
+// PipelineStep represents a step of a pipeline. The input of a step is the output of the previous step.
+type PipelineStep struct {
+	ID           string            `json:"id" schema:"required"`          // Unique ID of the step within the pipeline
+	OperationID  string            `json:"operationId" schema:"required"` // Operation run by the step
+	Next         string            `json:"next"`                          // Step run after this one, the following step when empty
+	Branches     []*PipelineBranch `json:"branches"`                      // Conditional next steps, checked in order before Next
+	MaxAttempts  int               `json:"maxAttempts"`                   // Number of times the operation is run before the step fails
+	RetryDelay   time.Duration     `json:"retryDelay"`                    // Time between two attempts
+	Compensation string            `json:"compensation"`                  // Operation undoing the step when a later step fails
+}
+
+// PipelineBranch represents a conditional next step of a pipeline step.
+type PipelineBranch struct {
+	Condition string `json:"condition" schema:"required"` // Operation returning a bool from the output of the step
+	Next      string `json:"next" schema:"required"`      // Step run when the condition holds
+}
+
+// PipelineCompensationInput represents the input data of a compensating operation.
+type PipelineCompensationInput struct {
+	// StepID is the ID of the step being compensated.
+	StepID string
+
+	// Input is the input data of the step.
+	Input interface{}
+
+	// Output is the output data of the step.
+	Output interface{}
+}
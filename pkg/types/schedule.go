@@ -0,0 +1,29 @@
+package types
+
+import "time"
+
+// OverlapPolicy determines what happens when a scheduled run of an operation is due
+// while the previous run is still in progress.
+type OverlapPolicy string
+
+const (
+	// OverlapSkip skips the run, this is the default policy.
+	OverlapSkip OverlapPolicy = "skip"
+
+	// OverlapQueue starts the run once the previous runs complete.
+	OverlapQueue OverlapPolicy = "queue"
+
+	// OverlapAllow starts the run alongside the previous runs.
+	OverlapAllow OverlapPolicy = "allow"
+)
+
+// ScheduleConfiguration represents the schedule of an operation run by the scheduler.
+// Either Cron or Interval must be set.
+type ScheduleConfiguration struct {
+	Cron     string        // Cron expression, such as "*/5 * * * *" or "@daily"
+	Interval time.Duration // Fixed interval between two runs
+	Overlap  OverlapPolicy // Policy applied when a run is due while the previous one is in progress
+	Jitter   time.Duration // Maximum random delay added to each run
+	CatchUp  bool          // Run once at start when runs were missed while the system was down
+	Input    interface{}   // Input data of the operation
+}
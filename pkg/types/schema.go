@@ -0,0 +1,53 @@
+package types
+
+// ConfigSchemaType represents the type of a configuration value.
+type ConfigSchemaType string
+
+const (
+	// SchemaAnyType represents a value of any type.
+	SchemaAnyType ConfigSchemaType = ""
+
+	// SchemaObjectType represents a map of keys to values.
+	SchemaObjectType ConfigSchemaType = "object"
+
+	// SchemaArrayType represents a list of values.
+	SchemaArrayType ConfigSchemaType = "array"
+
+	// SchemaStringType represents a string value.
+	SchemaStringType ConfigSchemaType = "string"
+
+	// SchemaIntegerType represents an integer value.
+	SchemaIntegerType ConfigSchemaType = "integer"
+
+	// SchemaNumberType represents a numeric value.
+	SchemaNumberType ConfigSchemaType = "number"
+
+	// SchemaBooleanType represents a boolean value.
+	SchemaBooleanType ConfigSchemaType = "boolean"
+
+	// SchemaDurationType represents a duration, given as a string such as "5s" or as nanoseconds.
+	SchemaDurationType ConfigSchemaType = "duration"
+)
+
+// ConfigSchema describes the expected structure of a configuration value.
+// It follows a subset of JSON Schema.
+type ConfigSchema struct {
+	// Type is the type of the value, any type is accepted when empty.
+	Type ConfigSchemaType
+
+	// Properties are the schemas of the known keys of an object.
+	Properties map[string]*ConfigSchema
+
+	// Required are the keys an object must have.
+	Required []string
+
+	// AdditionalProperties is the schema of the keys of an object that are not in Properties.
+	// Unknown keys are rejected when nil.
+	AdditionalProperties *ConfigSchema
+
+	// Items is the schema of the values of an array.
+	Items *ConfigSchema
+
+	// Enum lists the allowed values, any value is allowed when empty.
+	Enum []interface{}
+}
@@ -19,6 +19,26 @@ type SystemServiceInterface interface {
 	SystemComponentInterface
 }
 
+// DependentInterface represents a component that depends on other components.
+type DependentInterface interface {
+	// Dependencies returns the IDs of the components this component depends on.
+	Dependencies() []string
+}
+
+// HealthCheckInterface represents a component that can report its health.
+type HealthCheckInterface interface {
+	// HealthCheck checks the health of the component.
+	// Returns an error if the component is unhealthy.
+	HealthCheck(ctx *common.Context) error
+}
+
+// ReconfigurableInterface represents a service that can apply a new configuration without being restarted.
+type ReconfigurableInterface interface {
+	// Reconfigure applies the given configuration to the service.
+	// Returns an error if the configuration cannot be applied.
+	Reconfigure(ctx *common.Context, config *ComponentConfig) error
+}
+
 // SystemOperationInput represents the input data for an operation.
 type SystemOperationInput struct {
 	// Data is the input data for the operation.
@@ -40,10 +60,35 @@ type SystemOperationInterface interface {
 	Execute(ctx *common.Context, input *SystemOperationInput) (*SystemOperationOutput, error)
 }
 
+// SystemControllerInterface represents the operations and services of a system, as driven
+// by the clients of local and remote systems alike.
+type SystemControllerInterface interface {
+	// ExecuteOperation executes the operation with the given ID and input data.
+	// Returns the output of the operation and an error if the operation is not found or if execution fails.
+	ExecuteOperation(ctx *common.Context, operationID string, data *SystemOperationInput) (*SystemOperationOutput, error)
+
+	// StartService starts the service with the given ID.
+	// Returns an error if the service ID is not found or other error.
+	StartService(ctx *common.Context, serviceID string) error
+
+	// StopService stops the service with the given ID.
+	// Returns an error if the service ID is not found or other error.
+	StopService(ctx *common.Context, serviceID string) error
+
+	// RestartService restarts the service with the given ID.
+	// Returns an error if the service ID is not found or other error.
+	RestartService(ctx *common.Context, serviceID string) error
+
+	// ServiceStatus returns the lifecycle status of the service with the given ID.
+	// Returns an error if the service ID is not found or other error.
+	ServiceStatus(serviceID string) (ServiceStatusType, error)
+}
+
 // SystemInterface represents the core system in the application.
 type SystemInterface interface {
 	BootableInterface
 	StartableInterface
+	SystemControllerInterface
 
 	// Logger returns the system logger.
 	Logger() common.LoggerInterface
@@ -63,21 +108,38 @@ type SystemInterface interface {
 	// PluginManager returns the plugin manager
 	PluginManager() PluginManagerInterface
 
-	// ExecuteOperation executes the operation with the given ID and input data.
-	// Returns the output of the operation and an error if the operation is not found or if execution fails.
-	ExecuteOperation(ctx *common.Context, operationID string, data *SystemOperationInput) (*SystemOperationOutput, error)
+	// SubmitOperation queues the operation with the given ID for asynchronous execution.
+	// Returns the ID of the job executing the operation.
+	SubmitOperation(ctx *common.Context, operationID string, data *SystemOperationInput) (string, error)
 
-	// StartService starts the service with the given ID.
-	// Returns an error if the service ID is not found or other error.
-	StartService(ctx *common.Context, serviceID string) error
+	// JobStatus returns the state of the job with the given ID.
+	// Returns an error if the job is not found.
+	JobStatus(jobID string) (*JobInfo, error)
 
-	// StopService stops the service with the given ID.
-	// Returns an error if the service ID is not found or other error.
-	StopService(ctx *common.Context, serviceID string) error
+	// CancelJob cancels the job with the given ID.
+	// Returns an error if the job is not found or already completed.
+	CancelJob(jobID string) error
 
-	// RestartService restarts the service with the given ID.
-	// Returns an error if the service ID is not found or other error.
-	RestartService(ctx *common.Context, serviceID string) error
+	// JobQueueStats returns the depth of the job queue.
+	JobQueueStats() JobQueueStats
+
+	// RegisterInputCodec registers the codec of the inputs of the operation with the given ID.
+	// A codec is required to submit an operation when jobs are persistent.
+	RegisterInputCodec(operationID string, codec InputCodec)
+
+	// AddOperationInterceptors adds interceptors wrapping every operation execution.
+	// Interceptors run in the order they are added, the first one being the outermost.
+	AddOperationInterceptors(interceptors ...OperationInterceptor)
+
+	// Status returns the status of the system.
+	Status() SystemStatusType
+
+	// ListServiceStatuses returns the lifecycle status of every registered service, keyed by service ID.
+	ListServiceStatuses() map[string]ServiceStatusType
+
+	// Reload applies a new configuration to the running system.
+	// Returns an error if the configuration is invalid or could not be fully applied.
+	Reload(ctx *common.Context, configuration *Configuration) error
 }
 
 // System status.
@@ -88,3 +150,52 @@ const (
 	SystemStartedType
 	SystemStoppedType
 )
+
+// String returns the string representation of the system status.
+func (s SystemStatusType) String() string {
+	switch s {
+	case SystemInitializedType:
+		return "initialized"
+	case SystemStartedType:
+		return "started"
+	case SystemStoppedType:
+		return "stopped"
+	default:
+		return "unknown"
+	}
+}
+
+// Service lifecycle status.
+type ServiceStatusType int
+
+const (
+	ServiceCreatedType ServiceStatusType = iota
+	ServiceInitializedType
+	ServiceStartingType
+	ServiceRunningType
+	ServiceStoppingType
+	ServiceStoppedType
+	ServiceFailedType
+)
+
+// String returns the string representation of the service status.
+func (s ServiceStatusType) String() string {
+	switch s {
+	case ServiceCreatedType:
+		return "created"
+	case ServiceInitializedType:
+		return "initialized"
+	case ServiceStartingType:
+		return "starting"
+	case ServiceRunningType:
+		return "running"
+	case ServiceStoppingType:
+		return "stopping"
+	case ServiceStoppedType:
+		return "stopped"
+	case ServiceFailedType:
+		return "failed"
+	default:
+		return "unknown"
+	}
+}